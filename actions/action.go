@@ -0,0 +1,243 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/GitDataAI/jiaozifs/versionmgr/merkletrie"
+	"github.com/GitDataAI/jiaozifs/webhook"
+	"github.com/google/uuid"
+)
+
+// RunHeader header contains id of run posted by http action
+const RunHeader = "X-Jiaozifs-Action-Run"
+
+var (
+	// MaxLogSize output of command or response body larger than this is truncated
+	MaxLogSize = 64 << 10
+	// MaxChanges changes more than this are dropped from input and ChangesTruncated is set
+	MaxChanges = 10000
+)
+
+// Change file changed by commit
+type Change struct {
+	Action   string `json:"action"`
+	Path     string `json:"path"`
+	BaseHash string `json:"base_hash,omitempty"`
+	ToHash   string `json:"to_hash,omitempty"`
+}
+
+// Input json passed to command on stdin or posted to http endpoint
+type Input struct {
+	RunID      uuid.UUID               `json:"run_id"`
+	Action     string                  `json:"action"`
+	Event      models.ActionEvent      `json:"event"`
+	Repository webhook.EventRepository `json:"repository"`
+	Operator   webhook.EventOperator   `json:"operator"`
+	// Data payload of repository event which triggers the action, same as data of webhook event
+	Data json.RawMessage `json:"data"`
+	// Changes changes of commit compared with its parent, filled when run starts
+	Changes          []Change  `json:"changes"`
+	ChangesTruncated bool      `json:"changes_truncated,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// commitOf return commit created by operation or target of tag
+func commitOf(data any) hash.Hash {
+	switch payload := data.(type) {
+	case *webhook.CommitPayload:
+		if payload.Commit != nil {
+			return payload.Commit.Hash
+		}
+	case *webhook.MergeRequestPayload:
+		if payload.Commit != nil {
+			return payload.Commit.Hash
+		}
+	case *webhook.TagPayload:
+		if payload.Tag != nil {
+			return payload.Tag.Target
+		}
+	}
+	return hash.Empty
+}
+
+// commitChanges return changes of commit compared with its parent, merge commit is compared with target branch as
+// commit changes api does
+func commitChanges(ctx context.Context, repo models.IRepo, repositoryID uuid.UUID, commitHash hash.Hash) ([]Change, bool, error) {
+	commitRepo := repo.CommitRepo(repositoryID)
+	commit, err := commitRepo.Commit(ctx, commitHash)
+	if err != nil {
+		return nil, false, err
+	}
+
+	parentHash := hash.Empty
+	if len(commit.ParentHashes) == 1 {
+		parentHash = commit.ParentHashes[0]
+	} else if len(commit.ParentHashes) == 2 {
+		parentHash = commit.ParentHashes[1]
+	}
+	parentTree := hash.Empty
+	if !parentHash.IsEmpty() {
+		parent, err := commitRepo.Commit(ctx, parentHash)
+		if err != nil {
+			return nil, false, err
+		}
+		parentTree = parent.TreeHash
+	}
+
+	workTree, err := versionmgr.NewWorkTree(ctx, repo.FileTreeRepo(repositoryID), models.NewRootTreeEntry(parentTree))
+	if err != nil {
+		return nil, false, err
+	}
+	changes, err := workTree.Diff(ctx, commit.TreeHash, "")
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := []Change{}
+	for _, change := range changes.Changes() {
+		if len(result) >= MaxChanges {
+			return result, true, nil
+		}
+		action, err := change.Action()
+		if err != nil {
+			return nil, false, err
+		}
+		item := Change{Action: changeAction(action), Path: change.Path()}
+		if change.From() != nil {
+			item.BaseHash = hex.EncodeToString(change.From().Hash())
+		}
+		if change.To() != nil {
+			item.ToHash = hex.EncodeToString(change.To().Hash())
+		}
+		result = append(result, item)
+	}
+	return result, false, nil
+}
+
+func changeAction(action merkletrie.Action) string {
+	switch action {
+	case merkletrie.Insert:
+		return "insert"
+	case merkletrie.Delete:
+		return "delete"
+	}
+	return "modify"
+}
+
+// runResult result of running action once
+type runResult struct {
+	exitCode     *int
+	responseCode *int
+	logs         string
+	err          error
+}
+
+// runCommand run command with input on stdin, stdout and stderr are collected as logs
+func runCommand(ctx context.Context, command config.ActionCommand, env []string, input []byte) *runResult {
+	workDir, err := os.MkdirTemp("", "jiaozifs-action")
+	if err != nil {
+		return &runResult{err: err}
+	}
+	defer os.RemoveAll(workDir) //nolint
+
+	output := &logBuffer{limit: MaxLogSize}
+	cmd := exec.CommandContext(ctx, command.Path, command.Args...)
+	cmd.Dir = workDir
+	cmd.Env = append(append([]string{}, command.Env...), env...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	// not wait for children which still hold output after command killed
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	result := &runResult{logs: output.String()}
+	if cmd.ProcessState != nil {
+		exitCode := cmd.ProcessState.ExitCode()
+		result.exitCode = &exitCode
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.err = errors.New("command timeout")
+	case ctx.Err() != nil:
+		result.err = fmt.Errorf("command interrupted %w", ctx.Err())
+	case err != nil:
+		result.err = err
+	}
+	return result
+}
+
+// postInput post input to url of http action, run succeed if response status is 2xx
+func postInput(ctx context.Context, client *http.Client, action *models.Action, run *models.ActionRun, input []byte) *runResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, action.URL, bytes.NewReader(input))
+	if err != nil {
+		return &runResult{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jiaozifs-action")
+	req.Header.Set(webhook.EventHeader, string(run.Event))
+	req.Header.Set(RunHeader, run.ID.String())
+	if len(action.Secret) > 0 {
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(action.Secret, input))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &runResult{err: err}
+	}
+	defer resp.Body.Close() //nolint
+
+	output := &logBuffer{limit: MaxLogSize}
+	_, err = io.Copy(output, resp.Body)
+	result := &runResult{responseCode: &resp.StatusCode, logs: output.String()}
+	switch {
+	case err != nil:
+		result.err = fmt.Errorf("read response body %w", err)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		result.err = fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return result
+}
+
+// logBuffer keep the first limit bytes written and drop the rest
+type logBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	remain := b.limit - b.buf.Len()
+	if remain < len(p) {
+		b.truncated = true
+		if remain > 0 {
+			b.buf.Write(p[:remain])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// String return logs which can be saved as text, invalid utf8 and NUL are replaced
+func (b *logBuffer) String() string {
+	logs := strings.ReplaceAll(strings.ToValidUTF8(b.buf.String(), "\uFFFD"), "\x00", "\uFFFD")
+	if b.truncated {
+		logs += "\n... output truncated"
+	}
+	return logs
+}
@@ -0,0 +1,105 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/webhook"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommand(t *testing.T) {
+	ctx := context.Background()
+	input := []byte(`{"event":"post-commit"}`)
+
+	t.Run("input on stdin", func(t *testing.T) {
+		command := config.ActionCommand{Path: "/bin/sh", Args: []string{"-c", `cat; echo "$JIAOZIFS_EVENT $NAME" >&2`}, Env: []string{"NAME=index"}}
+		result := runCommand(ctx, command, []string{"JIAOZIFS_EVENT=post-commit"}, input)
+		require.NoError(t, result.err)
+		require.Equal(t, 0, *result.exitCode)
+		require.Nil(t, result.responseCode)
+		require.Equal(t, string(input)+"post-commit index\n", result.logs)
+	})
+
+	t.Run("exit with error", func(t *testing.T) {
+		command := config.ActionCommand{Path: "/bin/sh", Args: []string{"-c", "echo failed; exit 3"}}
+		result := runCommand(ctx, command, nil, input)
+		require.Error(t, result.err)
+		require.Equal(t, 3, *result.exitCode)
+		require.Equal(t, "failed\n", result.logs)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		command := config.ActionCommand{Path: "/bin/sh", Args: []string{"-c", "sleep 10"}}
+		result := runCommand(timeoutCtx, command, nil, input)
+		require.EqualError(t, result.err, "command timeout")
+	})
+
+	t.Run("command not found", func(t *testing.T) {
+		result := runCommand(ctx, config.ActionCommand{Path: "/not/exist"}, nil, input)
+		require.Error(t, result.err)
+		require.Nil(t, result.exitCode)
+	})
+}
+
+func TestPostInput(t *testing.T) {
+	ctx := context.Background()
+	input := []byte(`{"event":"post-merge"}`)
+	run := &models.ActionRun{ID: uuid.New(), Event: models.PostMergeEvent}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(RunHeader) != run.ID.String() || r.Header.Get(webhook.EventHeader) != string(models.PostMergeEvent) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !webhook.Verify("secret", body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("bad signature"))
+			return
+		}
+		_, _ = w.Write([]byte("training started"))
+	}))
+	defer server.Close()
+
+	result := postInput(ctx, server.Client(), &models.Action{URL: server.URL, Secret: "secret"}, run, input)
+	require.NoError(t, result.err)
+	require.Equal(t, http.StatusOK, *result.responseCode)
+	require.Equal(t, "training started", result.logs)
+
+	result = postInput(ctx, server.Client(), &models.Action{URL: server.URL, Secret: "other"}, run, input)
+	require.Error(t, result.err)
+	require.Equal(t, http.StatusForbidden, *result.responseCode)
+	require.Equal(t, "bad signature", result.logs)
+}
+
+func TestLogBuffer(t *testing.T) {
+	buf := &logBuffer{limit: 8}
+	_, _ = buf.Write([]byte("abc\x00"))
+	_, _ = buf.Write([]byte("\xffdefgh"))
+	require.Equal(t, "abc��def\n... output truncated", buf.String())
+}
+
+func TestInput(t *testing.T) {
+	input := &Input{
+		RunID:   uuid.New(),
+		Event:   models.PostTagEvent,
+		Data:    json.RawMessage(`{"tag":{"name":"v1"}}`),
+		Changes: []Change{{Action: "insert", Path: "a.txt", ToHash: "aa"}},
+	}
+	data, err := json.Marshal(input)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(data), `"data":{"tag":{"name":"v1"}}`))
+	require.True(t, strings.Contains(string(data), `"changes":[{"action":"insert","path":"a.txt","to_hash":"aa"}]`))
+}
@@ -0,0 +1,301 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/webhook"
+	"github.com/google/uuid"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+)
+
+var log = logging.Logger("actions")
+
+var (
+	// DefaultTimeout max duration of one run if timeout not configured
+	DefaultTimeout = 10 * time.Minute
+	// MaxConcurrentRuns max runs executed at the same time
+	MaxConcurrentRuns = 4
+
+	pollInterval = 5 * time.Second
+	runsBatch    = 100
+)
+
+var _ webhook.Emitter = (*ActionManager)(nil)
+
+// ActionManager persist events as pending runs of triggered actions and execute them in background.
+// runs are not retried, every run is recorded with its logs and state
+type ActionManager struct {
+	repo   models.IRepo
+	cfg    *config.ActionsConfig
+	client *http.Client
+	notify chan struct{}
+	slots  chan struct{}
+}
+
+func NewActionManager(repo models.IRepo, cfg *config.ActionsConfig, webhookCfg *config.WebhookConfig) *ActionManager {
+	return &ActionManager{
+		repo:   repo,
+		cfg:    cfg,
+		client: webhook.NewClient(webhookCfg, 0),
+		notify: make(chan struct{}, 1),
+		slots:  make(chan struct{}, MaxConcurrentRuns),
+	}
+}
+
+// NewActionEmitter create action manager and run actions along with lifecycle
+func NewActionEmitter(lc fx.Lifecycle, repo models.IRepo, cfg *config.ActionsConfig, webhookCfg *config.WebhookConfig) webhook.Emitter {
+	manager := NewActionManager(repo, cfg, webhookCfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			go func() {
+				defer close(done)
+				manager.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			return nil
+		},
+	})
+	return manager
+}
+
+// Emit create pending run for every active action triggered by the event, failure only logged to not break the operation
+func (manager *ActionManager) Emit(ctx context.Context, repository *models.Repository, operator *models.User, eventType models.EventType, data any) {
+	event, ok := models.ActionEventOf(eventType)
+	if !ok {
+		return
+	}
+
+	actions, _, err := manager.repo.ActionRepo().List(ctx, models.NewListActionParams().SetRepositoryID(repository.ID).SetEvent(event).SetActive(true))
+	if err != nil {
+		log.Errorf("list actions of repository %s %v", repository.ID, err)
+		return
+	}
+	if len(actions) == 0 {
+		return
+	}
+
+	eventData, err := json.Marshal(data)
+	if err != nil {
+		log.Errorf("marshal %s event %v", eventType, err)
+		return
+	}
+
+	webhookEvent := webhook.NewEvent(repository, operator, eventType, nil)
+	for _, action := range actions {
+		run := &models.ActionRun{
+			ID:           uuid.New(),
+			ActionID:     action.ID,
+			RepositoryID: repository.ID,
+			Event:        event,
+			CommitHash:   commitOf(data),
+			State:        models.RunPending,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+		payload, err := json.Marshal(&Input{
+			RunID:      run.ID,
+			Action:     action.Name,
+			Event:      event,
+			Repository: webhookEvent.Repository,
+			Operator:   webhookEvent.Operator,
+			Data:       eventData,
+			CreatedAt:  run.CreatedAt,
+		})
+		if err != nil {
+			log.Errorf("marshal input of action %s %v", action.ID, err)
+			continue
+		}
+		run.Payload = string(payload)
+
+		_, err = manager.repo.ActionRunRepo().Insert(ctx, run)
+		if err != nil {
+			log.Errorf("create run of action %s %v", action.ID, err)
+		}
+	}
+
+	select {
+	case manager.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run execute pending runs until ctx done
+func (manager *ActionManager) Run(ctx context.Context) {
+	err := manager.FailInterrupted(ctx)
+	if err != nil {
+		log.Errorf("fail interrupted action runs %v", err)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-manager.notify:
+		}
+
+		err := manager.RunPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("execute pending action runs %v", err)
+		}
+	}
+}
+
+// FailInterrupted mark runs left running by last process as failed
+func (manager *ActionManager) FailInterrupted(ctx context.Context) error {
+	for {
+		runs, hasMore, err := manager.repo.ActionRunRepo().List(ctx, models.NewListActionRunParams().SetState(models.RunRunning).SetAmount(runsBatch))
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			err = manager.repo.ActionRunRepo().UpdateByID(ctx, models.NewUpdateActionRunParams(run.ID).
+				SetState(models.RunFailed).
+				SetMessage("interrupted by server restart").
+				SetFinishedAt(time.Now()))
+			if err != nil {
+				return err
+			}
+		}
+		if !hasMore {
+			return nil
+		}
+	}
+}
+
+// RunPending execute all pending runs and wait for them finished, at most MaxConcurrentRuns runs are executed at the same time
+func (manager *ActionManager) RunPending(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		runs, hasMore, err := manager.repo.ActionRunRepo().List(ctx, models.NewListActionRunParams().
+			SetState(models.RunPending).
+			SetAmount(runsBatch))
+		if err != nil {
+			return err
+		}
+
+		for _, run := range runs {
+			select {
+			case manager.slots <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			// mark as running before execute so that run is not listed as pending again
+			err = manager.repo.ActionRunRepo().UpdateByID(ctx, models.NewUpdateActionRunParams(run.ID).SetState(models.RunRunning).SetStartedAt(time.Now()))
+			if err != nil {
+				<-manager.slots
+				return err
+			}
+
+			wg.Add(1)
+			go func(run *models.ActionRun) {
+				defer wg.Done()
+				defer func() { <-manager.slots }()
+				err := manager.execute(ctx, run)
+				if err != nil {
+					log.Errorf("record result of action run %s %v", run.ID, err)
+				}
+			}(run)
+		}
+
+		if !hasMore {
+			return nil
+		}
+	}
+}
+
+// execute run action once and record the result. failure of action is recorded in run, only error of recording is returned
+func (manager *ActionManager) execute(ctx context.Context, run *models.ActionRun) error {
+	result := manager.runAction(ctx, run)
+
+	updateParams := models.NewUpdateActionRunParams(run.ID).
+		SetState(models.RunSuccess).
+		SetLogs(result.logs).
+		SetFinishedAt(time.Now())
+	if result.exitCode != nil {
+		updateParams.SetExitCode(*result.exitCode)
+	}
+	if result.responseCode != nil {
+		updateParams.SetResponseCode(*result.responseCode)
+	}
+	if result.err != nil {
+		updateParams.SetState(models.RunFailed).SetMessage(result.err.Error())
+	}
+	// record result even if manager is stopping
+	return manager.repo.ActionRunRepo().UpdateByID(context.WithoutCancel(ctx), updateParams)
+}
+
+func (manager *ActionManager) runAction(ctx context.Context, run *models.ActionRun) *runResult {
+	action, err := manager.repo.ActionRepo().Get(ctx, models.NewGetActionParams().SetID(run.ActionID))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return &runResult{err: errors.New("action not found")}
+		}
+		return &runResult{err: err}
+	}
+	if !action.Active {
+		return &runResult{err: errors.New("action is inactive")}
+	}
+
+	input := &Input{}
+	err = json.Unmarshal([]byte(run.Payload), input)
+	if err != nil {
+		return &runResult{err: fmt.Errorf("invalid payload %w", err)}
+	}
+	if !run.CommitHash.IsEmpty() {
+		input.Changes, input.ChangesTruncated, err = commitChanges(ctx, manager.repo, run.RepositoryID, run.CommitHash)
+		if err != nil {
+			return &runResult{err: fmt.Errorf("get changes of commit %s %w", run.CommitHash, err)}
+		}
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return &runResult{err: err}
+	}
+
+	timeout := manager.cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch action.Type {
+	case models.CommandAction:
+		command, ok := manager.cfg.Commands[action.Command]
+		if !ok {
+			return &runResult{err: fmt.Errorf("command %s is not configured in server", action.Command)}
+		}
+		return runCommand(ctx, command, []string{
+			"JIAOZIFS_RUN_ID=" + run.ID.String(),
+			"JIAOZIFS_EVENT=" + string(run.Event),
+			"JIAOZIFS_REPOSITORY=" + input.Repository.Name,
+			"JIAOZIFS_COMMIT=" + run.CommitHash.Hex(),
+		}, data)
+	case models.HTTPAction:
+		return postInput(ctx, manager.client, action, run, data)
+	}
+	return &runResult{err: fmt.Errorf("unknown action type %s", action.Type)}
+}
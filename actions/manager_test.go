@@ -0,0 +1,115 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/webhook"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionManager(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewRepo(db)
+	manager := NewActionManager(repo, &config.ActionsConfig{
+		Commands: map[string]config.ActionCommand{
+			"echo": {Path: "/bin/sh", Args: []string{"-c", "cat"}},
+		},
+	}, &config.WebhookConfig{AllowPrivateNetwork: true})
+
+	received := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		input := &Input{}
+		_ = json.NewDecoder(r.Body).Decode(input)
+		received <- []byte(input.Event)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repository := &models.Repository{ID: uuid.New(), Name: "repo", OwnerID: uuid.New()}
+	operator := &models.User{ID: uuid.New(), Name: "jimmy"}
+
+	newAction := func(action *models.Action) *models.Action {
+		action.RepositoryID = repository.ID
+		action.Active = true
+		action.CreatorID = operator.ID
+		action.CreatedAt = time.Now()
+		action.UpdatedAt = time.Now()
+		action, err := repo.ActionRepo().Insert(ctx, action)
+		require.NoError(t, err)
+		return action
+	}
+	commandAction := newAction(&models.Action{Name: "index", Type: models.CommandAction, Command: "echo", Events: []models.ActionEvent{models.PostTagEvent}})
+	httpAction := newAction(&models.Action{Name: "train", Type: models.HTTPAction, URL: server.URL, Events: []models.ActionEvent{models.PostTagEvent, models.PostMergeEvent}})
+	missingAction := newAction(&models.Action{Name: "missing", Type: models.CommandAction, Command: "missing", Events: []models.ActionEvent{models.PostTagEvent}})
+
+	// event trigger no action
+	manager.Emit(ctx, repository, operator, models.PushEvent, &webhook.CommitPayload{})
+	runs, _, err := repo.ActionRunRepo().List(ctx, models.NewListActionRunParams().SetState(models.RunPending))
+	require.NoError(t, err)
+	require.Len(t, runs, 0)
+
+	manager.Emit(ctx, repository, operator, models.TagCreateEvent, &webhook.TagPayload{Tag: &models.Tag{Name: "v1"}})
+	require.NoError(t, manager.RunPending(ctx))
+
+	getRun := func(actionID uuid.UUID) *models.ActionRun {
+		runs, _, err := repo.ActionRunRepo().List(ctx, models.NewListActionRunParams().SetActionID(actionID))
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		return runs[0]
+	}
+
+	run := getRun(commandAction.ID)
+	require.Equal(t, models.RunSuccess, run.State)
+	require.Equal(t, models.PostTagEvent, run.Event)
+	require.Equal(t, 0, *run.ExitCode)
+	require.NotNil(t, run.StartedAt)
+	require.NotNil(t, run.FinishedAt)
+	input := &Input{}
+	require.NoError(t, json.Unmarshal([]byte(run.Logs), input))
+	require.Equal(t, run.ID, input.RunID)
+	require.Equal(t, "index", input.Action)
+	require.Equal(t, repository.ID, input.Repository.ID)
+	require.Equal(t, operator.Name, input.Operator.Name)
+	payload := &webhook.TagPayload{}
+	require.NoError(t, json.Unmarshal(input.Data, payload))
+	require.Equal(t, "v1", payload.Tag.Name)
+
+	run = getRun(httpAction.ID)
+	require.Equal(t, models.RunFailed, run.State)
+	require.Equal(t, http.StatusInternalServerError, *run.ResponseCode)
+	require.Equal(t, string(models.PostTagEvent), string(<-received))
+
+	run = getRun(missingAction.ID)
+	require.Equal(t, models.RunFailed, run.State)
+	require.Equal(t, "command missing is not configured in server", *run.Message)
+
+	t.Run("fail interrupted runs", func(t *testing.T) {
+		run, err := repo.ActionRunRepo().Insert(ctx, &models.ActionRun{
+			ActionID:     commandAction.ID,
+			RepositoryID: repository.ID,
+			Event:        models.PostCommitEvent,
+			Payload:      "{}",
+			State:        models.RunRunning,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, manager.FailInterrupted(ctx))
+		run, err = repo.ActionRunRepo().Get(ctx, models.NewGetActionRunParams().SetID(run.ID))
+		require.NoError(t, err)
+		require.Equal(t, models.RunFailed, run.State)
+	})
+}
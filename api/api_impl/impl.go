@@ -24,4 +24,20 @@ type APIController struct {
 	controller.GroupController
 	controller.MemberController
 	controller.TagController
+	controller.IpfsController
+	controller.WebhookController
+	controller.ActionController
+	controller.AuditController
+	controller.BranchProtectionController
+	controller.ReviewController
+	controller.CommentController
+	controller.CommitStatusController
+	controller.AutoMergeController
+	controller.AssigneeController
+	controller.LabelController
+	controller.TimelineController
+	controller.DiffController
+	controller.QuotaController
+	controller.StatsController
+	controller.ActivityController
 }
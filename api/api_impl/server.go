@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 
 	"github.com/GitDataAI/jiaozifs/auth/aksk"
@@ -17,6 +18,7 @@ import (
 	"github.com/getkin/kin-openapi/routers/gorillamux"
 
 	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/audit"
 	"github.com/GitDataAI/jiaozifs/auth"
 	"github.com/GitDataAI/jiaozifs/auth/crypt"
 	"github.com/GitDataAI/jiaozifs/config"
@@ -26,6 +28,7 @@ import (
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/getkin/kin-openapi/routers"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/sessions"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/rs/cors"
@@ -54,7 +57,8 @@ func SetupAPI(lc fx.Lifecycle,
 
 	// This is how you set up a basic chi router
 	r := chi.NewRouter()
-	r.Use(httplog.LoggerWithName("http"),
+	r.Use(middleware.RequestID,
+		httplog.LoggerWithName("http"),
 		cors.New(cors.Options{
 			AllowedOrigins: []string{"*"},
 			AllowedMethods: []string{
@@ -72,6 +76,7 @@ func SetupAPI(lc fx.Lifecycle,
 	// Use our validation middleware to check all requests against the
 	// OpenAPI schema.
 	apiRouter := r.With(
+		audit.Middleware(swagger, repo.AuditLogRepo()),
 		OapiRequestValidatorWithOptions(swagger, &openapi3filter.Options{
 			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
 		}),
@@ -85,6 +90,7 @@ func SetupAPI(lc fx.Lifecycle,
 
 	api.HandlerFromMuxWithBaseURL(controller, apiRouter, APIV1Prefix)
 	r.Handle("/api/docs/*", http.StripPrefix("/api/docs", swaggerui.Handler(raw)))
+	r.Get("/ipfs/*", ipfsGateway(r))
 	h, _ := health.New(health.WithComponent(health.Component{
 		Name:    "myservice",
 		Version: "v1.0",
@@ -116,6 +122,26 @@ func SetupAPI(lc fx.Lifecycle,
 	return nil
 }
 
+// ipfsGateway rewrite gateway style request /ipfs/{cid}/{path} to /api/v1/ipfs/{cid}?path={path}, so that it pass through
+// the same validation and authentication as other api
+func ipfsGateway(router http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cidStr, subPath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/ipfs/"), "/")
+
+		//drop route context of current request to route rewrite request from scratch
+		gatewayReq := r.Clone(context.WithValue(r.Context(), chi.RouteCtxKey, nil))
+		gatewayReq.URL.Path = path.Join(APIV1Prefix, "ipfs", cidStr)
+		gatewayReq.URL.RawPath = ""
+		query := gatewayReq.URL.Query()
+		if len(subPath) > 0 {
+			query.Set("path", subPath)
+		}
+		gatewayReq.URL.RawQuery = query.Encode()
+		gatewayReq.RequestURI = gatewayReq.URL.RequestURI()
+		router.ServeHTTP(w, gatewayReq)
+	}
+}
+
 // OapiRequestValidatorWithOptions Creates middleware to validate request by swagger spec.
 func OapiRequestValidatorWithOptions(swagger *openapi3.T, options *openapi3filter.Options) func(next http.Handler) http.Handler {
 	router, err := gorillamux.NewRouter(swagger)
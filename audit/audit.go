@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("audit")
+
+type contextKey string
+
+const recordContextKey contextKey = "audit_record"
+
+// Permission permission checked while handling request, Action is actions of all permissions in checked node joined by comma
+type Permission struct {
+	Action       string
+	Resource     string
+	RepositoryID uuid.UUID
+	Allowed      bool
+}
+
+// Record collect audit information of one request, it is filled by audit and auth middleware and permission checks in controllers
+type Record struct {
+	lk         sync.Mutex
+	operation  string
+	operator   *models.User
+	authMethod models.AuthMethod
+	permission *Permission
+	denied     bool
+}
+
+// WithRecord attach a new empty record to context
+func WithRecord(ctx context.Context) (context.Context, *Record) {
+	record := &Record{}
+	return context.WithValue(ctx, recordContextKey, record), record
+}
+
+// FromContext return record of request, nil if request not audited. all methods of record is nil safe
+func FromContext(ctx context.Context) *Record {
+	record, _ := ctx.Value(recordContextKey).(*Record)
+	return record
+}
+
+// SetOperation record operation id of request in api spec
+func (record *Record) SetOperation(operation string) {
+	if record == nil {
+		return
+	}
+	record.lk.Lock()
+	defer record.lk.Unlock()
+	record.operation = operation
+}
+
+// SetOperator record who send the request and how it authenticated
+func (record *Record) SetOperator(operator *models.User, authMethod models.AuthMethod) {
+	if record == nil {
+		return
+	}
+	record.lk.Lock()
+	defer record.lk.Unlock()
+	record.operator = operator
+	record.authMethod = authMethod
+}
+
+// SetPermission record permission checked and whether it is allowed. handlers check the permission of action they perform last,
+// and stop at the first denied check, so the last permission is the action of request, any denied check makes request denied
+func (record *Record) SetPermission(permission Permission) {
+	if record == nil {
+		return
+	}
+	record.lk.Lock()
+	defer record.lk.Unlock()
+	record.permission = &permission
+	if !permission.Allowed {
+		record.denied = true
+	}
+}
+
+// Log convert record to the audit log of request, action is empty if no permission checked
+func (record *Record) Log(r *http.Request, statusCode int) *models.AuditLog {
+	record.lk.Lock()
+	defer record.lk.Unlock()
+
+	outcome := Outcome(statusCode)
+	if record.denied {
+		outcome = models.AuditDenied
+	}
+
+	auditLog := &models.AuditLog{
+		AuthMethod: record.authMethod,
+		Operation:  record.operation,
+		RequestID:  middleware.GetReqID(r.Context()),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		StatusCode: statusCode,
+		Outcome:    outcome,
+		CreatedAt:  time.Now(),
+	}
+	if record.operator != nil {
+		auditLog.ActorID = record.operator.ID
+		auditLog.ActorName = record.operator.Name
+	}
+	if record.permission != nil {
+		auditLog.Action = record.permission.Action
+		auditLog.Resource = record.permission.Resource
+		auditLog.RepositoryID = record.permission.RepositoryID
+	}
+	return auditLog
+}
+
+// Outcome get outcome of request by response status code
+func Outcome(statusCode int) models.AuditOutcome {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return models.AuditDenied
+	case statusCode >= http.StatusBadRequest:
+		return models.AuditFailure
+	default:
+		return models.AuditSuccess
+	}
+}
+
+// IsMutating check whether request may change anything, only these requests are audited
+func IsMutating(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// Middleware record one audit log of every mutating request after it has been handled
+func Middleware(swagger *openapi3.T, repo models.IAuditLogRepo) func(next http.Handler) http.Handler {
+	router, err := legacy.NewRouter(swagger)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !IsMutating(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, record := WithRecord(r.Context())
+			if route, _, err := router.FindRoute(r); err == nil && route.Operation != nil {
+				record.SetOperation(route.Operation.OperationID)
+			}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			statusCode := ww.Status()
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+			// use a fresh context, request context may be canceled when client closed
+			_, err := repo.Insert(context.Background(), record.Log(r, statusCode))
+			if err != nil {
+				log.Errorf("insert audit log of %s %s %v", r.Method, r.URL.Path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAuditLogRepo struct {
+	logs []*models.AuditLog
+}
+
+func (m *memoryAuditLogRepo) Insert(_ context.Context, log *models.AuditLog) (*models.AuditLog, error) {
+	m.logs = append(m.logs, log)
+	return log, nil
+}
+
+func (m *memoryAuditLogRepo) List(_ context.Context, _ *models.ListAuditLogParams) ([]*models.AuditLog, bool, error) {
+	return m.logs, false, nil
+}
+
+func (m *memoryAuditLogRepo) Walk(_ context.Context, _ *models.ListAuditLogParams, fn func(*models.AuditLog) error) error {
+	for _, log := range m.logs {
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestOutcome(t *testing.T) {
+	require.Equal(t, models.AuditSuccess, Outcome(http.StatusOK))
+	require.Equal(t, models.AuditSuccess, Outcome(http.StatusCreated))
+	require.Equal(t, models.AuditDenied, Outcome(http.StatusUnauthorized))
+	require.Equal(t, models.AuditDenied, Outcome(http.StatusForbidden))
+	require.Equal(t, models.AuditFailure, Outcome(http.StatusNotFound))
+	require.Equal(t, models.AuditFailure, Outcome(http.StatusInternalServerError))
+}
+
+const testSpec = `
+openapi: 3.0.0
+info: {title: test, version: 1.0.0}
+servers:
+  - url: /api/v1
+paths:
+  /repos/{owner}/{repository}/branch:
+    parameters:
+      - {in: path, name: owner, required: true, schema: {type: string}}
+      - {in: path, name: repository, required: true, schema: {type: string}}
+    get:
+      operationId: getBranch
+      responses: {200: {description: ok}}
+    delete:
+      operationId: deleteBranch
+      responses: {200: {description: ok}}
+  /wip/{owner}/{repository}/commit:
+    parameters:
+      - {in: path, name: owner, required: true, schema: {type: string}}
+      - {in: path, name: repository, required: true, schema: {type: string}}
+    post:
+      operationId: commitWip
+      responses: {200: {description: ok}}
+  /auth/login:
+    post:
+      operationId: login
+      responses: {200: {description: ok}}
+`
+
+func TestMiddleware(t *testing.T) {
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	require.NoError(t, err)
+
+	repo := &memoryAuditLogRepo{}
+	operator := &models.User{ID: uuid.New(), Name: "jimmy"}
+	repoID := uuid.New()
+
+	handler := Middleware(swagger, repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record := FromContext(r.Context())
+		record.SetOperator(operator, models.AuthMethodAksk)
+		record.SetPermission(Permission{Action: "repo:ReadBranch", Resource: "arn", RepositoryID: repoID, Allowed: true})
+		record.SetPermission(Permission{Action: "repo:DeleteBranch", Resource: "arn", RepositoryID: repoID, Allowed: true})
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("skip read request", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/repos/a/b/branch", nil))
+		require.Len(t, repo.logs, 0)
+	})
+
+	t.Run("record mutating request once", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/repos/a/b/branch", nil))
+		require.Len(t, repo.logs, 1)
+		auditLog := repo.logs[0]
+		require.Equal(t, operator.ID, auditLog.ActorID)
+		require.Equal(t, operator.Name, auditLog.ActorName)
+		require.Equal(t, models.AuthMethodAksk, auditLog.AuthMethod)
+		require.Equal(t, "deleteBranch", auditLog.Operation)
+		require.Equal(t, "repo:DeleteBranch", auditLog.Action)
+		require.Equal(t, repoID, auditLog.RepositoryID)
+		require.Equal(t, http.MethodDelete, auditLog.Method)
+		require.Equal(t, http.StatusOK, auditLog.StatusCode)
+		require.Equal(t, models.AuditSuccess, auditLog.Outcome)
+	})
+
+	t.Run("denied permission", func(t *testing.T) {
+		repo.logs = nil
+		deniedHandler := Middleware(swagger, repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			record := FromContext(r.Context())
+			record.SetPermission(Permission{Action: "repo:ReadWip,repo:WriteBranch", Allowed: false})
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		deniedHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/wip/a/b/commit", nil))
+		require.Len(t, repo.logs, 1)
+		require.Equal(t, "commitWip", repo.logs[0].Operation)
+		require.Equal(t, "repo:ReadWip,repo:WriteBranch", repo.logs[0].Action)
+		require.Equal(t, models.AuditDenied, repo.logs[0].Outcome)
+		require.Equal(t, uuid.Nil, repo.logs[0].ActorID)
+	})
+
+	t.Run("request without permission check", func(t *testing.T) {
+		repo.logs = nil
+		loginHandler := Middleware(swagger, repo)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		loginHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil))
+		require.Len(t, repo.logs, 1)
+		require.Equal(t, "login", repo.logs[0].Operation)
+		require.Empty(t, repo.logs[0].Action)
+		require.Equal(t, models.AuditDenied, repo.logs[0].Outcome)
+	})
+}
+
+func TestNilRecord(t *testing.T) {
+	record := FromContext(context.Background())
+	require.Nil(t, record)
+	record.SetOperator(&models.User{}, models.AuthMethodJWT)
+	record.SetOperation("")
+	record.SetPermission(Permission{})
+}
@@ -9,6 +9,7 @@ import (
 
 	logging "github.com/ipfs/go-log/v2"
 
+	"github.com/GitDataAI/jiaozifs/audit"
 	"github.com/GitDataAI/jiaozifs/utils"
 
 	"github.com/GitDataAI/jiaozifs/auth/aksk"
@@ -87,13 +88,14 @@ func Middleware(swagger *openapi3.T,
 				_, _ = w.Write([]byte(err.Error()))
 				return
 			}
-			user, err := checkSecurityRequirements(r, securityRequirements, authenticator, sessionStore, secretStore, verifier, userRepo, akskRepo)
+			user, authMethod, err := checkSecurityRequirements(r, securityRequirements, authenticator, sessionStore, secretStore, verifier, userRepo, akskRepo)
 			if err != nil {
 				w.WriteHeader(http.StatusUnauthorized)
 				_, _ = w.Write([]byte(err.Error()))
 				return
 			}
 			if user != nil {
+				audit.FromContext(r.Context()).SetOperator(user, authMethod)
 				r = r.WithContext(WithOperator(r.Context(), user))
 			}
 			next.ServeHTTP(w, r)
@@ -111,9 +113,10 @@ func checkSecurityRequirements(r *http.Request,
 	verifier aksk.Verifier,
 	userRepo models.IUserRepo,
 	akskRepo models.IAkskRepo,
-) (*models.User, error) {
+) (*models.User, models.AuthMethod, error) {
 	ctx := r.Context()
 	var user *models.User
+	var authMethod models.AuthMethod
 	var err error
 
 	for _, securityRequirement := range securityRequirements {
@@ -129,6 +132,7 @@ func checkSecurityRequirements(r *http.Request,
 				continue
 			}
 			token := parts[1]
+			authMethod = models.AuthMethodJWT
 			user, err = userByToken(ctx, userRepo, secretStore.SharedSecret(), token)
 		} else if utils.Contain(securityKeys, "basic_auth") {
 			// validate using basic auth
@@ -137,6 +141,7 @@ func checkSecurityRequirements(r *http.Request,
 				continue
 			}
 
+			authMethod = models.AuthMethodBasic
 			user, err = userByAuth(ctx, authenticator, userName, password)
 		} else if utils.Contain(securityKeys, "cookie_auth") {
 			var internalAuthSession *sessions.Session
@@ -148,27 +153,29 @@ func checkSecurityRequirements(r *http.Request,
 			if token == "" {
 				continue
 			}
+			authMethod = models.AuthMethodSession
 			user, err = userByToken(ctx, userRepo, secretStore.SharedSecret(), token)
 		} else if utils.Contain(securityKeys, aksk.AccessKeykey) {
 			isAkskRequest := verifier.IsAkskCredential(r)
 			if !isAkskRequest {
 				continue
 			}
+			authMethod = models.AuthMethodAksk
 			user, err = userByAKSK(ctx, akskRepo, userRepo, verifier, r)
 		} else {
 			// unknown security requirement to check
 			log.With("provider", securityKeys).Error("Authentication middleware unknown security requirement provider")
-			return nil, ErrAuthenticatingRequest
+			return nil, models.AuthMethodNone, ErrAuthenticatingRequest
 		}
 
 		if err != nil {
-			return nil, err
+			return nil, models.AuthMethodNone, err
 		}
 		if user != nil {
-			return user, nil
+			return user, authMethod, nil
 		}
 	}
-	return nil, nil
+	return nil, models.AuthMethodNone, nil
 }
 
 func userByAKSK(ctx context.Context, akskRepo models.IAkskRepo, userRepo models.IUserRepo, verifier aksk.Verifier, r *http.Request) (*models.User, error) {
@@ -52,6 +52,12 @@ var statementByName = map[string]rbacmodel.Statement{
 			rbacmodel.ReadMergeRequestAction,
 			rbacmodel.UpdateMergeRequestAction,
 			rbacmodel.ListMergeRequestAction,
+			rbacmodel.ReviewMergeRequestAction,
+
+			rbacmodel.ReadCommitStatusAction,
+			rbacmodel.WriteCommitStatusAction,
+			rbacmodel.ReadLabelAction,
+			rbacmodel.WriteLabelAction,
 
 			rbacmodel.ReadConfigAction,
 			rbacmodel.WriteConfigAction,
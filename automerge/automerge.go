@@ -0,0 +1,280 @@
+package automerge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/block/params"
+	"github.com/GitDataAI/jiaozifs/hooks"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/protection"
+	"github.com/GitDataAI/jiaozifs/quota"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/GitDataAI/jiaozifs/webhook"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+)
+
+var log = logging.Logger("automerge")
+
+var pollInterval = 5 * time.Second
+
+// ErrTargetMoved target branch moved after merge state checked
+var ErrTargetMoved = errors.New("target branch moved")
+
+// Notifier notify auto merger to check merge requests immediately
+type Notifier interface {
+	Notify()
+}
+
+var _ Notifier = (*AutoMerger)(nil)
+
+// AutoMerger merge requests marked auto merge in background once they become mergeable
+type AutoMerger struct {
+	repo          models.IRepo
+	checker       *MergeChecker
+	emitter       webhook.Emitter
+	quota         *quota.Manager
+	adapterConfig params.AdapterConfig
+	notify        chan struct{}
+}
+
+func NewAutoMerger(repo models.IRepo, permissionCheck rbac.PermissionCheck, emitter webhook.Emitter, quotaManager *quota.Manager, adapterConfig params.AdapterConfig) *AutoMerger {
+	return &AutoMerger{
+		repo:          repo,
+		checker:       NewMergeChecker(repo, permissionCheck),
+		emitter:       emitter,
+		quota:         quotaManager,
+		adapterConfig: adapterConfig,
+		notify:        make(chan struct{}, 1),
+	}
+}
+
+// NewAutoMergeNotifier create auto merger and run merge loop along with lifecycle
+func NewAutoMergeNotifier(lc fx.Lifecycle, repo models.IRepo, permissionCheck rbac.PermissionCheck, emitter webhook.Emitter, quotaManager *quota.Manager, adapterConfig params.AdapterConfig) Notifier {
+	merger := NewAutoMerger(repo, permissionCheck, emitter, quotaManager, adapterConfig)
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			go merger.Run(ctx)
+			return nil
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return merger
+}
+
+func (merger *AutoMerger) Notify() {
+	select {
+	case merger.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run try to merge auto merge requests until ctx done
+func (merger *AutoMerger) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-merger.notify:
+		}
+
+		err := merger.MergeReady(ctx)
+		if err != nil {
+			log.Errorf("auto merge merge requests %v", err)
+		}
+	}
+}
+
+// MergeReady try to merge every auto merge request, failure of one merge request not stop others
+func (merger *AutoMerger) MergeReady(ctx context.Context) error {
+	autoMerges, err := merger.repo.AutoMergeRepo().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, autoMerge := range autoMerges {
+		err = merger.TryMerge(ctx, autoMerge)
+		if err != nil {
+			log.Errorf("auto merge merge request %s %v", autoMerge.MergeRequestID, err)
+		}
+	}
+	return nil
+}
+
+// TryMerge merge the merge request if it is mergeable, otherwise record the reason and wait for next attempt.
+// auto merge is removed once merge request is merged or closed
+func (merger *AutoMerger) TryMerge(ctx context.Context, autoMerge *models.AutoMerge) error {
+	mergeRequest, err := merger.repo.MergeRequestRepo().Get(ctx, models.NewGetMergeRequestParams().SetID(autoMerge.MergeRequestID))
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return err
+	}
+	if err != nil || mergeRequest.MergeState != models.MergeStateInit {
+		_, err = merger.repo.AutoMergeRepo().Delete(ctx, models.NewDeleteAutoMergeParams().SetMergeRequestID(autoMerge.MergeRequestID))
+		return err
+	}
+
+	operator, err := merger.repo.UserRepo().Get(ctx, models.NewGetUserParams().SetID(autoMerge.OperatorID))
+	if err != nil {
+		return err
+	}
+
+	repository, err := merger.repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetID(mergeRequest.TargetRepoID))
+	if err != nil {
+		return err
+	}
+
+	sourceBranch, err := merger.repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID))
+	if err != nil {
+		return err
+	}
+
+	targetBranch, err := merger.repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID))
+	if err != nil {
+		return err
+	}
+
+	// the same checks as merging by hand, permission of operator may be revoked after auto merge enabled
+	// head checked here is exactly what is merged, wait for next round if source branch moves before merging
+	sourceHead := sourceBranch.CommitHash
+	err = merger.checker.Check(ctx, operator, repository, mergeRequest, targetBranch.Name, sourceHead, autoMerge.ConflictResolve)
+	if isWaitable(err) {
+		return merger.wait(ctx, autoMerge, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, merger.repo, merger.adapterConfig)
+	if err != nil {
+		return err
+	}
+
+	err = workRepo.CheckOut(ctx, versionmgr.InBranch, targetBranch.Name)
+	if err != nil {
+		return err
+	}
+
+	changePairs, err := workRepo.GetMergeState(ctx, sourceHead, targetBranch.CommitHash)
+	if err != nil {
+		return err
+	}
+
+	err = CheckMergeState(changePairs, autoMerge.ConflictResolve)
+	if err != nil {
+		return merger.wait(ctx, autoMerge, err.Error())
+	}
+
+	var commit *models.Commit
+	err = merger.repo.Transaction(ctx, func(repo models.IRepo) error {
+		// branches are locked until transaction end, so that no one move them between the check and merging
+		_, currentTarget, err := LockBranches(ctx, repo, mergeRequest, sourceHead)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(currentTarget.CommitHash, targetBranch.CommitHash) {
+			return fmt.Errorf("%w from %s to %s", ErrTargetMoved, targetBranch.CommitHash.Hex(), currentTarget.CommitHash.Hex())
+		}
+
+		workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, repo, merger.adapterConfig)
+		if err != nil {
+			return err
+		}
+		// blobs merged by merge drivers take space too
+		workRepo.WithQuota(merger.quota)
+
+		err = workRepo.CheckOut(ctx, versionmgr.InBranch, targetBranch.Name)
+		if err != nil {
+			return err
+		}
+
+		commit, err = workRepo.Merge(ctx, sourceHead, autoMerge.Message, versionmgr.ResolveFromSelector(autoMerge.ConflictResolve))
+		if err != nil {
+			return err
+		}
+
+		// hooks run in transaction, merge commit is rolled back if rejected
+		report, err := hooks.NewRunner(repo, repository.ID, workRepo).Run(ctx, hooks.PreMerge, targetBranch.Name, currentTarget.CommitHash, commit.TreeHash)
+		if err != nil {
+			return err
+		}
+		if err = report.Err(); err != nil {
+			return err
+		}
+
+		err = repo.MergeRequestRepo().UpdateByID(ctx, models.NewUpdateMergeRequestParams(repository.ID, mergeRequest.Sequence).SetState(models.MergeStateMerged))
+		if err != nil {
+			return err
+		}
+
+		err = repo.TimelineRepo().Insert(ctx, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, models.TimelineMerged, utils.String(commit.Hash.Hex())))
+		if err != nil {
+			return err
+		}
+
+		_, err = repo.AutoMergeRepo().Delete(ctx, models.NewDeleteAutoMergeParams().SetMergeRequestID(mergeRequest.ID))
+		return err
+	})
+	if errors.Is(err, ErrTargetMoved) || isWaitable(err) {
+		return merger.wait(ctx, autoMerge, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	// emit after transaction committed, avoid notify the changes rolled back
+	targetBranch.CommitHash = commit.Hash
+	mergeRequest.MergeState = models.MergeStateMerged
+	merger.emitter.Emit(ctx, repository, operator, models.PushEvent, &webhook.CommitPayload{Branch: targetBranch, Commit: commit})
+	merger.emitter.Emit(ctx, repository, operator, models.MergeRequestMergeEvent, &webhook.MergeRequestPayload{MergeRequest: mergeRequest, Commit: commit})
+	return nil
+}
+
+// isWaitable return true if merge is rejected by checks which may pass later, such as approvals, status checks or permission granted again
+func isWaitable(err error) bool {
+	return errors.Is(err, ErrMergeNotAllowed) ||
+		errors.Is(err, ErrDraft) ||
+		errors.Is(err, versionmgr.ErrInvalidResolution) ||
+		errors.Is(err, versionmgr.ErrInvalidAttributes) ||
+		errors.Is(err, ErrUnresolvedConflict) ||
+		errors.Is(err, ErrSourceMoved) ||
+		errors.Is(err, protection.ErrBranchProtected) ||
+		errors.Is(err, hooks.ErrHookFailed) ||
+		errors.Is(err, quota.ErrQuotaExceeded)
+}
+
+// wait record why merge request can not be merged now, it will be tried again later
+func (merger *AutoMerger) wait(ctx context.Context, autoMerge *models.AutoMerge, reason string) error {
+	if autoMerge.Reason != nil && *autoMerge.Reason == reason {
+		return nil
+	}
+	return merger.repo.AutoMergeRepo().UpdateByID(ctx, models.NewUpdateAutoMergeParams(autoMerge.ID).SetReason(reason))
+}
+
+// UnresolvedConflicts return conflict paths which neither specified in conflict resolve nor resolved by merge driver in attributes
+func UnresolvedConflicts(changePairs []*versionmgr.ChangePair, conflictResolve map[string]string) []string {
+	var unresolved []string
+	for _, changePair := range changePairs {
+		if !changePair.IsConflict || changePair.MergeDriver.AutoResolve() {
+			continue
+		}
+		if _, ok := conflictResolve[changePair.Path()]; !ok {
+			unresolved = append(unresolved, changePair.Path())
+		}
+	}
+	sort.Strings(unresolved)
+	return unresolved
+}
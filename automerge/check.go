@@ -0,0 +1,122 @@
+package automerge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/protection"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+)
+
+var (
+	// ErrMergeNotAllowed operator has no permission to merge merge requests of repository
+	ErrMergeNotAllowed = errors.New("not allowed to merge")
+	// ErrDraft draft merge request can not be merged
+	ErrDraft = errors.New("draft merge request can not be merged")
+	// ErrUnresolvedConflict some conflicts of merge state are not resolved
+	ErrUnresolvedConflict = errors.New("conflicts not resolved")
+	// ErrSourceMoved source branch moved after checks passed, the new head is not approved or checked yet
+	ErrSourceMoved = errors.New("source branch moved")
+)
+
+// MergePermission permission required to merge merge requests of repository
+func MergePermission(repository *models.Repository) rbac.Node {
+	return rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.MergeMergeRequestAction,
+			Resource: rbacmodel.RepoURArn(repository.OwnerID.String(), repository.ID.String()),
+		},
+	}
+}
+
+// MergeChecker checks shared by merge handler and auto merger, so that auto merge never merges what the operator can not merge by hand
+type MergeChecker struct {
+	repo            models.IRepo
+	permissionCheck rbac.PermissionCheck
+}
+
+func NewMergeChecker(repo models.IRepo, permissionCheck rbac.PermissionCheck) *MergeChecker {
+	return &MergeChecker{repo: repo, permissionCheck: permissionCheck}
+}
+
+// Check check operator is allowed to merge sourceHead of merge request into target branch now with conflictResolve,
+// merge exactly sourceHead after checks passed and call CheckMergeState after merge state computed
+func (checker *MergeChecker) Check(ctx context.Context, operator *models.User, repository *models.Repository, mergeRequest *models.MergeRequest, targetBranchName string, sourceHead hash.Hash, conflictResolve map[string]string) error {
+	resp, err := checker.permissionCheck.AuthorizeMember(ctx, repository.ID, &rbac.AuthorizationRequest{
+		OperatorID:          operator.ID,
+		RequiredPermissions: MergePermission(repository),
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil || !resp.Allowed {
+		return fmt.Errorf("%w: user %s can not merge merge requests of %s", ErrMergeNotAllowed, operator.Name, repository.Name)
+	}
+
+	if mergeRequest.Draft {
+		return ErrDraft
+	}
+
+	err = protection.NewChecker(checker.repo).CheckMerge(ctx, mergeRequest, targetBranchName, sourceHead, operator)
+	if err != nil {
+		return err
+	}
+	return ValidateConflictResolve(ctx, checker.repo.FileTreeRepo(repository.ID), conflictResolve)
+}
+
+// ValidateConflictResolve check resolution of each path is left, right, delete or hash of existing blob
+func ValidateConflictResolve(ctx context.Context, fileTreeRepo models.IFileTreeRepo, conflictResolve map[string]string) error {
+	for path, resolve := range conflictResolve {
+		switch resolve {
+		case versionmgr.ResolveLeft, versionmgr.ResolveRight, versionmgr.ResolveDelete:
+			continue
+		}
+
+		blobHash, err := hash.FromHex(resolve)
+		if err != nil || blobHash.IsEmpty() {
+			return fmt.Errorf("%w: resolution of path %s must be left, right, delete or hash of blob", versionmgr.ErrInvalidResolution, path)
+		}
+
+		_, err = fileTreeRepo.Blob(ctx, blobHash)
+		if errors.Is(err, models.ErrNotFound) {
+			return fmt.Errorf("%w: blob %s of path %s not found", versionmgr.ErrInvalidResolution, resolve, path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LockBranches lock source and target branch of merge request in transaction of repo, ErrSourceMoved returned if
+// head of source branch is not sourceHead checked before
+func LockBranches(ctx context.Context, repo models.IRepo, mergeRequest *models.MergeRequest, sourceHead hash.Hash) (*models.Branch, *models.Branch, error) {
+	sourceBranch, err := repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID).SetForUpdate())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(sourceBranch.CommitHash, sourceHead) {
+		return nil, nil, fmt.Errorf("%w from %s to %s", ErrSourceMoved, sourceHead.Hex(), sourceBranch.CommitHash.Hex())
+	}
+
+	targetBranch, err := repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID).SetForUpdate())
+	if err != nil {
+		return nil, nil, err
+	}
+	return sourceBranch, targetBranch, nil
+}
+
+// CheckMergeState return ErrUnresolvedConflict if any conflict of current merge state is not resolved
+func CheckMergeState(changePairs []*versionmgr.ChangePair, conflictResolve map[string]string) error {
+	if unresolved := UnresolvedConflicts(changePairs, conflictResolve); len(unresolved) > 0 {
+		return fmt.Errorf("%w at paths %s", ErrUnresolvedConflict, strings.Join(unresolved, ","))
+	}
+	return nil
+}
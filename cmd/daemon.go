@@ -9,20 +9,25 @@ import (
 
 	"github.com/pelletier/go-toml/v2"
 
+	"github.com/GitDataAI/jiaozifs/actions"
 	apiImpl "github.com/GitDataAI/jiaozifs/api/api_impl"
 	"github.com/GitDataAI/jiaozifs/auth"
 	"github.com/GitDataAI/jiaozifs/auth/crypt"
+	"github.com/GitDataAI/jiaozifs/automerge"
 	"github.com/GitDataAI/jiaozifs/block/params"
 	"github.com/GitDataAI/jiaozifs/config"
 	"github.com/GitDataAI/jiaozifs/fx_opt"
 	"github.com/GitDataAI/jiaozifs/models"
 	"github.com/GitDataAI/jiaozifs/models/migrations"
+	"github.com/GitDataAI/jiaozifs/quota"
 	"github.com/GitDataAI/jiaozifs/utils"
 	"github.com/GitDataAI/jiaozifs/version"
+	"github.com/GitDataAI/jiaozifs/webhook"
 	"github.com/gorilla/sessions"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/spf13/cobra"
 	"github.com/uptrace/bun"
+	"go.uber.org/fx"
 )
 
 var log = logging.Logger("main")
@@ -60,6 +65,10 @@ var daemonCmd = &cobra.Command{
 			fx_opt.Override(new(*config.APIConfig), &cfg.API),
 			fx_opt.Override(new(*config.AuthConfig), &cfg.Auth),
 			fx_opt.Override(new(*config.DatabaseConfig), &cfg.Database),
+			fx_opt.Override(new(*config.ActionsConfig), &cfg.Actions),
+			fx_opt.Override(new(*config.WebhookConfig), &cfg.Webhook),
+			fx_opt.Override(new(*config.QuotaConfig), &cfg.Quota),
+			fx_opt.Override(new(*config.DiffConfig), &cfg.Diff),
 			fx_opt.Override(new(params.AdapterConfig), &cfg.Blockstore),
 			//database
 			fx_opt.Override(new(*bun.DB), models.SetupDatabase),
@@ -75,6 +84,13 @@ var daemonCmd = &cobra.Command{
 			fx_opt.Override(new(rbac.PermissionCheck), func(repo models.IRepo) rbac.PermissionCheck {
 				return rbac.NewRbacAuth(repo)
 			}),
+			//webhook and actions
+			fx_opt.Override(new(webhook.Emitter), func(lc fx.Lifecycle, repo models.IRepo, actionsCfg *config.ActionsConfig, webhookCfg *config.WebhookConfig) webhook.Emitter {
+				return webhook.Emitters{webhook.NewWebhookEmitter(lc, repo, webhookCfg), actions.NewActionEmitter(lc, repo, actionsCfg, webhookCfg)}
+			}),
+			//auto merge
+			fx_opt.Override(new(automerge.Notifier), automerge.NewAutoMergeNotifier),
+			fx_opt.Override(new(*quota.Manager), quota.NewManager),
 
 			//api
 			fx_opt.Override(new(crypt.SecretStore), auth.NewSectetStore),
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	path2 "path"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/spf13/cobra"
+)
+
+// importCarCmd represents the import car command
+var importCarCmd = &cobra.Command{
+	Use:   "importcar",
+	Short: "import files of unixfs dag in car file into wip",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := GetClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		path, err := cmd.Flags().GetString("path")
+		if err != nil {
+			return err
+		}
+		if len(path) == 0 {
+			return errors.New("path must be set")
+		}
+
+		owner, err := cmd.Flags().GetString("owner")
+		if err != nil {
+			return err
+		}
+
+		repo, err := cmd.Flags().GetString("repo")
+		if err != nil {
+			return err
+		}
+		if len(owner) == 0 || len(repo) == 0 {
+			return errors.New("owner and repo must be set")
+		}
+
+		refName, err := cmd.Flags().GetString("refName")
+		if err != nil {
+			return err
+		}
+		if len(refName) == 0 {
+			return errors.New("refName must be set")
+		}
+
+		uploadPath, err := cmd.Flags().GetString("uploadPath")
+		if err != nil {
+			return err
+		}
+		if len(uploadPath) > 0 {
+			uploadPath = path2.Clean(uploadPath)
+		}
+
+		replace, err := cmd.Flags().GetBool("replace")
+		if err != nil {
+			return err
+		}
+
+		fs, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fs.Close() //nolint
+
+		_, err = client.GetWip(cmd.Context(), owner, repo, &api.GetWipParams{RefName: refName})
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.ImportCarWithBody(cmd.Context(), owner, repo, &api.ImportCarParams{
+			RefName:   refName,
+			Path:      utils.String(uploadPath),
+			IsReplace: utils.Bool(replace),
+		}, "application/octet-stream", fs)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close() //nolint
+
+		if resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("import car failed %d, %s", resp.StatusCode, tryLogError(resp))
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		var files []string
+		err = json.Unmarshal(data, &files)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fmt.Println("Import file success, dest path", file)
+		}
+		fmt.Printf("Import car success, %d files imported\n", len(files))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCarCmd)
+
+	importCarCmd.Flags().String("path", "", "path of car file to import")
+	importCarCmd.Flags().String("owner", "", "owner")
+	importCarCmd.Flags().String("repo", "", "repo")
+	importCarCmd.Flags().String("refName", "main", "branch name")
+	importCarCmd.Flags().String("uploadPath", "", "path to save in server")
+	importCarCmd.Flags().Bool("replace", false, "replace existing files with different content")
+}
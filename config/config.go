@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
 	"github.com/mitchellh/go-homedir"
 	ms "github.com/mitchellh/mapstructure"
@@ -22,6 +23,10 @@ type Config struct {
 	API      APIConfig      `mapstructure:"api"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Auth     AuthConfig     `mapstructure:"auth"`
+	Actions  ActionsConfig  `mapstructure:"actions"`
+	Webhook  WebhookConfig  `mapstructure:"webhook"`
+	Quota    QuotaConfig    `mapstructure:"quota"`
+	Diff     DiffConfig     `mapstructure:"diff"`
 
 	Blockstore BlockStoreConfig `mapstructure:"blockstore"`
 }
@@ -39,6 +44,46 @@ type DatabaseConfig struct {
 	Debug      bool   `mapstructure:"debug"`
 }
 
+// ActionsConfig config of actions run after operations in repository
+type ActionsConfig struct {
+	// Commands executables which command actions can run, actions refer to them by name
+	// so that repository users can not run arbitrary programs on server
+	Commands map[string]ActionCommand `mapstructure:"commands"`
+	// Timeout max duration of one run
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// WebhookConfig config of requests sent to urls given by users, used by webhooks and http actions
+type WebhookConfig struct {
+	// AllowPrivateNetwork allow requests to loopback, private, link-local and unspecified addresses,
+	// only enable it when all users are trusted to reach internal services
+	AllowPrivateNetwork bool `mapstructure:"allow_private_network"`
+}
+
+// ActionCommand executable run by command action. environment of server is not inherited, only Env is passed
+type ActionCommand struct {
+	Path string   `mapstructure:"path"`
+	Args []string `mapstructure:"args"`
+	Env  []string `mapstructure:"env"`
+}
+
+// QuotaConfig default storage limits of repositories using public storage, admin can override them per user or repository.
+// non-positive value means unlimited
+type QuotaConfig struct {
+	RepositoryMaxBytes   int64 `mapstructure:"repository_max_bytes"`
+	RepositoryMaxObjects int64 `mapstructure:"repository_max_objects"`
+	// UserMaxBytes UserMaxObjects limits of all repositories owned by one user
+	UserMaxBytes   int64 `mapstructure:"user_max_bytes"`
+	UserMaxObjects int64 `mapstructure:"user_max_objects"`
+}
+
+// DiffConfig limits of content diff
+type DiffConfig struct {
+	// MaxTableRows table with more rows is not diffed, hashes of rows are kept in memory while diffing,
+	// so raise it only if server has enough memory for tables of that size
+	MaxTableRows int `mapstructure:"max_table_rows"`
+}
+
 type AuthConfig struct {
 	SecretKey string `mapstructure:"secretKey"`
 
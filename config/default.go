@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/hex"
+	"time"
 )
 
 var DefaultLocalBSPath = "~/.jiaozifs/blockstore"
@@ -28,6 +29,12 @@ var defaultCfg = Config{
 			AllowedExternalPrefixes []string
 		}{Path: DefaultLocalBSPath, ImportEnabled: false, ImportHidden: false, AllowedExternalPrefixes: nil}),
 	},
+	Actions: ActionsConfig{
+		Timeout: 10 * time.Minute,
+	},
+	Diff: DiffConfig{
+		MaxTableRows: 1 << 20,
+	},
 	Auth: AuthConfig{
 		SecretKey: hex.EncodeToString([]byte("THIS_MUST_BE_CHANGED_IN_PRODUCTION")),
 		UIConfig: struct {
@@ -0,0 +1,181 @@
+package contentdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// IsNotebook check whether path is jupyter notebook
+func IsNotebook(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".ipynb")
+}
+
+type NotebookCellChangeType string
+
+const (
+	NotebookCellAdded    NotebookCellChangeType = "added"
+	NotebookCellRemoved  NotebookCellChangeType = "removed"
+	NotebookCellModified NotebookCellChangeType = "modified"
+)
+
+// NotebookCellDiff changes of cell, OldIndex or NewIndex is -1 if cell not exit in that side.
+// Outputs contains changes of outputs and execution count
+type NotebookCellDiff struct {
+	Type     NotebookCellChangeType
+	OldIndex int
+	NewIndex int
+	CellType string
+	Source   []Hunk
+	Metadata []StructChange
+	Outputs  []StructChange
+}
+
+// NotebookDiff changes of notebook metadata and cells, unchanged cells are not included
+type NotebookDiff struct {
+	Metadata []StructChange
+	Cells    []NotebookCellDiff
+}
+
+// NotebookOption option of notebook diff
+type NotebookOption struct {
+	// IgnoreOutputs not compare outputs and execution count of cells
+	IgnoreOutputs bool
+}
+
+type notebookCell struct {
+	CellType       string                 `json:"cell_type"`
+	Source         interface{}            `json:"source"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	Outputs        interface{}            `json:"outputs"`
+	ExecutionCount interface{}            `json:"execution_count"`
+}
+
+func (cell notebookCell) source() string {
+	switch source := cell.Source.(type) {
+	case string:
+		return source
+	case []interface{}:
+		var builder strings.Builder
+		for _, line := range source {
+			builder.WriteString(fmt.Sprint(line))
+		}
+		return builder.String()
+	}
+	return ""
+}
+
+func (cell notebookCell) outputs() interface{} {
+	return map[string]interface{}{
+		"execution_count": cell.ExecutionCount,
+		"outputs":         cell.Outputs,
+	}
+}
+
+type notebook struct {
+	Cells    []notebookCell         `json:"cells"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+func decodeNotebook(data []byte) (*notebook, error) {
+	nb := &notebook{}
+	if data == nil {
+		return nb, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(nb); err != nil {
+		return nil, fmt.Errorf("decode notebook %v %w", err, ErrInvalidDocument)
+	}
+	return nb, nil
+}
+
+// NotebookDiffOf compare two notebooks, cells are matched by cell type and source, nil content means notebook not exit
+func NotebookDiffOf(oldData, newData []byte, opt NotebookOption) (*NotebookDiff, error) {
+	oldNotebook, err := decodeNotebook(oldData)
+	if err != nil {
+		return nil, err
+	}
+	newNotebook, err := decodeNotebook(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &NotebookDiff{
+		Metadata: compareValue(make([]StructChange, 0), "", mapValue(oldNotebook.Metadata), mapValue(newNotebook.Metadata)),
+		Cells:    make([]NotebookCellDiff, 0),
+	}
+
+	cellKey := func(cell notebookCell) string {
+		return cell.CellType + "\x00" + cell.source()
+	}
+	oldKeys := make([]string, len(oldNotebook.Cells))
+	for i, cell := range oldNotebook.Cells {
+		oldKeys[i] = cellKey(cell)
+	}
+	newKeys := make([]string, len(newNotebook.Cells))
+	for i, cell := range newNotebook.Cells {
+		newKeys[i] = cellKey(cell)
+	}
+
+	matcher := difflib.NewMatcherWithJunk(oldKeys, newKeys, false, nil)
+	for _, op := range matcher.GetOpCodes() {
+		oldCount, newCount := op.I2-op.I1, op.J2-op.J1
+		for k := 0; k < oldCount || k < newCount; k++ {
+			switch {
+			case k >= oldCount:
+				cell := newNotebook.Cells[op.J1+k]
+				diff.Cells = append(diff.Cells, NotebookCellDiff{
+					Type:     NotebookCellAdded,
+					OldIndex: -1,
+					NewIndex: op.J1 + k,
+					CellType: cell.CellType,
+					Source:   LineDiff(nil, []byte(cell.source()), TextOption{Context: DefaultContext}),
+				})
+			case k >= newCount:
+				cell := oldNotebook.Cells[op.I1+k]
+				diff.Cells = append(diff.Cells, NotebookCellDiff{
+					Type:     NotebookCellRemoved,
+					OldIndex: op.I1 + k,
+					NewIndex: -1,
+					CellType: cell.CellType,
+					Source:   LineDiff([]byte(cell.source()), nil, TextOption{Context: DefaultContext}),
+				})
+			default:
+				cellDiff := compareCell(oldNotebook.Cells[op.I1+k], newNotebook.Cells[op.J1+k], opt)
+				if len(cellDiff.Source) == 0 && len(cellDiff.Metadata) == 0 && len(cellDiff.Outputs) == 0 {
+					continue
+				}
+				cellDiff.OldIndex, cellDiff.NewIndex = op.I1+k, op.J1+k
+				diff.Cells = append(diff.Cells, cellDiff)
+			}
+		}
+	}
+	return diff, nil
+}
+
+func compareCell(oldCell, newCell notebookCell, opt NotebookOption) NotebookCellDiff {
+	cellDiff := NotebookCellDiff{
+		Type:     NotebookCellModified,
+		CellType: newCell.CellType,
+		Source:   LineDiff([]byte(oldCell.source()), []byte(newCell.source()), TextOption{Context: DefaultContext}),
+		Metadata: compareValue(make([]StructChange, 0), "", mapValue(oldCell.Metadata), mapValue(newCell.Metadata)),
+		Outputs:  make([]StructChange, 0),
+	}
+	if !opt.IgnoreOutputs {
+		cellDiff.Outputs = compareValue(cellDiff.Outputs, "", oldCell.outputs(), newCell.outputs())
+	}
+	return cellDiff
+}
+
+// mapValue convert typed map to interface{} so that missing metadata compare as empty object
+func mapValue(value map[string]interface{}) interface{} {
+	if value == nil {
+		return map[string]interface{}{}
+	}
+	return value
+}
@@ -0,0 +1,83 @@
+package contentdiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotebookDiff(t *testing.T) {
+	oldNotebook := []byte(`{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n"]},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "outputs": [{"output_type": "stream", "text": ["1\n"]}], "source": ["x = 1\n", "print(x)"]},
+  {"cell_type": "code", "execution_count": 2, "metadata": {}, "outputs": [], "source": "y = 2"},
+  {"cell_type": "code", "execution_count": 3, "metadata": {}, "outputs": [], "source": "z = 3"}
+ ],
+ "metadata": {"kernelspec": {"name": "python3"}},
+ "nbformat": 4
+}`)
+	newNotebook := []byte(`{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Title\n"]},
+  {"cell_type": "code", "execution_count": 5, "metadata": {"tags": ["a"]}, "outputs": [{"output_type": "stream", "text": ["2\n"]}], "source": ["x = 1\n", "print(x)"]},
+  {"cell_type": "code", "execution_count": 6, "metadata": {}, "outputs": [], "source": "y = 20"},
+  {"cell_type": "markdown", "metadata": {}, "source": "end"}
+ ],
+ "metadata": {"kernelspec": {"name": "python3.10"}},
+ "nbformat": 4
+}`)
+
+	t.Run("diff cells", func(t *testing.T) {
+		diff, err := NotebookDiffOf(oldNotebook, newNotebook, NotebookOption{})
+		require.NoError(t, err)
+		require.Equal(t, []StructChange{
+			{Type: StructChanged, Path: "/kernelspec/name", Old: "python3", New: "python3.10"},
+		}, diff.Metadata)
+
+		require.Len(t, diff.Cells, 3)
+		require.Equal(t, NotebookCellModified, diff.Cells[0].Type)
+		require.Equal(t, 1, diff.Cells[0].OldIndex)
+		require.Len(t, diff.Cells[0].Source, 0)
+		require.Equal(t, "/tags", diff.Cells[0].Metadata[0].Path)
+		require.Equal(t, []StructChange{
+			{Type: StructChanged, Path: "/execution_count", Old: json.Number("1"), New: json.Number("5")},
+			{Type: StructChanged, Path: "/outputs/0/text/0", Old: "1\n", New: "2\n"},
+		}, diff.Cells[0].Outputs)
+
+		require.Equal(t, NotebookCellModified, diff.Cells[1].Type)
+		require.Equal(t, []Line{
+			{Type: LineDelete, Content: "y = 2"},
+			{Type: LineInsert, Content: "y = 20"},
+		}, diff.Cells[1].Source[0].Lines)
+
+		require.Equal(t, NotebookCellModified, diff.Cells[2].Type)
+		require.Equal(t, "markdown", diff.Cells[2].CellType)
+		require.Equal(t, 3, diff.Cells[2].NewIndex)
+	})
+
+	t.Run("ignore outputs", func(t *testing.T) {
+		diff, err := NotebookDiffOf(oldNotebook, newNotebook, NotebookOption{IgnoreOutputs: true})
+		require.NoError(t, err)
+		require.Len(t, diff.Cells, 3)
+		require.Len(t, diff.Cells[0].Outputs, 0)
+		require.Len(t, diff.Cells[0].Metadata, 1)
+	})
+
+	t.Run("new notebook", func(t *testing.T) {
+		diff, err := NotebookDiffOf(nil, newNotebook, NotebookOption{})
+		require.NoError(t, err)
+		require.Len(t, diff.Cells, 4)
+		for i, cell := range diff.Cells {
+			require.Equal(t, NotebookCellAdded, cell.Type)
+			require.Equal(t, -1, cell.OldIndex)
+			require.Equal(t, i, cell.NewIndex)
+		}
+	})
+
+	t.Run("invalid notebook", func(t *testing.T) {
+		_, err := NotebookDiffOf([]byte("{"), newNotebook, NotebookOption{})
+		require.ErrorIs(t, err, ErrInvalidDocument)
+	})
+}
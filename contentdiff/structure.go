@@ -0,0 +1,266 @@
+package contentdiff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var ErrInvalidDocument = errors.New("invalid document")
+
+// Format format of structured document
+type Format string
+
+const (
+	FormatJSON      Format = "json"
+	FormatJSONLines Format = "jsonl"
+	FormatYAML      Format = "yaml"
+)
+
+// DetectFormat detect structured document format by extension of path
+func DetectFormat(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, true
+	case ".jsonl", ".ndjson":
+		return FormatJSONLines, true
+	case ".yaml", ".yml":
+		return FormatYAML, true
+	}
+	return "", false
+}
+
+type StructChangeType string
+
+const (
+	StructAdded   StructChangeType = "added"
+	StructRemoved StructChangeType = "removed"
+	StructChanged StructChangeType = "changed"
+)
+
+// StructChange value added, removed or changed, Path is JSON pointer of value
+type StructChange struct {
+	Type StructChangeType
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// StructOption option of structural diff
+type StructOption struct {
+	// IDField field used to match records of json lines, records are matched by index if empty
+	IDField string
+}
+
+// StructDiff compare two structured documents, nil content means document not exit
+func StructDiff(oldData, newData []byte, format Format, opt StructOption) ([]StructChange, error) {
+	changes := make([]StructChange, 0)
+	if format == FormatJSONLines {
+		oldRecords, err := decodeRecords(oldData, opt.IDField)
+		if err != nil {
+			return nil, err
+		}
+		newRecords, err := decodeRecords(newData, opt.IDField)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range newRecords.records {
+			oldRecord, ok := oldRecords.values[record.id]
+			if !ok {
+				changes = append(changes, StructChange{Type: StructAdded, Path: "/" + escapePointer(record.id), New: record.value})
+				continue
+			}
+			changes = compareValue(changes, "/"+escapePointer(record.id), oldRecord, record.value)
+		}
+		for _, record := range oldRecords.records {
+			if _, ok := newRecords.values[record.id]; !ok {
+				changes = append(changes, StructChange{Type: StructRemoved, Path: "/" + escapePointer(record.id), Old: record.value})
+			}
+		}
+		return changes, nil
+	}
+
+	oldValue, err := decodeDocument(oldData, format)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := decodeDocument(newData, format)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case oldData == nil && newData == nil:
+	case oldData == nil:
+		changes = append(changes, StructChange{Type: StructAdded, Path: "", New: newValue})
+	case newData == nil:
+		changes = append(changes, StructChange{Type: StructRemoved, Path: "", Old: oldValue})
+	default:
+		changes = compareValue(changes, "", oldValue, newValue)
+	}
+	return changes, nil
+}
+
+func compareValue(changes []StructChange, path string, oldValue, newValue interface{}) []StructChange {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			childPath := path + "/" + escapePointer(key)
+			oldChild, inOld := oldMap[key]
+			newChild, inNew := newMap[key]
+			switch {
+			case !inOld:
+				changes = append(changes, StructChange{Type: StructAdded, Path: childPath, New: newChild})
+			case !inNew:
+				changes = append(changes, StructChange{Type: StructRemoved, Path: childPath, Old: oldChild})
+			default:
+				changes = compareValue(changes, childPath, oldChild, newChild)
+			}
+		}
+		return changes
+	}
+
+	oldArray, oldIsArray := oldValue.([]interface{})
+	newArray, newIsArray := newValue.([]interface{})
+	if oldIsArray && newIsArray {
+		for i := 0; i < len(oldArray) || i < len(newArray); i++ {
+			childPath := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(oldArray):
+				changes = append(changes, StructChange{Type: StructAdded, Path: childPath, New: newArray[i]})
+			case i >= len(newArray):
+				changes = append(changes, StructChange{Type: StructRemoved, Path: childPath, Old: oldArray[i]})
+			default:
+				changes = compareValue(changes, childPath, oldArray[i], newArray[i])
+			}
+		}
+		return changes
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		changes = append(changes, StructChange{Type: StructChanged, Path: path, Old: oldValue, New: newValue})
+	}
+	return changes
+}
+
+// escapePointer escape reference token of JSON pointer, see rfc6901
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func decodeDocument(data []byte, format Format) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var value interface{}
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("decode json %v %w", err, ErrInvalidDocument)
+		}
+		return value, nil
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("decode yaml %v %w", err, ErrInvalidDocument)
+		}
+		return normalizeYAML(value), nil
+	}
+	return nil, fmt.Errorf("unsupported format %s %w", format, ErrInvalidDocument)
+}
+
+// normalizeYAML convert map with interface key decoded by yaml to map with string key like json
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			result[fmt.Sprint(key)] = normalizeYAML(child)
+		}
+		return result
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalizeYAML(child)
+		}
+		return v
+	}
+	return value
+}
+
+type jsonRecord struct {
+	id    string
+	value interface{}
+}
+
+type jsonRecords struct {
+	records []jsonRecord
+	values  map[string]interface{}
+}
+
+// decodeRecords decode records of json lines, record is identified by value of idField or index of record
+func decodeRecords(data []byte, idField string) (*jsonRecords, error) {
+	result := &jsonRecords{values: make(map[string]interface{})}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("decode line %d %v %w", lineNumber, err, ErrInvalidDocument)
+		}
+
+		id := strconv.Itoa(len(result.records))
+		if len(idField) > 0 {
+			record, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("line %d is not object %w", lineNumber, ErrInvalidDocument)
+			}
+			idValue, ok := record[idField]
+			if !ok {
+				return nil, fmt.Errorf("line %d missing id field %s %w", lineNumber, idField, ErrInvalidDocument)
+			}
+			id = fmt.Sprint(idValue)
+		}
+		if _, ok := result.values[id]; ok {
+			return nil, fmt.Errorf("duplicate id %s at line %d %w", id, lineNumber, ErrInvalidDocument)
+		}
+		result.records = append(result.records, jsonRecord{id: id, value: value})
+		result.values[id] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read lines %v %w", err, ErrInvalidDocument)
+	}
+	return result, nil
+}
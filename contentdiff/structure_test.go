@@ -0,0 +1,98 @@
+package contentdiff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	format, ok := DetectFormat("a/b.JSON")
+	require.True(t, ok)
+	require.Equal(t, FormatJSON, format)
+
+	format, ok = DetectFormat("labels.jsonl")
+	require.True(t, ok)
+	require.Equal(t, FormatJSONLines, format)
+
+	format, ok = DetectFormat("conf.yml")
+	require.True(t, ok)
+	require.Equal(t, FormatYAML, format)
+
+	_, ok = DetectFormat("a.txt")
+	require.False(t, ok)
+}
+
+func TestStructDiff(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		changes, err := StructDiff(
+			[]byte(`{"a":1,"b":{"c":[1,2]},"d/e":true}`),
+			[]byte(`{
+  "a": 2,
+  "b": {"c": [1]},
+  "f": "x"
+}`), FormatJSON, StructOption{})
+		require.NoError(t, err)
+		require.Equal(t, []StructChange{
+			{Type: StructChanged, Path: "/a", Old: json.Number("1"), New: json.Number("2")},
+			{Type: StructRemoved, Path: "/b/c/1", Old: json.Number("2")},
+			{Type: StructRemoved, Path: "/d~1e", Old: true},
+			{Type: StructAdded, Path: "/f", New: "x"},
+		}, changes)
+	})
+
+	t.Run("reformat json", func(t *testing.T) {
+		changes, err := StructDiff([]byte(`{"a":[1,{"b":2}]}`), []byte("{\n \"a\": [ 1, { \"b\": 2 } ]\n}\n"), FormatJSON, StructOption{})
+		require.NoError(t, err)
+		require.Len(t, changes, 0)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		changes, err := StructDiff([]byte("a: 1\nb:\n  c: x\n"), []byte("a: 1\nb:\n  c: z\n"), FormatYAML, StructOption{})
+		require.NoError(t, err)
+		require.Equal(t, []StructChange{
+			{Type: StructChanged, Path: "/b/c", Old: "x", New: "z"},
+		}, changes)
+	})
+
+	t.Run("new document", func(t *testing.T) {
+		changes, err := StructDiff(nil, []byte(`{"a":1}`), FormatJSON, StructOption{})
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, StructAdded, changes[0].Type)
+		require.Equal(t, "", changes[0].Path)
+	})
+
+	t.Run("jsonl by id", func(t *testing.T) {
+		changes, err := StructDiff(
+			[]byte("{\"id\":1,\"label\":\"cat\"}\n{\"id\":2,\"label\":\"dog\"}\n"),
+			[]byte("{\"id\":3,\"label\":\"cow\"}\n\n{\"label\":\"cats\",\"id\":1}\n"),
+			FormatJSONLines, StructOption{IDField: "id"})
+		require.NoError(t, err)
+		require.Equal(t, []StructChange{
+			{Type: StructAdded, Path: "/3", New: map[string]interface{}{"id": json.Number("3"), "label": "cow"}},
+			{Type: StructChanged, Path: "/1/label", Old: "cat", New: "cats"},
+			{Type: StructRemoved, Path: "/2", Old: map[string]interface{}{"id": json.Number("2"), "label": "dog"}},
+		}, changes)
+	})
+
+	t.Run("jsonl by index", func(t *testing.T) {
+		changes, err := StructDiff([]byte("{\"a\":1}\n{\"a\":2}\n"), []byte("{\"a\":1}\n{\"a\":3}\n{\"a\":4}\n"), FormatJSONLines, StructOption{})
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, "/1/a", changes[0].Path)
+		require.Equal(t, "/2", changes[1].Path)
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		_, err := StructDiff([]byte(`{"a":`), []byte(`{}`), FormatJSON, StructOption{})
+		require.ErrorIs(t, err, ErrInvalidDocument)
+
+		_, err = StructDiff([]byte("{\"id\":1}\n{\"id\":1}\n"), nil, FormatJSONLines, StructOption{IDField: "id"})
+		require.ErrorIs(t, err, ErrInvalidDocument)
+
+		_, err = StructDiff([]byte("{\"a\":1}\n"), nil, FormatJSONLines, StructOption{IDField: "id"})
+		require.ErrorIs(t, err, ErrInvalidDocument)
+	})
+}
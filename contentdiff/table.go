@@ -0,0 +1,422 @@
+package contentdiff
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidTable = errors.New("invalid table")
+
+// ErrTableTooLarge table has more rows than max rows of TableOption
+var ErrTableTooLarge = errors.New("table too large")
+
+// MaxTableRows default max rows of TableOption, memory used to match rows grows with rows of table
+var MaxTableRows = 1 << 20
+
+// DefaultRowLimit default number of changed rows reported in detail
+const DefaultRowLimit = 100
+
+// Opener open content for reading, table diff read each side more than once to avoid loading whole content in memory
+type Opener func() (io.ReadCloser, error)
+
+type RowChangeType string
+
+const (
+	RowAdded   RowChangeType = "added"
+	RowRemoved RowChangeType = "removed"
+	RowChanged RowChangeType = "changed"
+)
+
+// CellChange value of column changed in row
+type CellChange struct {
+	Column string
+	Old    string
+	New    string
+}
+
+// RowChange row added, removed or changed. Values is the values of added or removed row,
+// Cells is the changed values of changed row, Key is nil if rows are matched by row hash
+type RowChange struct {
+	Type   RowChangeType
+	Key    []string
+	Values []string
+	Cells  []CellChange
+}
+
+// TableDiff difference between two tables, counts cover all rows while Rows has at most limit rows
+type TableDiff struct {
+	BaseColumns    []string
+	HeadColumns    []string
+	AddedColumns   []string
+	RemovedColumns []string
+	Added          int
+	Removed        int
+	Changed        int
+	Unchanged      int
+	Rows           []RowChange
+	// Truncated more row changes than limit
+	Truncated bool
+}
+
+// TableOption option of table diff
+type TableOption struct {
+	// Comma field delimiter, ',' for csv and '\t' for tsv
+	Comma rune
+	// KeyColumns columns identify row, rows are matched by hash of row values if empty
+	KeyColumns []string
+	// Limit max number of row changes reported in detail
+	Limit int
+	// MaxRows table with more rows than this is not diffed, MaxTableRows is used if not positive
+	MaxRows int
+}
+
+type tableReader struct {
+	closer  io.Closer
+	reader  *csv.Reader
+	header  []string
+	maxRows int
+}
+
+func openTable(open Opener, comma rune, maxRows int) (*tableReader, error) {
+	if open == nil {
+		return nil, nil
+	}
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(rc)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = rc.Close()
+		return nil, fmt.Errorf("read header %v %w", err, ErrInvalidTable)
+	}
+	return &tableReader{closer: rc, reader: reader, header: append([]string(nil), header...), maxRows: maxRows}, nil
+}
+
+// forEach call fn with each row after header, record is reused between calls
+func (table *tableReader) forEach(fn func(record []string) error) error {
+	defer table.closer.Close() //nolint
+	for rows := 0; ; rows++ {
+		record, err := table.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read row %v %w", err, ErrInvalidTable)
+		}
+		if rows >= table.maxRows {
+			return fmt.Errorf("more than %d rows %w", table.maxRows, ErrTableTooLarge)
+		}
+		err = fn(record)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readHeader read header of table, nil opener means table not exit
+func readHeader(open Opener, comma rune) ([]string, error) {
+	table, err := openTable(open, comma, 0)
+	if err != nil || table == nil {
+		return nil, err
+	}
+	_ = table.closer.Close()
+	return table.header, nil
+}
+
+// tableLayout position of key and compared columns in base and head
+type tableLayout struct {
+	columns     []string
+	baseCompare []int
+	headCompare []int
+	baseKey     []int
+	headKey     []int
+}
+
+func columnIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		if _, ok := index[column]; !ok {
+			index[column] = i
+		}
+	}
+	return index
+}
+
+func cell(record []string, index int) string {
+	if index < len(record) {
+		return record[index]
+	}
+	return ""
+}
+
+func pick(record []string, indexes []int) []string {
+	values := make([]string, len(indexes))
+	for i, index := range indexes {
+		values[i] = cell(record, index)
+	}
+	return values
+}
+
+// joinValues join values into map key, length prefix avoid ambiguous of values contains separator
+func joinValues(values []string) string {
+	var builder strings.Builder
+	for _, value := range values {
+		_, _ = fmt.Fprintf(&builder, "%d:%s", len(value), value)
+	}
+	return builder.String()
+}
+
+func hashValues(values []string) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(joinValues(values)))
+	return hasher.Sum64()
+}
+
+// TableDiffOf compare rows of two tables. Each side is read sequentially twice at most,
+// only hashes of key and values of rows are kept in memory, nil opener means the table not exit
+func TableDiffOf(base, head Opener, opt TableOption) (*TableDiff, error) {
+	if opt.Comma == 0 {
+		opt.Comma = ','
+	}
+	if opt.Limit <= 0 {
+		opt.Limit = DefaultRowLimit
+	}
+	if opt.MaxRows <= 0 {
+		opt.MaxRows = MaxTableRows
+	}
+
+	baseHeader, err := readHeader(base, opt.Comma)
+	if err != nil {
+		return nil, err
+	}
+	headHeader, err := readHeader(head, opt.Comma)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &TableDiff{
+		BaseColumns:    baseHeader,
+		HeadColumns:    headHeader,
+		AddedColumns:   []string{},
+		RemovedColumns: []string{},
+		Rows:           []RowChange{},
+	}
+
+	baseIndex, headIndex := columnIndex(baseHeader), columnIndex(headHeader)
+	layout := tableLayout{}
+	for i, column := range baseHeader {
+		if baseIndex[column] != i {
+			continue
+		}
+		headPos, ok := headIndex[column]
+		if !ok {
+			diff.RemovedColumns = append(diff.RemovedColumns, column)
+			continue
+		}
+		layout.columns = append(layout.columns, column)
+		layout.baseCompare = append(layout.baseCompare, i)
+		layout.headCompare = append(layout.headCompare, headPos)
+	}
+	for i, column := range headHeader {
+		if _, ok := baseIndex[column]; !ok && headIndex[column] == i {
+			diff.AddedColumns = append(diff.AddedColumns, column)
+		}
+	}
+
+	for _, column := range opt.KeyColumns {
+		basePos, inBase := baseIndex[column]
+		headPos, inHead := headIndex[column]
+		if (base != nil && !inBase) || (head != nil && !inHead) {
+			return nil, fmt.Errorf("key column %s not found %w", column, ErrInvalidTable)
+		}
+		layout.baseKey = append(layout.baseKey, basePos)
+		layout.headKey = append(layout.headKey, headPos)
+	}
+
+	addRow := func(change RowChange) {
+		if len(diff.Rows) >= opt.Limit {
+			diff.Truncated = true
+			return
+		}
+		diff.Rows = append(diff.Rows, change)
+	}
+
+	if len(opt.KeyColumns) > 0 {
+		err = diffByKey(base, head, opt, layout, diff, addRow)
+	} else {
+		err = diffByHash(base, head, opt, layout, diff, addRow)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+func diffByKey(base, head Opener, opt TableOption, layout tableLayout, diff *TableDiff, addRow func(RowChange)) error {
+	// rows are matched by hash of key rather than key itself to bound memory used by long keys,
+	// key of changed row is confirmed when base is read again
+	// hash of base row key => hash of compared values
+	baseRows := make(map[uint64]uint64)
+	if baseTable, err := openTable(base, opt.Comma, opt.MaxRows); err != nil {
+		return err
+	} else if baseTable != nil {
+		err = baseTable.forEach(func(record []string) error {
+			keyValues := pick(record, layout.baseKey)
+			key := hashValues(keyValues)
+			if _, ok := baseRows[key]; ok {
+				return fmt.Errorf("duplicate key %s in base %w", strings.Join(keyValues, ","), ErrInvalidTable)
+			}
+			baseRows[key] = hashValues(pick(record, layout.baseCompare))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// changed rows wait for old values in base, position in diff rows
+	pendingChanged := make(map[uint64]int)
+	seen := make(map[uint64]struct{})
+	if headTable, err := openTable(head, opt.Comma, opt.MaxRows); err != nil {
+		return err
+	} else if headTable != nil {
+		err = headTable.forEach(func(record []string) error {
+			keyValues := pick(record, layout.headKey)
+			key := hashValues(keyValues)
+			if _, ok := seen[key]; ok {
+				return fmt.Errorf("duplicate key %s in head %w", strings.Join(keyValues, ","), ErrInvalidTable)
+			}
+			seen[key] = struct{}{}
+
+			rowHash, ok := baseRows[key]
+			if !ok {
+				diff.Added++
+				addRow(RowChange{Type: RowAdded, Key: keyValues, Values: append([]string(nil), record...)})
+				return nil
+			}
+			delete(baseRows, key)
+
+			if rowHash == hashValues(pick(record, layout.headCompare)) {
+				diff.Unchanged++
+				return nil
+			}
+			diff.Changed++
+			if len(diff.Rows) < opt.Limit {
+				pendingChanged[key] = len(diff.Rows)
+			}
+			// keep new values in cells, old values filled when read base again
+			cells := make([]CellChange, len(layout.columns))
+			for i, column := range layout.columns {
+				cells[i] = CellChange{Column: column, New: cell(record, layout.headCompare[i])}
+			}
+			addRow(RowChange{Type: RowChanged, Key: keyValues, Cells: cells})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// left base rows are removed
+	diff.Removed = len(baseRows)
+	if len(pendingChanged) == 0 && (diff.Removed == 0 || len(diff.Rows) >= opt.Limit) {
+		diff.Truncated = diff.Truncated || diff.Removed > 0
+		return nil
+	}
+
+	baseTable, err := openTable(base, opt.Comma, opt.MaxRows)
+	if err != nil {
+		return err
+	}
+	return baseTable.forEach(func(record []string) error {
+		keyValues := pick(record, layout.baseKey)
+		key := hashValues(keyValues)
+		if pos, ok := pendingChanged[key]; ok && slices.Equal(diff.Rows[pos].Key, keyValues) {
+			row := diff.Rows[pos]
+			cells := row.Cells[:0]
+			for i, cellChange := range row.Cells {
+				cellChange.Old = cell(record, layout.baseCompare[i])
+				if cellChange.Old != cellChange.New {
+					cells = append(cells, cellChange)
+				}
+			}
+			diff.Rows[pos].Cells = cells
+			return nil
+		}
+		if _, ok := baseRows[key]; ok {
+			addRow(RowChange{Type: RowRemoved, Key: keyValues, Values: append([]string(nil), record...)})
+		}
+		return nil
+	})
+}
+
+func diffByHash(base, head Opener, opt TableOption, layout tableLayout, diff *TableDiff, addRow func(RowChange)) error {
+	// hash of base row => count of rows
+	baseRows := make(map[uint64]int)
+	if baseTable, err := openTable(base, opt.Comma, opt.MaxRows); err != nil {
+		return err
+	} else if baseTable != nil {
+		err = baseTable.forEach(func(record []string) error {
+			baseRows[hashValues(pick(record, layout.baseCompare))]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if headTable, err := openTable(head, opt.Comma, opt.MaxRows); err != nil {
+		return err
+	} else if headTable != nil {
+		err = headTable.forEach(func(record []string) error {
+			rowHash := hashValues(pick(record, layout.headCompare))
+			if baseRows[rowHash] > 0 {
+				baseRows[rowHash]--
+				diff.Unchanged++
+				return nil
+			}
+			diff.Added++
+			addRow(RowChange{Type: RowAdded, Values: append([]string(nil), record...)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, count := range baseRows {
+		diff.Removed += count
+	}
+	if diff.Removed == 0 {
+		return nil
+	}
+	if len(diff.Rows) >= opt.Limit {
+		diff.Truncated = true
+		return nil
+	}
+
+	baseTable, err := openTable(base, opt.Comma, opt.MaxRows)
+	if err != nil {
+		return err
+	}
+	return baseTable.forEach(func(record []string) error {
+		rowHash := hashValues(pick(record, layout.baseCompare))
+		if baseRows[rowHash] > 0 {
+			baseRows[rowHash]--
+			addRow(RowChange{Type: RowRemoved, Values: append([]string(nil), record...)})
+		}
+		return nil
+	})
+}
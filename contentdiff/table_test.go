@@ -0,0 +1,98 @@
+package contentdiff
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func stringOpener(content string) Opener {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+}
+
+func TestTableDiffByKey(t *testing.T) {
+	base := "id,name,age\n1,a,10\n2,b,20\n3,c,30\n"
+	head := "id,age,name,city\n1,10,a,x\n3,31,c,y\n4,40,d,z\n"
+
+	t.Run("diff rows", func(t *testing.T) {
+		diff, err := TableDiffOf(stringOpener(base), stringOpener(head), TableOption{KeyColumns: []string{"id"}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"city"}, diff.AddedColumns)
+		require.Equal(t, []string{}, diff.RemovedColumns)
+		require.Equal(t, 1, diff.Added)
+		require.Equal(t, 1, diff.Removed)
+		require.Equal(t, 1, diff.Changed)
+		require.Equal(t, 1, diff.Unchanged)
+		require.False(t, diff.Truncated)
+
+		require.Equal(t, []RowChange{
+			{Type: RowChanged, Key: []string{"3"}, Cells: []CellChange{{Column: "age", Old: "30", New: "31"}}},
+			{Type: RowAdded, Key: []string{"4"}, Values: []string{"4", "40", "d", "z"}},
+			{Type: RowRemoved, Key: []string{"2"}, Values: []string{"2", "b", "20"}},
+		}, diff.Rows)
+	})
+
+	t.Run("limit rows", func(t *testing.T) {
+		diff, err := TableDiffOf(stringOpener(base), stringOpener(head), TableOption{KeyColumns: []string{"id"}, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, diff.Rows, 1)
+		require.True(t, diff.Truncated)
+		require.Equal(t, 1, diff.Added)
+		require.Equal(t, 1, diff.Removed)
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		_, err := TableDiffOf(stringOpener(base), stringOpener(head), TableOption{KeyColumns: []string{"city"}})
+		require.ErrorIs(t, err, ErrInvalidTable)
+	})
+
+	t.Run("duplicate key", func(t *testing.T) {
+		_, err := TableDiffOf(stringOpener("id\n1\n1\n"), stringOpener(head), TableOption{KeyColumns: []string{"id"}})
+		require.ErrorIs(t, err, ErrInvalidTable)
+	})
+
+	t.Run("too many rows", func(t *testing.T) {
+		defer func(maxRows int) { MaxTableRows = maxRows }(MaxTableRows)
+		MaxTableRows = 2
+		_, err := TableDiffOf(stringOpener(base), stringOpener(head), TableOption{KeyColumns: []string{"id"}})
+		require.ErrorIs(t, err, ErrTableTooLarge)
+
+		// max rows of option take precedence over default
+		_, err = TableDiffOf(stringOpener(base), stringOpener(head), TableOption{KeyColumns: []string{"id"}, MaxRows: 10})
+		require.NoError(t, err)
+		_, err = TableDiffOf(stringOpener(base), stringOpener(head), TableOption{KeyColumns: []string{"id"}, MaxRows: 1})
+		require.ErrorIs(t, err, ErrTableTooLarge)
+	})
+
+	t.Run("new table", func(t *testing.T) {
+		diff, err := TableDiffOf(nil, stringOpener(head), TableOption{KeyColumns: []string{"id"}})
+		require.NoError(t, err)
+		require.Equal(t, 3, diff.Added)
+		require.Equal(t, []string{"id", "age", "name", "city"}, diff.AddedColumns)
+	})
+}
+
+func TestTableDiffByHash(t *testing.T) {
+	base := "a\tb\n1\t2\n1\t2\n3\t4\n"
+	head := "a\tb\n1\t2\n5\t6\n3\t4\n"
+
+	diff, err := TableDiffOf(stringOpener(base), stringOpener(head), TableOption{Comma: '\t'})
+	require.NoError(t, err)
+	require.Equal(t, 1, diff.Added)
+	require.Equal(t, 1, diff.Removed)
+	require.Equal(t, 0, diff.Changed)
+	require.Equal(t, 2, diff.Unchanged)
+	require.Equal(t, []RowChange{
+		{Type: RowAdded, Values: []string{"5", "6"}},
+		{Type: RowRemoved, Values: []string{"1", "2"}},
+	}, diff.Rows)
+
+	diff, err = TableDiffOf(stringOpener(base), stringOpener(base), TableOption{Comma: '\t'})
+	require.NoError(t, err)
+	require.Equal(t, 3, diff.Unchanged)
+	require.Len(t, diff.Rows, 0)
+}
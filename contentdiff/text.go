@@ -0,0 +1,153 @@
+package contentdiff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// MaxTextSize content larger than this size is not diffed
+var MaxTextSize int64 = 1 << 20
+
+// DefaultContext number of unchanged lines around changes
+const DefaultContext = 3
+
+// binarySniffSize like git, only check the beginning of content to detect binary
+const binarySniffSize = 8000
+
+type LineType string
+
+const (
+	LineContext LineType = "context"
+	LineInsert  LineType = "insert"
+	LineDelete  LineType = "delete"
+)
+
+// Line line of hunk
+type Line struct {
+	Type    LineType
+	Content string
+}
+
+// Hunk continuous changed lines with context, line number start from 1
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Header return unified diff header of hunk, like @@ -1,3 +1,4 @@
+func (hunk Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(hunk.OldStart, hunk.OldLines), hunkRange(hunk.NewStart, hunk.NewLines))
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// TextOption option of line diff
+type TextOption struct {
+	// Context number of unchanged lines around changes
+	Context int
+	// IgnoreWhitespace lines differ only in whitespace are treated as same
+	IgnoreWhitespace bool
+}
+
+// IsBinary detect binary content by NUL byte or invalid utf8 at the beginning of content
+func IsBinary(data []byte) bool {
+	if len(data) > binarySniffSize {
+		data = data[:binarySniffSize]
+		// avoid cutting multi bytes rune
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
+
+// LineDiff compute unified diff hunks between two text contents
+func LineDiff(oldData, newData []byte, opt TextOption) []Hunk {
+	oldLines := SplitLines(oldData)
+	newLines := SplitLines(newData)
+
+	oldKeys, newKeys := oldLines, newLines
+	if opt.IgnoreWhitespace {
+		oldKeys = removeWhitespace(oldLines)
+		newKeys = removeWhitespace(newLines)
+	}
+
+	matcher := difflib.NewMatcherWithJunk(oldKeys, newKeys, false, nil)
+	hunks := make([]Hunk, 0)
+	for _, group := range matcher.GetGroupedOpCodes(opt.Context) {
+		first, last := group[0], group[len(group)-1]
+		hunk := Hunk{
+			OldStart: first.I1 + 1,
+			OldLines: last.I2 - first.I1,
+			NewStart: first.J1 + 1,
+			NewLines: last.J2 - first.J1,
+		}
+		// empty range point to the line before it, same as diff -u
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+
+		for _, op := range group {
+			if op.Tag == 'e' {
+				for _, line := range newLines[op.J1:op.J2] {
+					hunk.Lines = append(hunk.Lines, Line{Type: LineContext, Content: line})
+				}
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				for _, line := range oldLines[op.I1:op.I2] {
+					hunk.Lines = append(hunk.Lines, Line{Type: LineDelete, Content: line})
+				}
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				for _, line := range newLines[op.J1:op.J2] {
+					hunk.Lines = append(hunk.Lines, Line{Type: LineInsert, Content: line})
+				}
+			}
+		}
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+// SplitLines split content to lines without line ending
+func SplitLines(data []byte) []string {
+	content := strings.TrimSuffix(string(data), "\n")
+	if len(content) == 0 {
+		return []string{}
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+func removeWhitespace(lines []string) []string {
+	keys := make([]string, len(lines))
+	for i, line := range lines {
+		keys[i] = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return keys
+}
@@ -0,0 +1,66 @@
+package contentdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBinary(t *testing.T) {
+	require.False(t, IsBinary([]byte("hello\nworld")))
+	require.False(t, IsBinary([]byte("你好")))
+	require.False(t, IsBinary([]byte(strings.Repeat("a", binarySniffSize-1)+"你好")))
+	require.True(t, IsBinary([]byte{'a', 0, 'b'}))
+	require.True(t, IsBinary([]byte{0xff, 0xfe, 0xfd}))
+}
+
+func TestLineDiff(t *testing.T) {
+	t.Run("same content", func(t *testing.T) {
+		hunks := LineDiff([]byte("a\nb\n"), []byte("a\nb\n"), TextOption{Context: DefaultContext})
+		require.Len(t, hunks, 0)
+	})
+
+	t.Run("modify line", func(t *testing.T) {
+		oldData := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+		newData := "1\n2\n3\nfour\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+		hunks := LineDiff([]byte(oldData), []byte(newData), TextOption{Context: 2})
+		require.Len(t, hunks, 2)
+
+		require.Equal(t, "@@ -2,5 +2,5 @@", hunks[0].Header())
+		require.Equal(t, []Line{
+			{Type: LineContext, Content: "2"},
+			{Type: LineContext, Content: "3"},
+			{Type: LineDelete, Content: "4"},
+			{Type: LineInsert, Content: "four"},
+			{Type: LineContext, Content: "5"},
+			{Type: LineContext, Content: "6"},
+		}, hunks[0].Lines)
+
+		require.Equal(t, "@@ -11,2 +11,3 @@", hunks[1].Header())
+		require.Equal(t, LineInsert, hunks[1].Lines[2].Type)
+		require.Equal(t, "13", hunks[1].Lines[2].Content)
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		hunks := LineDiff(nil, []byte("a\nb"), TextOption{Context: DefaultContext})
+		require.Len(t, hunks, 1)
+		require.Equal(t, "@@ -0,0 +1,2 @@", hunks[0].Header())
+	})
+
+	t.Run("ignore whitespace", func(t *testing.T) {
+		oldData := []byte("a b\n  c\r\nd\n")
+		newData := []byte("a  b\nc\nD\n")
+		hunks := LineDiff(oldData, newData, TextOption{Context: 0, IgnoreWhitespace: true})
+		require.Len(t, hunks, 1)
+		require.Equal(t, "@@ -3 +3 @@", hunks[0].Header())
+		require.Equal(t, []Line{
+			{Type: LineDelete, Content: "d"},
+			{Type: LineInsert, Content: "D"},
+		}, hunks[0].Lines)
+
+		hunks = LineDiff(oldData, newData, TextOption{Context: 0})
+		require.Len(t, hunks, 1)
+		require.Equal(t, "@@ -1,3 +1,3 @@", hunks[0].Header())
+	})
+}
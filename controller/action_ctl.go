@@ -0,0 +1,388 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/controller/validator"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.uber.org/fx"
+)
+
+type ActionController struct {
+	fx.In
+	BaseController
+
+	Repo          models.IRepo
+	ActionsConfig *config.ActionsConfig
+	WebhookConfig *config.WebhookConfig
+}
+
+func (actionCtl ActionController) ListActions(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.ListActionsParams) {
+	repository, ok := actionCtl.getRepository(ctx, w, actionCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	listParams := models.NewListActionParams().SetRepositoryID(repository.ID)
+	if params.After != nil {
+		listParams.SetAfter(time.UnixMilli(utils.Int64Value(params.After)))
+	}
+	pageAmount := utils.IntValue(params.Amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	actions, hasMore, err := actionCtl.Repo.ActionRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	results := utils.Silent(utils.ArrMap(actions, actionToDto))
+	pagMag := utils.PaginationFor(hasMore, results, "CreatedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.ActionList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
+
+func (actionCtl ActionController) CreateAction(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.CreateActionJSONRequestBody, ownerName string, repositoryName string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, ok := actionCtl.getRepository(ctx, w, actionCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	if len(strings.TrimSpace(body.Name)) == 0 {
+		w.BadRequest("action name must not be empty")
+		return
+	}
+
+	actionType, err := models.ParseActionType(string(body.Type))
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	action := &models.Action{
+		RepositoryID: repository.ID,
+		Name:         body.Name,
+		Type:         actionType,
+		Active:       body.Active == nil || *body.Active,
+		CreatorID:    operator.ID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	switch actionType {
+	case models.CommandAction:
+		action.Command = utils.StringValue(body.Command)
+		err = actionCtl.checkCommand(action.Command)
+	case models.HTTPAction:
+		action.URL = utils.StringValue(body.Url)
+		action.Secret = utils.StringValue(body.Secret)
+		err = validator.ValidateWebhookURL(action.URL, actionCtl.WebhookConfig.AllowPrivateNetwork)
+	}
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	action.Events, err = parseActionEvents(body.Events)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	action, err = actionCtl.Repo.ActionRepo().Insert(ctx, action)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(actionToDto(action)), http.StatusCreated)
+}
+
+func (actionCtl ActionController) GetAction(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := actionCtl.getRepository(ctx, w, actionCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	action, err := actionCtl.Repo.ActionRepo().Get(ctx, models.NewGetActionParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(actionToDto(action)))
+}
+
+func (actionCtl ActionController) UpdateAction(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateActionJSONRequestBody, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := actionCtl.getRepository(ctx, w, actionCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	action, err := actionCtl.Repo.ActionRepo().Get(ctx, models.NewGetActionParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	updateParams := models.NewUpdateActionParams(action.ID)
+	if body.Name != nil {
+		if len(strings.TrimSpace(*body.Name)) == 0 {
+			w.BadRequest("action name must not be empty")
+			return
+		}
+		updateParams.SetName(*body.Name)
+	}
+
+	if body.Command != nil {
+		if action.Type != models.CommandAction {
+			w.BadRequest(fmt.Sprintf("command can not be set to %s action", action.Type))
+			return
+		}
+		err = actionCtl.checkCommand(*body.Command)
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+		updateParams.SetCommand(*body.Command)
+	}
+
+	if body.Url != nil || body.Secret != nil {
+		if action.Type != models.HTTPAction {
+			w.BadRequest(fmt.Sprintf("url and secret can not be set to %s action", action.Type))
+			return
+		}
+	}
+	if body.Url != nil {
+		err = validator.ValidateWebhookURL(*body.Url, actionCtl.WebhookConfig.AllowPrivateNetwork)
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+		updateParams.SetURL(*body.Url)
+	}
+
+	if body.Secret != nil {
+		updateParams.SetSecret(*body.Secret)
+	}
+
+	if body.Events != nil {
+		events, err := parseActionEvents(*body.Events)
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+		updateParams.SetEvents(events)
+	}
+
+	if body.Active != nil {
+		updateParams.SetActive(*body.Active)
+	}
+
+	err = actionCtl.Repo.ActionRepo().UpdateByID(ctx, updateParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	action, err = actionCtl.Repo.ActionRepo().Get(ctx, models.NewGetActionParams().SetID(action.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(actionToDto(action)))
+}
+
+func (actionCtl ActionController) DeleteAction(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := actionCtl.getRepository(ctx, w, actionCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	err := actionCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		affectRows, err := repo.ActionRepo().Delete(ctx, models.NewDeleteActionParams().SetID(id).SetRepositoryID(repository.ID))
+		if err != nil {
+			return err
+		}
+		if affectRows == 0 {
+			return fmt.Errorf("action %s %w", id, models.ErrNotFound)
+		}
+
+		_, err = repo.ActionRunRepo().Delete(ctx, models.NewDeleteActionRunParams().SetActionID(id))
+		return err
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.OK()
+}
+
+func (actionCtl ActionController) ListActionRuns(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID, params api.ListActionRunsParams) {
+	repository, ok := actionCtl.getRepository(ctx, w, actionCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	action, err := actionCtl.Repo.ActionRepo().Get(ctx, models.NewGetActionParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	listParams := models.NewListActionRunParams().SetActionID(action.ID)
+	if params.State != nil {
+		listParams.SetState(models.RunState(*params.State))
+	}
+	if params.After != nil {
+		listParams.SetAfter(time.UnixMilli(utils.Int64Value(params.After)))
+	}
+	pageAmount := utils.IntValue(params.Amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	runs, hasMore, err := actionCtl.Repo.ActionRunRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	results := utils.Silent(utils.ArrMap(runs, actionRunToDto))
+	pagMag := utils.PaginationFor(hasMore, results, "CreatedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.ActionRunList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
+
+func (actionCtl ActionController) GetActionRun(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID, runID openapi_types.UUID) {
+	repository, ok := actionCtl.getRepository(ctx, w, actionCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	action, err := actionCtl.Repo.ActionRepo().Get(ctx, models.NewGetActionParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	run, err := actionCtl.Repo.ActionRunRepo().Get(ctx, models.NewGetActionRunParams().SetID(runID).SetActionID(action.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(actionRunToDto(run)))
+}
+
+// checkCommand command action can only run commands configured in server
+func (actionCtl ActionController) checkCommand(command string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("command must be specified for command action")
+	}
+	if _, ok := actionCtl.ActionsConfig.Commands[command]; !ok {
+		return fmt.Errorf("command %s is not configured in server", command)
+	}
+	return nil
+}
+
+func parseActionEvents(events []string) ([]models.ActionEvent, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("action must be triggered by at least one event")
+	}
+
+	actionEvents := make([]models.ActionEvent, 0, len(events))
+	for _, event := range events {
+		actionEvent, err := models.ParseActionEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		actionEvents = append(actionEvents, actionEvent)
+	}
+	return actionEvents, nil
+}
+
+func actionToDto(in *models.Action) (api.Action, error) {
+	events := make([]string, len(in.Events))
+	for index, event := range in.Events {
+		events[index] = string(event)
+	}
+	action := api.Action{
+		Id:           in.ID,
+		RepositoryId: in.RepositoryID,
+		Name:         in.Name,
+		Type:         api.ActionType(in.Type),
+		Events:       events,
+		Active:       in.Active,
+		CreatorId:    in.CreatorID,
+		CreatedAt:    in.CreatedAt.UnixMilli(),
+		UpdatedAt:    in.UpdatedAt.UnixMilli(),
+	}
+	if len(in.Command) > 0 {
+		action.Command = utils.String(in.Command)
+	}
+	if len(in.URL) > 0 {
+		action.Url = utils.String(in.URL)
+	}
+	return action, nil
+}
+
+func actionRunToDto(in *models.ActionRun) (api.ActionRun, error) {
+	run := api.ActionRun{
+		Id:           in.ID,
+		ActionId:     in.ActionID,
+		Event:        string(in.Event),
+		Payload:      in.Payload,
+		State:        api.ActionRunState(in.State),
+		ExitCode:     in.ExitCode,
+		ResponseCode: in.ResponseCode,
+		Logs:         in.Logs,
+		Message:      in.Message,
+		CreatedAt:    in.CreatedAt.UnixMilli(),
+		UpdatedAt:    in.UpdatedAt.UnixMilli(),
+	}
+	if !in.CommitHash.IsEmpty() {
+		run.CommitHash = utils.String(in.CommitHash.Hex())
+	}
+	if in.StartedAt != nil {
+		run.StartedAt = utils.Int64(in.StartedAt.UnixMilli())
+	}
+	if in.FinishedAt != nil {
+		run.FinishedAt = utils.Int64(in.FinishedAt.UnixMilli())
+	}
+	return run, nil
+}
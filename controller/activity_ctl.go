@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+type ActivityController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (activityCtl ActivityController) GetRepositoryActivity(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetRepositoryActivityParams) {
+	repository, ok := activityCtl.getRepository(ctx, w, activityCtl.Repo, ownerName, repositoryName, rbacmodel.ReadRepositoryAction)
+	if !ok || !checkActivityRange(w, params.Since, params.Until) {
+		return
+	}
+
+	bucket := api.ActivityBucketUnitWeek
+	if params.Bucket != nil {
+		bucket = *params.Bucket
+	}
+
+	commitParams := models.NewAggregateCommitStatsParams(repository.ID, models.ActivityBucket(bucket))
+	mrParams := models.NewMergeRequestActivityParams(repository.ID, models.ActivityBucket(bucket))
+	if params.Since != nil {
+		commitParams.SetSince(time.UnixMilli(*params.Since))
+		mrParams.SetSince(time.UnixMilli(*params.Since))
+	}
+	if params.Until != nil {
+		commitParams.SetUntil(time.UnixMilli(*params.Until))
+		mrParams.SetUntil(time.UnixMilli(*params.Until))
+	}
+
+	// merge requests are recorded by author id, author who is not a user never opened any merge request
+	countMergeRequests := true
+	if params.Author != nil {
+		commitParams.SetAuthor(*params.Author)
+		author, err := activityCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(*params.Author))
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			w.Error(err)
+			return
+		}
+		countMergeRequests = err == nil
+		if countMergeRequests {
+			mrParams.SetAuthorID(author.ID)
+		}
+	}
+
+	commitActivities, err := activityCtl.Repo.CommitStatsRepo().Aggregate(ctx, commitParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	mrActivities := make([]*models.MergeRequestActivity, 0)
+	if countMergeRequests {
+		mrActivities, err = activityCtl.Repo.MergeRequestRepo().Activity(ctx, mrParams)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+	}
+
+	aggregator := newActivityAggregator()
+	for _, activity := range commitActivities {
+		contributor := aggregator.contributor(activity.Bucket, activity.Author)
+		contributor.Commits += activity.Commits
+		contributor.FilesAdded += activity.FilesAdded
+		contributor.FilesModified += activity.FilesModified
+		contributor.FilesRemoved += activity.FilesRemoved
+		contributor.BytesAdded += activity.BytesAdded
+		contributor.BytesRemoved += activity.BytesRemoved
+	}
+
+	userNames := make(map[uuid.UUID]string)
+	for _, activity := range mrActivities {
+		name, ok := userNames[activity.AuthorID]
+		if !ok {
+			name, err = activityCtl.userName(ctx, activity.AuthorID)
+			if err != nil {
+				w.Error(err)
+				return
+			}
+			userNames[activity.AuthorID] = name
+		}
+		contributor := aggregator.contributor(activity.Bucket, name)
+		contributor.MergeRequestsOpened += activity.Opened
+		contributor.MergeRequestsMerged += activity.Merged
+	}
+
+	contributors, periods := aggregator.result()
+	w.JSON(api.RepositoryActivity{
+		Bucket:       bucket,
+		Contributors: contributors,
+		Periods:      periods,
+	})
+}
+
+func (activityCtl ActivityController) ListCommitStats(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.ListCommitStatsParams) {
+	repository, ok := activityCtl.getRepository(ctx, w, activityCtl.Repo, ownerName, repositoryName, rbacmodel.ReadRepositoryAction)
+	if !ok || !checkActivityRange(w, params.Since, params.Until) {
+		return
+	}
+
+	listParams := models.NewListCommitStatsParams().SetRepositoryID(repository.ID)
+	if params.Author != nil {
+		listParams.SetAuthor(*params.Author)
+	}
+	if params.Since != nil {
+		listParams.SetSince(time.UnixMilli(*params.Since))
+	}
+	if params.Until != nil {
+		listParams.SetUntil(time.UnixMilli(*params.Until))
+	}
+	if params.After != nil {
+		listParams.SetAfter(time.UnixMilli(*params.After))
+	}
+	pageAmount := utils.IntValue(params.Amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	stats, hasMore, err := activityCtl.Repo.CommitStatsRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	results := make([]api.CommitStats, 0, len(stats))
+	for _, item := range stats {
+		results = append(results, commitStatsToDto(item))
+	}
+	pagMag := utils.PaginationFor(hasMore, results, "CommittedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.CommitStatsList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
+
+// userName return name of user, id is returned if user has been deleted
+func (activityCtl ActivityController) userName(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := activityCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetID(userID))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return userID.String(), nil
+		}
+		return "", err
+	}
+	return user.Name, nil
+}
+
+func checkActivityRange(w *api.JiaozifsResponse, since, until *int64) bool {
+	if since != nil && until != nil && *since >= *until {
+		w.BadRequest("since must be before until")
+		return false
+	}
+	return true
+}
+
+// activityAggregator collect activities of contributors in periods
+type activityAggregator struct {
+	periods map[int64]map[string]*api.ContributorActivity
+}
+
+func newActivityAggregator() *activityAggregator {
+	return &activityAggregator{periods: make(map[int64]map[string]*api.ContributorActivity)}
+}
+
+func (aggregator *activityAggregator) contributor(start time.Time, author string) *api.ContributorActivity {
+	contributors, ok := aggregator.periods[start.UnixMilli()]
+	if !ok {
+		contributors = make(map[string]*api.ContributorActivity)
+		aggregator.periods[start.UnixMilli()] = contributors
+	}
+	contributor, ok := contributors[author]
+	if !ok {
+		contributor = &api.ContributorActivity{Author: author}
+		contributors[author] = contributor
+	}
+	return contributor
+}
+
+// result return contributors in whole range and periods ordered by start time
+func (aggregator *activityAggregator) result() ([]api.ContributorActivity, []api.ActivityPeriod) {
+	totals := make(map[string]*api.ContributorActivity)
+	periods := make([]api.ActivityPeriod, 0, len(aggregator.periods))
+	for start, contributors := range aggregator.periods {
+		period := api.ActivityPeriod{Start: start}
+		for author, contributor := range contributors {
+			total, ok := totals[author]
+			if !ok {
+				total = &api.ContributorActivity{Author: author}
+				totals[author] = total
+			}
+			addContributorActivity(total, contributor)
+
+			period.Commits += contributor.Commits
+			period.FilesAdded += contributor.FilesAdded
+			period.FilesModified += contributor.FilesModified
+			period.FilesRemoved += contributor.FilesRemoved
+			period.BytesAdded += contributor.BytesAdded
+			period.BytesRemoved += contributor.BytesRemoved
+			period.MergeRequestsOpened += contributor.MergeRequestsOpened
+			period.MergeRequestsMerged += contributor.MergeRequestsMerged
+			period.Contributors = append(period.Contributors, *contributor)
+		}
+		sortContributors(period.Contributors)
+		periods = append(periods, period)
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Start < periods[j].Start
+	})
+
+	contributors := make([]api.ContributorActivity, 0, len(totals))
+	for _, total := range totals {
+		contributors = append(contributors, *total)
+	}
+	sortContributors(contributors)
+	return contributors, periods
+}
+
+func addContributorActivity(total *api.ContributorActivity, contributor *api.ContributorActivity) {
+	total.Commits += contributor.Commits
+	total.FilesAdded += contributor.FilesAdded
+	total.FilesModified += contributor.FilesModified
+	total.FilesRemoved += contributor.FilesRemoved
+	total.BytesAdded += contributor.BytesAdded
+	total.BytesRemoved += contributor.BytesRemoved
+	total.MergeRequestsOpened += contributor.MergeRequestsOpened
+	total.MergeRequestsMerged += contributor.MergeRequestsMerged
+}
+
+// sortContributors order contributors by commits desc, then by author name
+func sortContributors(contributors []api.ContributorActivity) {
+	sort.Slice(contributors, func(i, j int) bool {
+		if contributors[i].Commits == contributors[j].Commits {
+			return contributors[i].Author < contributors[j].Author
+		}
+		return contributors[i].Commits > contributors[j].Commits
+	})
+}
+
+func commitStatsToDto(in *models.CommitStats) api.CommitStats {
+	return api.CommitStats{
+		CommitHash:    in.CommitHash.Hex(),
+		Author:        in.AuthorName,
+		AuthorEmail:   in.AuthorEmail,
+		IsMerge:       in.IsMerge,
+		FilesAdded:    in.FilesAdded,
+		FilesModified: in.FilesModified,
+		FilesRemoved:  in.FilesRemoved,
+		BytesAdded:    in.BytesAdded,
+		BytesRemoved:  in.BytesRemoved,
+		CommittedAt:   in.CommittedAt.UnixMilli(),
+	}
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+type AssigneeController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (assigneeCtl AssigneeController) ListAssignees(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64) {
+	_, mergeRequest, ok := assigneeCtl.getMergeRequest(ctx, w, assigneeCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReadMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	assignees, err := listAssignees(ctx, assigneeCtl.Repo, mergeRequest.ID)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(assignees)
+}
+
+func (assigneeCtl AssigneeController) AssignUsers(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.AssignUsersJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := assigneeCtl.getMergeRequest(ctx, w, assigneeCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	if len(body.Assignees) == 0 {
+		w.BadRequest("must assign at least one user")
+		return
+	}
+
+	users, err := getAssignees(ctx, assigneeCtl.Repo.UserRepo(), body.Assignees)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	err = assigneeCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		return assignUsers(ctx, repo, mergeRequest.ID, operator.ID, users)
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	assignees, err := listAssignees(ctx, assigneeCtl.Repo, mergeRequest.ID)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(assignees, http.StatusCreated)
+}
+
+func (assigneeCtl AssigneeController) UnassignUser(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, assigneeName string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := assigneeCtl.getMergeRequest(ctx, w, assigneeCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	assignee, err := assigneeCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(assigneeName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	err = assigneeCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		affectedRows, err := repo.AssigneeRepo().Delete(ctx, models.NewDeleteAssigneeParams().SetMergeRequestID(mergeRequest.ID).SetAssigneeID(assignee.ID))
+		if err != nil {
+			return err
+		}
+		if affectedRows == 0 {
+			return fmt.Errorf("user %s not assigned to merge request %w", assigneeName, models.ErrNotFound)
+		}
+		return repo.TimelineRepo().Insert(ctx, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, models.TimelineUnassigned, utils.String(assignee.Name)))
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.OK()
+}
+
+// getAssignees get users by names, response bad request if any user not exit
+func getAssignees(ctx context.Context, userRepo models.IUserRepo, userNames []string) ([]*models.User, error) {
+	users := make([]*models.User, 0, len(userNames))
+	for _, userName := range userNames {
+		user, err := userRepo.Get(ctx, models.NewGetUserParams().SetName(userName))
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, fmt.Errorf("user %s not found %w", userName, api.ErrCode(http.StatusBadRequest))
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// assignUsers assign users to merge request and record timeline of users newly assigned
+func assignUsers(ctx context.Context, repo models.IRepo, mergeRequestID uuid.UUID, operatorID uuid.UUID, users []*models.User) error {
+	assignees := make([]*models.MergeRequestAssignee, 0, len(users))
+	userNames := make(map[uuid.UUID]string, len(users))
+	for _, user := range users {
+		assignees = append(assignees, &models.MergeRequestAssignee{
+			MergeRequestID: mergeRequestID,
+			AssigneeID:     user.ID,
+			AssignerID:     operatorID,
+			CreatedAt:      time.Now(),
+		})
+		userNames[user.ID] = user.Name
+	}
+
+	assigned, err := repo.AssigneeRepo().Insert(ctx, assignees...)
+	if err != nil {
+		return err
+	}
+
+	timelines := make([]*models.MergeRequestTimeline, 0, len(assigned))
+	for _, assignee := range assigned {
+		timelines = append(timelines, models.NewMergeRequestTimeline(mergeRequestID, operatorID, models.TimelineAssigned, utils.String(userNames[assignee.AssigneeID])))
+	}
+	return repo.TimelineRepo().Insert(ctx, timelines...)
+}
+
+func listAssignees(ctx context.Context, repo models.IRepo, mergeRequestID uuid.UUID) ([]api.Assignee, error) {
+	assignees, err := repo.AssigneeRepo().List(ctx, models.NewListAssigneeParams().SetMergeRequestID(mergeRequestID))
+	if err != nil {
+		return nil, err
+	}
+
+	userNames := make(map[uuid.UUID]string)
+	results := make([]api.Assignee, 0, len(assignees))
+	for _, assignee := range assignees {
+		assigneeName, err := cachedUserName(ctx, repo.UserRepo(), userNames, assignee.AssigneeID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, api.Assignee{
+			AssigneeId:   assignee.AssigneeID,
+			AssigneeName: assigneeName,
+			AssignerId:   assignee.AssignerID,
+			CreatedAt:    assignee.CreatedAt.UnixMilli(),
+		})
+	}
+	return results, nil
+}
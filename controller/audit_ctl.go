@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/google/uuid"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+)
+
+var auditLog = logging.Logger("audit_ctl")
+
+type AuditController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (auditCtl AuditController) ListAuditLogs(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, params api.ListAuditLogsParams) {
+	if !auditCtl.authorize(ctx, w, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.QueryAuditLogAction,
+			Resource: rbacmodel.AuditLogArn(),
+		},
+	}) {
+		return
+	}
+
+	listParams := newListAuditLogParams(params.Actor, params.Action, (*string)(params.AuthMethod), (*string)(params.Outcome), params.Since, params.Until)
+	auditCtl.listAuditLogs(ctx, w, listParams, params.After, params.Amount)
+}
+
+func (auditCtl AuditController) ExportAuditLogs(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, params api.ExportAuditLogsParams) {
+	if !auditCtl.authorize(ctx, w, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.QueryAuditLogAction,
+			Resource: rbacmodel.AuditLogArn(),
+		},
+	}) {
+		return
+	}
+
+	listParams := newListAuditLogParams(params.Actor, params.Action, (*string)(params.AuthMethod), (*string)(params.Outcome), params.Since, params.Until)
+	auditCtl.exportAuditLogs(ctx, w, listParams)
+}
+
+func (auditCtl AuditController) ListRepoAuditLogs(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.ListRepoAuditLogsParams) {
+	repository, ok := auditCtl.getRepository(ctx, w, auditCtl.Repo, ownerName, repositoryName, rbacmodel.QueryRepoAuditLogAction)
+	if !ok {
+		return
+	}
+
+	listParams := newListAuditLogParams(params.Actor, params.Action, (*string)(params.AuthMethod), (*string)(params.Outcome), params.Since, params.Until).
+		SetRepositoryID(repository.ID)
+	auditCtl.listAuditLogs(ctx, w, listParams, params.After, params.Amount)
+}
+
+func (auditCtl AuditController) ExportRepoAuditLogs(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.ExportRepoAuditLogsParams) {
+	repository, ok := auditCtl.getRepository(ctx, w, auditCtl.Repo, ownerName, repositoryName, rbacmodel.QueryRepoAuditLogAction)
+	if !ok {
+		return
+	}
+
+	listParams := newListAuditLogParams(params.Actor, params.Action, (*string)(params.AuthMethod), (*string)(params.Outcome), params.Since, params.Until).
+		SetRepositoryID(repository.ID)
+	auditCtl.exportAuditLogs(ctx, w, listParams)
+}
+
+func (auditCtl AuditController) listAuditLogs(ctx context.Context, w *api.JiaozifsResponse, listParams *models.ListAuditLogParams, after *int64, amount *int) {
+	if after != nil {
+		listParams.SetAfter(time.UnixMilli(utils.Int64Value(after)))
+	}
+	pageAmount := utils.IntValue(amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	logs, hasMore, err := auditCtl.Repo.AuditLogRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	results := utils.Silent(utils.ArrMap(logs, auditLogToDto))
+	pagMag := utils.PaginationFor(hasMore, results, "CreatedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.AuditLogList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
+
+// exportAuditLogs write all matched logs in json lines format, one log per line
+func (auditCtl AuditController) exportAuditLogs(ctx context.Context, w *api.JiaozifsResponse, listParams *models.ListAuditLogParams) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-logs.jsonl"`)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	err := auditCtl.Repo.AuditLogRepo().Walk(ctx, listParams, func(log *models.AuditLog) error {
+		dto, err := auditLogToDto(log)
+		if err != nil {
+			return err
+		}
+		return encoder.Encode(dto)
+	})
+	if err != nil {
+		// header has been written, only able to log the error
+		auditLog.Errorf("export audit logs %v", err)
+	}
+}
+
+func newListAuditLogParams(actor, action, authMethod, outcome *string, since, until *int64) *models.ListAuditLogParams {
+	listParams := models.NewListAuditLogParams()
+	if actor != nil {
+		listParams.SetActorName(*actor)
+	}
+	if action != nil {
+		listParams.SetAction(*action)
+	}
+	if authMethod != nil {
+		listParams.SetAuthMethod(models.AuthMethod(*authMethod))
+	}
+	if outcome != nil {
+		listParams.SetOutcome(models.AuditOutcome(*outcome))
+	}
+	if since != nil {
+		listParams.SetSince(time.UnixMilli(*since))
+	}
+	if until != nil {
+		listParams.SetUntil(time.UnixMilli(*until))
+	}
+	return listParams
+}
+
+func auditLogToDto(in *models.AuditLog) (api.AuditLog, error) {
+	dto := api.AuditLog{
+		Id:         in.ID,
+		AuthMethod: string(in.AuthMethod),
+		Operation:  in.Operation,
+		Action:     in.Action,
+		Resource:   in.Resource,
+		RequestId:  in.RequestID,
+		Method:     in.Method,
+		Path:       in.Path,
+		StatusCode: in.StatusCode,
+		Outcome:    api.AuditLogOutcome(in.Outcome),
+		CreatedAt:  in.CreatedAt.UnixMilli(),
+	}
+	if in.ActorID != uuid.Nil {
+		actorID := in.ActorID
+		dto.ActorId = &actorID
+		dto.ActorName = utils.String(in.ActorName)
+	}
+	if in.RepositoryID != uuid.Nil {
+		repositoryID := in.RepositoryID
+		dto.RepositoryId = &repositoryID
+	}
+	return dto, nil
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/automerge"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"go.uber.org/fx"
+)
+
+type AutoMergeController struct {
+	fx.In
+	BaseController
+
+	Repo      models.IRepo
+	AutoMerge automerge.Notifier
+}
+
+func (autoMergeCtl AutoMergeController) GetAutoMerge(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64) {
+	_, mergeRequest, ok := autoMergeCtl.getMergeRequest(ctx, w, autoMergeCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReadMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	autoMerge, err := autoMergeCtl.Repo.AutoMergeRepo().Get(ctx, models.NewGetAutoMergeParams().SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(autoMergeToDto(autoMerge))
+}
+
+func (autoMergeCtl AutoMergeController) EnableAutoMerge(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.EnableAutoMergeJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := autoMergeCtl.getMergeRequest(ctx, w, autoMergeCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.MergeMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	if mergeRequest.MergeState != models.MergeStateInit {
+		w.BadRequest("only open merge request can be auto merged")
+		return
+	}
+
+	conflictResolve := utils.Map(body.ConflictResolve)
+	err = automerge.ValidateConflictResolve(ctx, autoMergeCtl.Repo.FileTreeRepo(mergeRequest.TargetRepoID), conflictResolve)
+	if err != nil {
+		w.Error(mergeCheckError(err))
+		return
+	}
+
+	autoMerge, err := autoMergeCtl.Repo.AutoMergeRepo().Upsert(ctx, &models.AutoMerge{
+		MergeRequestID:  mergeRequest.ID,
+		OperatorID:      operator.ID,
+		Message:         body.Msg,
+		ConflictResolve: conflictResolve,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	autoMergeCtl.AutoMerge.Notify()
+	w.JSON(autoMergeToDto(autoMerge), http.StatusCreated)
+}
+
+func (autoMergeCtl AutoMergeController) DisableAutoMerge(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64) {
+	_, mergeRequest, ok := autoMergeCtl.getMergeRequest(ctx, w, autoMergeCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.MergeMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	affectedRows, err := autoMergeCtl.Repo.AutoMergeRepo().Delete(ctx, models.NewDeleteAutoMergeParams().SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	if affectedRows == 0 {
+		w.Error(models.ErrNotFound)
+		return
+	}
+	w.OK()
+}
+
+func autoMergeToDto(in *models.AutoMerge) api.AutoMerge {
+	autoMerge := api.AutoMerge{
+		Id:             in.ID,
+		MergeRequestId: in.MergeRequestID,
+		OperatorId:     in.OperatorID,
+		Msg:            in.Message,
+		Reason:         in.Reason,
+		CreatedAt:      in.CreatedAt.UnixMilli(),
+		UpdatedAt:      in.UpdatedAt.UnixMilli(),
+	}
+	if len(in.ConflictResolve) > 0 {
+		autoMerge.ConflictResolve = &in.ConflictResolve
+	}
+	return autoMerge
+}
@@ -3,14 +3,19 @@ package controller
 import (
 	"context"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 
 	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/audit"
 
 	"github.com/GitDataAI/jiaozifs/auth"
 
 	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
 	"go.uber.org/fx"
 )
 
@@ -31,6 +36,7 @@ func (c *BaseController) authorize(ctx context.Context, w *api.JiaozifsResponse,
 		OperatorID:          operator.ID,
 		RequiredPermissions: perms,
 	})
+	recordPermissions(ctx, uuid.Nil, perms, err == nil && resp.Allowed)
 	if err != nil {
 		w.String(err.Error(), http.StatusInternalServerError)
 		return false
@@ -58,6 +64,7 @@ func (c *BaseController) authorizeMember(ctx context.Context, w *api.JiaozifsRes
 		OperatorID:          operator.ID,
 		RequiredPermissions: perms,
 	})
+	recordPermissions(ctx, repoID, perms, err == nil && resp.Allowed)
 	if err != nil {
 		w.String(err.Error(), http.StatusInternalServerError)
 		return false
@@ -73,3 +80,78 @@ func (c *BaseController) authorizeMember(ctx context.Context, w *api.JiaozifsRes
 	}
 	return true
 }
+
+// findRepository get repository by owner name and repository name, response is written if not found
+func (c *BaseController) findRepository(ctx context.Context, w *api.JiaozifsResponse, repo models.IRepo, ownerName string, repositoryName string) (*models.Repository, bool) {
+	owner, err := repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return nil, false
+	}
+
+	repository, err := repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetName(repositoryName).SetOwnerID(owner.ID))
+	if err != nil {
+		w.Error(err)
+		return nil, false
+	}
+	return repository, true
+}
+
+// getRepository get repository and check whether operator has permission of action on it, response is written if not ok
+func (c *BaseController) getRepository(ctx context.Context, w *api.JiaozifsResponse, repo models.IRepo, ownerName string, repositoryName string, action string) (*models.Repository, bool) {
+	repository, ok := c.findRepository(ctx, w, repo, ownerName, repositoryName)
+	if !ok {
+		return nil, false
+	}
+
+	if !c.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   action,
+			Resource: rbacmodel.RepoURArn(repository.OwnerID.String(), repository.ID.String()),
+		},
+	}) {
+		return nil, false
+	}
+	return repository, true
+}
+
+// getMergeRequest get merge request of repository and check whether operator has permission of action on the repository,
+// response is written if not ok
+func (c *BaseController) getMergeRequest(ctx context.Context, w *api.JiaozifsResponse, repo models.IRepo, ownerName string, repositoryName string, mrSeq uint64, action string) (*models.Repository, *models.MergeRequest, bool) {
+	repository, ok := c.getRepository(ctx, w, repo, ownerName, repositoryName, action)
+	if !ok {
+		return nil, nil, false
+	}
+
+	mergeRequest, err := repo.MergeRequestRepo().Get(ctx, models.NewGetMergeRequestParams().SetTargetRepo(repository.ID).SetNumber(mrSeq))
+	if err != nil {
+		w.Error(err)
+		return nil, nil, false
+	}
+	return repository, mergeRequest, true
+}
+
+// recordPermissions set checked node as the permission of audit record of request
+func recordPermissions(ctx context.Context, repoID uuid.UUID, perms rbac.Node, allowed bool) {
+	var actions, resources []string
+	collectPermissions(perms, &actions, &resources)
+	audit.FromContext(ctx).SetPermission(audit.Permission{
+		Action:       strings.Join(actions, ","),
+		Resource:     strings.Join(resources, ","),
+		RepositoryID: repoID,
+		Allowed:      allowed,
+	})
+}
+
+func collectPermissions(perms rbac.Node, actions, resources *[]string) {
+	if perms.Type == rbac.NodeTypeNode {
+		*actions = append(*actions, perms.Permission.Action)
+		if resource := perms.Permission.Resource.String(); !slices.Contains(*resources, resource) {
+			*resources = append(*resources, resource)
+		}
+		return
+	}
+	for _, node := range perms.Nodes {
+		collectPermissions(node, actions, resources)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/GitDataAI/jiaozifs/block/params"
 	"github.com/GitDataAI/jiaozifs/controller/validator"
 	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/protection"
 	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/GitDataAI/jiaozifs/webhook"
 
 	"github.com/GitDataAI/jiaozifs/api"
 	"github.com/GitDataAI/jiaozifs/auth"
@@ -24,6 +26,7 @@ type BranchController struct {
 
 	Repo                models.IRepo
 	PublicStorageConfig params.AdapterConfig
+	Emitter             webhook.Emitter
 }
 
 func (bct BranchController) ListBranches(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.ListBranchesParams) {
@@ -127,6 +130,7 @@ func (bct BranchController) CreateBranch(ctx context.Context, w *api.JiaozifsRes
 		w.Error(err)
 		return
 	}
+	workRepo.WithEmitter(bct.Emitter)
 
 	err = workRepo.CheckOut(ctx, versionmgr.InCommit, sourceBranch.CommitHash.Hex())
 	if err != nil {
@@ -177,11 +181,16 @@ func (bct BranchController) DeleteBranch(ctx context.Context, w *api.JiaozifsRes
 		return
 	}
 
+	if !checkProtection(w, protection.NewChecker(bct.Repo).CheckDelete(ctx, repository.ID, params.RefName)) {
+		return
+	}
+
 	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, bct.Repo, bct.PublicStorageConfig)
 	if err != nil {
 		w.Error(err)
 		return
 	}
+	workRepo.WithEmitter(bct.Emitter)
 
 	err = workRepo.CheckOut(ctx, versionmgr.InBranch, params.RefName)
 	if err != nil {
@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/protection"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.uber.org/fx"
+)
+
+type BranchProtectionController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (bpCtl BranchProtectionController) ListBranchProtections(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string) {
+	repository, ok := bpCtl.getRepository(ctx, w, bpCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	protections, err := bpCtl.Repo.BranchProtectionRepo().List(ctx, models.NewListBranchProtectionParams().SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	results := make([]api.BranchProtection, 0, len(protections))
+	for _, bp := range protections {
+		dto, err := bpCtl.branchProtectionToDto(ctx, bp)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		results = append(results, dto)
+	}
+	w.JSON(results)
+}
+
+func (bpCtl BranchProtectionController) CreateBranchProtection(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.CreateBranchProtectionJSONRequestBody, ownerName string, repositoryName string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, ok := bpCtl.getRepository(ctx, w, bpCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	err = protection.ValidatePattern(body.Pattern)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	requiredApprovals := utils.IntValue(body.RequiredApprovals)
+	if requiredApprovals < 0 {
+		w.BadRequest("required approvals must not be negative")
+		return
+	}
+
+	allowedMergers := []uuid.UUID{}
+	if body.AllowedMergers != nil {
+		allowedMergers, err = bpCtl.getUserIDs(ctx, *body.AllowedMergers)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+	}
+
+	err = bpCtl.checkPatternExist(ctx, repository.ID, body.Pattern)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	requiredStatusChecks := []string{}
+	if body.RequiredStatusChecks != nil {
+		requiredStatusChecks = *body.RequiredStatusChecks
+	}
+
+	bp, err := bpCtl.Repo.BranchProtectionRepo().Insert(ctx, &models.BranchProtection{
+		RepositoryID:         repository.ID,
+		Pattern:              body.Pattern,
+		RequireMergeRequest:  utils.BoolValue(body.RequireMergeRequest),
+		BlockDeletion:        utils.BoolValue(body.BlockDeletion),
+		BlockForcePush:       utils.BoolValue(body.BlockForcePush),
+		RequiredApprovals:    requiredApprovals,
+		RequiredStatusChecks: requiredStatusChecks,
+		AllowedMergers:       allowedMergers,
+		CreatorID:            operator.ID,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	dto, err := bpCtl.branchProtectionToDto(ctx, bp)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(dto, http.StatusCreated)
+}
+
+func (bpCtl BranchProtectionController) GetBranchProtection(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := bpCtl.getRepository(ctx, w, bpCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	bp, err := bpCtl.Repo.BranchProtectionRepo().Get(ctx, models.NewGetBranchProtectionParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	dto, err := bpCtl.branchProtectionToDto(ctx, bp)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(dto)
+}
+
+func (bpCtl BranchProtectionController) UpdateBranchProtection(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateBranchProtectionJSONRequestBody, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := bpCtl.getRepository(ctx, w, bpCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	bp, err := bpCtl.Repo.BranchProtectionRepo().Get(ctx, models.NewGetBranchProtectionParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	updateParams := models.NewUpdateBranchProtectionParams(bp.ID)
+	if body.Pattern != nil && *body.Pattern != bp.Pattern {
+		err = protection.ValidatePattern(*body.Pattern)
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+
+		err = bpCtl.checkPatternExist(ctx, repository.ID, *body.Pattern)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		updateParams.SetPattern(*body.Pattern)
+	}
+	if body.RequireMergeRequest != nil {
+		updateParams.SetRequireMergeRequest(*body.RequireMergeRequest)
+	}
+	if body.BlockDeletion != nil {
+		updateParams.SetBlockDeletion(*body.BlockDeletion)
+	}
+	if body.BlockForcePush != nil {
+		updateParams.SetBlockForcePush(*body.BlockForcePush)
+	}
+	if body.RequiredApprovals != nil {
+		if *body.RequiredApprovals < 0 {
+			w.BadRequest("required approvals must not be negative")
+			return
+		}
+		updateParams.SetRequiredApprovals(*body.RequiredApprovals)
+	}
+	if body.RequiredStatusChecks != nil {
+		updateParams.SetRequiredStatusChecks(*body.RequiredStatusChecks)
+	}
+	if body.AllowedMergers != nil {
+		allowedMergers, err := bpCtl.getUserIDs(ctx, *body.AllowedMergers)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		updateParams.SetAllowedMergers(allowedMergers)
+	}
+
+	err = bpCtl.Repo.BranchProtectionRepo().UpdateByID(ctx, updateParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	bp, err = bpCtl.Repo.BranchProtectionRepo().Get(ctx, models.NewGetBranchProtectionParams().SetID(bp.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	dto, err := bpCtl.branchProtectionToDto(ctx, bp)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(dto)
+}
+
+func (bpCtl BranchProtectionController) DeleteBranchProtection(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := bpCtl.getRepository(ctx, w, bpCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	affectedRows, err := bpCtl.Repo.BranchProtectionRepo().Delete(ctx, models.NewDeleteBranchProtectionParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	if affectedRows == 0 {
+		w.Error(models.ErrNotFound)
+		return
+	}
+	w.OK()
+}
+
+func (bpCtl BranchProtectionController) checkPatternExist(ctx context.Context, repositoryID uuid.UUID, pattern string) error {
+	_, err := bpCtl.Repo.BranchProtectionRepo().Get(ctx, models.NewGetBranchProtectionParams().SetRepositoryID(repositoryID).SetPattern(pattern))
+	if err == nil {
+		return fmt.Errorf("branch protection of pattern %s already exists %w", pattern, api.ErrCode(http.StatusConflict))
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (bpCtl BranchProtectionController) getUserIDs(ctx context.Context, userNames []string) ([]uuid.UUID, error) {
+	userIDs := make([]uuid.UUID, 0, len(userNames))
+	for _, userName := range userNames {
+		user, err := bpCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(userName))
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, fmt.Errorf("user %s not found %w", userName, api.ErrCode(http.StatusBadRequest))
+		}
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+	return userIDs, nil
+}
+
+func (bpCtl BranchProtectionController) branchProtectionToDto(ctx context.Context, in *models.BranchProtection) (api.BranchProtection, error) {
+	allowedMergers := make([]string, 0, len(in.AllowedMergers))
+	for _, userID := range in.AllowedMergers {
+		user, err := bpCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetID(userID))
+		if errors.Is(err, models.ErrNotFound) {
+			// user has been deleted
+			continue
+		}
+		if err != nil {
+			return api.BranchProtection{}, err
+		}
+		allowedMergers = append(allowedMergers, user.Name)
+	}
+
+	return api.BranchProtection{
+		Id:                   in.ID,
+		RepositoryId:         in.RepositoryID,
+		Pattern:              in.Pattern,
+		RequireMergeRequest:  in.RequireMergeRequest,
+		BlockDeletion:        in.BlockDeletion,
+		BlockForcePush:       in.BlockForcePush,
+		RequiredApprovals:    in.RequiredApprovals,
+		RequiredStatusChecks: in.RequiredStatusChecks,
+		AllowedMergers:       allowedMergers,
+		CreatorId:            in.CreatorID,
+		CreatedAt:            in.CreatedAt.UnixMilli(),
+		UpdatedAt:            in.UpdatedAt.UnixMilli(),
+	}, nil
+}
+
+// checkProtection response forbidden if the operation is rejected by branch protection rule
+func checkProtection(w *api.JiaozifsResponse, err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, protection.ErrBranchProtected) {
+		w.Error(fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusForbidden)))
+		return false
+	}
+	w.Error(err)
+	return false
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/GitDataAI/jiaozifs/auth"
 	"github.com/GitDataAI/jiaozifs/auth/rbac"
 	"github.com/GitDataAI/jiaozifs/block/params"
+	"github.com/GitDataAI/jiaozifs/contentdiff"
 	"github.com/GitDataAI/jiaozifs/models"
 	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
 	"github.com/GitDataAI/jiaozifs/utils"
@@ -145,18 +146,7 @@ func (commitCtl CommitController) GetEntriesInRef(ctx context.Context, w *api.Ji
 		w.Error(err)
 		return
 	}
-	apiTreeEntries := make([]api.FullTreeEntry, len(treeEntry))
-	for index, entry := range treeEntry {
-		apiTreeEntries[index] = api.FullTreeEntry{
-			CreatedAt: entry.CreatedAt.UnixMilli(),
-			Hash:      entry.Hash.Hex(),
-			IsDir:     entry.IsDir,
-			Name:      entry.Name,
-			Size:      entry.Size,
-			UpdatedAt: entry.UpdatedAt.UnixMilli(),
-		}
-	}
-	w.JSON(apiTreeEntries)
+	w.JSON(fullTreeEntriesToDto(treeEntry))
 }
 
 func (commitCtl CommitController) CompareCommit(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, basehead string, params api.CompareCommitParams) {
@@ -222,6 +212,24 @@ func (commitCtl CommitController) CompareCommit(ctx context.Context, w *api.Jiao
 		w.Error(err)
 		return
 	}
+
+	if utils.BoolValue(params.Notebook) {
+		err = fillNotebookDiffs(ctx, workRepo, commitCtl.Repo.FileTreeRepo(repository.ID), changesResp, contentdiff.NotebookOption{
+			IgnoreOutputs: utils.BoolValue(params.IgnoreOutputs),
+		})
+		if err != nil {
+			w.Error(err)
+			return
+		}
+	}
+
+	if utils.BoolValue(params.ParquetSchema) {
+		err = fillParquetSchemaChanges(ctx, workRepo, commitCtl.Repo.FileTreeRepo(repository.ID), changesResp)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+	}
 	w.JSON(changesResp)
 }
 
@@ -276,6 +284,16 @@ func (commitCtl CommitController) GetCommitChanges(ctx context.Context, w *api.J
 		w.Error(err)
 		return
 	}
+
+	if utils.BoolValue(params.Notebook) {
+		err = fillNotebookDiffs(ctx, workRepo, commitCtl.Repo.FileTreeRepo(repository.ID), changesResp, contentdiff.NotebookOption{
+			IgnoreOutputs: utils.BoolValue(params.IgnoreOutputs),
+		})
+		if err != nil {
+			w.Error(err)
+			return
+		}
+	}
 	w.JSON(changesResp)
 }
 
@@ -301,3 +319,21 @@ func commitToDto(commit *models.Commit) *api.Commit {
 		UpdatedAt:    commit.UpdatedAt.UnixMilli(),
 	}
 }
+
+func fullTreeEntriesToDto(entries []versionmgr.FullTreeEntry) []api.FullTreeEntry {
+	apiTreeEntries := make([]api.FullTreeEntry, len(entries))
+	for index, entry := range entries {
+		apiTreeEntries[index] = api.FullTreeEntry{
+			CreatedAt: entry.CreatedAt.UnixMilli(),
+			Hash:      entry.Hash.Hex(),
+			IsDir:     entry.IsDir,
+			Name:      entry.Name,
+			Size:      entry.Size,
+			UpdatedAt: entry.UpdatedAt.UnixMilli(),
+		}
+		if len(entry.Cid) > 0 {
+			apiTreeEntries[index].Cid = utils.String(entry.Cid)
+		}
+	}
+	return apiTreeEntries
+}
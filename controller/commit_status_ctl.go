@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+type CommitStatusController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (statusCtl CommitStatusController) ListCommitStatuses(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, commitID string) {
+	repository, ok := statusCtl.getRepository(ctx, w, statusCtl.Repo, ownerName, repositoryName, rbacmodel.ReadCommitStatusAction)
+	if !ok {
+		return
+	}
+
+	commitHash, err := hash.FromHex(commitID)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	statuses, err := statusCtl.Repo.CommitStatusRepo().List(ctx, models.NewListCommitStatusParams().SetRepositoryID(repository.ID).SetCommitHash(commitHash))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(utils.ArrMap(statuses, commitStatusToDto)))
+}
+
+func (statusCtl CommitStatusController) CreateCommitStatus(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.CreateCommitStatusJSONRequestBody, ownerName string, repositoryName string, commitID string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, ok := statusCtl.getRepository(ctx, w, statusCtl.Repo, ownerName, repositoryName, rbacmodel.WriteCommitStatusAction)
+	if !ok {
+		return
+	}
+
+	if len(strings.TrimSpace(body.Context)) == 0 {
+		w.BadRequest("context of status must not be empty")
+		return
+	}
+
+	state, err := models.ParseCommitStatusState(string(body.State))
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	commitHash, err := hash.FromHex(commitID)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	commit, err := statusCtl.Repo.CommitRepo(repository.ID).Commit(ctx, commitHash)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	status, err := statusCtl.Repo.CommitStatusRepo().Upsert(ctx, &models.CommitStatus{
+		RepositoryID: repository.ID,
+		CommitHash:   commit.Hash,
+		Context:      strings.TrimSpace(body.Context),
+		State:        state,
+		TargetURL:    body.TargetUrl,
+		Description:  body.Description,
+		CreatorID:    operator.ID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(commitStatusToDto(status)), http.StatusCreated)
+}
+
+func (statusCtl CommitStatusController) GetCombinedStatus(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetCombinedStatusParams) {
+	repository, ok := statusCtl.getRepository(ctx, w, statusCtl.Repo, ownerName, repositoryName, rbacmodel.ReadCommitStatusAction)
+	if !ok {
+		return
+	}
+
+	var commitHash hash.Hash
+	switch params.Type {
+	case api.RefTypeBranch:
+		refName := repository.HEAD
+		if params.Ref != nil {
+			refName = *params.Ref
+		}
+
+		branch, err := statusCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetRepositoryID(repository.ID).SetName(refName))
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		commitHash = branch.CommitHash
+	case api.RefTypeTag:
+		tag, err := statusCtl.Repo.TagRepo().Get(ctx, models.NewGetTagParams().SetRepositoryID(repository.ID).SetName(utils.StringValue(params.Ref)))
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		commitHash = tag.Target
+	case api.RefTypeCommit:
+		var err error
+		commitHash, err = hash.FromHex(utils.StringValue(params.Ref))
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+	default:
+		w.BadRequest("combined status only support branch, tag and commit")
+		return
+	}
+
+	combined, err := combinedStatus(ctx, statusCtl.Repo, repository.ID, commitHash)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(combined)
+}
+
+// combinedStatus get statuses of all checks on commit and combine them into one state
+func combinedStatus(ctx context.Context, repo models.IRepo, repositoryID uuid.UUID, commitHash hash.Hash) (api.CombinedStatus, error) {
+	statuses, err := repo.CommitStatusRepo().List(ctx, models.NewListCommitStatusParams().SetRepositoryID(repositoryID).SetCommitHash(commitHash))
+	if err != nil {
+		return api.CombinedStatus{}, err
+	}
+
+	return api.CombinedStatus{
+		CommitHash: commitHash.Hex(),
+		State:      api.CombinedStatusState(models.CombineStatuses(statuses)),
+		TotalCount: len(statuses),
+		Statuses:   utils.Silent(utils.ArrMap(statuses, commitStatusToDto)),
+	}, nil
+}
+
+func commitStatusToDto(in *models.CommitStatus) (api.CommitStatus, error) {
+	return api.CommitStatus{
+		Id:           in.ID,
+		RepositoryId: in.RepositoryID,
+		CommitHash:   in.CommitHash.Hex(),
+		Context:      in.Context,
+		State:        api.CommitStatusState(in.State),
+		TargetUrl:    in.TargetURL,
+		Description:  in.Description,
+		CreatorId:    in.CreatorID,
+		CreatedAt:    in.CreatedAt.UnixMilli(),
+		UpdatedAt:    in.UpdatedAt.UnixMilli(),
+	}, nil
+}
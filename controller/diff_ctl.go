@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/block/params"
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/contentdiff"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"go.uber.org/fx"
+)
+
+type DiffController struct {
+	fx.In
+	BaseController
+
+	Repo                models.IRepo
+	PublicStorageConfig params.AdapterConfig
+	DiffConfig          *config.DiffConfig
+}
+
+// diffSide content of path in one side of diff, blob is nil if path not exit, content is nil if blob is too large
+type diffSide struct {
+	blob    *models.Blob
+	content []byte
+}
+
+func (side diffSide) hash() *string {
+	if side.blob == nil {
+		return nil
+	}
+	return utils.String(side.blob.Hash.Hex())
+}
+
+func (diffCtl DiffController) GetFileDiff(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetFileDiffParams) {
+	path := versionmgr.CleanPath(params.Path)
+	base, head, ok := diffCtl.readDiffSides(ctx, w, ownerName, repositoryName, path, params.BaseType, params.Base, params.HeadType, params.Head, contentdiff.MaxTextSize)
+	if !ok {
+		return
+	}
+
+	fileDiff := api.FileDiff{
+		Path:     path,
+		BaseHash: base.hash(),
+		HeadHash: head.hash(),
+		Hunks:    []api.DiffHunk{},
+	}
+	if (base.blob != nil && base.content == nil) || (head.blob != nil && head.content == nil) {
+		fileDiff.TooLarge = true
+		w.JSON(fileDiff)
+		return
+	}
+	if contentdiff.IsBinary(base.content) || contentdiff.IsBinary(head.content) {
+		fileDiff.Binary = true
+		w.JSON(fileDiff)
+		return
+	}
+
+	if params.Mode != nil && *params.Mode == api.Structure {
+		format, ok := contentdiff.DetectFormat(path)
+		if !ok {
+			w.BadRequest(fmt.Sprintf("structure diff not support path %s", path))
+			return
+		}
+		changes, err := contentdiff.StructDiff(base.content, head.content, format, contentdiff.StructOption{
+			IDField: utils.StringValue(params.IdField),
+		})
+		if errors.Is(err, contentdiff.ErrInvalidDocument) {
+			w.BadRequest(err.Error())
+			return
+		}
+		if err != nil {
+			w.Error(err)
+			return
+		}
+
+		changesDto := structChangesToDto(changes)
+		fileDiff.Changes = &changesDto
+		w.JSON(fileDiff)
+		return
+	}
+
+	diffContext := contentdiff.DefaultContext
+	if params.Context != nil {
+		diffContext = *params.Context
+	}
+	hunks := contentdiff.LineDiff(base.content, head.content, contentdiff.TextOption{
+		Context:          diffContext,
+		IgnoreWhitespace: utils.BoolValue(params.IgnoreWhitespace),
+	})
+	for _, hunk := range hunks {
+		fileDiff.Hunks = append(fileDiff.Hunks, hunkToDto(hunk))
+	}
+	w.JSON(fileDiff)
+}
+
+func (diffCtl DiffController) GetTableDiff(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetTableDiffParams) {
+	path := versionmgr.CleanPath(params.Path)
+	workRepo, base, head, ok := diffCtl.findDiffBlobs(ctx, w, ownerName, repositoryName, path, params.BaseType, params.Base, params.HeadType, params.Head)
+	if !ok {
+		return
+	}
+
+	opt := contentdiff.TableOption{
+		Comma:      ',',
+		KeyColumns: utils.Slice(params.Keys),
+		Limit:      utils.IntValue(params.Limit),
+		MaxRows:    diffCtl.DiffConfig.MaxTableRows,
+	}
+	if params.Format != nil {
+		if *params.Format == api.Tsv {
+			opt.Comma = '\t'
+		}
+	} else if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		opt.Comma = '\t'
+	}
+
+	tableDiff, err := contentdiff.TableDiffOf(blobOpener(ctx, workRepo, base), blobOpener(ctx, workRepo, head), opt)
+	if errors.Is(err, contentdiff.ErrInvalidTable) || errors.Is(err, contentdiff.ErrTableTooLarge) {
+		w.BadRequest(err.Error())
+		return
+	}
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	result := api.TableDiff{
+		Path:           path,
+		BaseHash:       diffSide{blob: base}.hash(),
+		HeadHash:       diffSide{blob: head}.hash(),
+		BaseColumns:    utils.Slice(&tableDiff.BaseColumns),
+		HeadColumns:    utils.Slice(&tableDiff.HeadColumns),
+		AddedColumns:   tableDiff.AddedColumns,
+		RemovedColumns: tableDiff.RemovedColumns,
+		Added:          tableDiff.Added,
+		Removed:        tableDiff.Removed,
+		Changed:        tableDiff.Changed,
+		Unchanged:      tableDiff.Unchanged,
+		Rows:           make([]api.RowChange, len(tableDiff.Rows)),
+		Truncated:      tableDiff.Truncated,
+	}
+	for i, row := range tableDiff.Rows {
+		result.Rows[i] = rowChangeToDto(row)
+	}
+	w.JSON(result)
+}
+
+// readDiffSides read content of path in base and head, content larger than maxSize is not read
+func (diffCtl DiffController) readDiffSides(ctx context.Context, w *api.JiaozifsResponse, ownerName string, repositoryName string, path string,
+	baseType api.RefType, baseRef string, headType api.RefType, headRef string, maxSize int64) (diffSide, diffSide, bool) {
+	workRepo, baseBlob, headBlob, ok := diffCtl.findDiffBlobs(ctx, w, ownerName, repositoryName, path, baseType, baseRef, headType, headRef)
+	if !ok {
+		return diffSide{}, diffSide{}, false
+	}
+
+	base, err := readDiffSide(ctx, workRepo, baseBlob, maxSize)
+	if err != nil {
+		w.Error(err)
+		return diffSide{}, diffSide{}, false
+	}
+
+	head, err := readDiffSide(ctx, workRepo, headBlob, maxSize)
+	if err != nil {
+		w.Error(err)
+		return diffSide{}, diffSide{}, false
+	}
+	return base, head, true
+}
+
+// findDiffBlobs find blob of path in base and head, blob is nil if path not exit in that side
+func (diffCtl DiffController) findDiffBlobs(ctx context.Context, w *api.JiaozifsResponse, ownerName string, repositoryName string, path string,
+	baseType api.RefType, baseRef string, headType api.RefType, headRef string) (*versionmgr.WorkRepository, *models.Blob, *models.Blob, bool) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, nil, false
+	}
+
+	owner, err := diffCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return nil, nil, nil, false
+	}
+
+	repository, err := diffCtl.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetName(repositoryName).SetOwnerID(owner.ID))
+	if err != nil {
+		w.Error(err)
+		return nil, nil, nil, false
+	}
+
+	if !diffCtl.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.ReadObjectAction,
+			Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+		},
+	}) {
+		return nil, nil, nil, false
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, diffCtl.Repo, diffCtl.PublicStorageConfig)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, nil, false
+	}
+
+	base, err := findDiffBlob(ctx, workRepo, baseType, baseRef, path)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, nil, false
+	}
+
+	head, err := findDiffBlob(ctx, workRepo, headType, headRef, path)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, nil, false
+	}
+
+	if base == nil && head == nil {
+		w.Error(fmt.Errorf("path %s not found in both sides %w", path, api.ErrCode(http.StatusNotFound)))
+		return nil, nil, nil, false
+	}
+	return workRepo, base, head, true
+}
+
+func findDiffBlob(ctx context.Context, workRepo *versionmgr.WorkRepository, refType api.RefType, refName string, path string) (*models.Blob, error) {
+	if refType == api.RefTypeCommit {
+		_, err := hash.FromHex(refName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit hash %s %w", refName, api.ErrCode(http.StatusBadRequest))
+		}
+	}
+
+	err := workRepo.CheckOut(ctx, versionmgr.WorkRepoState(refType), refName)
+	if err != nil {
+		return nil, err
+	}
+
+	workTree, err := workRepo.RootTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, _, err := workTree.FindBlob(ctx, path)
+	if errors.Is(err, versionmgr.ErrPathNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+func readDiffSide(ctx context.Context, workRepo *versionmgr.WorkRepository, blob *models.Blob, maxSize int64) (diffSide, error) {
+	if blob == nil || blob.Size > maxSize {
+		return diffSide{blob: blob}, nil
+	}
+
+	reader, err := workRepo.ReadBlob(ctx, blob, nil)
+	if err != nil {
+		return diffSide{}, err
+	}
+	defer reader.Close() //nolint
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return diffSide{}, err
+	}
+	return diffSide{blob: blob, content: content}, nil
+}
+
+// blobOpener open blob for streaming read, nil blob means content not exit
+func blobOpener(ctx context.Context, workRepo *versionmgr.WorkRepository, blob *models.Blob) contentdiff.Opener {
+	if blob == nil {
+		return nil
+	}
+	return func() (io.ReadCloser, error) {
+		return workRepo.ReadBlob(ctx, blob, nil)
+	}
+}
+
+func hunkToDto(hunk contentdiff.Hunk) api.DiffHunk {
+	lines := make([]api.DiffLine, len(hunk.Lines))
+	for i, line := range hunk.Lines {
+		lines[i] = api.DiffLine{
+			Type:    api.DiffLineType(line.Type),
+			Content: line.Content,
+		}
+	}
+	return api.DiffHunk{
+		Header:   hunk.Header(),
+		OldStart: hunk.OldStart,
+		OldLines: hunk.OldLines,
+		NewStart: hunk.NewStart,
+		NewLines: hunk.NewLines,
+		Lines:    lines,
+	}
+}
+
+func rowChangeToDto(row contentdiff.RowChange) api.RowChange {
+	change := api.RowChange{Type: api.RowChangeType(row.Type)}
+	if row.Key != nil {
+		change.Key = &row.Key
+	}
+	if row.Values != nil {
+		change.Values = &row.Values
+	}
+	if row.Type == contentdiff.RowChanged {
+		cells := make([]api.CellChange, len(row.Cells))
+		for i, cell := range row.Cells {
+			cells[i] = api.CellChange{Column: cell.Column, Old: cell.Old, New: cell.New}
+		}
+		change.Cells = &cells
+	}
+	return change
+}
+
+func structChangesToDto(changes []contentdiff.StructChange) []api.StructChange {
+	changesDto := make([]api.StructChange, len(changes))
+	for i, change := range changes {
+		changesDto[i] = structChangeToDto(change)
+	}
+	return changesDto
+}
+
+func structChangeToDto(change contentdiff.StructChange) api.StructChange {
+	changeDto := api.StructChange{
+		Type: api.StructChangeType(change.Type),
+		Path: change.Path,
+	}
+	if change.Type != contentdiff.StructAdded {
+		changeDto.Old = &change.Old
+	}
+	if change.Type != contentdiff.StructRemoved {
+		changeDto.New = &change.New
+	}
+	return changeDto
+}
@@ -1,10 +1,19 @@
 package controller
 
 import (
+	"context"
 	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
 
 	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/contentdiff"
+	"github.com/GitDataAI/jiaozifs/hooks"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/tabular"
 	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
 	"github.com/GitDataAI/jiaozifs/versionmgr"
 )
 
@@ -34,3 +43,177 @@ func changesToDTO(changes *versionmgr.Changes) ([]api.Change, error) {
 	}
 	return changesResp, nil
 }
+
+// fillNotebookDiffs attach cell level diff to changes of jupyter notebooks,
+// notebooks which are too large or not valid are left without diff
+func fillNotebookDiffs(ctx context.Context, workRepo *versionmgr.WorkRepository, fileTreeRepo models.IFileTreeRepo, changes []api.Change, opt contentdiff.NotebookOption) error {
+	for i, change := range changes {
+		if !contentdiff.IsNotebook(change.Path) {
+			continue
+		}
+
+		base, baseOk, err := readNotebookContent(ctx, workRepo, fileTreeRepo, change.BaseHash)
+		if err != nil {
+			return err
+		}
+		head, headOk, err := readNotebookContent(ctx, workRepo, fileTreeRepo, change.ToHash)
+		if err != nil {
+			return err
+		}
+		if !baseOk || !headOk {
+			continue
+		}
+
+		notebookDiff, err := contentdiff.NotebookDiffOf(base, head, opt)
+		if errors.Is(err, contentdiff.ErrInvalidDocument) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		changes[i].Notebook = notebookDiffToDto(notebookDiff)
+	}
+	return nil
+}
+
+// readNotebookContent read content of blob, return false if blob is too large to diff
+func readNotebookContent(ctx context.Context, workRepo *versionmgr.WorkRepository, fileTreeRepo models.IFileTreeRepo, blobHash *string) ([]byte, bool, error) {
+	if blobHash == nil {
+		return nil, true, nil
+	}
+	hashValue, err := hash.FromHex(*blobHash)
+	if err != nil {
+		return nil, false, err
+	}
+	blob, err := fileTreeRepo.Blob(ctx, hashValue)
+	if err != nil {
+		return nil, false, err
+	}
+	side, err := readDiffSide(ctx, workRepo, blob, contentdiff.MaxTextSize)
+	if err != nil {
+		return nil, false, err
+	}
+	return side.content, side.content != nil, nil
+}
+
+func notebookDiffToDto(notebookDiff *contentdiff.NotebookDiff) *api.NotebookDiff {
+	cells := make([]api.NotebookCellDiff, len(notebookDiff.Cells))
+	for i, cell := range notebookDiff.Cells {
+		cells[i] = api.NotebookCellDiff{
+			Type:     api.NotebookCellDiffType(cell.Type),
+			CellType: cell.CellType,
+			Source:   make([]api.DiffHunk, len(cell.Source)),
+			Metadata: structChangesToDto(cell.Metadata),
+			Outputs:  structChangesToDto(cell.Outputs),
+		}
+		if cell.OldIndex >= 0 {
+			cells[i].OldIndex = utils.Int(cell.OldIndex)
+		}
+		if cell.NewIndex >= 0 {
+			cells[i].NewIndex = utils.Int(cell.NewIndex)
+		}
+		for j, hunk := range cell.Source {
+			cells[i].Source[j] = hunkToDto(hunk)
+		}
+	}
+	return &api.NotebookDiff{
+		Metadata: structChangesToDto(notebookDiff.Metadata),
+		Cells:    cells,
+	}
+}
+
+// fillParquetSchemaChanges attach schema changes to modified parquet files, files not in valid parquet are left without changes
+func fillParquetSchemaChanges(ctx context.Context, workRepo *versionmgr.WorkRepository, fileTreeRepo models.IFileTreeRepo, changes []api.Change) error {
+	for i, change := range changes {
+		if !strings.HasSuffix(strings.ToLower(change.Path), ".parquet") || change.BaseHash == nil || change.ToHash == nil {
+			continue
+		}
+
+		baseMetadata, err := readParquetMetadata(ctx, workRepo, fileTreeRepo, *change.BaseHash)
+		if err != nil {
+			return err
+		}
+		headMetadata, err := readParquetMetadata(ctx, workRepo, fileTreeRepo, *change.ToHash)
+		if err != nil {
+			return err
+		}
+		if baseMetadata == nil || headMetadata == nil {
+			continue
+		}
+
+		schemaChanges := tabular.DiffSchema(baseMetadata.Columns, headMetadata.Columns)
+		changesDto := make([]api.SchemaChange, len(schemaChanges))
+		for j, schemaChange := range schemaChanges {
+			changesDto[j] = api.SchemaChange{
+				Type:     api.SchemaChangeType(schemaChange.Type),
+				Column:   schemaChange.Column,
+				Breaking: schemaChange.Breaking,
+			}
+			if len(schemaChange.Old) > 0 {
+				changesDto[j].Old = utils.String(schemaChange.Old)
+			}
+			if len(schemaChange.New) > 0 {
+				changesDto[j].New = utils.String(schemaChange.New)
+			}
+		}
+		changes[i].SchemaChanges = &changesDto
+	}
+	return nil
+}
+
+// readParquetMetadata read parquet footer of blob by range read, return nil if blob is not valid parquet
+func readParquetMetadata(ctx context.Context, workRepo *versionmgr.WorkRepository, fileTreeRepo models.IFileTreeRepo, blobHash string) (*tabular.ParquetMetadata, error) {
+	hashValue, err := hash.FromHex(blobHash)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := fileTreeRepo.Blob(ctx, hashValue)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := tabular.ReadParquetMetadata(workRepo.BlobReaderAt(ctx, blob), blob.Size)
+	if errors.Is(err, tabular.ErrInvalidParquet) {
+		return nil, nil
+	}
+	return metadata, err
+}
+
+// checkHooks response precondition failed with report if the operation is rejected by hooks
+func checkHooks(w *api.JiaozifsResponse, err error) bool {
+	if err == nil {
+		return true
+	}
+	var failedErr *hooks.FailedError
+	if errors.As(err, &failedErr) {
+		w.JSON(hookReportToDto(failedErr.Report), http.StatusPreconditionFailed)
+		return false
+	}
+	w.Error(err)
+	return false
+}
+
+func hookReportToDto(report *hooks.Report) api.HookReport {
+	result := api.HookReport{
+		Event:   api.HookReportEvent(report.Event),
+		Branch:  report.Branch,
+		Passed:  report.Passed,
+		Results: make([]api.HookResult, len(report.Results)),
+	}
+	for i, hookResult := range report.Results {
+		violations := make([]api.HookViolation, len(hookResult.Violations))
+		for j, violation := range hookResult.Violations {
+			violations[j] = api.HookViolation{Path: violation.Path, Message: violation.Message}
+			if len(violation.Validator) > 0 {
+				validator := api.HookViolationValidator(violation.Validator)
+				violations[j].Validator = &validator
+			}
+		}
+		result.Results[i] = api.HookResult{
+			Hook:       hookResult.Hook,
+			Name:       hookResult.Name,
+			Passed:     hookResult.Passed,
+			Violations: violations,
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/block/params"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/utils/httputil"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+)
+
+var ipfsLog = logging.Logger("ipfs_ctl")
+
+type IpfsController struct {
+	fx.In
+	BaseController
+
+	PublicStorageConfig params.AdapterConfig
+	Repo                models.IRepo
+}
+
+// GetIpfsContent resolve cid against contents of repositories which operator can read, return file content for file
+// and entries for directory
+func (ipfsCtl IpfsController) GetIpfsContent(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, cidStr string, params api.GetIpfsContentParams) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	contentCid, err := cid.Decode(cidStr)
+	if err != nil {
+		w.BadRequest(fmt.Sprintf("invalid cid %s", err))
+		return
+	}
+
+	repositories, _, err := ipfsCtl.Repo.RepositoryRepo().List(ctx, models.NewListRepoParams().SetCid(contentCid.String()))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	var repository *models.Repository
+	for _, repo := range repositories {
+		resp, err := ipfsCtl.PermissionCheck.AuthorizeMember(ctx, repo.ID, &rbac.AuthorizationRequest{
+			OperatorID: operator.ID,
+			RequiredPermissions: rbac.Node{
+				Permission: rbac.Permission{
+					Action:   rbacmodel.ReadObjectAction,
+					Resource: rbacmodel.RepoURArn(repo.OwnerID.String(), repo.ID.String()),
+				},
+			},
+		})
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		if resp.Allowed {
+			repository = repo
+			break
+		}
+	}
+	if repository == nil {
+		//not tell user whether cid exit in repositories which user can not read
+		w.NotFound()
+		return
+	}
+
+	object, err := ipfsCtl.Repo.FileTreeRepo(repository.ID).Get(ctx, models.NewGetObjParams().SetCid(contentCid.String()))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, ipfsCtl.Repo, ipfsCtl.PublicStorageConfig)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	path := versionmgr.CleanPath(utils.StringValue(params.Path))
+	if object.Type == models.BlobObject {
+		if len(path) > 0 {
+			w.NotFound()
+			return
+		}
+		ipfsCtl.writeBlob(ctx, w, workRepo, object.Blob(), contentCid.String())
+		return
+	}
+
+	workTree, err := versionmgr.NewWorkTree(ctx, ipfsCtl.Repo.FileTreeRepo(repository.ID), models.NewRootTreeEntry(object.Hash))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	entries, err := workTree.Ls(ctx, path)
+	if err == nil {
+		w.JSON(fullTreeEntriesToDto(entries))
+		return
+	}
+	if errors.Is(err, versionmgr.ErrPathNotFound) {
+		w.NotFound()
+		return
+	}
+	if !errors.Is(err, versionmgr.ErrNotDirectory) {
+		w.Error(err)
+		return
+	}
+
+	blob, name, err := workTree.FindBlob(ctx, path)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	ipfsCtl.writeBlob(ctx, w, workRepo, blob, name)
+}
+
+func (ipfsCtl IpfsController) writeBlob(ctx context.Context, w *api.JiaozifsResponse, workRepo *versionmgr.WorkRepository, blob *models.Blob, name string) {
+	reader, err := workRepo.ReadBlob(ctx, blob, nil)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	defer reader.Close() //nolint
+
+	w.Header().Set("Content-Length", fmt.Sprint(blob.Size))
+	w.Header().Set("ETag", httputil.ETag(blob.Cid))
+	w.Header().Set("Last-Modified", httputil.HeaderTimestamp(blob.CreatedAt))
+	w.Header().Set("Content-Type", httputil.ExtensionsByType(name))
+	w.Header().Set("X-Ipfs-Path", "/ipfs/"+blob.Cid)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'")
+	_, err = io.Copy(w, reader)
+	if err != nil {
+		ipfsLog.With("cid", blob.Cid).Debugf("GetIpfsContent copy content %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+var labelColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type LabelController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (labelCtl LabelController) ListLabels(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string) {
+	repository, ok := labelCtl.getRepository(ctx, w, labelCtl.Repo, ownerName, repositoryName, rbacmodel.ReadLabelAction)
+	if !ok {
+		return
+	}
+
+	labels, err := labelCtl.Repo.LabelRepo().List(ctx, models.NewListLabelParams().SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(utils.ArrMap(labels, labelToDto)))
+}
+
+func (labelCtl LabelController) CreateLabel(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.CreateLabelJSONRequestBody, ownerName string, repositoryName string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, ok := labelCtl.getRepository(ctx, w, labelCtl.Repo, ownerName, repositoryName, rbacmodel.WriteLabelAction)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	err = validateLabel(name, body.Color)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	err = labelCtl.checkLabelExist(ctx, repository.ID, name)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	label, err := labelCtl.Repo.LabelRepo().Insert(ctx, &models.Label{
+		RepositoryID: repository.ID,
+		Name:         name,
+		Color:        body.Color,
+		Description:  body.Description,
+		CreatorID:    operator.ID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(labelToDto(label)), http.StatusCreated)
+}
+
+func (labelCtl LabelController) GetLabel(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, labelName string) {
+	repository, ok := labelCtl.getRepository(ctx, w, labelCtl.Repo, ownerName, repositoryName, rbacmodel.ReadLabelAction)
+	if !ok {
+		return
+	}
+
+	label, err := labelCtl.Repo.LabelRepo().Get(ctx, models.NewGetLabelParams().SetRepositoryID(repository.ID).SetName(labelName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(labelToDto(label)))
+}
+
+func (labelCtl LabelController) UpdateLabel(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateLabelJSONRequestBody, ownerName string, repositoryName string, labelName string) {
+	repository, ok := labelCtl.getRepository(ctx, w, labelCtl.Repo, ownerName, repositoryName, rbacmodel.WriteLabelAction)
+	if !ok {
+		return
+	}
+
+	label, err := labelCtl.Repo.LabelRepo().Get(ctx, models.NewGetLabelParams().SetRepositoryID(repository.ID).SetName(labelName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	updateParams := models.NewUpdateLabelParams(label.ID)
+	name, color := label.Name, label.Color
+	if body.Name != nil {
+		name = strings.TrimSpace(*body.Name)
+		updateParams.SetName(name)
+	}
+	if body.Color != nil {
+		color = *body.Color
+		updateParams.SetColor(color)
+	}
+	if body.Description != nil {
+		updateParams.SetDescription(*body.Description)
+	}
+
+	err = validateLabel(name, color)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	if name != label.Name {
+		err = labelCtl.checkLabelExist(ctx, repository.ID, name)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+	}
+
+	err = labelCtl.Repo.LabelRepo().UpdateByID(ctx, updateParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	label, err = labelCtl.Repo.LabelRepo().Get(ctx, models.NewGetLabelParams().SetID(label.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(labelToDto(label)))
+}
+
+func (labelCtl LabelController) DeleteLabel(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, labelName string) {
+	repository, ok := labelCtl.getRepository(ctx, w, labelCtl.Repo, ownerName, repositoryName, rbacmodel.WriteLabelAction)
+	if !ok {
+		return
+	}
+
+	label, err := labelCtl.Repo.LabelRepo().Get(ctx, models.NewGetLabelParams().SetRepositoryID(repository.ID).SetName(labelName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	err = labelCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		_, err := repo.LabelRepo().Delete(ctx, label.ID)
+		return err
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.OK()
+}
+
+func (labelCtl LabelController) ListMergeRequestLabels(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64) {
+	_, mergeRequest, ok := labelCtl.getMergeRequest(ctx, w, labelCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReadMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	labelCtl.writeMergeRequestLabels(ctx, w, mergeRequest, http.StatusOK)
+}
+
+func (labelCtl LabelController) AttachLabels(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.AttachLabelsJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := labelCtl.getMergeRequest(ctx, w, labelCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	if len(body.Labels) == 0 {
+		w.BadRequest("must attach at least one label")
+		return
+	}
+
+	labels, err := getLabels(ctx, labelCtl.Repo.LabelRepo(), mergeRequest.TargetRepoID, body.Labels)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	err = labelCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		return attachLabels(ctx, repo, mergeRequest.ID, operator.ID, labels)
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	labelCtl.writeMergeRequestLabels(ctx, w, mergeRequest, http.StatusCreated)
+}
+
+func (labelCtl LabelController) DetachLabel(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, labelName string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := labelCtl.getMergeRequest(ctx, w, labelCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	label, err := labelCtl.Repo.LabelRepo().Get(ctx, models.NewGetLabelParams().SetRepositoryID(mergeRequest.TargetRepoID).SetName(labelName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	err = labelCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		affectedRows, err := repo.LabelRepo().Detach(ctx, models.NewDeleteMergeRequestLabelParams().SetMergeRequestID(mergeRequest.ID).SetLabelID(label.ID))
+		if err != nil {
+			return err
+		}
+		if affectedRows == 0 {
+			return fmt.Errorf("label %s not attached to merge request %w", labelName, models.ErrNotFound)
+		}
+		return repo.TimelineRepo().Insert(ctx, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, models.TimelineUnlabeled, utils.String(label.Name)))
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.OK()
+}
+
+func (labelCtl LabelController) checkLabelExist(ctx context.Context, repositoryID uuid.UUID, name string) error {
+	_, err := labelCtl.Repo.LabelRepo().Get(ctx, models.NewGetLabelParams().SetRepositoryID(repositoryID).SetName(name))
+	if err == nil {
+		return fmt.Errorf("label %s already exists %w", name, api.ErrCode(http.StatusConflict))
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (labelCtl LabelController) writeMergeRequestLabels(ctx context.Context, w *api.JiaozifsResponse, mergeRequest *models.MergeRequest, code int) {
+	labels, err := labelCtl.Repo.LabelRepo().List(ctx, models.NewListLabelParams().SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(utils.ArrMap(labels, labelToDto)), code)
+}
+
+func validateLabel(name string, color string) error {
+	if len(name) == 0 {
+		return errors.New("label name must not be empty")
+	}
+	if !labelColorRegex.MatchString(color) {
+		return fmt.Errorf("label color %s must be hex color like #d73a4a", color)
+	}
+	return nil
+}
+
+// getLabels get labels of repository by names, response bad request if any label not exit
+func getLabels(ctx context.Context, labelRepo models.ILabelRepo, repositoryID uuid.UUID, labelNames []string) ([]*models.Label, error) {
+	labels := make([]*models.Label, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		label, err := labelRepo.Get(ctx, models.NewGetLabelParams().SetRepositoryID(repositoryID).SetName(labelName))
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, fmt.Errorf("label %s not found %w", labelName, api.ErrCode(http.StatusBadRequest))
+		}
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// attachLabels attach labels to merge request and record timeline of labels newly attached
+func attachLabels(ctx context.Context, repo models.IRepo, mergeRequestID uuid.UUID, operatorID uuid.UUID, labels []*models.Label) error {
+	mergeRequestLabels := make([]*models.MergeRequestLabel, 0, len(labels))
+	labelNames := make(map[uuid.UUID]string, len(labels))
+	for _, label := range labels {
+		mergeRequestLabels = append(mergeRequestLabels, &models.MergeRequestLabel{
+			MergeRequestID: mergeRequestID,
+			LabelID:        label.ID,
+			CreatedAt:      time.Now(),
+		})
+		labelNames[label.ID] = label.Name
+	}
+
+	attached, err := repo.LabelRepo().Attach(ctx, mergeRequestLabels...)
+	if err != nil {
+		return err
+	}
+
+	timelines := make([]*models.MergeRequestTimeline, 0, len(attached))
+	for _, mergeRequestLabel := range attached {
+		timelines = append(timelines, models.NewMergeRequestTimeline(mergeRequestID, operatorID, models.TimelineLabeled, utils.String(labelNames[mergeRequestLabel.LabelID])))
+	}
+	return repo.TimelineRepo().Insert(ctx, timelines...)
+}
+
+func labelToDto(in *models.Label) (api.Label, error) {
+	return api.Label{
+		Id:           in.ID,
+		RepositoryId: in.RepositoryID,
+		Name:         in.Name,
+		Color:        in.Color,
+		Description:  in.Description,
+		CreatorId:    in.CreatorID,
+		CreatedAt:    in.CreatedAt.UnixMilli(),
+		UpdatedAt:    in.UpdatedAt.UnixMilli(),
+	}, nil
+}
@@ -0,0 +1,408 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/block/params"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.uber.org/fx"
+)
+
+type CommentController struct {
+	fx.In
+	BaseController
+
+	Repo                models.IRepo
+	PublicStorageConfig params.AdapterConfig
+}
+
+func (commentCtl CommentController) ListMergeRequestComments(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, params api.ListMergeRequestCommentsParams) {
+	_, mergeRequest, ok := commentCtl.getMergeRequest(ctx, w, commentCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReadMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	listParams := models.NewListCommentParams().SetMergeRequestID(mergeRequest.ID)
+	if params.ParentId != nil {
+		listParams.SetParentID(*params.ParentId)
+	}
+	if params.Path != nil {
+		listParams.SetPath(*params.Path)
+	}
+	if params.Resolved != nil {
+		listParams.SetResolved(*params.Resolved)
+	}
+	if params.After != nil {
+		listParams.SetAfter(time.UnixMilli(utils.Int64Value(params.After)))
+	}
+	pageAmount := utils.IntValue(params.Amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	comments, hasMore, err := commentCtl.Repo.MergeRequestCommentRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	userNames := make(map[uuid.UUID]string)
+	results := make([]api.Comment, 0, len(comments))
+	for _, comment := range comments {
+		authorName, err := cachedUserName(ctx, commentCtl.Repo.UserRepo(), userNames, comment.AuthorID)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		results = append(results, commentToDto(comment, authorName))
+	}
+
+	pagMag := utils.PaginationFor(hasMore, results, "CreatedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.CommentList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
+
+func (commentCtl CommentController) CreateMergeRequestComment(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.CreateMergeRequestCommentJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, mergeRequest, ok := commentCtl.getMergeRequest(ctx, w, commentCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	if len(strings.TrimSpace(body.Body)) == 0 {
+		w.BadRequest("comment body must not be empty")
+		return
+	}
+
+	comment := &models.MergeRequestComment{
+		MergeRequestID: mergeRequest.ID,
+		AuthorID:       operator.ID,
+		Body:           body.Body,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if body.ParentId != nil {
+		if body.Path != nil || body.StartLine != nil || body.EndLine != nil {
+			w.BadRequest("reply can not anchor to path, reply to thread anchored instead")
+			return
+		}
+
+		parent, err := commentCtl.Repo.MergeRequestCommentRepo().Get(ctx, models.NewGetCommentParams().SetID(*body.ParentId).SetMergeRequestID(mergeRequest.ID))
+		if errors.Is(err, models.ErrNotFound) {
+			w.BadRequest(fmt.Sprintf("thread %s not found", *body.ParentId))
+			return
+		}
+		if err != nil {
+			w.Error(err)
+			return
+		}
+
+		if !parent.IsThread() {
+			w.BadRequest("can not reply to a reply, reply to thread instead")
+			return
+		}
+		comment.ParentID = parent.ID
+	}
+
+	if body.Path == nil && (body.StartLine != nil || body.EndLine != nil) {
+		w.BadRequest("line range must anchor to a path")
+		return
+	}
+
+	if body.StartLine == nil && body.EndLine != nil {
+		w.BadRequest("end line must be specified with start line")
+		return
+	}
+
+	if body.StartLine != nil {
+		startLine := *body.StartLine
+		endLine := startLine
+		if body.EndLine != nil {
+			endLine = *body.EndLine
+		}
+		if startLine < 1 || endLine < startLine {
+			w.BadRequest(fmt.Sprintf("invalid line range %d-%d", startLine, endLine))
+			return
+		}
+		comment.StartLine = &startLine
+		comment.EndLine = &endLine
+	}
+
+	sourceBranch, err := commentCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	comment.CommitHash = sourceBranch.CommitHash
+
+	if body.Path != nil {
+		path := versionmgr.CleanPath(*body.Path)
+		changed, err := commentCtl.isChangedPath(ctx, operator, repository, mergeRequest, sourceBranch, path)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		if !changed {
+			w.BadRequest(fmt.Sprintf("path %s is not changed in merge request", path))
+			return
+		}
+		comment.Path = &path
+	}
+
+	comment, err = commentCtl.Repo.MergeRequestCommentRepo().Insert(ctx, comment)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(commentToDto(comment, operator.Name), http.StatusCreated)
+}
+
+func (commentCtl CommentController) GetMergeRequestComment(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, commentID openapi_types.UUID) {
+	_, mergeRequest, ok := commentCtl.getMergeRequest(ctx, w, commentCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReadMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	comment, err := commentCtl.Repo.MergeRequestCommentRepo().Get(ctx, models.NewGetCommentParams().SetID(commentID).SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	commentCtl.writeComment(ctx, w, comment)
+}
+
+func (commentCtl CommentController) UpdateMergeRequestComment(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateMergeRequestCommentJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64, commentID openapi_types.UUID) {
+	comment, ok := commentCtl.getAuthoredComment(ctx, w, ownerName, repositoryName, mrSeq, commentID)
+	if !ok {
+		return
+	}
+
+	if len(strings.TrimSpace(body.Body)) == 0 {
+		w.BadRequest("comment body must not be empty")
+		return
+	}
+
+	err := commentCtl.Repo.MergeRequestCommentRepo().UpdateByID(ctx, models.NewUpdateCommentParams(comment.ID).SetBody(body.Body))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	comment, err = commentCtl.Repo.MergeRequestCommentRepo().Get(ctx, models.NewGetCommentParams().SetID(comment.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	commentCtl.writeComment(ctx, w, comment)
+}
+
+func (commentCtl CommentController) DeleteMergeRequestComment(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, commentID openapi_types.UUID) {
+	comment, ok := commentCtl.getAuthoredComment(ctx, w, ownerName, repositoryName, mrSeq, commentID)
+	if !ok {
+		return
+	}
+
+	// thread with replies is kept as deleted placeholder so that replies of other users are not removed by thread author,
+	// and deleted thread is removed together with its last reply
+	err := commentCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		commentRepo := repo.MergeRequestCommentRepo()
+		if comment.IsThread() {
+			replies, _, err := commentRepo.List(ctx, models.NewListCommentParams().SetMergeRequestID(comment.MergeRequestID).SetParentID(comment.ID).SetAmount(1))
+			if err != nil {
+				return err
+			}
+			if len(replies) > 0 {
+				return commentRepo.UpdateByID(ctx, models.NewUpdateCommentParams(comment.ID).SetDeleted())
+			}
+		}
+
+		_, err := commentRepo.Delete(ctx, models.NewDeleteCommentParams().SetID(comment.ID).SetMergeRequestID(comment.MergeRequestID))
+		if err != nil || comment.IsThread() {
+			return err
+		}
+
+		thread, err := commentRepo.Get(ctx, models.NewGetCommentParams().SetID(comment.ParentID))
+		if err != nil || !thread.Deleted {
+			return err
+		}
+		replies, _, err := commentRepo.List(ctx, models.NewListCommentParams().SetMergeRequestID(comment.MergeRequestID).SetParentID(thread.ID).SetAmount(1))
+		if err != nil || len(replies) > 0 {
+			return err
+		}
+		_, err = commentRepo.Delete(ctx, models.NewDeleteCommentParams().SetID(thread.ID).SetMergeRequestID(thread.MergeRequestID))
+		return err
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.OK()
+}
+
+func (commentCtl CommentController) ResolveMergeRequestComment(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, commentID openapi_types.UUID) {
+	commentCtl.resolveThread(ctx, w, ownerName, repositoryName, mrSeq, commentID, true)
+}
+
+func (commentCtl CommentController) UnresolveMergeRequestComment(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, commentID openapi_types.UUID) {
+	commentCtl.resolveThread(ctx, w, ownerName, repositoryName, mrSeq, commentID, false)
+}
+
+func (commentCtl CommentController) resolveThread(ctx context.Context, w *api.JiaozifsResponse, ownerName string, repositoryName string, mrSeq uint64, commentID uuid.UUID, resolved bool) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := commentCtl.getMergeRequest(ctx, w, commentCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	comment, err := commentCtl.Repo.MergeRequestCommentRepo().Get(ctx, models.NewGetCommentParams().SetID(commentID).SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !comment.IsThread() {
+		w.BadRequest("only thread can be resolved")
+		return
+	}
+
+	err = commentCtl.Repo.MergeRequestCommentRepo().UpdateByID(ctx, models.NewUpdateCommentParams(comment.ID).SetResolved(resolved, operator.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	comment, err = commentCtl.Repo.MergeRequestCommentRepo().Get(ctx, models.NewGetCommentParams().SetID(comment.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	commentCtl.writeComment(ctx, w, comment)
+}
+
+// getAuthoredComment get comment which can be modified by operator, only author can edit or delete his comment
+func (commentCtl CommentController) getAuthoredComment(ctx context.Context, w *api.JiaozifsResponse, ownerName string, repositoryName string, mrSeq uint64, commentID uuid.UUID) (*models.MergeRequestComment, bool) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return nil, false
+	}
+
+	_, mergeRequest, ok := commentCtl.getMergeRequest(ctx, w, commentCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return nil, false
+	}
+
+	comment, err := commentCtl.Repo.MergeRequestCommentRepo().Get(ctx, models.NewGetCommentParams().SetID(commentID).SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return nil, false
+	}
+
+	if comment.AuthorID != operator.ID {
+		w.Error(fmt.Errorf("only author can modify comment %w", api.ErrCode(http.StatusForbidden)))
+		return nil, false
+	}
+	if comment.Deleted {
+		w.Error(fmt.Errorf("comment has been deleted %w", api.ErrCode(http.StatusNotFound)))
+		return nil, false
+	}
+	return comment, true
+}
+
+// isChangedPath check whether path is in changes between source branch and target branch of merge request
+func (commentCtl CommentController) isChangedPath(ctx context.Context, operator *models.User, repository *models.Repository, mergeRequest *models.MergeRequest, sourceBranch *models.Branch, path string) (bool, error) {
+	targetBranch, err := commentCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID))
+	if err != nil {
+		return false, err
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, commentCtl.Repo, commentCtl.PublicStorageConfig)
+	if err != nil {
+		return false, err
+	}
+
+	err = workRepo.CheckOut(ctx, versionmgr.InBranch, sourceBranch.Name)
+	if err != nil {
+		return false, err
+	}
+
+	changePairs, err := workRepo.GetMergeState(ctx, targetBranch.CommitHash, targetBranch.CommitHash)
+	if err != nil {
+		return false, mergeCheckError(err)
+	}
+
+	for _, changePair := range changePairs {
+		if changePair.Path() == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (commentCtl CommentController) writeComment(ctx context.Context, w *api.JiaozifsResponse, comment *models.MergeRequestComment) {
+	author, err := commentCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetID(comment.AuthorID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(commentToDto(comment, author.Name))
+}
+
+func commentToDto(in *models.MergeRequestComment, authorName string) api.Comment {
+	comment := api.Comment{
+		Id:             in.ID,
+		MergeRequestId: in.MergeRequestID,
+		AuthorId:       in.AuthorID,
+		AuthorName:     authorName,
+		Body:           in.Body,
+		Path:           in.Path,
+		StartLine:      in.StartLine,
+		EndLine:        in.EndLine,
+		CommitHash:     in.CommitHash.Hex(),
+		Resolved:       in.Resolved,
+		Deleted:        in.Deleted,
+		CreatedAt:      in.CreatedAt.UnixMilli(),
+		UpdatedAt:      in.UpdatedAt.UnixMilli(),
+	}
+	if !in.IsThread() {
+		comment.ParentId = &in.ParentID
+	}
+	if in.ResolvedBy != uuid.Nil {
+		comment.ResolvedBy = &in.ResolvedBy
+	}
+	return comment
+}
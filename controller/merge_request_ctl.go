@@ -8,17 +8,23 @@ import (
 	"time"
 
 	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/automerge"
+	"github.com/GitDataAI/jiaozifs/hooks"
 	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/protection"
 	"github.com/GitDataAI/jiaozifs/utils/hash"
 
 	"github.com/GitDataAI/jiaozifs/utils"
 
 	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/GitDataAI/jiaozifs/webhook"
 
 	"github.com/GitDataAI/jiaozifs/api"
 	"github.com/GitDataAI/jiaozifs/auth"
 	"github.com/GitDataAI/jiaozifs/block/params"
 	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/quota"
+	"github.com/google/uuid"
 	"go.uber.org/fx"
 )
 
@@ -28,8 +34,13 @@ type MergeRequestController struct {
 
 	Repo                models.IRepo
 	PublicStorageConfig params.AdapterConfig
+	Emitter             webhook.Emitter
+	Quota               *quota.Manager
 }
 
+// ListMergeRequests list merge requests with mergeability cached when they were got or updated,
+// mergeability is not computed here because branches may be moved or deleted and diffs of a whole page are expensive,
+// cache computed from heads other than current heads of branches is reported outdated instead
 func (mrCtl MergeRequestController) ListMergeRequests(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.ListMergeRequestsParams) {
 	owner, err := mrCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
 	if err != nil {
@@ -57,6 +68,44 @@ func (mrCtl MergeRequestController) ListMergeRequests(ctx context.Context, w *ap
 		listParams.SetMergeState(models.MergeState(*params.State))
 	}
 
+	// filter by user or label not exit match nothing
+	if params.Author != nil {
+		author, err := mrCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(*params.Author))
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			w.Error(err)
+			return
+		}
+		if err != nil {
+			w.JSON(api.MergeRequestList{Results: []api.MergeRequest{}})
+			return
+		}
+		listParams.SetAuthorID(author.ID)
+	}
+	if params.Assignee != nil {
+		assignee, err := mrCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(*params.Assignee))
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			w.Error(err)
+			return
+		}
+		if err != nil {
+			w.JSON(api.MergeRequestList{Results: []api.MergeRequest{}})
+			return
+		}
+		listParams.SetAssigneeID(assignee.ID)
+	}
+	if params.Label != nil {
+		label, err := mrCtl.Repo.LabelRepo().Get(ctx, models.NewGetLabelParams().SetRepositoryID(repository.ID).SetName(*params.Label))
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			w.Error(err)
+			return
+		}
+		if err != nil {
+			w.JSON(api.MergeRequestList{Results: []api.MergeRequest{}})
+			return
+		}
+		listParams.SetLabelID(label.ID)
+	}
+
 	if params.After != nil {
 		listParams.SetAfter(time.UnixMilli(*params.After))
 	}
@@ -73,19 +122,45 @@ func (mrCtl MergeRequestController) ListMergeRequests(ctx context.Context, w *ap
 		return
 	}
 
+	branches := make(map[uuid.UUID]*models.Branch)
 	results := make([]api.MergeRequest, len(mrs))
-	for index, mr := range mrs {
+	for index := range mrs {
+		mr := &mrs[index]
+		outdated := false
+		if mr.MergeState == models.MergeStateInit {
+			sourceBranch, err := mrCtl.cachedBranch(ctx, branches, mr.SourceBranchID)
+			if err != nil {
+				w.Error(err)
+				return
+			}
+			targetBranch, err := mrCtl.cachedBranch(ctx, branches, mr.TargetBranchID)
+			if err != nil {
+				w.Error(err)
+				return
+			}
+			outdated = sourceBranch == nil || targetBranch == nil || mr.MergeabilityOutdated(sourceBranch.CommitHash, targetBranch.CommitHash)
+		}
+
 		results[index] = api.MergeRequest{
-			Title:        mr.Title,
-			Description:  mr.Description,
-			AuthorId:     mr.AuthorID,
-			MergeStatus:  int(mr.MergeState),
-			SourceBranch: mr.SourceBranchID,
-			SourceRepoId: mr.SourceRepoID,
-			TargetBranch: mr.TargetBranchID,
-			TargetRepoId: mr.TargetRepoID,
-			CreatedAt:    mr.CreatedAt.UnixMilli(),
-			UpdatedAt:    mr.UpdatedAt.UnixMilli(),
+			Title:                mr.Title,
+			Description:          mr.Description,
+			AuthorId:             mr.AuthorID,
+			MergeStatus:          int(mr.MergeState),
+			Draft:                mr.Draft,
+			SourceBranch:         mr.SourceBranchID,
+			SourceRepoId:         mr.SourceRepoID,
+			TargetBranch:         mr.TargetBranchID,
+			TargetRepoId:         mr.TargetRepoID,
+			Mergeable:            mr.Mergeable,
+			MergeabilityOutdated: outdated,
+			BehindBy:             mr.BehindBy,
+			ConflictCount:        mr.ConflictCount,
+			CreatedAt:            mr.CreatedAt.UnixMilli(),
+			UpdatedAt:            mr.UpdatedAt.UnixMilli(),
+		}
+		if mr.CheckedSourceHash != nil {
+			results[index].CheckedSourceHash = utils.String(mr.CheckedSourceHash.Hex())
+			results[index].CheckedTargetHash = utils.String(mr.CheckedTargetHash.Hex())
 		}
 	}
 	pagMag := utils.PaginationFor(hasMore, results, "UpdatedAt")
@@ -158,23 +233,54 @@ func (mrCtl MergeRequestController) CreateMergeRequest(ctx context.Context, w *a
 		return
 	}
 
-	mrModel, err := mrCtl.Repo.MergeRequestRepo().Insert(ctx, &models.MergeRequest{
-		TargetBranchID: targetBranch.ID,
-		SourceBranchID: sourceBranch.ID,
-		SourceRepoID:   repository.ID,
-		TargetRepoID:   repository.ID,
-		Title:          body.Title,
-		MergeState:     models.MergeStateInit,
-		Description:    body.Description,
-		AuthorID:       operator.ID,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
-	})
+	assignees, err := getAssignees(ctx, mrCtl.Repo.UserRepo(), utils.Slice(body.Assignees))
+	if err != nil {
+		w.Error(err)
+		return
+	}
 
+	labels, err := getLabels(ctx, mrCtl.Repo.LabelRepo(), repository.ID, utils.Slice(body.Labels))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	var mrModel *models.MergeRequest
+	err = mrCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		var err error
+		mrModel, err = repo.MergeRequestRepo().Insert(ctx, &models.MergeRequest{
+			TargetBranchID: targetBranch.ID,
+			SourceBranchID: sourceBranch.ID,
+			SourceRepoID:   repository.ID,
+			TargetRepoID:   repository.ID,
+			Title:          body.Title,
+			MergeState:     models.MergeStateInit,
+			Description:    body.Description,
+			Draft:          utils.BoolValue(body.Draft),
+			AuthorID:       operator.ID,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = repo.TimelineRepo().Insert(ctx, models.NewMergeRequestTimeline(mrModel.ID, operator.ID, models.TimelineOpened, nil))
+		if err != nil {
+			return err
+		}
+
+		err = assignUsers(ctx, repo, mrModel.ID, operator.ID, assignees)
+		if err != nil {
+			return err
+		}
+		return attachLabels(ctx, repo, mrModel.ID, operator.ID, labels)
+	})
 	if err != nil {
 		w.Error(err)
 		return
 	}
+	mrCtl.Emitter.Emit(ctx, repository, operator, models.MergeRequestOpenEvent, &webhook.MergeRequestPayload{MergeRequest: mrModel})
 
 	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, mrCtl.Repo, mrCtl.PublicStorageConfig)
 	if err != nil {
@@ -188,25 +294,35 @@ func (mrCtl MergeRequestController) CreateMergeRequest(ctx context.Context, w *a
 		return
 	}
 
-	changePairs, err := workRepo.GetMergeState(ctx, targetBranch.CommitHash)
+	changePairs, err := workRepo.GetMergeState(ctx, targetBranch.CommitHash, targetBranch.CommitHash)
+	if err != nil {
+		w.Error(mergeCheckError(err))
+		return
+	}
+
+	err = refreshMergeability(ctx, mrCtl.Repo, workRepo, mrModel, sourceBranch, targetBranch, changePairs)
 	if err != nil {
 		w.Error(err)
 		return
 	}
 
 	resp := api.MergeRequestFullState{
-		Id:           mrModel.ID,
-		Sequence:     mrModel.Sequence,
-		Title:        mrModel.Title,
-		Description:  mrModel.Description,
-		AuthorId:     mrModel.AuthorID,
-		MergeStatus:  int(mrModel.MergeState),
-		SourceBranch: mrModel.SourceBranchID,
-		SourceRepoId: mrModel.SourceRepoID,
-		TargetBranch: mrModel.TargetBranchID,
-		TargetRepoId: mrModel.TargetRepoID,
-		CreatedAt:    mrModel.CreatedAt.UnixMilli(),
-		UpdatedAt:    mrModel.UpdatedAt.UnixMilli(),
+		Id:            mrModel.ID,
+		Sequence:      mrModel.Sequence,
+		Title:         mrModel.Title,
+		Description:   mrModel.Description,
+		AuthorId:      mrModel.AuthorID,
+		MergeStatus:   int(mrModel.MergeState),
+		Draft:         mrModel.Draft,
+		SourceBranch:  mrModel.SourceBranchID,
+		SourceRepoId:  mrModel.SourceRepoID,
+		TargetBranch:  mrModel.TargetBranchID,
+		TargetRepoId:  mrModel.TargetRepoID,
+		Mergeable:     mrModel.Mergeable,
+		BehindBy:      mrModel.BehindBy,
+		ConflictCount: mrModel.ConflictCount,
+		CreatedAt:     mrModel.CreatedAt.UnixMilli(),
+		UpdatedAt:     mrModel.UpdatedAt.UnixMilli(),
 	}
 
 	resp.Changes, err = changePairToDTO(changePairs)
@@ -214,6 +330,12 @@ func (mrCtl MergeRequestController) CreateMergeRequest(ctx context.Context, w *a
 		w.Error(err)
 		return
 	}
+
+	resp.Assignees, resp.Labels, err = mrCtl.assigneesAndLabels(ctx, mrModel.ID)
+	if err != nil {
+		w.Error(err)
+		return
+	}
 	//get merge state
 	w.JSON(resp, http.StatusCreated)
 }
@@ -275,35 +397,71 @@ func (mrCtl MergeRequestController) GetMergeRequest(ctx context.Context, w *api.
 		return
 	}
 
-	changePairs, err := workRepo.GetMergeState(ctx, targetBranch.CommitHash)
+	changePairs, err := workRepo.GetMergeState(ctx, targetBranch.CommitHash, targetBranch.CommitHash)
+	if err != nil {
+		w.Error(mergeCheckError(err))
+		return
+	}
+
+	err = refreshMergeability(ctx, mrCtl.Repo, workRepo, mergeRequest, sourceBranch, targetBranch, changePairs)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	reviews, err := mrCtl.Repo.ReviewRepo().List(ctx, models.NewListReviewParams().SetMergeRequestID(mergeRequest.ID).SetDismissed(false))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	status, err := combinedStatus(ctx, mrCtl.Repo, mergeRequest.SourceRepoID, sourceBranch.CommitHash)
 	if err != nil {
 		w.Error(err)
 		return
 	}
 
 	resp := api.MergeRequestFullState{
-		Id:           mergeRequest.ID,
-		Sequence:     mergeRequest.Sequence,
-		Title:        mergeRequest.Title,
-		Description:  mergeRequest.Description,
-		AuthorId:     mergeRequest.AuthorID,
-		MergeStatus:  int(mergeRequest.MergeState),
-		SourceBranch: mergeRequest.SourceBranchID,
-		SourceRepoId: mergeRequest.SourceRepoID,
-		TargetBranch: mergeRequest.TargetBranchID,
-		TargetRepoId: mergeRequest.TargetRepoID,
-		CreatedAt:    mergeRequest.CreatedAt.UnixMilli(),
-		UpdatedAt:    mergeRequest.UpdatedAt.UnixMilli(),
+		Id:            mergeRequest.ID,
+		Sequence:      mergeRequest.Sequence,
+		Title:         mergeRequest.Title,
+		Description:   mergeRequest.Description,
+		AuthorId:      mergeRequest.AuthorID,
+		MergeStatus:   int(mergeRequest.MergeState),
+		Draft:         mergeRequest.Draft,
+		SourceBranch:  mergeRequest.SourceBranchID,
+		SourceRepoId:  mergeRequest.SourceRepoID,
+		TargetBranch:  mergeRequest.TargetBranchID,
+		TargetRepoId:  mergeRequest.TargetRepoID,
+		Mergeable:     mergeRequest.Mergeable,
+		BehindBy:      mergeRequest.BehindBy,
+		ConflictCount: mergeRequest.ConflictCount,
+		Approvals:     len(models.Approvers(reviews)),
+		Status:        status,
+		CreatedAt:     mergeRequest.CreatedAt.UnixMilli(),
+		UpdatedAt:     mergeRequest.UpdatedAt.UnixMilli(),
 	}
 	resp.Changes, err = changePairToDTO(changePairs)
 	if err != nil {
 		w.Error(err)
 		return
 	}
+
+	resp.Assignees, resp.Labels, err = mrCtl.assigneesAndLabels(ctx, mergeRequest.ID)
+	if err != nil {
+		w.Error(err)
+		return
+	}
 	w.JSON(resp)
 }
 
 func (mrCtl MergeRequestController) UpdateMergeRequest(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateMergeRequestJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
 	owner, err := mrCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
 	if err != nil {
 		w.Error(err)
@@ -325,6 +483,12 @@ func (mrCtl MergeRequestController) UpdateMergeRequest(ctx context.Context, w *a
 		return
 	}
 
+	mergeRequest, err := mrCtl.Repo.MergeRequestRepo().Get(ctx, models.NewGetMergeRequestParams().SetTargetRepo(repository.ID).SetNumber(mrSeq))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
 	updateParams := models.NewUpdateMergeRequestParams(repository.ID, mrSeq)
 	if body.Title != nil {
 		updateParams.SetTitle(utils.StringValue(body.Title))
@@ -332,18 +496,86 @@ func (mrCtl MergeRequestController) UpdateMergeRequest(ctx context.Context, w *a
 	if body.Description != nil {
 		updateParams.SetDescription(utils.StringValue(body.Description))
 	}
-	if body.Status != nil {
-		updateParams.SetState(models.MergeState(utils.IntValue(body.Status)))
+
+	var timelines []*models.MergeRequestTimeline
+	var event models.EventType
+	if body.Status != nil && models.MergeState(utils.IntValue(body.Status)) != mergeRequest.MergeState {
+		state := models.MergeState(utils.IntValue(body.Status))
+		switch {
+		case state == models.MergeStateClosed && mergeRequest.MergeState == models.MergeStateInit:
+			timelines = append(timelines, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, models.TimelineClosed, nil))
+			event = models.MergeRequestCloseEvent
+		case state == models.MergeStateInit && mergeRequest.MergeState == models.MergeStateClosed:
+			err = mrCtl.checkReopen(ctx, mergeRequest)
+			if err != nil {
+				w.Error(err)
+				return
+			}
+			timelines = append(timelines, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, models.TimelineReopened, nil))
+			event = models.MergeRequestReopenEvent
+		default:
+			w.BadRequest(fmt.Sprintf("can not change state of merge request from %d to %d", mergeRequest.MergeState, state))
+			return
+		}
+		updateParams.SetState(state)
+		mergeRequest.MergeState = state
+	}
+
+	if body.Draft != nil && *body.Draft != mergeRequest.Draft {
+		if mergeRequest.MergeState != models.MergeStateInit {
+			w.BadRequest("only draft of open merge request can be changed")
+			return
+		}
+		action := models.TimelineReady
+		if *body.Draft {
+			action = models.TimelineDraft
+		}
+		timelines = append(timelines, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, action, nil))
+		updateParams.SetDraft(*body.Draft)
+		mergeRequest.Draft = *body.Draft
 	}
 
-	err = mrCtl.Repo.MergeRequestRepo().UpdateByID(ctx, updateParams)
+	err = mrCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		err := repo.MergeRequestRepo().UpdateByID(ctx, updateParams)
+		if err != nil {
+			return err
+		}
+		return repo.TimelineRepo().Insert(ctx, timelines...)
+	})
 	if err != nil {
 		w.Error(err)
 		return
 	}
+
+	if len(event) > 0 {
+		mrCtl.Emitter.Emit(ctx, repository, operator, event, &webhook.MergeRequestPayload{MergeRequest: mergeRequest})
+	}
 	w.OK()
 }
 
+// checkReopen check branches of merge request still exist and there is no other open merge request between them
+func (mrCtl MergeRequestController) checkReopen(ctx context.Context, mergeRequest *models.MergeRequest) error {
+	for _, branchID := range []uuid.UUID{mergeRequest.SourceBranchID, mergeRequest.TargetBranchID} {
+		_, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(branchID))
+		if errors.Is(err, models.ErrNotFound) {
+			return fmt.Errorf("branch of merge request has been deleted %w", api.ErrCode(http.StatusBadRequest))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	params := models.NewGetMergeRequestParams().SetTargetRepo(mergeRequest.TargetRepoID).SetTargetBranch(mergeRequest.TargetBranchID).SetSourceBranch(mergeRequest.SourceBranchID).SetState(models.MergeStateInit)
+	_, err := mrCtl.Repo.MergeRequestRepo().Get(ctx, params)
+	if err == nil {
+		return fmt.Errorf("another open merge request between the branches already exists %w", api.ErrCode(http.StatusBadRequest))
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
 func (mrCtl MergeRequestController) Merge(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.MergeJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
 	operator, err := auth.GetOperator(ctx)
 	if err != nil {
@@ -364,10 +596,134 @@ func (mrCtl MergeRequestController) Merge(ctx context.Context, w *api.JiaozifsRe
 		return
 	}
 
+	if !mrCtl.authorizeMember(ctx, w, repository.ID, automerge.MergePermission(repository)) {
+		return
+	}
+
+	mergeRequest, err := mrCtl.Repo.MergeRequestRepo().Get(ctx, models.NewGetMergeRequestParams().SetTargetRepo(repository.ID).SetNumber(mrSeq))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	targetBranch, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	// head checked here is exactly what is merged, merge fail if source branch moves before merging
+	sourceBranch, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	sourceHead := sourceBranch.CommitHash
+
+	conflictResolve := utils.Map(body.ConflictResolve)
+	err = automerge.NewMergeChecker(mrCtl.Repo, mrCtl.PermissionCheck).Check(ctx, operator, repository, mergeRequest, targetBranch.Name, sourceHead, conflictResolve)
+	if err != nil {
+		w.Error(mergeCheckError(err))
+		return
+	}
+
+	var commit *models.Commit
+	err = mrCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, repo, mrCtl.PublicStorageConfig)
+		if err != nil {
+			return err
+		}
+		// blobs merged by merge drivers take space too
+		workRepo.WithQuota(mrCtl.Quota)
+
+		_, targetBranch, err = automerge.LockBranches(ctx, repo, mergeRequest, sourceHead)
+		if err != nil {
+			return mergeCheckError(err)
+		}
+
+		err = workRepo.CheckOut(ctx, versionmgr.InBranch, targetBranch.Name)
+		if err != nil {
+			return err
+		}
+
+		changePairs, err := workRepo.GetMergeState(ctx, sourceHead, targetBranch.CommitHash)
+		if err != nil {
+			return mergeCheckError(err)
+		}
+
+		err = automerge.CheckMergeState(changePairs, conflictResolve)
+		if err != nil {
+			return mergeCheckError(err)
+		}
+
+		baseCommit := targetBranch.CommitHash
+		commit, err = workRepo.Merge(ctx, sourceHead, body.Msg, versionmgr.ResolveFromSelector(conflictResolve))
+		if err != nil {
+			return mergeCheckError(err)
+		}
+
+		// hooks run in transaction, merge commit is rolled back if rejected
+		report, err := hooks.NewRunner(repo, repository.ID, workRepo).Run(ctx, hooks.PreMerge, targetBranch.Name, baseCommit, commit.TreeHash)
+		if err != nil {
+			return err
+		}
+		if err = report.Err(); err != nil {
+			return err
+		}
+
+		err = repo.MergeRequestRepo().UpdateByID(ctx, models.NewUpdateMergeRequestParams(repository.ID, mergeRequest.Sequence).SetState(models.MergeStateMerged))
+		if err != nil {
+			return err
+		}
+		return repo.TimelineRepo().Insert(ctx, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, models.TimelineMerged, utils.String(commit.Hash.Hex())))
+	})
+	if !checkHooks(w, err) {
+		return
+	}
+
+	// emit after transaction committed, avoid notify the changes rolled back
+	targetBranch.CommitHash = commit.Hash
+	mergeRequest.MergeState = models.MergeStateMerged
+	mrCtl.Emitter.Emit(ctx, repository, operator, models.PushEvent, &webhook.CommitPayload{Branch: targetBranch, Commit: commit})
+	mrCtl.Emitter.Emit(ctx, repository, operator, models.MergeRequestMergeEvent, &webhook.MergeRequestPayload{MergeRequest: mergeRequest, Commit: commit})
+
+	w.JSON(commitToDto(commit))
+}
+
+func (mrCtl MergeRequestController) UpdateMergeRequestBranch(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateMergeRequestBranchJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	owner, err := mrCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, err := mrCtl.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetOwnerID(owner.ID).SetName(repositoryName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
 	if !mrCtl.authorizeMember(ctx, w, repository.ID, rbac.Node{
-		Permission: rbac.Permission{
-			Action:   rbacmodel.MergeMergeRequestAction,
-			Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+		Type: rbac.NodeTypeAnd,
+		Nodes: []rbac.Node{
+			{
+				Permission: rbac.Permission{
+					Action:   rbacmodel.UpdateMergeRequestAction,
+					Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+				},
+			},
+			{
+				Permission: rbac.Permission{
+					Action:   rbacmodel.WriteBranchAction,
+					Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+				},
+			},
 		},
 	}) {
 		return
@@ -379,43 +735,310 @@ func (mrCtl MergeRequestController) Merge(ctx context.Context, w *api.JiaozifsRe
 		return
 	}
 
+	if mergeRequest.MergeState != models.MergeStateInit {
+		w.BadRequest("only source branch of open merge request can be updated")
+		return
+	}
+
+	sourceBranch, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !checkProtection(w, protection.NewChecker(mrCtl.Repo).CheckWrite(ctx, repository.ID, sourceBranch.Name)) {
+		return
+	}
+
+	msg := body.Msg
+	if len(msg) == 0 {
+		targetBranch, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID))
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		msg = fmt.Sprintf("Merge branch %s into %s", targetBranch.Name, sourceBranch.Name)
+	}
+
+	conflictResolve := utils.Map(body.ConflictResolve)
+	err = automerge.ValidateConflictResolve(ctx, mrCtl.Repo.FileTreeRepo(repository.ID), conflictResolve)
+	if err != nil {
+		w.Error(mergeCheckError(err))
+		return
+	}
+
 	var commit *models.Commit
 	err = mrCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
 		workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, repo, mrCtl.PublicStorageConfig)
 		if err != nil {
 			return err
 		}
+		// blobs merged by merge drivers take space too
+		workRepo.WithQuota(mrCtl.Quota)
 
-		sourceBranch, err := repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID))
+		targetBranch, err := repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID))
 		if err != nil {
 			return err
 		}
 
-		targetBranch, err := repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID))
+		err = workRepo.CheckOut(ctx, versionmgr.InBranch, sourceBranch.Name)
 		if err != nil {
 			return err
 		}
 
-		err = workRepo.CheckOut(ctx, versionmgr.InBranch, targetBranch.Name)
+		behindBy, err := workRepo.CountBehind(ctx, targetBranch.CommitHash)
 		if err != nil {
 			return err
 		}
+		if behindBy == 0 {
+			return fmt.Errorf("source branch %s is up to date with target branch %w", sourceBranch.Name, api.ErrCode(http.StatusBadRequest))
+		}
 
-		commit, err = workRepo.Merge(ctx, sourceBranch.CommitHash, body.Msg, versionmgr.ResolveFromSelector(utils.Map(body.ConflictResolve)))
+		changePairs, err := workRepo.GetMergeState(ctx, targetBranch.CommitHash, sourceBranch.CommitHash)
 		if err != nil {
-			return err
+			return mergeCheckError(err)
 		}
 
-		return repo.MergeRequestRepo().UpdateByID(ctx, models.NewUpdateMergeRequestParams(repository.ID, mergeRequest.Sequence).SetState(models.MergeStateMerged))
+		err = automerge.CheckMergeState(changePairs, conflictResolve)
+		if err != nil {
+			return mergeCheckError(err)
+		}
+
+		// conflict resolution use the sides of merge request, left is source and right is target
+		commit, err = workRepo.Merge(ctx, targetBranch.CommitHash, msg, versionmgr.ResolveFromSelector(swapResolveSide(conflictResolve)))
+		if err != nil {
+			return mergeCheckError(err)
+		}
+		sourceBranch = workRepo.CurBranch()
+		return repo.TimelineRepo().Insert(ctx, models.NewMergeRequestTimeline(mergeRequest.ID, operator.ID, models.TimelineBranchUpdated, utils.String(commit.Hash.Hex())))
 	})
 	if err != nil {
 		w.Error(err)
 		return
 	}
 
+	// emit after transaction committed, avoid notify the changes rolled back
+	mrCtl.Emitter.Emit(ctx, repository, operator, models.PushEvent, &webhook.CommitPayload{Branch: sourceBranch, Commit: commit})
 	w.JSON(commitToDto(commit))
 }
 
+func (mrCtl MergeRequestController) UploadConflictResolution(ctx context.Context, w *api.JiaozifsResponse, r *http.Request, ownerName string, repositoryName string, mrSeq uint64, params api.UploadConflictResolutionParams) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	owner, err := mrCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, err := mrCtl.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetOwnerID(owner.ID).SetName(repositoryName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	// resolution is used by both merge and update branch
+	if !mrCtl.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Type: rbac.NodeTypeOr,
+		Nodes: []rbac.Node{
+			{
+				Permission: rbac.Permission{
+					Action:   rbacmodel.MergeMergeRequestAction,
+					Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+				},
+			},
+			{
+				Permission: rbac.Permission{
+					Action:   rbacmodel.UpdateMergeRequestAction,
+					Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+				},
+			},
+		},
+	}) {
+		return
+	}
+
+	mergeRequest, err := mrCtl.Repo.MergeRequestRepo().Get(ctx, models.NewGetMergeRequestParams().SetTargetRepo(repository.ID).SetNumber(mrSeq))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if mergeRequest.MergeState != models.MergeStateInit {
+		w.BadRequest("only conflict of open merge request can be resolved")
+		return
+	}
+
+	reader, _, err := uploadContentReader(r)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	defer reader.Close() //nolint
+
+	sourceBranch, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	targetBranch, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.TargetBranchID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, mrCtl.Repo, mrCtl.PublicStorageConfig)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	workRepo.WithQuota(mrCtl.Quota)
+
+	err = workRepo.CheckOut(ctx, versionmgr.InBranch, targetBranch.Name)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	changePairs, err := workRepo.GetMergeState(ctx, sourceBranch.CommitHash, targetBranch.CommitHash)
+	if err != nil {
+		w.Error(mergeCheckError(err))
+		return
+	}
+
+	path := versionmgr.CleanPath(params.Path)
+	isConflict := false
+	for _, changePair := range changePairs {
+		if changePair.IsConflict && changePair.Path() == path {
+			isConflict = true
+			break
+		}
+	}
+	if !isConflict {
+		w.BadRequest(fmt.Sprintf("path %s is not conflict", path))
+		return
+	}
+
+	blob, err := workRepo.WriteBlob(ctx, reader, r.ContentLength, models.DefaultLeafProperty())
+	if !checkQuota(w, err) {
+		return
+	}
+
+	_, err = mrCtl.Repo.FileTreeRepo(repository.ID).Insert(ctx, blob.FileTree())
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(blobToDto(blob), http.StatusCreated)
+}
+
+func (mrCtl MergeRequestController) assigneesAndLabels(ctx context.Context, mergeRequestID uuid.UUID) ([]api.Assignee, []api.Label, error) {
+	assignees, err := listAssignees(ctx, mrCtl.Repo, mergeRequestID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	labels, err := mrCtl.Repo.LabelRepo().List(ctx, models.NewListLabelParams().SetMergeRequestID(mergeRequestID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return assignees, utils.Silent(utils.ArrMap(labels, labelToDto)), nil
+}
+
+// cachedBranch get branch through branches cache, many merge requests share the same target branch. nil returned if branch was deleted
+func (mrCtl MergeRequestController) cachedBranch(ctx context.Context, branches map[uuid.UUID]*models.Branch, branchID uuid.UUID) (*models.Branch, error) {
+	if branch, ok := branches[branchID]; ok {
+		return branch, nil
+	}
+
+	branch, err := mrCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(branchID))
+	if errors.Is(err, models.ErrNotFound) {
+		branch, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	branches[branchID] = branch
+	return branch, nil
+}
+
+// refreshMergeability save mergeability of open merge request computed from changePairs if source or target branch moved,
+// workRepo must be checked out on source branch
+func refreshMergeability(ctx context.Context, repo models.IRepo, workRepo *versionmgr.WorkRepository, mergeRequest *models.MergeRequest, sourceBranch, targetBranch *models.Branch, changePairs []*versionmgr.ChangePair) error {
+	if mergeRequest.MergeState != models.MergeStateInit || !mergeRequest.MergeabilityOutdated(sourceBranch.CommitHash, targetBranch.CommitHash) {
+		return nil
+	}
+
+	behindBy, err := workRepo.CountBehind(ctx, targetBranch.CommitHash)
+	if err != nil {
+		return err
+	}
+
+	conflictCount := 0
+	for _, changePair := range changePairs {
+		if changePair.IsConflict {
+			conflictCount++
+		}
+	}
+
+	mergeRequest.CheckedSourceHash = sourceBranch.CommitHash
+	mergeRequest.CheckedTargetHash = targetBranch.CommitHash
+	// conflicts resolved by merge driver in attributes not block merging
+	mergeRequest.Mergeable = len(automerge.UnresolvedConflicts(changePairs, nil)) == 0
+	mergeRequest.BehindBy = behindBy
+	mergeRequest.ConflictCount = conflictCount
+	return repo.MergeRequestRepo().UpdateMergeability(ctx, mergeRequest)
+}
+
+// swapResolveSide swap left and right of conflict resolution
+func swapResolveSide(conflictResolve map[string]string) map[string]string {
+	swapped := make(map[string]string, len(conflictResolve))
+	for path, side := range conflictResolve {
+		switch side {
+		case versionmgr.ResolveLeft:
+			swapped[path] = versionmgr.ResolveRight
+		case versionmgr.ResolveRight:
+			swapped[path] = versionmgr.ResolveLeft
+		default:
+			swapped[path] = side
+		}
+	}
+	return swapped
+}
+
+// mergeCheckError set status code of errors returned by merge checks
+func mergeCheckError(err error) error {
+	switch {
+	case errors.Is(err, automerge.ErrMergeNotAllowed), errors.Is(err, protection.ErrBranchProtected):
+		return fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusForbidden))
+	case errors.Is(err, automerge.ErrDraft), errors.Is(err, versionmgr.ErrInvalidResolution):
+		return fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusBadRequest))
+	case errors.Is(err, automerge.ErrUnresolvedConflict), errors.Is(err, automerge.ErrSourceMoved), errors.Is(err, versionmgr.ErrInvalidAttributes):
+		return fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusConflict))
+	case errors.Is(err, quota.ErrQuotaExceeded):
+		return fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusRequestEntityTooLarge))
+	}
+	return err
+}
+
+func blobToDto(in *models.Blob) api.Blob {
+	return api.Blob{
+		Hash:         in.Hash.Hex(),
+		RepositoryId: in.RepositoryID,
+		CheckSum:     in.CheckSum.Hex(),
+		Type:         int8(in.Type),
+		Size:         in.Size,
+		Properties:   in.Properties.ToMap(),
+		CreatedAt:    in.CreatedAt.UnixMilli(),
+		UpdatedAt:    in.UpdatedAt.UnixMilli(),
+	}
+}
+
 func changePairToDTO(pairs []*versionmgr.ChangePair) ([]api.ChangePair, error) {
 
 	var changes = make([]api.ChangePair, len(pairs))
@@ -430,6 +1053,9 @@ func changePairToDTO(pairs []*versionmgr.ChangePair) ([]api.ChangePair, error) {
 			Path:       path,
 			IsConflict: ch.IsConflict,
 		}
+		if len(ch.MergeDriver) > 0 {
+			pair.MergeDriver = utils.String(string(ch.MergeDriver))
+		}
 
 		if ch.Left != nil {
 			leftAction, err := ch.Left.Action()
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+type TimelineController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (timelineCtl TimelineController) ListMergeRequestTimeline(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, params api.ListMergeRequestTimelineParams) {
+	owner, err := timelineCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, err := timelineCtl.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetName(repositoryName).SetOwnerID(owner.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !timelineCtl.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.ReadMergeRequestAction,
+			Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+		},
+	}) {
+		return
+	}
+
+	mergeRequest, err := timelineCtl.Repo.MergeRequestRepo().Get(ctx, models.NewGetMergeRequestParams().SetTargetRepo(repository.ID).SetNumber(mrSeq))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	listParams := models.NewListTimelineParams().SetMergeRequestID(mergeRequest.ID)
+	if params.After != nil {
+		listParams.SetAfter(time.UnixMilli(utils.Int64Value(params.After)))
+	}
+	pageAmount := utils.IntValue(params.Amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	timelines, hasMore, err := timelineCtl.Repo.TimelineRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	userNames := make(map[uuid.UUID]string)
+	results := make([]api.TimelineEvent, 0, len(timelines))
+	for _, timeline := range timelines {
+		actorName, err := cachedUserName(ctx, timelineCtl.Repo.UserRepo(), userNames, timeline.ActorID)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		results = append(results, api.TimelineEvent{
+			Id:             timeline.ID,
+			MergeRequestId: timeline.MergeRequestID,
+			ActorId:        timeline.ActorID,
+			ActorName:      actorName,
+			Action:         api.TimelineEventAction(timeline.Action),
+			Detail:         timeline.Detail,
+			CreatedAt:      timeline.CreatedAt.UnixMilli(),
+		})
+	}
+
+	pagMag := utils.PaginationFor(hasMore, results, "CreatedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.TimelineList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
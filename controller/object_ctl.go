@@ -12,7 +12,7 @@ import (
 	"time"
 
 	"github.com/GitDataAI/jiaozifs/auth/rbac"
-	"github.com/GitDataAI/jiaozifs/controller/validator"
+	"github.com/GitDataAI/jiaozifs/utils/pathutil"
 
 	"github.com/GitDataAI/jiaozifs/api"
 	"github.com/GitDataAI/jiaozifs/auth"
@@ -20,6 +20,9 @@ import (
 	"github.com/GitDataAI/jiaozifs/models"
 	"github.com/GitDataAI/jiaozifs/models/filemode"
 	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/protection"
+	"github.com/GitDataAI/jiaozifs/quota"
+	"github.com/GitDataAI/jiaozifs/tabular"
 	"github.com/GitDataAI/jiaozifs/utils"
 	"github.com/GitDataAI/jiaozifs/utils/hash"
 	"github.com/GitDataAI/jiaozifs/utils/httputil"
@@ -37,6 +40,7 @@ type ObjectController struct {
 
 	PublicStorageConfig params.AdapterConfig
 	Repo                models.IRepo
+	Quota               *quota.Manager
 }
 
 func (oct ObjectController) DeleteObject(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.DeleteObjectParams) { //nolint
@@ -67,6 +71,10 @@ func (oct ObjectController) DeleteObject(ctx context.Context, w *api.JiaozifsRes
 		return
 	}
 
+	if !checkProtection(w, protection.NewChecker(oct.Repo).CheckWrite(ctx, repository.ID, params.RefName)) {
+		return
+	}
+
 	ref, err := oct.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetRepositoryID(repository.ID).SetName(params.RefName))
 	if err != nil {
 		w.Error(err)
@@ -308,56 +316,23 @@ func (oct ObjectController) UploadObject(ctx context.Context, w *api.JiaozifsRes
 	}
 
 	// read request body parse multipart for "content" and upload the data
-	contentType := r.Header.Get("Content-Type")
-	mediaType, p, err := mime.ParseMediaType(contentType)
+	reader, contentType, err := uploadContentReader(r)
 	if err != nil {
 		w.Error(err)
 		return
 	}
-
-	reader := r.Body
-	if mediaType == "multipart/form-data" {
-		// handle multipart upload
-		boundary, ok := p["boundary"]
-		if !ok {
-			w.Error(err)
-			return
-		}
-
-		contentUploaded := false
-		partReader := multipart.NewReader(r.Body, boundary)
-		for !contentUploaded {
-			part, err := partReader.NextPart()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				w.Error(err)
-				return
-			}
-			contentType = part.Header.Get("Content-Type")
-			partName := part.FormName()
-			if partName == "content" {
-				reader = part
-				contentUploaded = true
-			} else { //close not target part
-				_ = part.Close()
-			}
-
-		}
-		if !contentUploaded {
-			w.Error(fmt.Errorf("multipart upload missing key 'content': %w", http.ErrMissingFile))
-			return
-		}
-	}
 	defer reader.Close() //nolint
 
-	err = validator.ValidateObjectPath(params.Path)
+	err = pathutil.ValidateObjectPath(params.Path)
 	if err != nil {
 		w.BadRequest(err.Error())
 		return
 	}
 
+	if !checkProtection(w, protection.NewChecker(oct.Repo).CheckWrite(ctx, repository.ID, params.RefName)) {
+		return
+	}
+
 	operator, err := auth.GetOperator(ctx)
 	if err != nil {
 		w.Error(err)
@@ -369,6 +344,7 @@ func (oct ObjectController) UploadObject(ctx context.Context, w *api.JiaozifsRes
 		w.Error(err)
 		return
 	}
+	workRepo.WithQuota(oct.Quota)
 
 	err = workRepo.CheckOut(ctx, versionmgr.InWip, params.RefName)
 	if err != nil {
@@ -383,8 +359,7 @@ func (oct ObjectController) UploadObject(ctx context.Context, w *api.JiaozifsRes
 	}
 
 	blob, err := workRepo.WriteBlob(ctx, reader, r.ContentLength, models.DefaultLeafProperty())
-	if err != nil {
-		w.Error(err)
+	if !checkQuota(w, err) {
 		return
 	}
 
@@ -487,3 +462,248 @@ func (oct ObjectController) GetFiles(ctx context.Context, w *api.JiaozifsRespons
 
 	w.JSON(treeManifest.FileList)
 }
+
+// uploadContentReader return reader and content type of uploaded content, read the part named "content" for multipart upload, otherwise the whole body
+func (oct ObjectController) GetParquetMetadata(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetParquetMetadataParams) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	owner, err := oct.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, err := oct.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetOwnerID(owner.ID).SetName(repositoryName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !oct.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.ReadObjectAction,
+			Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+		},
+	}) {
+		return
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, oct.Repo, oct.PublicStorageConfig)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	path := versionmgr.CleanPath(params.Path)
+	blob, err := findDiffBlob(ctx, workRepo, params.Type, params.RefName, path)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	if blob == nil {
+		w.NotFound()
+		return
+	}
+
+	metadata, err := tabular.ReadParquetMetadata(workRepo.BlobReaderAt(ctx, blob), blob.Size)
+	if errors.Is(err, tabular.ErrInvalidParquet) {
+		w.BadRequest(err.Error())
+		return
+	}
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(parquetMetadataToDto(path, blob, metadata))
+}
+
+func (oct ObjectController) GetObjectPreview(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetObjectPreviewParams) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	owner, err := oct.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, err := oct.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetOwnerID(owner.ID).SetName(repositoryName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !oct.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.ReadObjectAction,
+			Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+		},
+	}) {
+		return
+	}
+
+	path := versionmgr.CleanPath(params.Path)
+	format, ok := tabular.DetectFormat(path)
+	if params.Format != nil {
+		format, ok = tabular.Format(*params.Format), true
+	}
+	if !ok {
+		w.BadRequest(fmt.Sprintf("unable to detect table format of %s, format must be specified", path))
+		return
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, oct.Repo, oct.PublicStorageConfig)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	blob, err := findDiffBlob(ctx, workRepo, params.Type, params.RefName, path)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	if blob == nil {
+		w.NotFound()
+		return
+	}
+
+	opt := tabular.PreviewOption{
+		Offset: utils.Int64Value(params.Offset),
+		Limit:  utils.IntValue(params.Limit),
+	}
+	preview, err := previewObject(ctx, workRepo, blob, format, opt)
+	if errors.Is(err, tabular.ErrInvalidTable) || errors.Is(err, tabular.ErrInvalidParquet) || errors.Is(err, tabular.ErrUnsupportedTable) {
+		w.BadRequest(err.Error())
+		return
+	}
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(tablePreviewToDto(path, blob, format, preview))
+}
+
+// previewObject read rows of object, parquet is read by column chunks and text table is read up to MaxPreviewScanSize
+func previewObject(ctx context.Context, workRepo *versionmgr.WorkRepository, blob *models.Blob, format tabular.Format, opt tabular.PreviewOption) (*tabular.Preview, error) {
+	if format == tabular.FormatParquet {
+		return tabular.PreviewParquet(workRepo.BlobReaderAt(ctx, blob), blob.Size, opt)
+	}
+
+	var rangeSpec *string
+	complete := blob.Size <= tabular.MaxPreviewScanSize
+	if !complete {
+		rangeSpec = utils.String(fmt.Sprintf("bytes=0-%d", tabular.MaxPreviewScanSize-1))
+	}
+	reader, err := workRepo.ReadBlob(ctx, blob, rangeSpec)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close() //nolint
+	return tabular.PreviewText(reader, format, complete, opt)
+}
+
+func tablePreviewToDto(path string, blob *models.Blob, format tabular.Format, preview *tabular.Preview) api.TablePreview {
+	result := api.TablePreview{
+		Path:      path,
+		Hash:      blob.Hash.Hex(),
+		Format:    string(format),
+		Columns:   make([]api.PreviewColumn, len(preview.Columns)),
+		Rows:      preview.Rows,
+		TotalRows: preview.TotalRows,
+	}
+	for i, column := range preview.Columns {
+		result.Columns[i] = api.PreviewColumn{Name: column.Name, Type: api.PreviewColumnType(column.Type)}
+	}
+	return result
+}
+
+func uploadContentReader(r *http.Request) (io.ReadCloser, string, error) {
+	contentType := r.Header.Get("Content-Type")
+	mediaType, p, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if mediaType != "multipart/form-data" {
+		return r.Body, contentType, nil
+	}
+
+	// handle multipart upload
+	boundary, ok := p["boundary"]
+	if !ok {
+		return nil, "", fmt.Errorf("multipart upload missing boundary %w", api.ErrCode(http.StatusBadRequest))
+	}
+
+	partReader := multipart.NewReader(r.Body, boundary)
+	for {
+		part, err := partReader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() == "content" {
+			return part, part.Header.Get("Content-Type"), nil
+		}
+		//close not target part
+		_ = part.Close()
+	}
+	return nil, "", fmt.Errorf("multipart upload missing key 'content': %w", http.ErrMissingFile)
+}
+
+func parquetMetadataToDto(path string, blob *models.Blob, metadata *tabular.ParquetMetadata) api.ParquetMetadata {
+	result := api.ParquetMetadata{
+		Path:      path,
+		Hash:      blob.Hash.Hex(),
+		Version:   metadata.Version,
+		NumRows:   metadata.NumRows,
+		CreatedBy: metadata.CreatedBy,
+		Columns:   make([]api.ParquetColumn, len(metadata.Columns)),
+		RowGroups: make([]api.ParquetRowGroup, len(metadata.RowGroups)),
+	}
+	for i, column := range metadata.Columns {
+		result.Columns[i] = api.ParquetColumn{
+			Name:         column.Name,
+			PhysicalType: column.PhysicalType,
+			Repetition:   api.ParquetColumnRepetition(column.Repetition),
+		}
+		if len(column.LogicalType) > 0 {
+			result.Columns[i].LogicalType = utils.String(column.LogicalType)
+		}
+	}
+	for i, rowGroup := range metadata.RowGroups {
+		chunks := make([]api.ParquetColumnChunk, len(rowGroup.Columns))
+		for j, chunk := range rowGroup.Columns {
+			chunks[j] = api.ParquetColumnChunk{
+				Column:           chunk.Column,
+				Codec:            chunk.Codec,
+				NumValues:        chunk.NumValues,
+				CompressedSize:   chunk.CompressedSize,
+				UncompressedSize: chunk.UncompressedSize,
+			}
+			if chunk.Statistics != nil {
+				chunks[j].Statistics = &api.ParquetColumnStatistics{
+					NullCount:     chunk.Statistics.NullCount,
+					DistinctCount: chunk.Statistics.DistinctCount,
+					Min:           chunk.Statistics.Min,
+					Max:           chunk.Statistics.Max,
+				}
+			}
+		}
+		result.RowGroups[i] = api.ParquetRowGroup{
+			NumRows:       rowGroup.NumRows,
+			TotalByteSize: rowGroup.TotalByteSize,
+			Columns:       chunks,
+		}
+	}
+	return result
+}
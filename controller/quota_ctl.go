@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/quota"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+type QuotaController struct {
+	fx.In
+	BaseController
+
+	Repo  models.IRepo
+	Quota *quota.Manager
+}
+
+func (quotaCtl QuotaController) GetRepositoryQuota(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string) {
+	repository, ok := quotaCtl.getRepository(ctx, w, quotaCtl.Repo, ownerName, repositoryName, rbacmodel.ReadRepositoryAction)
+	if !ok {
+		return
+	}
+
+	quotaCtl.writeUsage(ctx, w, models.RepositoryQuotaScope, repository.ID)
+}
+
+func (quotaCtl QuotaController) UpdateRepositoryQuota(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateRepositoryQuotaJSONRequestBody, ownerName string, repositoryName string) {
+	repository, ok := quotaCtl.findRepository(ctx, w, quotaCtl.Repo, ownerName, repositoryName)
+	if !ok || !quotaCtl.authorizeManage(ctx, w) {
+		return
+	}
+
+	quotaCtl.updateLimits(ctx, w, models.RepositoryQuotaScope, repository.ID, body)
+}
+
+func (quotaCtl QuotaController) ResetRepositoryQuota(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string) {
+	repository, ok := quotaCtl.findRepository(ctx, w, quotaCtl.Repo, ownerName, repositoryName)
+	if !ok || !quotaCtl.authorizeManage(ctx, w) {
+		return
+	}
+
+	quotaCtl.resetLimits(ctx, w, models.RepositoryQuotaScope, repository.ID)
+}
+
+func (quotaCtl QuotaController) GetUserQuota(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	owner, err := quotaCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	// user can see usage of himself, others need admin permission
+	if operator.ID != owner.ID && !quotaCtl.authorizeManage(ctx, w) {
+		return
+	}
+
+	quotaCtl.writeUsage(ctx, w, models.UserQuotaScope, owner.ID)
+}
+
+func (quotaCtl QuotaController) UpdateUserQuota(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateUserQuotaJSONRequestBody, ownerName string) {
+	owner, err := quotaCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !quotaCtl.authorizeManage(ctx, w) {
+		return
+	}
+
+	quotaCtl.updateLimits(ctx, w, models.UserQuotaScope, owner.ID, body)
+}
+
+func (quotaCtl QuotaController) ResetUserQuota(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string) {
+	owner, err := quotaCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !quotaCtl.authorizeManage(ctx, w) {
+		return
+	}
+
+	quotaCtl.resetLimits(ctx, w, models.UserQuotaScope, owner.ID)
+}
+
+func (quotaCtl QuotaController) updateLimits(ctx context.Context, w *api.JiaozifsResponse, scope models.QuotaScope, targetID uuid.UUID, body api.QuotaUpdate) {
+	if body.MaxBytes == nil && body.MaxObjects == nil {
+		w.BadRequest("at least one of max_bytes and max_objects must be set")
+		return
+	}
+
+	updateParams := models.NewUpdateQuotaLimitsParams(scope, targetID)
+	if body.MaxBytes != nil {
+		updateParams.SetMaxBytes(*body.MaxBytes)
+	}
+	if body.MaxObjects != nil {
+		updateParams.SetMaxObjects(*body.MaxObjects)
+	}
+
+	err := quotaCtl.Repo.QuotaRepo().UpdateLimits(ctx, updateParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	quotaCtl.writeUsage(ctx, w, scope, targetID)
+}
+
+func (quotaCtl QuotaController) resetLimits(ctx context.Context, w *api.JiaozifsResponse, scope models.QuotaScope, targetID uuid.UUID) {
+	err := quotaCtl.Repo.QuotaRepo().UpdateLimits(ctx, models.NewUpdateQuotaLimitsParams(scope, targetID).SetClear())
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	quotaCtl.writeUsage(ctx, w, scope, targetID)
+}
+
+func (quotaCtl QuotaController) writeUsage(ctx context.Context, w *api.JiaozifsResponse, scope models.QuotaScope, targetID uuid.UUID) {
+	usage, err := quotaCtl.Quota.Usage(ctx, scope, targetID)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	w.JSON(usageToDto(usage))
+}
+
+func (quotaCtl QuotaController) authorizeManage(ctx context.Context, w *api.JiaozifsResponse) bool {
+	return quotaCtl.authorize(ctx, w, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.ManageQuotaAction,
+			Resource: rbacmodel.QuotaArn(),
+		},
+	})
+}
+
+func usageToDto(in *quota.Usage) api.StorageQuota {
+	return api.StorageQuota{
+		Scope:       api.StorageQuotaScope(in.Scope),
+		TargetId:    in.TargetID,
+		UsedBytes:   in.UsedBytes,
+		UsedObjects: in.UsedObjects,
+		MaxBytes:    in.MaxBytes,
+		MaxObjects:  in.MaxObjects,
+		Overridden:  in.Overridden,
+	}
+}
+
+// checkQuota response request entity too large if the content is rejected by storage quota
+func checkQuota(w *api.JiaozifsResponse, err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		w.Error(fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusRequestEntityTooLarge)))
+		return false
+	}
+	w.Error(err)
+	return false
+}
@@ -3,14 +3,17 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/GitDataAI/jiaozifs/auth/rbac"
 	"github.com/GitDataAI/jiaozifs/controller/validator"
 	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/quota"
 
 	"github.com/GitDataAI/jiaozifs/api"
 	"github.com/GitDataAI/jiaozifs/auth"
@@ -312,6 +315,12 @@ func (repositoryCtl RepositoryController) DeleteRepository(ctx context.Context,
 			return err
 		}
 
+		//delete commit stats
+		_, err = repo.CommitStatsRepo().Delete(ctx, models.NewDeleteCommitStatsParams().SetRepositoryID(repository.ID))
+		if err != nil {
+			return err
+		}
+
 		//delete tag
 		_, err = repo.TagRepo().Delete(ctx, models.NewDeleteTagParams().SetRepositoryID(repository.ID))
 		if err != nil {
@@ -332,7 +341,12 @@ func (repositoryCtl RepositoryController) DeleteRepository(ctx context.Context,
 
 		//delete all membership
 		_, err = repo.MemberRepo().DeleteMember(ctx, models.NewDeleteMemberParams().SetRepoID(repository.ID))
-		return err
+		if err != nil {
+			return err
+		}
+
+		//release storage usage
+		return quota.Release(ctx, repo, repository)
 	})
 	if err != nil {
 		w.Error(err)
@@ -590,26 +604,32 @@ func (repositoryCtl RepositoryController) GetArchive(ctx context.Context, w *api
 		return
 	}
 
-	readeCloser, size, err := workRepo.Archive(ctx, versionmgr.ArchiveType(params.ArchiveType))
+	archiver, err := workRepo.Archiver(ctx, utils.StringValue(params.Path))
 	if err != nil {
+		if errors.Is(err, versionmgr.ErrPathNotFound) || errors.Is(err, versionmgr.ErrNotDirectory) {
+			w.BadRequest(fmt.Sprintf("path %s not found or not a directory", utils.StringValue(params.Path)))
+			return
+		}
 		w.Error(err)
 		return
 	}
-	defer readeCloser.Close() //nolint
-	w.Header().Set("Content-Length", fmt.Sprint(size))
+
+	fileName := repository.Name
+	if path := versionmgr.CleanPath(utils.StringValue(params.Path)); len(path) > 0 {
+		fileName = fmt.Sprintf("%s-%s", fileName, strings.ReplaceAll(path, "/", "-"))
+	}
 	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fmt.Sprintf("%s.%s", repository.Name, params.ArchiveType)))
-	_, err = io.Copy(w, readeCloser)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fmt.Sprintf("%s.%s", fileName, params.ArchiveType)))
+	err = archiver.Archive(ctx, versionmgr.ArchiveType(params.ArchiveType), w)
 	if err != nil {
+		// response already started, error can only be logged and client get an unfinished archive
 		objLog.With(
 			"user", ownerName,
 			"repo", repositoryName,
 			"reftype", params.RefType,
 			"refname", params.RefName,
-		).Debugf("archive copy content %v", err)
-
+		).Errorf("archive copy content %v", err)
 	}
-	w.OK()
 }
 
 func repositoryToDto(repository *models.Repository) *api.Repository {
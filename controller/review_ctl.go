@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+type ReviewController struct {
+	fx.In
+	BaseController
+
+	Repo models.IRepo
+}
+
+func (reviewCtl ReviewController) ListReviewers(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64) {
+	_, mergeRequest, ok := reviewCtl.getMergeRequest(ctx, w, reviewCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReadMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	reviewCtl.writeReviewers(ctx, w, mergeRequest, http.StatusOK)
+}
+
+func (reviewCtl ReviewController) RequestReviewers(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.RequestReviewersJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := reviewCtl.getMergeRequest(ctx, w, reviewCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	if len(body.Reviewers) == 0 {
+		w.BadRequest("must request at least one reviewer")
+		return
+	}
+
+	reviewers := make([]*models.MergeRequestReviewer, 0, len(body.Reviewers))
+	for _, reviewerName := range body.Reviewers {
+		reviewer, err := reviewCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(reviewerName))
+		if errors.Is(err, models.ErrNotFound) {
+			w.BadRequest(fmt.Sprintf("user %s not found", reviewerName))
+			return
+		}
+		if err != nil {
+			w.Error(err)
+			return
+		}
+
+		if reviewer.ID == mergeRequest.AuthorID {
+			w.BadRequest("author can not review merge request himself")
+			return
+		}
+
+		reviewers = append(reviewers, &models.MergeRequestReviewer{
+			MergeRequestID: mergeRequest.ID,
+			ReviewerID:     reviewer.ID,
+			RequesterID:    operator.ID,
+			CreatedAt:      time.Now(),
+		})
+	}
+
+	err = reviewCtl.Repo.ReviewerRepo().Insert(ctx, reviewers...)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	reviewCtl.writeReviewers(ctx, w, mergeRequest, http.StatusCreated)
+}
+
+func (reviewCtl ReviewController) RemoveReviewer(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64, reviewerName string) {
+	_, mergeRequest, ok := reviewCtl.getMergeRequest(ctx, w, reviewCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.UpdateMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	reviewer, err := reviewCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(reviewerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	affectedRows, err := reviewCtl.Repo.ReviewerRepo().Delete(ctx, models.NewDeleteReviewerParams().SetMergeRequestID(mergeRequest.ID).SetReviewerID(reviewer.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	if affectedRows == 0 {
+		w.Error(models.ErrNotFound)
+		return
+	}
+	w.OK()
+}
+
+func (reviewCtl ReviewController) ListReviews(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, mrSeq uint64) {
+	_, mergeRequest, ok := reviewCtl.getMergeRequest(ctx, w, reviewCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReadMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	reviews, err := reviewCtl.Repo.ReviewRepo().List(ctx, models.NewListReviewParams().SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	userNames := make(map[uuid.UUID]string)
+	results := make([]api.Review, 0, len(reviews))
+	for _, review := range reviews {
+		reviewerName, err := cachedUserName(ctx, reviewCtl.Repo.UserRepo(), userNames, review.ReviewerID)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		results = append(results, reviewToDto(review, reviewerName))
+	}
+	w.JSON(results)
+}
+
+func (reviewCtl ReviewController) SubmitReview(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.SubmitReviewJSONRequestBody, ownerName string, repositoryName string, mrSeq uint64) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	_, mergeRequest, ok := reviewCtl.getMergeRequest(ctx, w, reviewCtl.Repo, ownerName, repositoryName, mrSeq, rbacmodel.ReviewMergeRequestAction)
+	if !ok {
+		return
+	}
+
+	if mergeRequest.MergeState != models.MergeStateInit {
+		w.BadRequest("only open merge request can be reviewed")
+		return
+	}
+
+	verdict, err := models.ParseReviewVerdict(string(body.Verdict))
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	if verdict == models.ReviewApprove && operator.ID == mergeRequest.AuthorID {
+		w.BadRequest("author can not approve merge request himself")
+		return
+	}
+
+	// source branch is locked until review inserted, so that moving branch either happen before reading head
+	// or dismiss the review inserted after
+	var review *models.Review
+	err = reviewCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		sourceBranch, err := repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetID(mergeRequest.SourceBranchID).SetForUpdate())
+		if err != nil {
+			return err
+		}
+
+		if body.Commit != nil && *body.Commit != sourceBranch.CommitHash.Hex() {
+			return fmt.Errorf("source branch has moved to %s %w", sourceBranch.CommitHash.Hex(), api.ErrCode(http.StatusConflict))
+		}
+
+		review, err = repo.ReviewRepo().Insert(ctx, &models.Review{
+			MergeRequestID: mergeRequest.ID,
+			ReviewerID:     operator.ID,
+			Verdict:        verdict,
+			CommitHash:     sourceBranch.CommitHash,
+			Body:           body.Body,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		})
+		return err
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(reviewToDto(review, operator.Name), http.StatusCreated)
+}
+
+func (reviewCtl ReviewController) writeReviewers(ctx context.Context, w *api.JiaozifsResponse, mergeRequest *models.MergeRequest, code int) {
+	reviewers, err := reviewCtl.Repo.ReviewerRepo().List(ctx, models.NewListReviewerParams().SetMergeRequestID(mergeRequest.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	userNames := make(map[uuid.UUID]string)
+	results := make([]api.Reviewer, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		reviewerName, err := cachedUserName(ctx, reviewCtl.Repo.UserRepo(), userNames, reviewer.ReviewerID)
+		if err != nil {
+			w.Error(err)
+			return
+		}
+		results = append(results, api.Reviewer{
+			ReviewerId:   reviewer.ReviewerID,
+			ReviewerName: reviewerName,
+			RequesterId:  reviewer.RequesterID,
+			CreatedAt:    reviewer.CreatedAt.UnixMilli(),
+		})
+	}
+	w.JSON(results, code)
+}
+
+// cachedUserName get name of user, names are cached in userNames as one user may have many reviews or comments
+func cachedUserName(ctx context.Context, userRepo models.IUserRepo, userNames map[uuid.UUID]string, userID uuid.UUID) (string, error) {
+	if name, ok := userNames[userID]; ok {
+		return name, nil
+	}
+	user, err := userRepo.Get(ctx, models.NewGetUserParams().SetID(userID))
+	if err != nil {
+		return "", err
+	}
+	userNames[userID] = user.Name
+	return user.Name, nil
+}
+
+func reviewToDto(in *models.Review, reviewerName string) api.Review {
+	return api.Review{
+		Id:             in.ID,
+		MergeRequestId: in.MergeRequestID,
+		ReviewerId:     in.ReviewerID,
+		ReviewerName:   reviewerName,
+		Verdict:        api.ReviewVerdict(in.Verdict),
+		CommitHash:     in.CommitHash.Hex(),
+		Body:           in.Body,
+		Dismissed:      in.Dismissed,
+		CreatedAt:      in.CreatedAt.UnixMilli(),
+		UpdatedAt:      in.UpdatedAt.UnixMilli(),
+	}
+}
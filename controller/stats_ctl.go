@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/auth/rbac"
+	"github.com/GitDataAI/jiaozifs/block/params"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"go.uber.org/fx"
+)
+
+const (
+	defaultStatsTop      = 10
+	defaultSizeTreeDepth = 2
+	defaultSizeTreeLimit = 100
+)
+
+type StatsController struct {
+	fx.In
+	BaseController
+
+	Repo                models.IRepo
+	PublicStorageConfig params.AdapterConfig
+}
+
+func (statsCtl StatsController) GetRepositoryStats(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetRepositoryStatsParams) {
+	repository, workTree, ok := statsCtl.getWorkTree(ctx, w, ownerName, repositoryName, params.Type, params.RefName)
+	if !ok {
+		return
+	}
+
+	path := versionmgr.CleanPath(utils.StringValue(params.Path))
+	stats, err := workTree.Stats(ctx, path)
+	if !checkStatsPath(w, path, err) {
+		return
+	}
+
+	storedBytes, storedObjects, err := statsCtl.Repo.FileTreeRepo(repository.ID).StoredBlobs(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	top := defaultStatsTop
+	if params.Top != nil {
+		top = int(*params.Top)
+	}
+	w.JSON(api.RepositoryStats{
+		Path:               path,
+		Size:               stats.Size,
+		FileCount:          stats.FileCount,
+		DirCount:           stats.DirCount,
+		StoredBytes:        storedBytes,
+		StoredObjects:      storedObjects,
+		LargestFiles:       sizeEntriesToDto(path, stats.LargestFiles, top),
+		LargestDirectories: sizeEntriesToDto(path, stats.LargestDirs, top),
+	})
+}
+
+func (statsCtl StatsController) GetRepositorySizeTree(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.GetRepositorySizeTreeParams) {
+	_, workTree, ok := statsCtl.getWorkTree(ctx, w, ownerName, repositoryName, params.Type, params.RefName)
+	if !ok {
+		return
+	}
+
+	depth, limit := defaultSizeTreeDepth, defaultSizeTreeLimit
+	if params.Depth != nil {
+		depth = int(*params.Depth)
+	}
+	if params.Limit != nil {
+		limit = int(*params.Limit)
+	}
+
+	path := versionmgr.CleanPath(utils.StringValue(params.Path))
+	sizeTree, err := workTree.SizeTree(ctx, path, depth, limit)
+	if !checkStatsPath(w, path, err) {
+		return
+	}
+	w.JSON(sizeNodeToDto(sizeTree))
+}
+
+func (statsCtl StatsController) getWorkTree(ctx context.Context, w *api.JiaozifsResponse, ownerName string, repositoryName string, refType api.RefType, refName string) (*models.Repository, *versionmgr.WorkTree, bool) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, false
+	}
+
+	owner, err := statsCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return nil, nil, false
+	}
+
+	repository, err := statsCtl.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetName(repositoryName).SetOwnerID(owner.ID))
+	if err != nil {
+		w.Error(err)
+		return nil, nil, false
+	}
+
+	if !statsCtl.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.ReadObjectAction,
+			Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+		},
+	}) {
+		return nil, nil, false
+	}
+
+	if refType == api.RefTypeCommit {
+		_, err := hash.FromHex(refName)
+		if err != nil {
+			w.BadRequest("invalid commit hash %s", refName)
+			return nil, nil, false
+		}
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, statsCtl.Repo, statsCtl.PublicStorageConfig)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, false
+	}
+
+	err = workRepo.CheckOut(ctx, versionmgr.WorkRepoState(refType), refName)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, false
+	}
+
+	workTree, err := workRepo.RootTree(ctx)
+	if err != nil {
+		w.Error(err)
+		return nil, nil, false
+	}
+	return repository, workTree, true
+}
+
+// checkStatsPath response bad request if path is not a directory in tree
+func checkStatsPath(w *api.JiaozifsResponse, path string, err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, versionmgr.ErrPathNotFound) || errors.Is(err, versionmgr.ErrNotDirectory) {
+		w.BadRequest(fmt.Sprintf("path %s not found or not a directory", path))
+		return false
+	}
+	w.Error(err)
+	return false
+}
+
+// sizeEntriesToDto take first top entries and make their paths relative to the ref
+func sizeEntriesToDto(dir string, entries []versionmgr.SizeEntry, top int) []api.SizeEntry {
+	if len(entries) > top {
+		entries = entries[:top]
+	}
+	result := make([]api.SizeEntry, 0, len(entries))
+	for _, entry := range entries {
+		entryPath := entry.Path
+		if len(dir) > 0 {
+			entryPath = dir + "/" + entryPath
+		}
+		result = append(result, api.SizeEntry{
+			Path:      entryPath,
+			Size:      entry.Size,
+			FileCount: entry.FileCount,
+		})
+	}
+	return result
+}
+
+func sizeNodeToDto(in *versionmgr.SizeNode) api.SizeNode {
+	children := make([]api.SizeNode, 0, len(in.Children))
+	for _, child := range in.Children {
+		children = append(children, sizeNodeToDto(child))
+	}
+	return api.SizeNode{
+		Name:      in.Name,
+		Path:      in.Path,
+		IsDir:     in.IsDir,
+		Size:      in.Size,
+		FileCount: in.FileCount,
+		Truncated: in.Truncated,
+		Children:  children,
+	}
+}
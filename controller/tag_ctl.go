@@ -12,6 +12,7 @@ import (
 	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
 	"github.com/GitDataAI/jiaozifs/utils"
 	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/GitDataAI/jiaozifs/webhook"
 
 	"github.com/GitDataAI/jiaozifs/api"
 	"github.com/GitDataAI/jiaozifs/block/params"
@@ -25,6 +26,7 @@ type TagController struct {
 
 	Repo                models.IRepo
 	PublicStorageConfig params.AdapterConfig
+	Emitter             webhook.Emitter
 }
 
 func (tagCtl TagController) CreateTag(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.CreateTagJSONRequestBody, ownerName string, repositoryName string) {
@@ -66,6 +68,7 @@ func (tagCtl TagController) CreateTag(ctx context.Context, w *api.JiaozifsRespon
 		w.Error(err)
 		return
 	}
+	workRepo.WithEmitter(tagCtl.Emitter)
 
 	//check target is branch
 	_, err = tagCtl.Repo.BranchRepo().Get(ctx, models.NewGetBranchParams().SetRepositoryID(repository.ID).SetName(body.Target))
@@ -2,8 +2,12 @@ package validator
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"regexp"
 	"strings"
+	"syscall"
 )
 
 var (
@@ -13,7 +17,6 @@ var (
 	ReValidRepo = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_\-]{1,61}[a-zA-Z0-9]$`)
 	ReValidTag  = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.\-]{1,61}[a-zA-Z0-9]$`)
 	ReValidUser = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{1,28}[a-zA-Z0-9]$`)
-	ReValidPath = regexp.MustCompile(`^[^\x00/:*?"<>|]*/?([^/\s\x00:*?"<>|]+/)*[^/\s\x00:*?"<>|]+(?:\.[a-zA-Z0-9]+)?$`)
 
 	// RepoNameBlackList forbid repo name, reserve for routes
 	RepoNameBlackList = []string{"repository", "repositories", "wip", "wips", "object", "objects", "tags", "tag", "commit", "commits", "ref", "refs", "repo", "repos", "user", "users"}
@@ -27,7 +30,8 @@ var (
 	ErrInvalidRepoName   = errors.New("repository name must start with a number or letter, can only contain numbers, letters, or hyphens, and must be between 3 and 63 characters in length")
 	ErrInvalidTagName    = errors.New("tag name must start with a number or letter, can only contain numbers, letters, dot, or hyphens, and must be between 3 and 63 characters in length")
 	ErrInvalidUsername   = errors.New("invalid username: it must start and end with a letter or digit, can contain letters, digits, hyphens, and cannot start or end with a hyphen; the length must be between 3 and 30 characters")
-	ErrInvalidObjectPath = errors.New("invalid object path: it must not contain null characters or NTFS forbidden characters")
+	ErrInvalidWebhookURL = errors.New("invalid webhook url: it must be an absolute http or https url")
+	ErrPrivateAddress    = errors.New("loopback, private, link-local and unspecified addresses are not allowed")
 )
 
 func ValidateBranchName(name string) error {
@@ -84,9 +88,46 @@ func ValidateUsername(name string) error {
 	return nil
 }
 
-func ValidateObjectPath(path string) error {
-	if !ReValidPath.MatchString(path) {
-		return ErrInvalidObjectPath
+// ValidateWebhookURL check url is an absolute http or https url, host of ip or localhost must be public unless allowPrivate.
+// domain is not resolved here because it may resolve to other address later, WebhookDialControl check the address really dialed
+func ValidateWebhookURL(webhookURL string, allowPrivate bool) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Hostname()) == 0 {
+		return ErrInvalidWebhookURL
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ValidatePublicIP(ip)
+	}
+	return nil
+}
+
+// ValidatePublicIP check ip is not loopback, private, link-local or unspecified address
+func ValidatePublicIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, ip)
 	}
 	return nil
 }
+
+// WebhookDialControl used as net.Dialer Control to refuse connecting to non public address after host resolved,
+// so that a domain resolving to public address when validated can not be rebound to internal address
+func WebhookDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return ValidatePublicIP(ip)
+}
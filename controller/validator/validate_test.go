@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -124,32 +125,44 @@ func TestValidateUsername(t *testing.T) {
 	}
 }
 
-func TestValidateObjectPath(t *testing.T) {
-	//Validate Obj Path
-	validObjectPaths := []string{"path/to/object", "file.txt", "folder/file.txt", "我的图片.png", "我的文件/我的应用.exe", "私のビデオ.mp3", "/video.mp3", "/path/pic.png"}
-	for _, path := range validObjectPaths {
-		err := ValidateObjectPath(path)
+func TestValidateWebhookURL(t *testing.T) {
+	validURLs := []string{"https://example.com/webhook?token=a", "http://8.8.8.8:8080/hook"}
+	for _, webhookURL := range validURLs {
+		err := ValidateWebhookURL(webhookURL, false)
 		if err != nil {
-			t.Errorf("Expected no error for object path '%s', but got: %s", path, err)
+			t.Errorf("Expected no error for webhook url '%s', but got: %s", webhookURL, err)
 		}
 	}
 
-	//Invalidate Obj Path
-	invalidObjectPaths := []struct {
-		path  string
-		error string
-	}{
-		{"path/with/null\x00character", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
-		{"path/with/invalid/characters/:", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
-		{"path/with/invalid/characters/*", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
-		{"path/with/invalid/characters/\"", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
-		{"path/with/invalid/characters/<?", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
+	invalidURLs := []string{"", "example.com/webhook", "ftp://example.com", "http://", "://example.com", "http://:8080/hook"}
+	for _, webhookURL := range invalidURLs {
+		err := ValidateWebhookURL(webhookURL, true)
+		if err == nil {
+			t.Errorf("Expected error for invalid webhook url '%s'", webhookURL)
+		}
 	}
 
-	for _, testCase := range invalidObjectPaths {
-		err := ValidateObjectPath(testCase.path)
-		if err == nil || err.Error() != testCase.error {
-			t.Errorf("Expected error '%s' for invalid object path '%s', but got: %v", testCase.error, testCase.path, err)
+	privateURLs := []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://10.0.0.1", "http://192.168.1.1",
+		"http://169.254.169.254/latest/meta-data", "http://0.0.0.0", "http://[::1]/hook", "http://[fe80::1]", "http://[::ffff:127.0.0.1]"}
+	for _, webhookURL := range privateURLs {
+		err := ValidateWebhookURL(webhookURL, false)
+		if !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("Expected private address error for webhook url '%s', but got: %v", webhookURL, err)
+		}
+		err = ValidateWebhookURL(webhookURL, true)
+		if err != nil {
+			t.Errorf("Expected no error for webhook url '%s' when private network allowed, but got: %s", webhookURL, err)
+		}
+	}
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	if err := WebhookDialControl("tcp", "8.8.8.8:443", nil); err != nil {
+		t.Errorf("Expected no error for public address, but got: %s", err)
+	}
+	for _, address := range []string{"127.0.0.1:80", "[::1]:80", "10.1.2.3:443", "169.254.169.254:80", "0.0.0.0:80"} {
+		if err := WebhookDialControl("tcp", address, nil); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("Expected private address error for %s, but got: %v", address, err)
 		}
 	}
 }
@@ -0,0 +1,311 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	"github.com/GitDataAI/jiaozifs/auth"
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/controller/validator"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.uber.org/fx"
+)
+
+type WebhookController struct {
+	fx.In
+	BaseController
+
+	Repo          models.IRepo
+	WebhookConfig *config.WebhookConfig
+}
+
+func (webhookCtl WebhookController) ListWebhooks(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, params api.ListWebhooksParams) {
+	repository, ok := webhookCtl.getRepository(ctx, w, webhookCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	listParams := models.NewListWebhookParams().SetRepositoryID(repository.ID)
+	if params.After != nil {
+		listParams.SetAfter(time.UnixMilli(utils.Int64Value(params.After)))
+	}
+	pageAmount := utils.IntValue(params.Amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	webhooks, hasMore, err := webhookCtl.Repo.WebhookRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	results := utils.Silent(utils.ArrMap(webhooks, webhookToDto))
+	pagMag := utils.PaginationFor(hasMore, results, "CreatedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.WebhookList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
+
+func (webhookCtl WebhookController) CreateWebhook(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.CreateWebhookJSONRequestBody, ownerName string, repositoryName string) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, ok := webhookCtl.getRepository(ctx, w, webhookCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	err = validator.ValidateWebhookURL(body.Url, webhookCtl.WebhookConfig.AllowPrivateNetwork)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	events, err := parseEventTypes(body.Events)
+	if err != nil {
+		w.BadRequest(err.Error())
+		return
+	}
+
+	webhook, err := webhookCtl.Repo.WebhookRepo().Insert(ctx, &models.Webhook{
+		RepositoryID: repository.ID,
+		URL:          body.Url,
+		Secret:       utils.StringValue(body.Secret),
+		Events:       events,
+		Active:       body.Active == nil || *body.Active,
+		CreatorID:    operator.ID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(webhookToDto(webhook)), http.StatusCreated)
+}
+
+func (webhookCtl WebhookController) GetWebhook(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := webhookCtl.getRepository(ctx, w, webhookCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	webhook, err := webhookCtl.Repo.WebhookRepo().Get(ctx, models.NewGetWebhookParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(webhookToDto(webhook)))
+}
+
+func (webhookCtl WebhookController) UpdateWebhook(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, body api.UpdateWebhookJSONRequestBody, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := webhookCtl.getRepository(ctx, w, webhookCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	webhook, err := webhookCtl.Repo.WebhookRepo().Get(ctx, models.NewGetWebhookParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	updateParams := models.NewUpdateWebhookParams(webhook.ID)
+	if body.Url != nil {
+		err = validator.ValidateWebhookURL(*body.Url, webhookCtl.WebhookConfig.AllowPrivateNetwork)
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+		updateParams.SetURL(*body.Url)
+	}
+
+	if body.Secret != nil {
+		updateParams.SetSecret(*body.Secret)
+	}
+
+	if body.Events != nil {
+		events, err := parseEventTypes(*body.Events)
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+		updateParams.SetEvents(events)
+	}
+
+	if body.Active != nil {
+		updateParams.SetActive(*body.Active)
+	}
+
+	err = webhookCtl.Repo.WebhookRepo().UpdateByID(ctx, updateParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	webhook, err = webhookCtl.Repo.WebhookRepo().Get(ctx, models.NewGetWebhookParams().SetID(webhook.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(webhookToDto(webhook)))
+}
+
+func (webhookCtl WebhookController) DeleteWebhook(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID) {
+	repository, ok := webhookCtl.getRepository(ctx, w, webhookCtl.Repo, ownerName, repositoryName, rbacmodel.WriteConfigAction)
+	if !ok {
+		return
+	}
+
+	err := webhookCtl.Repo.Transaction(ctx, func(repo models.IRepo) error {
+		affectRows, err := repo.WebhookRepo().Delete(ctx, models.NewDeleteWebhookParams().SetID(id).SetRepositoryID(repository.ID))
+		if err != nil {
+			return err
+		}
+		if affectRows == 0 {
+			return fmt.Errorf("webhook %s %w", id, models.ErrNotFound)
+		}
+
+		_, err = repo.WebhookDeliveryRepo().Delete(ctx, models.NewDeleteWebhookDeliveryParams().SetWebhookID(id))
+		return err
+	})
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.OK()
+}
+
+func (webhookCtl WebhookController) ListWebhookDeliveries(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID, params api.ListWebhookDeliveriesParams) {
+	repository, ok := webhookCtl.getRepository(ctx, w, webhookCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	webhook, err := webhookCtl.Repo.WebhookRepo().Get(ctx, models.NewGetWebhookParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	listParams := models.NewListWebhookDeliveryParams().SetWebhookID(webhook.ID)
+	if params.State != nil {
+		listParams.SetState(models.DeliveryState(*params.State))
+	}
+	if params.After != nil {
+		listParams.SetAfter(time.UnixMilli(utils.Int64Value(params.After)))
+	}
+	pageAmount := utils.IntValue(params.Amount)
+	if pageAmount > utils.DefaultMaxPerPage || pageAmount <= 0 {
+		listParams.SetAmount(utils.DefaultMaxPerPage)
+	} else {
+		listParams.SetAmount(pageAmount)
+	}
+
+	deliveries, hasMore, err := webhookCtl.Repo.WebhookDeliveryRepo().List(ctx, listParams)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	results := utils.Silent(utils.ArrMap(deliveries, webhookDeliveryToDto))
+	pagMag := utils.PaginationFor(hasMore, results, "CreatedAt")
+	pagination := api.Pagination{
+		HasMore:    pagMag.HasMore,
+		MaxPerPage: pagMag.MaxPerPage,
+		NextOffset: pagMag.NextOffset,
+		Results:    pagMag.Results,
+	}
+	w.JSON(api.WebhookDeliveryList{
+		Pagination: pagination,
+		Results:    results,
+	})
+}
+
+func (webhookCtl WebhookController) GetWebhookDelivery(ctx context.Context, w *api.JiaozifsResponse, _ *http.Request, ownerName string, repositoryName string, id openapi_types.UUID, deliveryID openapi_types.UUID) {
+	repository, ok := webhookCtl.getRepository(ctx, w, webhookCtl.Repo, ownerName, repositoryName, rbacmodel.ReadConfigAction)
+	if !ok {
+		return
+	}
+
+	webhook, err := webhookCtl.Repo.WebhookRepo().Get(ctx, models.NewGetWebhookParams().SetID(id).SetRepositoryID(repository.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	delivery, err := webhookCtl.Repo.WebhookDeliveryRepo().Get(ctx, models.NewGetWebhookDeliveryParams().SetID(deliveryID).SetWebhookID(webhook.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	w.JSON(utils.Silent(webhookDeliveryToDto(delivery)))
+}
+
+func parseEventTypes(events []string) ([]models.EventType, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("webhook must subscribe at least one event")
+	}
+
+	eventTypes := make([]models.EventType, 0, len(events))
+	for _, event := range events {
+		eventType, err := models.ParseEventType(event)
+		if err != nil {
+			return nil, err
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+	return eventTypes, nil
+}
+
+func webhookToDto(in *models.Webhook) (api.Webhook, error) {
+	events := make([]string, len(in.Events))
+	for index, event := range in.Events {
+		events[index] = string(event)
+	}
+	return api.Webhook{
+		Id:           in.ID,
+		RepositoryId: in.RepositoryID,
+		Url:          in.URL,
+		Events:       events,
+		Active:       in.Active,
+		CreatorId:    in.CreatorID,
+		CreatedAt:    in.CreatedAt.UnixMilli(),
+		UpdatedAt:    in.UpdatedAt.UnixMilli(),
+	}, nil
+}
+
+func webhookDeliveryToDto(in *models.WebhookDelivery) (api.WebhookDelivery, error) {
+	return api.WebhookDelivery{
+		Id:            in.ID,
+		WebhookId:     in.WebhookID,
+		Event:         string(in.Event),
+		Payload:       in.Payload,
+		State:         api.WebhookDeliveryState(in.State),
+		Attempts:      in.Attempts,
+		NextAttemptAt: in.NextAttemptAt.UnixMilli(),
+		ResponseCode:  in.ResponseCode,
+		Message:       in.Message,
+		CreatedAt:     in.CreatedAt.UnixMilli(),
+		UpdatedAt:     in.UpdatedAt.UnixMilli(),
+	}, nil
+}
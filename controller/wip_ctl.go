@@ -3,6 +3,7 @@ package controller
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -10,11 +11,16 @@ import (
 	"github.com/GitDataAI/jiaozifs/auth"
 	"github.com/GitDataAI/jiaozifs/auth/rbac"
 	"github.com/GitDataAI/jiaozifs/block/params"
+	"github.com/GitDataAI/jiaozifs/hooks"
 	"github.com/GitDataAI/jiaozifs/models"
 	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/protection"
+	"github.com/GitDataAI/jiaozifs/quota"
 	"github.com/GitDataAI/jiaozifs/utils"
 	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/utils/pathutil"
 	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/GitDataAI/jiaozifs/webhook"
 	"go.uber.org/fx"
 )
 
@@ -24,6 +30,8 @@ type WipController struct {
 
 	Repo                models.IRepo
 	PublicStorageConfig params.AdapterConfig
+	Emitter             webhook.Emitter
+	Quota               *quota.Manager
 }
 
 // GetWip get wip of specific repository, operator only get himself wip
@@ -178,11 +186,16 @@ func (wipCtl WipController) CommitWip(ctx context.Context, w *api.JiaozifsRespon
 		return
 	}
 
+	if !checkProtection(w, protection.NewChecker(wipCtl.Repo).CheckWrite(ctx, repository.ID, params.RefName)) {
+		return
+	}
+
 	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, wipCtl.Repo, wipCtl.PublicStorageConfig)
 	if err != nil {
 		w.Error(err)
 		return
 	}
+	workRepo.WithEmitter(wipCtl.Emitter)
 
 	err = workRepo.CheckOut(ctx, versionmgr.InWip, params.RefName)
 	if err != nil {
@@ -190,11 +203,19 @@ func (wipCtl WipController) CommitWip(ctx context.Context, w *api.JiaozifsRespon
 		return
 	}
 
-	_, err = workRepo.CommitChanges(ctx, params.Msg)
+	report, err := hooks.NewRunner(wipCtl.Repo, repository.ID, workRepo).Run(ctx, hooks.PreCommit, params.RefName, workRepo.CurBranch().CommitHash, workRepo.CurWip().CurrentTree)
 	if err != nil {
 		w.Error(err)
 		return
 	}
+	if !checkHooks(w, report.Err()) {
+		return
+	}
+
+	_, err = workRepo.CommitChanges(ctx, params.Msg)
+	if !checkProtection(w, err) {
+		return
+	}
 
 	w.JSON(wipToDto(workRepo.CurWip()), http.StatusCreated)
 }
@@ -469,6 +490,84 @@ func (wipCtl WipController) RevertWipChanges(ctx context.Context, w *api.Jiaozif
 	w.OK()
 }
 
+// ImportCar import files of unixfs dag in car file into wip, operator only import into himself wip
+func (wipCtl WipController) ImportCar(ctx context.Context, w *api.JiaozifsResponse, r *http.Request, ownerName string, repositoryName string, params api.ImportCarParams) {
+	operator, err := auth.GetOperator(ctx)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	owner, err := wipCtl.Repo.UserRepo().Get(ctx, models.NewGetUserParams().SetName(ownerName))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	repository, err := wipCtl.Repo.RepositoryRepo().Get(ctx, models.NewGetRepoParams().SetName(repositoryName).SetOwnerID(owner.ID))
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	if !wipCtl.authorizeMember(ctx, w, repository.ID, rbac.Node{
+		Permission: rbac.Permission{
+			Action:   rbacmodel.WriteObjectAction,
+			Resource: rbacmodel.RepoURArn(owner.ID.String(), repository.ID.String()),
+		},
+	}) {
+		return
+	}
+
+	if params.Path != nil {
+		err = pathutil.ValidateObjectPath(*params.Path)
+		if err != nil {
+			w.BadRequest(err.Error())
+			return
+		}
+	}
+
+	if !checkProtection(w, protection.NewChecker(wipCtl.Repo).CheckWrite(ctx, repository.ID, params.RefName)) {
+		return
+	}
+
+	workRepo, err := versionmgr.NewWorkRepositoryFromConfig(ctx, operator, repository, wipCtl.Repo, wipCtl.PublicStorageConfig)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+	workRepo.WithQuota(wipCtl.Quota)
+
+	err = workRepo.CheckOut(ctx, versionmgr.InWip, params.RefName)
+	if err != nil {
+		w.Error(err)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, versionmgr.MaxCarSize)
+	defer body.Close() //nolint
+	files, err := workRepo.ImportCar(ctx, body, utils.StringValue(params.Path), utils.BoolValue(params.IsReplace))
+	if err != nil {
+		if errors.Is(err, versionmgr.ErrEntryExit) {
+			w.Error(fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusConflict)))
+			return
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			w.Error(fmt.Errorf("%w: %w", err, api.ErrCode(http.StatusRequestEntityTooLarge)))
+			return
+		}
+		if errors.Is(err, versionmgr.ErrCarRootIsFile) || errors.Is(err, versionmgr.ErrInvalidCarPath) {
+			w.BadRequest(err.Error())
+			return
+		}
+		checkQuota(w, err)
+		return
+	}
+
+	w.JSON(files, http.StatusCreated)
+}
+
 func wipToDto(wip *models.WorkingInProcess) *api.Wip {
 	return &api.Wip{
 		BaseCommit:   wip.BaseCommit.Hex(),
@@ -0,0 +1,252 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/dustin/go-humanize"
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+)
+
+// HooksDir directory of hook definitions at the root of tree, each yaml or json file in it defines one hook, for example
+//
+//	name: labels
+//	on: [pre-commit, pre-merge]
+//	branches: [main, release/*]
+//	validators:
+//	  - type: json_schema
+//	    paths: [labels/**]
+//	    schema_path: schemas/label.json
+//	  - type: size_limit
+//	    max_size: 5GB
+//	  - type: path_pattern
+//	    deny: ["*.tmp"]
+//	  - type: required_files
+//	    files: [README.md]
+//
+// pattern without "/" matches file name in any directory, hook without branches applies to all branches.
+// hooks are read from the head of branch before operation, so a change of hooks takes effect from the next operation
+const HooksDir = ".jiaozifs/hooks"
+
+// MaxDefinitionSize definition file larger than this size is invalid
+const MaxDefinitionSize = 1 << 20
+
+var ErrInvalidHook = errors.New("invalid hook")
+
+// Event operation evaluating hooks
+type Event string
+
+const (
+	PreCommit Event = "pre-commit"
+	PreMerge  Event = "pre-merge"
+)
+
+// ValidatorType kind of built-in validator
+type ValidatorType string
+
+const (
+	// JSONSchema changed json or yaml files must match json schema
+	JSONSchema ValidatorType = "json_schema"
+	// SizeLimit changed files must not be larger than max size
+	SizeLimit ValidatorType = "size_limit"
+	// PathPattern changed files must match one of allow patterns and none of deny patterns
+	PathPattern ValidatorType = "path_pattern"
+	// RequiredFiles files must exist in tree after changes
+	RequiredFiles ValidatorType = "required_files"
+)
+
+// ValidatorConfig config of validator in definition, fields used depend on type
+type ValidatorConfig struct {
+	Type ValidatorType `yaml:"type"`
+	// Paths patterns of files the validator applies to, empty means all files. not used by required_files
+	Paths []string `yaml:"paths"`
+
+	// Schema inline json schema, SchemaPath path of json schema file in tree
+	Schema     interface{} `yaml:"schema"`
+	SchemaPath string      `yaml:"schema_path"`
+
+	// MaxSize size like 5GB or bytes
+	MaxSize string `yaml:"max_size"`
+
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+
+	Files []string `yaml:"files"`
+}
+
+// Definition hook defined in tree
+type Definition struct {
+	// Path path of definition file
+	Path       string            `yaml:"-"`
+	Name       string            `yaml:"name"`
+	On         []Event           `yaml:"on"`
+	Branches   []string          `yaml:"branches"`
+	Validators []ValidatorConfig `yaml:"validators"`
+
+	branches   []glob.Glob
+	validators []validator
+}
+
+// ParseDefinition parse and check definition, validators are compiled so that errors of config are found before evaluation
+func ParseDefinition(defPath string, r io.Reader) (*Definition, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxDefinitionSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxDefinitionSize {
+		return nil, fmt.Errorf("definition larger than %d bytes %w", MaxDefinitionSize, ErrInvalidHook)
+	}
+
+	definition := &Definition{}
+	if err = yaml.UnmarshalStrict(data, definition); err != nil {
+		return nil, fmt.Errorf("%v %w", err, ErrInvalidHook)
+	}
+	definition.Path = defPath
+	if len(definition.Name) == 0 {
+		definition.Name = strings.TrimSuffix(path.Base(defPath), path.Ext(defPath))
+	}
+
+	if len(definition.On) == 0 {
+		return nil, fmt.Errorf("hook %s must run on at least one event %w", definition.Name, ErrInvalidHook)
+	}
+	for _, event := range definition.On {
+		if event != PreCommit && event != PreMerge {
+			return nil, fmt.Errorf("unknown event %s of hook %s %w", event, definition.Name, ErrInvalidHook)
+		}
+	}
+	for _, pattern := range definition.Branches {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("branch pattern %s of hook %s %w", pattern, definition.Name, ErrInvalidHook)
+		}
+		definition.branches = append(definition.branches, g)
+	}
+	if len(definition.Validators) == 0 {
+		return nil, fmt.Errorf("hook %s has no validator %w", definition.Name, ErrInvalidHook)
+	}
+	for i, config := range definition.Validators {
+		v, err := newValidator(config)
+		if err != nil {
+			return nil, fmt.Errorf("validator %d of hook %s %v %w", i, definition.Name, err, ErrInvalidHook)
+		}
+		definition.validators = append(definition.validators, v)
+	}
+	return definition, nil
+}
+
+// Applies check whether hook runs on event of branch
+func (definition *Definition) Applies(event Event, branchName string) bool {
+	onEvent := false
+	for _, on := range definition.On {
+		onEvent = onEvent || on == event
+	}
+	if !onEvent {
+		return false
+	}
+	if len(definition.branches) == 0 {
+		return true
+	}
+	for _, g := range definition.branches {
+		if g.Match(branchName) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadDefinitions load definitions in HooksDir of tree. definition failed to parse is returned in invalid with its error
+// so that a broken hook blocks operations instead of being skipped silently
+func LoadDefinitions(ctx context.Context, fileTreeRepo models.IFileTreeRepo, workTree *versionmgr.WorkTree, store versionmgr.BlobStore) ([]*Definition, map[string]error, error) {
+	entries, err := workTree.Ls(ctx, HooksDir)
+	if errors.Is(err, versionmgr.ErrPathNotFound) || errors.Is(err, versionmgr.ErrNotDirectory) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var definitions []*Definition
+	invalid := map[string]error{}
+	for _, entry := range entries {
+		ext := strings.ToLower(path.Ext(entry.Name))
+		if entry.IsDir || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		defPath := path.Join(HooksDir, entry.Name)
+
+		blob, err := fileTreeRepo.Blob(ctx, entry.Hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader, err := store.ReadBlob(ctx, blob, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		definition, err := ParseDefinition(defPath, reader)
+		_ = reader.Close()
+		if errors.Is(err, ErrInvalidHook) {
+			invalid[defPath] = err
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, invalid, nil
+}
+
+// pathMatcher match path by glob patterns, pattern without "/" matches file name
+type pathMatcher struct {
+	globs     []glob.Glob
+	matchName []bool
+}
+
+func newPathMatcher(patterns []string) (*pathMatcher, error) {
+	matcher := &pathMatcher{}
+	for _, pattern := range patterns {
+		g, err := glob.Compile(strings.TrimPrefix(pattern, "/"), '/')
+		if err != nil {
+			return nil, fmt.Errorf("path pattern %s %w", pattern, err)
+		}
+		matcher.globs = append(matcher.globs, g)
+		matcher.matchName = append(matcher.matchName, !strings.Contains(pattern, "/"))
+	}
+	return matcher, nil
+}
+
+func (matcher *pathMatcher) empty() bool {
+	return len(matcher.globs) == 0
+}
+
+func (matcher *pathMatcher) match(fullPath string) bool {
+	for i, g := range matcher.globs {
+		target := fullPath
+		if matcher.matchName[i] {
+			target = path.Base(fullPath)
+		}
+		if g.Match(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSize parse size like 5GB, 10 MiB or 1024
+func parseSize(size string) (int64, error) {
+	value, err := humanize.ParseBytes(size)
+	if err != nil {
+		return 0, err
+	}
+	if value > 1<<62 {
+		return 0, fmt.Errorf("size %s too large", size)
+	}
+	return int64(value), nil
+}
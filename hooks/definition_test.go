@@ -0,0 +1,60 @@
+package hooks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefinition(t *testing.T) {
+	definition, err := ParseDefinition(".jiaozifs/hooks/labels.yaml", strings.NewReader(`
+on: [pre-commit]
+branches: [main, "release/*"]
+validators:
+  - type: size_limit
+    max_size: 5GB
+  - type: path_pattern
+    deny: ["*.tmp"]
+`))
+	require.NoError(t, err)
+	require.Equal(t, "labels", definition.Name)
+	require.Equal(t, ".jiaozifs/hooks/labels.yaml", definition.Path)
+	require.Len(t, definition.validators, 2)
+	require.Equal(t, int64(5000000000), definition.validators[0].(*sizeLimitValidator).maxSize)
+
+	require.True(t, definition.Applies(PreCommit, "main"))
+	require.True(t, definition.Applies(PreCommit, "release/v1"))
+	require.False(t, definition.Applies(PreCommit, "release/v1/fix"))
+	require.False(t, definition.Applies(PreCommit, "feat"))
+	require.False(t, definition.Applies(PreMerge, "main"))
+
+	t.Run("json definition", func(t *testing.T) {
+		definition, err := ParseDefinition("a.json", strings.NewReader(`{"name":"required","on":["pre-merge"],"validators":[{"type":"required_files","files":["/README.md"]}]}`))
+		require.NoError(t, err)
+		require.Equal(t, "required", definition.Name)
+		require.True(t, definition.Applies(PreMerge, "any/branch"))
+		require.Equal(t, []string{"README.md"}, definition.validators[0].(*requiredFilesValidator).files)
+	})
+
+	for name, content := range map[string]string{
+		"not yaml":          "on: [",
+		"unknown field":     "on: [pre-commit]\nvalidator: []",
+		"no event":          "validators: [{type: size_limit, max_size: 1KB}]",
+		"unknown event":     "on: [post-commit]\nvalidators: [{type: size_limit, max_size: 1KB}]",
+		"no validator":      "on: [pre-commit]",
+		"unknown validator": "on: [pre-commit]\nvalidators: [{type: lint}]",
+		"invalid size":      "on: [pre-commit]\nvalidators: [{type: size_limit, max_size: big}]",
+		"no pattern":        "on: [pre-commit]\nvalidators: [{type: path_pattern}]",
+		"no schema":         "on: [pre-commit]\nvalidators: [{type: json_schema}]",
+		"invalid schema":    "on: [pre-commit]\nvalidators: [{type: json_schema, schema: {type: 1}}]",
+		"external ref":      "on: [pre-commit]\nvalidators: [{type: json_schema, schema: {$ref: 'file:///etc/passwd'}}]",
+		"no files":          "on: [pre-commit]\nvalidators: [{type: required_files}]",
+		"invalid branch":    "on: [pre-commit]\nbranches: ['[']\nvalidators: [{type: size_limit, max_size: 1KB}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseDefinition("a.yaml", strings.NewReader(content))
+			require.ErrorIs(t, err, ErrInvalidHook)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/GitDataAI/jiaozifs/versionmgr/merkletrie"
+	"github.com/google/uuid"
+)
+
+var ErrHookFailed = errors.New("hook failed")
+
+// HookResult result of one hook
+type HookResult struct {
+	// Hook path of definition file
+	Hook       string
+	Name       string
+	Passed     bool
+	Violations []Violation
+}
+
+// Report results of hooks evaluated on operation
+type Report struct {
+	Event   Event
+	Branch  string
+	Passed  bool
+	Results []HookResult
+}
+
+// Err return FailedError if any hook failed
+func (report *Report) Err() error {
+	if report.Passed {
+		return nil
+	}
+	return &FailedError{Report: report}
+}
+
+// FailedError operation rejected by hooks, Report tell which hooks failed
+type FailedError struct {
+	Report *Report
+}
+
+func (err *FailedError) Error() string {
+	var failed []string
+	for _, result := range err.Report.Results {
+		if !result.Passed {
+			failed = append(failed, result.Name)
+		}
+	}
+	return fmt.Sprintf("%s of branch %s rejected by hooks %s", err.Report.Event, err.Report.Branch, strings.Join(failed, ","))
+}
+
+func (err *FailedError) Unwrap() error {
+	return ErrHookFailed
+}
+
+// Evaluate run hooks applied to event of branch on files added or modified by operation
+func Evaluate(ctx context.Context, definitions []*Definition, event Event, branchName string, files []File, source Source) (*Report, error) {
+	report := &Report{Event: event, Branch: branchName, Passed: true, Results: []HookResult{}}
+	for _, definition := range definitions {
+		if !definition.Applies(event, branchName) {
+			continue
+		}
+
+		result := HookResult{Hook: definition.Path, Name: definition.Name, Violations: []Violation{}}
+		for _, v := range definition.validators {
+			violations, err := v.validate(ctx, files, source)
+			if err != nil {
+				return nil, fmt.Errorf("evaluate hook %s %w", definition.Name, err)
+			}
+			result.Violations = append(result.Violations, violations...)
+		}
+		result.Passed = len(result.Violations) == 0
+		report.Passed = report.Passed && result.Passed
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// Runner evaluate hooks defined in the head of branch before operation, so that an operation can not remove or weaken
+// hooks protecting itself. changing hooks takes a separate commit before they take effect
+type Runner struct {
+	repo         models.IRepo
+	repositoryID uuid.UUID
+	store        versionmgr.BlobStore
+}
+
+func NewRunner(repo models.IRepo, repositoryID uuid.UUID, store versionmgr.BlobStore) *Runner {
+	return &Runner{repo: repo, repositoryID: repositoryID, store: store}
+}
+
+// Run evaluate hooks defined in baseCommit on changes from baseCommit to resultTree, baseCommit is the head of branch before operation
+func (runner *Runner) Run(ctx context.Context, event Event, branchName string, baseCommit hash.Hash, resultTree hash.Hash) (*Report, error) {
+	fileTreeRepo := runner.repo.FileTreeRepo(runner.repositoryID)
+	baseTree := hash.Empty
+	if !baseCommit.IsEmpty() {
+		commit, err := runner.repo.CommitRepo(runner.repositoryID).Commit(ctx, baseCommit)
+		if err != nil {
+			return nil, err
+		}
+		baseTree = commit.TreeHash
+	}
+	baseWorkTree, err := versionmgr.NewWorkTree(ctx, fileTreeRepo, models.NewRootTreeEntry(baseTree))
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, invalid, err := LoadDefinitions(ctx, fileTreeRepo, baseWorkTree, runner.store)
+	if err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 && len(invalid) == 0 {
+		return &Report{Event: event, Branch: branchName, Passed: true, Results: []HookResult{}}, nil
+	}
+
+	workTree, err := versionmgr.NewWorkTree(ctx, fileTreeRepo, models.NewRootTreeEntry(resultTree))
+	if err != nil {
+		return nil, err
+	}
+	changes, err := baseWorkTree.Diff(ctx, resultTree, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	onlyHooksChanged := true
+	for _, change := range changes.Changes() {
+		onlyHooksChanged = onlyHooksChanged && strings.HasPrefix(change.Path(), HooksDir+"/")
+		action, err := change.Action()
+		if err != nil {
+			return nil, err
+		}
+		if action == merkletrie.Delete {
+			continue
+		}
+		blob, err := fileTreeRepo.Blob(ctx, hash.Hash(change.To().Hash()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: change.Path(), Size: blob.Size})
+	}
+
+	report, err := Evaluate(ctx, definitions, event, branchName, files, &treeSource{workTree: workTree, store: runner.store})
+	if err != nil {
+		return nil, err
+	}
+	// invalid definition fail whatever event it is defined for, except operation changing hooks only which is the way to fix it
+	if onlyHooksChanged {
+		return report, nil
+	}
+	invalidPaths := make([]string, 0, len(invalid))
+	for defPath := range invalid {
+		invalidPaths = append(invalidPaths, defPath)
+	}
+	sort.Strings(invalidPaths)
+	for _, defPath := range invalidPaths {
+		report.Passed = false
+		report.Results = append(report.Results, HookResult{
+			Hook:       defPath,
+			Name:       defPath,
+			Violations: []Violation{{Path: defPath, Message: invalid[defPath].Error()}},
+		})
+	}
+	return report, nil
+}
+
+// treeSource read files of work tree
+type treeSource struct {
+	workTree *versionmgr.WorkTree
+	store    versionmgr.BlobStore
+}
+
+func (source *treeSource) Open(ctx context.Context, fullPath string) (io.ReadCloser, error) {
+	blob, _, err := source.workTree.FindBlob(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+	return source.store.ReadBlob(ctx, blob, nil)
+}
+
+func (source *treeSource) Exists(ctx context.Context, fullPath string) (bool, error) {
+	_, _, err := source.workTree.FindBlob(ctx, fullPath)
+	if errors.Is(err, versionmgr.ErrPathNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,323 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/dustin/go-humanize"
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v2"
+)
+
+// MaxValidateSize file larger than this size is rejected by json_schema validator instead of being read
+var MaxValidateSize int64 = 32 << 20
+
+// File added or modified file in changes
+type File struct {
+	Path string
+	Size int64
+}
+
+// Source read files of tree after changes applied
+type Source interface {
+	// Open read file at path, return versionmgr.ErrPathNotFound if file not exit
+	Open(ctx context.Context, fullPath string) (io.ReadCloser, error)
+	Exists(ctx context.Context, fullPath string) (bool, error)
+}
+
+// Violation file breaks rule of validator
+type Violation struct {
+	Validator ValidatorType
+	Path      string
+	Message   string
+}
+
+type validator interface {
+	validate(ctx context.Context, files []File, source Source) ([]Violation, error)
+}
+
+func newValidator(config ValidatorConfig) (validator, error) {
+	paths, err := newPathMatcher(config.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Type {
+	case JSONSchema:
+		if (config.Schema == nil) == (len(config.SchemaPath) == 0) {
+			return nil, errors.New("one of schema and schema_path must be specified")
+		}
+		v := &jsonSchemaValidator{paths: paths, schemaPath: versionmgr.CleanPath(config.SchemaPath)}
+		if config.Schema != nil {
+			v.schema, err = compileSchema(normalizeYAML(config.Schema))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	case SizeLimit:
+		if len(config.MaxSize) == 0 {
+			return nil, errors.New("max_size must be specified")
+		}
+		maxSize, err := parseSize(config.MaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_size %w", err)
+		}
+		return &sizeLimitValidator{paths: paths, maxSize: maxSize}, nil
+	case PathPattern:
+		if len(config.Allow) == 0 && len(config.Deny) == 0 {
+			return nil, errors.New("one of allow and deny must be specified")
+		}
+		allow, err := newPathMatcher(config.Allow)
+		if err != nil {
+			return nil, err
+		}
+		deny, err := newPathMatcher(config.Deny)
+		if err != nil {
+			return nil, err
+		}
+		return &pathPatternValidator{paths: paths, allow: allow, deny: deny}, nil
+	case RequiredFiles:
+		if len(config.Files) == 0 {
+			return nil, errors.New("files must be specified")
+		}
+		files := make([]string, len(config.Files))
+		for i, file := range config.Files {
+			files[i] = versionmgr.CleanPath(file)
+		}
+		return &requiredFilesValidator{files: files}, nil
+	}
+	return nil, fmt.Errorf("unknown validator type %s", config.Type)
+}
+
+// filterFiles return files matched by paths, all files if paths is empty
+func filterFiles(paths *pathMatcher, files []File) []File {
+	if paths.empty() {
+		return files
+	}
+	var matched []File
+	for _, file := range files {
+		if paths.match(file.Path) {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
+
+type jsonSchemaValidator struct {
+	paths      *pathMatcher
+	schema     *gojsonschema.Schema
+	schemaPath string
+}
+
+var errInvalidSchema = errors.New("invalid schema")
+
+func (v *jsonSchemaValidator) loadSchema(ctx context.Context, source Source) (*gojsonschema.Schema, error) {
+	if v.schema != nil {
+		return v.schema, nil
+	}
+	reader, err := source.Open(ctx, v.schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close() //nolint
+	document, err := decodeDocument(v.schemaPath, io.LimitReader(reader, MaxDefinitionSize))
+	if err != nil {
+		return nil, err
+	}
+	v.schema, err = compileSchema(document)
+	return v.schema, err
+}
+
+// compileSchema compile json schema, only references inside the schema are allowed so that no file or url is loaded
+func compileSchema(document interface{}) (*gojsonschema.Schema, error) {
+	if ref, ok := findExternalRef(document); ok {
+		return nil, fmt.Errorf("%w: external reference %s is not allowed", errInvalidSchema, ref)
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(document))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidSchema, err)
+	}
+	return schema, nil
+}
+
+func findExternalRef(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if ref, ok := item.(string); ok && (key == "$ref" || key == "$id" || key == "id") && !strings.HasPrefix(ref, "#") {
+				return ref, true
+			}
+			if ref, ok := findExternalRef(item); ok {
+				return ref, true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if ref, ok := findExternalRef(item); ok {
+				return ref, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (v *jsonSchemaValidator) validate(ctx context.Context, files []File, source Source) ([]Violation, error) {
+	files = filterFiles(v.paths, files)
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	schema, err := v.loadSchema(ctx, source)
+	if errors.Is(err, versionmgr.ErrPathNotFound) {
+		return []Violation{{Validator: JSONSchema, Path: v.schemaPath, Message: "schema file not found"}}, nil
+	}
+	if errors.Is(err, errInvalidDocument) || errors.Is(err, errInvalidSchema) {
+		return []Violation{{Validator: JSONSchema, Path: v.schemaPath, Message: err.Error()}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	for _, file := range files {
+		if file.Size > MaxValidateSize {
+			violations = append(violations, Violation{Validator: JSONSchema, Path: file.Path, Message: fmt.Sprintf("file larger than %s can not be validated", humanize.IBytes(uint64(MaxValidateSize)))})
+			continue
+		}
+
+		reader, err := source.Open(ctx, file.Path)
+		if err != nil {
+			return nil, err
+		}
+		document, err := decodeDocument(file.Path, reader)
+		_ = reader.Close()
+		if errors.Is(err, errInvalidDocument) {
+			violations = append(violations, Violation{Validator: JSONSchema, Path: file.Path, Message: err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := schema.Validate(gojsonschema.NewGoLoader(document))
+		if err != nil {
+			return nil, err
+		}
+		for _, resultErr := range result.Errors() {
+			violations = append(violations, Violation{Validator: JSONSchema, Path: file.Path, Message: resultErr.String()})
+		}
+	}
+	return violations, nil
+}
+
+var errInvalidDocument = errors.New("invalid document")
+
+// decodeDocument decode yaml file by extension, otherwise decode as json
+func decodeDocument(fullPath string, reader io.Reader) (interface{}, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	switch strings.ToLower(path.Ext(fullPath)) {
+	case ".yaml", ".yml":
+		if err = yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidDocument, err)
+		}
+		return normalizeYAML(document), nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidDocument, err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("%w: unexpected content after json value", errInvalidDocument)
+	}
+	return document, nil
+}
+
+// normalizeYAML convert maps decoded by yaml to map[string]interface{} as json does
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalizeYAML(item)
+		}
+		return result
+	}
+	return value
+}
+
+type sizeLimitValidator struct {
+	paths   *pathMatcher
+	maxSize int64
+}
+
+func (v *sizeLimitValidator) validate(_ context.Context, files []File, _ Source) ([]Violation, error) {
+	var violations []Violation
+	for _, file := range filterFiles(v.paths, files) {
+		if file.Size > v.maxSize {
+			violations = append(violations, Violation{
+				Validator: SizeLimit,
+				Path:      file.Path,
+				Message:   fmt.Sprintf("size %s exceed limit %s", humanize.IBytes(uint64(file.Size)), humanize.IBytes(uint64(v.maxSize))),
+			})
+		}
+	}
+	return violations, nil
+}
+
+type pathPatternValidator struct {
+	paths *pathMatcher
+	allow *pathMatcher
+	deny  *pathMatcher
+}
+
+func (v *pathPatternValidator) validate(_ context.Context, files []File, _ Source) ([]Violation, error) {
+	var violations []Violation
+	for _, file := range filterFiles(v.paths, files) {
+		if v.deny.match(file.Path) {
+			violations = append(violations, Violation{Validator: PathPattern, Path: file.Path, Message: "path is denied"})
+			continue
+		}
+		if !v.allow.empty() && !v.allow.match(file.Path) {
+			violations = append(violations, Violation{Validator: PathPattern, Path: file.Path, Message: "path is not allowed"})
+		}
+	}
+	return violations, nil
+}
+
+type requiredFilesValidator struct {
+	files []string
+}
+
+func (v *requiredFilesValidator) validate(ctx context.Context, _ []File, source Source) ([]Violation, error) {
+	var violations []Violation
+	for _, file := range v.files {
+		exist, err := source.Exists(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			violations = append(violations, Violation{Validator: RequiredFiles, Path: file, Message: "required file not found"})
+		}
+	}
+	return violations, nil
+}
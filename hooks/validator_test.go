@@ -0,0 +1,134 @@
+package hooks
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/versionmgr"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySource map[string]string
+
+func (source memorySource) Open(_ context.Context, fullPath string) (io.ReadCloser, error) {
+	content, ok := source[fullPath]
+	if !ok {
+		return nil, versionmgr.ErrPathNotFound
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (source memorySource) Exists(_ context.Context, fullPath string) (bool, error) {
+	_, ok := source[fullPath]
+	return ok, nil
+}
+
+func mustParse(t *testing.T, content string) *Definition {
+	definition, err := ParseDefinition(".jiaozifs/hooks/test.yaml", strings.NewReader(content))
+	require.NoError(t, err)
+	return definition
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+	source := memorySource{
+		"schemas/label.json": `{"type":"object","required":["label"],"properties":{"label":{"type":"string"}}}`,
+		"labels/a.json":      `{"label":"cat"}`,
+		"labels/b.json":      `{"label":1}`,
+		"labels/c.yaml":      "label: dog\n",
+		"labels/d.json":      `{"label":`,
+		"data/big.bin":       "",
+		"data/x.tmp":         "",
+		"other/y.txt":        "",
+		"README.md":          "",
+	}
+	files := []File{
+		{Path: "labels/a.json", Size: 15},
+		{Path: "labels/b.json", Size: 11},
+		{Path: "labels/c.yaml", Size: 11},
+		{Path: "labels/d.json", Size: 9},
+		{Path: "data/big.bin", Size: 6 << 30},
+		{Path: "data/x.tmp", Size: 1},
+		{Path: "other/y.txt", Size: 1},
+	}
+
+	t.Run("json schema", func(t *testing.T) {
+		definition := mustParse(t, `
+on: [pre-commit]
+validators:
+  - type: json_schema
+    paths: ["labels/**"]
+    schema_path: schemas/label.json
+`)
+		report, err := Evaluate(ctx, []*Definition{definition}, PreCommit, "main", files, source)
+		require.NoError(t, err)
+		require.False(t, report.Passed)
+		require.Error(t, report.Err())
+		violations := report.Results[0].Violations
+		require.Len(t, violations, 2)
+		require.Equal(t, "labels/b.json", violations[0].Path)
+		require.Contains(t, violations[0].Message, "label")
+		require.Equal(t, "labels/d.json", violations[1].Path)
+	})
+
+	t.Run("inline schema and missing schema file", func(t *testing.T) {
+		definition := mustParse(t, `
+on: [pre-commit]
+validators:
+  - type: json_schema
+    paths: ["*.yaml"]
+    schema: {type: object, properties: {label: {enum: [dog]}}}
+  - type: json_schema
+    paths: ["a.json"]
+    schema_path: schemas/missing.json
+`)
+		report, err := Evaluate(ctx, []*Definition{definition}, PreCommit, "main", files, source)
+		require.NoError(t, err)
+		require.Equal(t, []Violation{{Validator: JSONSchema, Path: "schemas/missing.json", Message: "schema file not found"}}, report.Results[0].Violations)
+	})
+
+	t.Run("size limit path pattern and required files", func(t *testing.T) {
+		definition := mustParse(t, `
+name: governance
+on: [pre-commit, pre-merge]
+validators:
+  - type: size_limit
+    max_size: 5GB
+  - type: path_pattern
+    allow: ["labels/**", "data/**"]
+    deny: ["*.tmp"]
+  - type: required_files
+    files: [README.md, LICENSE]
+`)
+		report, err := Evaluate(ctx, []*Definition{definition}, PreMerge, "main", files, source)
+		require.NoError(t, err)
+		require.Equal(t, "governance", report.Results[0].Name)
+		require.Equal(t, []Violation{
+			{Validator: SizeLimit, Path: "data/big.bin", Message: "size 6.0 GiB exceed limit 4.7 GiB"},
+			{Validator: PathPattern, Path: "data/x.tmp", Message: "path is denied"},
+			{Validator: PathPattern, Path: "other/y.txt", Message: "path is not allowed"},
+			{Validator: RequiredFiles, Path: "LICENSE", Message: "required file not found"},
+		}, report.Results[0].Violations)
+	})
+
+	t.Run("hook not applied", func(t *testing.T) {
+		definition := mustParse(t, `
+on: [pre-merge]
+branches: [main]
+validators:
+  - type: size_limit
+    max_size: 1B
+`)
+		report, err := Evaluate(ctx, []*Definition{definition}, PreCommit, "main", files, source)
+		require.NoError(t, err)
+		require.True(t, report.Passed)
+		require.Empty(t, report.Results)
+		require.NoError(t, report.Err())
+
+		report, err = Evaluate(ctx, []*Definition{definition}, PreMerge, "dev", files, source)
+		require.NoError(t, err)
+		require.True(t, report.Passed)
+	})
+}
@@ -0,0 +1,176 @@
+package integrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/actions"
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func ActionSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var actionID openapi_types.UUID
+
+	received := make(chan *actions.Input, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		input := &actions.Input{}
+		_ = json.NewDecoder(r.Body).Decode(input)
+		received <- input
+		_, _ = w.Write([]byte("index rebuilt"))
+	}))
+	return func(c convey.C) {
+		userName := "actionman"
+		repoName := "actionrepo"
+		branchName := "main"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, branchName)
+			uploadContent(ctx, client, userName, repoName, branchName, "data/a.txt", "a")
+		})
+
+		c.Convey("create action", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.CreateAction(ctx, userName, repoName, api.CreateActionJSONRequestBody{
+					Name:   "index",
+					Type:   api.ActionCreationTypeHttp,
+					Url:    utils.String(server.URL),
+					Events: []string{"post-commit"},
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to create action with command not configured", func() {
+				resp, err := client.CreateAction(ctx, userName, repoName, api.CreateActionJSONRequestBody{
+					Name:    "index",
+					Type:    api.ActionCreationTypeCommand,
+					Command: utils.String("rm"),
+					Events:  []string{"post-commit"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to create action with unknown event", func() {
+				resp, err := client.CreateAction(ctx, userName, repoName, api.CreateActionJSONRequestBody{
+					Name:   "index",
+					Type:   api.ActionCreationTypeHttp,
+					Url:    utils.String(server.URL),
+					Events: []string{"pre-commit"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to create action", func() {
+				resp, err := client.CreateAction(ctx, userName, repoName, api.CreateActionJSONRequestBody{
+					Name:   "index",
+					Type:   api.ActionCreationTypeHttp,
+					Url:    utils.String(server.URL),
+					Events: []string{"post-merge"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseCreateActionResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.Active, convey.ShouldBeTrue)
+				convey.So(result.JSON201.Type, convey.ShouldEqual, api.ActionTypeHttp)
+				actionID = result.JSON201.Id
+			})
+		})
+
+		c.Convey("list and update action", func(c convey.C) {
+			c.Convey("list actions", func() {
+				resp, err := client.ListActions(ctx, userName, repoName, &api.ListActionsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListActionsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+			})
+
+			c.Convey("fail to set command of http action", func() {
+				resp, err := client.UpdateAction(ctx, userName, repoName, actionID, api.UpdateActionJSONRequestBody{
+					Command: utils.String("rm"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("update action", func() {
+				resp, err := client.UpdateAction(ctx, userName, repoName, actionID, api.UpdateActionJSONRequestBody{
+					Events: &[]string{"post-commit"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseUpdateActionResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Events, convey.ShouldResemble, []string{"post-commit"})
+			})
+		})
+
+		c.Convey("run action after commit", func() {
+			_ = commitWip(ctx, client, userName, repoName, branchName, "commit wip")
+
+			select {
+			case input := <-received:
+				convey.So(string(input.Event), convey.ShouldEqual, "post-commit")
+				convey.So(input.Action, convey.ShouldEqual, "index")
+				convey.So(input.Changes, convey.ShouldHaveLength, 1)
+				convey.So(input.Changes[0].Path, convey.ShouldEqual, "data/a.txt")
+				convey.So(input.Changes[0].Action, convey.ShouldEqual, "insert")
+			case <-time.After(time.Second * 30):
+				convey.So("timeout to receive action input", convey.ShouldBeEmpty)
+			}
+
+			var run *api.ActionRun
+			for i := 0; i < 10 && run == nil; i++ {
+				resp, err := client.ListActionRuns(ctx, userName, repoName, actionID, &api.ListActionRunsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				result, err := api.ParseListActionRunsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+				if result.JSON200.Results[0].State == api.ActionRunStateSuccess {
+					run = &result.JSON200.Results[0]
+				}
+				time.Sleep(time.Millisecond * 500)
+			}
+			convey.So(run, convey.ShouldNotBeNil)
+
+			resp, err := client.GetActionRun(ctx, userName, repoName, actionID, run.Id)
+			convey.So(err, convey.ShouldBeNil)
+			result, err := api.ParseGetActionRunResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(result.JSON200.Logs, convey.ShouldEqual, "index rebuilt")
+			convey.So(*result.JSON200.ResponseCode, convey.ShouldEqual, http.StatusOK)
+			convey.So(result.JSON200.CommitHash, convey.ShouldNotBeNil)
+		})
+
+		c.Convey("delete action", func() {
+			resp, err := client.DeleteAction(ctx, userName, repoName, actionID)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			resp, err = client.GetAction(ctx, userName, repoName, actionID)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+		})
+	}
+}
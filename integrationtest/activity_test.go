@@ -0,0 +1,144 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func ActivitySpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	return func(c convey.C) {
+		userName := "activityman"
+		repoName := "activityrepo"
+		featBranch := "feat/activity"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "a.txt", "a")
+			uploadContent(ctx, client, userName, repoName, "main", "data/b.csv", "1111")
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			uploadContent(ctx, client, userName, repoName, featBranch, "feat.txt", "feat")
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add feat")
+
+			uploadContent(ctx, client, userName, repoName, "main", "a.txt", "abc")
+			_ = commitWip(ctx, client, userName, repoName, "main", "update a")
+
+			mrSeq := createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+			resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+				Msg: "merge feat",
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+		})
+
+		c.Convey("get activity", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.GetRepositoryActivity(ctx, userName, repoName, &api.GetRepositoryActivityParams{})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to get activity with invalid range", func() {
+				resp, err := client.GetRepositoryActivity(ctx, userName, repoName, &api.GetRepositoryActivityParams{
+					Since: utils.Int64(2000),
+					Until: utils.Int64(1000),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to get activity", func() {
+				bucket := api.ActivityBucketUnitMonth
+				resp, err := client.GetRepositoryActivity(ctx, userName, repoName, &api.GetRepositoryActivityParams{
+					Bucket: &bucket,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetRepositoryActivityResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Bucket, convey.ShouldEqual, api.ActivityBucketUnitMonth)
+				convey.So(result.JSON200.Contributors, convey.ShouldHaveLength, 1)
+
+				contributor := result.JSON200.Contributors[0]
+				convey.So(contributor.Author, convey.ShouldEqual, userName)
+				convey.So(contributor.Commits, convey.ShouldEqual, 4)
+				convey.So(contributor.FilesAdded, convey.ShouldEqual, 4)
+				convey.So(contributor.FilesModified, convey.ShouldEqual, 1)
+				convey.So(contributor.BytesAdded, convey.ShouldEqual, 16)
+				convey.So(contributor.BytesRemoved, convey.ShouldEqual, 1)
+				convey.So(contributor.MergeRequestsOpened, convey.ShouldEqual, 1)
+				convey.So(contributor.MergeRequestsMerged, convey.ShouldEqual, 1)
+
+				convey.So(len(result.JSON200.Periods), convey.ShouldBeGreaterThan, 0)
+				convey.So(result.JSON200.Periods[0].Contributors[0].Author, convey.ShouldEqual, userName)
+			})
+
+			c.Convey("success to get activity of other author", func() {
+				resp, err := client.GetRepositoryActivity(ctx, userName, repoName, &api.GetRepositoryActivityParams{
+					Author: utils.String("nobody"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetRepositoryActivityResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Contributors, convey.ShouldHaveLength, 0)
+				convey.So(result.JSON200.Periods, convey.ShouldHaveLength, 0)
+			})
+		})
+
+		c.Convey("list commit stats", func(c convey.C) {
+			c.Convey("success to list commit stats", func() {
+				resp, err := client.ListCommitStats(ctx, userName, repoName, &api.ListCommitStatsParams{
+					Amount: utils.Int(2),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListCommitStatsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Pagination.HasMore, convey.ShouldBeTrue)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 2)
+
+				merge := result.JSON200.Results[0]
+				convey.So(merge.IsMerge, convey.ShouldBeTrue)
+				convey.So(merge.FilesAdded, convey.ShouldEqual, 1)
+				convey.So(merge.BytesAdded, convey.ShouldEqual, 4)
+				convey.So(merge.CommitHash, convey.ShouldEqual, getBranch(ctx, client, userName, repoName, "main").CommitHash)
+
+				update := result.JSON200.Results[1]
+				convey.So(update.IsMerge, convey.ShouldBeFalse)
+				convey.So(update.FilesModified, convey.ShouldEqual, 1)
+				convey.So(update.BytesAdded, convey.ShouldEqual, 3)
+				convey.So(update.BytesRemoved, convey.ShouldEqual, 1)
+			})
+
+			c.Convey("success to list commit stats of other author", func() {
+				resp, err := client.ListCommitStats(ctx, userName, repoName, &api.ListCommitStatsParams{
+					Author: utils.String("nobody"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListCommitStatsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 0)
+			})
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package integrationtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/models/rbacmodel"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func AuditSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	return func(c convey.C) {
+		userName := "auditman"
+		otherName := "auditother"
+		repoName := "auditrepo"
+		branchName := "feat/audit"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, otherName)
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createBranch(ctx, client, userName, repoName, "main", branchName)
+
+			resp, err := client.DeleteBranch(ctx, userName, repoName, &api.DeleteBranchParams{RefName: branchName})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+		})
+
+		c.Convey("list repo audit logs", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.ListRepoAuditLogs(ctx, userName, repoName, &api.ListRepoAuditLogsParams{})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to list audit logs in non exit repo", func() {
+				resp, err := client.ListRepoAuditLogs(ctx, userName, "fakerepo", &api.ListRepoAuditLogsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("success to find who delete branch", func() {
+				resp, err := client.ListRepoAuditLogs(ctx, userName, repoName, &api.ListRepoAuditLogsParams{
+					Action: utils.String(rbacmodel.DeleteBranchAction),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListRepoAuditLogsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+				auditLog := result.JSON200.Results[0]
+				convey.So(*auditLog.ActorName, convey.ShouldEqual, userName)
+				convey.So(auditLog.AuthMethod, convey.ShouldEqual, "jwt")
+				convey.So(auditLog.Operation, convey.ShouldEqual, "deleteBranch")
+				convey.So(auditLog.Method, convey.ShouldEqual, http.MethodDelete)
+				convey.So(auditLog.Outcome, convey.ShouldEqual, api.AuditLogOutcomeSuccess)
+				convey.So(auditLog.RequestId, convey.ShouldNotBeEmpty)
+			})
+
+			c.Convey("export repo audit logs", func() {
+				resp, err := client.ExportRepoAuditLogs(ctx, userName, repoName, &api.ExportRepoAuditLogsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+				defer resp.Body.Close() //nolint
+
+				actions := map[string]bool{}
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					auditLog := api.AuditLog{}
+					convey.So(json.Unmarshal(scanner.Bytes(), &auditLog), convey.ShouldBeNil)
+					actions[auditLog.Action] = true
+				}
+				convey.So(actions[rbacmodel.CreateBranchAction], convey.ShouldBeTrue)
+				convey.So(actions[rbacmodel.DeleteBranchAction], convey.ShouldBeTrue)
+			})
+
+			c.Convey("fail to list system audit logs without admin", func() {
+				resp, err := client.ListAuditLogs(ctx, &api.ListAuditLogsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldNotEqual, http.StatusOK)
+			})
+
+			c.Convey("fail to list audit logs of other's repo", func() {
+				loginAndSwitch(ctx, client, otherName, false)
+				resp, err := client.ListRepoAuditLogs(ctx, userName, repoName, &api.ListRepoAuditLogsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldNotEqual, http.StatusOK)
+				loginAndSwitch(ctx, client, userName, false)
+			})
+		})
+	}
+}
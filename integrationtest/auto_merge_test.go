@@ -0,0 +1,153 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func AutoMergeSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	var commitHash string
+	return func(c convey.C) {
+		userName := "automergeman"
+		repoName := "automergerepo"
+		featBranch := "feat/automerge"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add b")
+			commitHash = getBranch(ctx, client, userName, repoName, featBranch).CommitHash
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+
+			resp, err := client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+				Pattern:              "main",
+				RequiredStatusChecks: &[]string{"quality"},
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+		})
+
+		c.Convey("enable auto merge", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.EnableAutoMerge(ctx, userName, repoName, mrSeq, api.EnableAutoMergeJSONRequestBody{
+					Msg: "auto merge",
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to enable auto merge of non exit merge request", func() {
+				resp, err := client.EnableAutoMerge(ctx, userName, repoName, 100, api.EnableAutoMergeJSONRequestBody{
+					Msg: "auto merge",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("fail to enable auto merge with invalid resolution", func() {
+				resp, err := client.EnableAutoMerge(ctx, userName, repoName, mrSeq, api.EnableAutoMergeJSONRequestBody{
+					Msg:             "auto merge",
+					ConflictResolve: &map[string]string{"b.txt": "middle"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to get auto merge not enabled", func() {
+				resp, err := client.GetAutoMerge(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("success to enable auto merge", func() {
+				resp, err := client.EnableAutoMerge(ctx, userName, repoName, mrSeq, api.EnableAutoMergeJSONRequestBody{
+					Msg: "auto merge",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseEnableAutoMergeResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.Msg, convey.ShouldEqual, "auto merge")
+			})
+
+			c.Convey("record reason when check not passed", func() {
+				var reason *string
+				for i := 0; i < 30 && reason == nil; i++ {
+					resp, err := client.GetAutoMerge(ctx, userName, repoName, mrSeq)
+					convey.So(err, convey.ShouldBeNil)
+					result, err := api.ParseGetAutoMergeResponse(resp)
+					convey.So(err, convey.ShouldBeNil)
+					reason = result.JSON200.Reason
+					time.Sleep(time.Second)
+				}
+				convey.So(reason, convey.ShouldNotBeNil)
+				convey.So(*reason, convey.ShouldContainSubstring, "quality")
+			})
+		})
+
+		c.Convey("disable auto merge", func(c convey.C) {
+			c.Convey("success to disable auto merge", func() {
+				resp, err := client.DisableAutoMerge(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				resp, err = client.DisableAutoMerge(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+		})
+
+		c.Convey("auto merge when ready", func(c convey.C) {
+			c.Convey("success to merge after check passed", func() {
+				resp, err := client.EnableAutoMerge(ctx, userName, repoName, mrSeq, api.EnableAutoMergeJSONRequestBody{
+					Msg: "auto merge",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				resp, err = client.CreateCommitStatus(ctx, userName, repoName, commitHash, api.CreateCommitStatusJSONRequestBody{
+					Context: "quality",
+					State:   api.CommitStatusCreationStateSuccess,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				mergeStatus := int(models.MergeStateInit)
+				for i := 0; i < 30 && mergeStatus != int(models.MergeStateMerged); i++ {
+					time.Sleep(time.Second)
+					resp, err := client.GetMergeRequest(ctx, userName, repoName, mrSeq)
+					convey.So(err, convey.ShouldBeNil)
+					result, err := api.ParseGetMergeRequestResponse(resp)
+					convey.So(err, convey.ShouldBeNil)
+					mergeStatus = result.JSON200.MergeStatus
+				}
+				convey.So(mergeStatus, convey.ShouldEqual, int(models.MergeStateMerged))
+
+				resp, err = client.GetAutoMerge(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+		})
+	}
+}
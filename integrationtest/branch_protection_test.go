@@ -0,0 +1,232 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func BranchProtectionSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var (
+		protectionID openapi_types.UUID
+		mrSeq        uint64
+	)
+	return func(c convey.C) {
+		userName := "protectman"
+		otherName := "protectother"
+		repoName := "protectrepo"
+		featBranch := "feat/protect"
+		releaseBranch := "release/v1"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, otherName)
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add b")
+			_ = createBranch(ctx, client, userName, repoName, "main", releaseBranch)
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+		})
+
+		c.Convey("create branch protection", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+					Pattern: "main",
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to create protection with invalid pattern", func() {
+				resp, err := client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+					Pattern: "release/[a",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to create protection with non exit merger", func() {
+				resp, err := client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+					Pattern:        "main",
+					AllowedMergers: &[]string{"mockuser"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to create protection in others repo", func() {
+				resp, err := client.CreateBranchProtection(ctx, "jimmy", "happygo", api.CreateBranchProtectionJSONRequestBody{
+					Pattern: "main",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("success to create protection", func() {
+				resp, err := client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+					Pattern:             "main",
+					RequireMergeRequest: utils.Bool(true),
+					BlockDeletion:       utils.Bool(true),
+					AllowedMergers:      &[]string{otherName},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseCreateBranchProtectionResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.AllowedMergers, convey.ShouldResemble, []string{otherName})
+				protectionID = result.JSON201.Id
+
+				resp, err = client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+					Pattern:       "release/**",
+					BlockDeletion: utils.Bool(true),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+			})
+
+			c.Convey("fail to create protection with exit pattern", func() {
+				resp, err := client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+					Pattern: "main",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusConflict)
+			})
+		})
+
+		c.Convey("list and get branch protection", func(c convey.C) {
+			c.Convey("success to list protections", func() {
+				resp, err := client.ListBranchProtections(ctx, userName, repoName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListBranchProtectionsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON200, convey.ShouldHaveLength, 2)
+			})
+
+			c.Convey("success to get protection", func() {
+				resp, err := client.GetBranchProtection(ctx, userName, repoName, protectionID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetBranchProtectionResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Pattern, convey.ShouldEqual, "main")
+				convey.So(result.JSON200.RequireMergeRequest, convey.ShouldBeTrue)
+			})
+		})
+
+		c.Convey("enforce branch protection", func(c convey.C) {
+			c.Convey("fail to upload object to protected branch", func() {
+				resp, err := client.UploadObjectWithBody(ctx, userName, repoName, &api.UploadObjectParams{
+					RefName: "main",
+					Path:    "c.txt",
+				}, "application/octet-stream", nil)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("fail to delete object in protected branch", func() {
+				resp, err := client.DeleteObject(ctx, userName, repoName, &api.DeleteObjectParams{
+					RefName: "main",
+					Path:    "a.txt",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("fail to commit wip to protected branch", func() {
+				resp, err := client.CommitWip(ctx, userName, repoName, &api.CommitWipParams{
+					RefName: "main",
+					Msg:     "direct commit",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("fail to delete protected branch", func() {
+				resp, err := client.DeleteBranch(ctx, userName, repoName, &api.DeleteBranchParams{RefName: releaseBranch})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("success to write unprotected branch", func() {
+				_ = uploadObject(ctx, client, userName, repoName, featBranch, "c.txt", true)
+			})
+
+			c.Convey("fail to merge by user not allowed", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge feat",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+		})
+
+		c.Convey("update branch protection", func(c convey.C) {
+			c.Convey("fail to update protection to invalid pattern", func() {
+				resp, err := client.UpdateBranchProtection(ctx, userName, repoName, protectionID, api.UpdateBranchProtectionJSONRequestBody{
+					Pattern: utils.String("release/[a"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to allow merger", func() {
+				resp, err := client.UpdateBranchProtection(ctx, userName, repoName, protectionID, api.UpdateBranchProtectionJSONRequestBody{
+					AllowedMergers: &[]string{otherName, userName},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseUpdateBranchProtectionResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.AllowedMergers, convey.ShouldResemble, []string{otherName, userName})
+			})
+
+			c.Convey("success to merge into protected branch", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge feat",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+		})
+
+		c.Convey("delete branch protection", func(c convey.C) {
+			c.Convey("success to delete protection", func() {
+				resp, err := client.DeleteBranchProtection(ctx, userName, repoName, protectionID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+
+			c.Convey("fail to delete non exit protection", func() {
+				resp, err := client.DeleteBranchProtection(ctx, userName, repoName, protectionID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("success to write branch after protection deleted", func() {
+				_ = uploadObject(ctx, client, userName, repoName, "main", "d.txt", true)
+			})
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/auth/aksk"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func CommitStatusSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var validatorClient *api.Client
+	var mrSeq uint64
+	var commitHash string
+	return func(c convey.C) {
+		userName := "statusman"
+		repoName := "statusrepo"
+		featBranch := "feat/status"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add b")
+			commitHash = getBranch(ctx, client, userName, repoName, featBranch).CommitHash
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+
+			resp, err := client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+				Pattern:              "main",
+				RequiredStatusChecks: &[]string{"quality"},
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+			credential := createAksk(ctx, client)
+			validatorClient, err = api.NewClient(urlStr+apiimpl.APIV1Prefix, api.WithRequestEditorFn(func(_ context.Context, req *http.Request) error {
+				return aksk.NewV0Signer(credential.AccessKey, credential.SecretKey).Sign(req)
+			}))
+			convey.So(err, convey.ShouldBeNil)
+		})
+
+		c.Convey("report status", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.CreateCommitStatus(ctx, userName, repoName, commitHash, api.CreateCommitStatusJSONRequestBody{
+					Context: "quality",
+					State:   api.CommitStatusCreationStatePending,
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to report status of non exit commit", func() {
+				resp, err := validatorClient.CreateCommitStatus(ctx, userName, repoName, "aaaaaaaa", api.CreateCommitStatusJSONRequestBody{
+					Context: "quality",
+					State:   api.CommitStatusCreationStatePending,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("fail to report status without context", func() {
+				resp, err := validatorClient.CreateCommitStatus(ctx, userName, repoName, commitHash, api.CreateCommitStatusJSONRequestBody{
+					Context: " ",
+					State:   api.CommitStatusCreationStatePending,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to report pending status with aksk", func() {
+				resp, err := validatorClient.CreateCommitStatus(ctx, userName, repoName, commitHash, api.CreateCommitStatusJSONRequestBody{
+					Context:     "quality",
+					State:       api.CommitStatusCreationStatePending,
+					TargetUrl:   utils.String("http://ci.example.com/jobs/1"),
+					Description: utils.String("checking null values"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseCreateCommitStatusResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.CommitHash, convey.ShouldEqual, commitHash)
+				convey.So(result.JSON201.State, convey.ShouldEqual, api.CommitStatusStatePending)
+			})
+
+			c.Convey("pending combined status of branch", func() {
+				resp, err := client.GetCombinedStatus(ctx, userName, repoName, &api.GetCombinedStatusParams{
+					Ref:  utils.String(featBranch),
+					Type: api.RefTypeBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetCombinedStatusResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.State, convey.ShouldEqual, api.CombinedStatusStatePending)
+				convey.So(result.JSON200.TotalCount, convey.ShouldEqual, 1)
+			})
+
+			c.Convey("fail to get combined status of wip", func() {
+				resp, err := client.GetCombinedStatus(ctx, userName, repoName, &api.GetCombinedStatusParams{
+					Ref:  utils.String(featBranch),
+					Type: api.RefTypeWip,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to merge with pending check", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge feat",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("success to overwrite status", func() {
+				resp, err := validatorClient.CreateCommitStatus(ctx, userName, repoName, commitHash, api.CreateCommitStatusJSONRequestBody{
+					Context: "quality",
+					State:   api.CommitStatusCreationStateSuccess,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				resp, err = client.ListCommitStatuses(ctx, userName, repoName, commitHash)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListCommitStatusesResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON200, convey.ShouldHaveLength, 1)
+				convey.So((*result.JSON200)[0].State, convey.ShouldEqual, api.CommitStatusStateSuccess)
+			})
+		})
+
+		c.Convey("status of merge request", func(c convey.C) {
+			c.Convey("success combined status in merge request", func() {
+				resp, err := client.GetMergeRequest(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetMergeRequestResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Status.State, convey.ShouldEqual, api.CombinedStatusStateSuccess)
+				convey.So(result.JSON200.Status.CommitHash, convey.ShouldEqual, commitHash)
+			})
+
+			c.Convey("success to merge after check passed", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge feat",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package integrationtest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func ConflictResolutionSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	var blobHash string
+	return func(c convey.C) {
+		userName := "resolveman"
+		repoName := "resolverepo"
+		featBranch := "feat/resolve"
+		resolvedContent := "hand merged content"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = uploadObject(ctx, client, userName, repoName, "main", "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "a.txt", true)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "c.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "update a b in feat")
+
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = uploadObject(ctx, client, userName, repoName, "main", "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "update a b in main")
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+		})
+
+		c.Convey("upload resolution", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.UploadConflictResolutionWithBody(ctx, userName, repoName, mrSeq, &api.UploadConflictResolutionParams{
+					Path: "a.txt",
+				}, "application/octet-stream", strings.NewReader(resolvedContent))
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to upload resolution of non conflict path", func() {
+				resp, err := client.UploadConflictResolutionWithBody(ctx, userName, repoName, mrSeq, &api.UploadConflictResolutionParams{
+					Path: "c.txt",
+				}, "application/octet-stream", strings.NewReader(resolvedContent))
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to upload resolution", func() {
+				resp, err := client.UploadConflictResolutionWithBody(ctx, userName, repoName, mrSeq, &api.UploadConflictResolutionParams{
+					Path: "a.txt",
+				}, "application/octet-stream", strings.NewReader(resolvedContent))
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseUploadConflictResolutionResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.Size, convey.ShouldEqual, len(resolvedContent))
+				blobHash = result.JSON201.Hash
+			})
+		})
+
+		c.Convey("merge with resolution", func(c convey.C) {
+			c.Convey("fail to merge with unresolved conflict", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg:             "merge",
+					ConflictResolve: &map[string]string{"a.txt": blobHash},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusConflict)
+			})
+
+			c.Convey("fail to merge with invalid resolution", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg:             "merge",
+					ConflictResolve: &map[string]string{"a.txt": "middle", "b.txt": "delete"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to merge with non exit blob", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg:             "merge",
+					ConflictResolve: &map[string]string{"a.txt": "abcdef", "b.txt": "delete"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to merge with resolution", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg:             "merge",
+					ConflictResolve: &map[string]string{"a.txt": blobHash, "b.txt": "delete"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+
+			c.Convey("merge result use resolution", func() {
+				resp, err := client.GetObject(ctx, userName, repoName, &api.GetObjectParams{
+					RefName: "main",
+					Path:    "a.txt",
+					Type:    api.RefTypeBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+				data, err := io.ReadAll(resp.Body)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(string(data), convey.ShouldEqual, resolvedContent)
+
+				resp, err = client.GetObject(ctx, userName, repoName, &api.GetObjectParams{
+					RefName: "main",
+					Path:    "b.txt",
+					Type:    api.RefTypeBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+
+				resp, err = client.GetObject(ctx, userName, repoName, &api.GetObjectParams{
+					RefName: "main",
+					Path:    "c.txt",
+					Type:    api.RefTypeBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+		})
+	}
+}
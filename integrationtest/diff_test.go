@@ -0,0 +1,182 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func DiffSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var baseCommit string
+	return func(c convey.C) {
+		userName := "diffman"
+		repoName := "diffrepo"
+		featBranch := "feat/diff"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "a.txt", "a\nb\nc\n")
+			uploadContent(ctx, client, userName, repoName, "main", "b.bin", "a\x00b")
+			uploadContent(ctx, client, userName, repoName, "main", "conf.json", `{"a":1,"b":{"c":true}}`)
+			uploadContent(ctx, client, userName, repoName, "main", "labels.jsonl", "{\"id\":\"x\",\"label\":1}\n{\"id\":\"y\",\"label\":2}\n")
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+			baseCommit = getBranch(ctx, client, userName, repoName, "main").CommitHash
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			uploadContent(ctx, client, userName, repoName, featBranch, "a.txt", "a\nB\nc\n d\n")
+			uploadContent(ctx, client, userName, repoName, featBranch, "b.bin", "a\x00c")
+			uploadContent(ctx, client, userName, repoName, featBranch, "conf.json", "{\n  \"b\": {\"c\": false},\n  \"a\": 1\n}\n")
+			uploadContent(ctx, client, userName, repoName, featBranch, "labels.jsonl", "{\"id\":\"y\",\"label\":3}\n{\"id\":\"x\",\"label\":1}\n")
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "update feat")
+		})
+
+		c.Convey("file diff", func(c convey.C) {
+			structureMode := api.Structure
+			getFileDiff := func(params *api.GetFileDiffParams) *api.FileDiff {
+				resp, err := client.GetFileDiff(ctx, userName, repoName, params)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetFileDiffResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				return result.JSON200
+			}
+
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.GetFileDiff(ctx, userName, repoName, &api.GetFileDiffParams{
+					Path:     "a.txt",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to diff non exit path", func() {
+				resp, err := client.GetFileDiff(ctx, userName, repoName, &api.GetFileDiffParams{
+					Path:     "c.txt",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("fail to diff with invalid commit", func() {
+				resp, err := client.GetFileDiff(ctx, userName, repoName, &api.GetFileDiffParams{
+					Path:     "a.txt",
+					BaseType: api.RefTypeCommit,
+					Base:     "zzz",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to diff text", func() {
+				fileDiff := getFileDiff(&api.GetFileDiffParams{
+					Path:     "a.txt",
+					BaseType: api.RefTypeCommit,
+					Base:     baseCommit,
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+				})
+				convey.So(fileDiff.Binary, convey.ShouldBeFalse)
+				convey.So(fileDiff.Hunks, convey.ShouldHaveLength, 1)
+				convey.So(fileDiff.Hunks[0].Header, convey.ShouldEqual, "@@ -1,3 +1,4 @@")
+				convey.So(fileDiff.Hunks[0].Lines, convey.ShouldResemble, []api.DiffLine{
+					{Type: api.Context, Content: "a"},
+					{Type: api.Delete, Content: "b"},
+					{Type: api.Insert, Content: "B"},
+					{Type: api.Context, Content: "c"},
+					{Type: api.Insert, Content: " d"},
+				})
+			})
+
+			c.Convey("success to diff ignore whitespace", func() {
+				fileDiff := getFileDiff(&api.GetFileDiffParams{
+					Path:             "a.txt",
+					BaseType:         api.RefTypeBranch,
+					Base:             "main",
+					HeadType:         api.RefTypeBranch,
+					Head:             featBranch,
+					Context:          utils.Int(0),
+					IgnoreWhitespace: utils.Bool(true),
+				})
+				convey.So(fileDiff.Hunks, convey.ShouldHaveLength, 2)
+				convey.So(fileDiff.Hunks[0].Header, convey.ShouldEqual, "@@ -2 +2 @@")
+			})
+
+			c.Convey("success to diff binary", func() {
+				fileDiff := getFileDiff(&api.GetFileDiffParams{
+					Path:     "b.bin",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+				})
+				convey.So(fileDiff.Binary, convey.ShouldBeTrue)
+				convey.So(fileDiff.Hunks, convey.ShouldHaveLength, 0)
+			})
+
+			c.Convey("fail to structure diff unsupported path", func() {
+				resp, err := client.GetFileDiff(ctx, userName, repoName, &api.GetFileDiffParams{
+					Path:     "a.txt",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+					Mode:     &structureMode,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to structure diff json", func() {
+				fileDiff := getFileDiff(&api.GetFileDiffParams{
+					Path:     "conf.json",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+					Mode:     &structureMode,
+				})
+				convey.So(fileDiff.Changes, convey.ShouldNotBeNil)
+				convey.So(*fileDiff.Changes, convey.ShouldHaveLength, 1)
+				convey.So((*fileDiff.Changes)[0].Path, convey.ShouldEqual, "/b/c")
+				convey.So((*fileDiff.Changes)[0].Type, convey.ShouldEqual, api.StructChangeTypeChanged)
+			})
+
+			c.Convey("success to structure diff jsonl by id", func() {
+				fileDiff := getFileDiff(&api.GetFileDiffParams{
+					Path:     "labels.jsonl",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+					Mode:     &structureMode,
+					IdField:  utils.String("id"),
+				})
+				convey.So(*fileDiff.Changes, convey.ShouldHaveLength, 1)
+				convey.So((*fileDiff.Changes)[0].Path, convey.ShouldEqual, "/y/label")
+			})
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/GitDataAI/jiaozifs/utils"
 	"github.com/phayes/freeport"
 	"github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
 
@@ -68,6 +69,8 @@ func SetupDaemon(t *testing.T, ctx context.Context) (string, Closer) { //nolint
 	tmpDir, err := os.MkdirTemp(os.TempDir(), "*")
 	require.NoError(t, err)
 	require.NoError(t, InitCmd(ctx, tmpDir, url, connectString))
+	// webhooks and actions in tests are received by local servers
+	viper.Set("webhook.allow_private_network", true)
 	buf := new(bytes.Buffer)
 
 	closer := func() {
@@ -198,6 +201,16 @@ func uploadObject(ctx context.Context, client *api.Client, user string, repoName
 	return result.JSON201
 }
 
+func uploadContent(ctx context.Context, client *api.Client, user string, repoName string, refName string, path string, content string) { //nolint
+	resp, err := client.UploadObjectWithBody(ctx, user, repoName, &api.UploadObjectParams{
+		RefName:   refName,
+		Path:      path,
+		IsReplace: utils.Bool(true),
+	}, "application/octet-stream", strings.NewReader(content))
+	convey.So(err, convey.ShouldBeNil)
+	convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+}
+
 func deleteObject(ctx context.Context, client *api.Client, user string, repoName string, refName string, path string) { //nolint
 	resp, err := client.DeleteObject(ctx, user, repoName, &api.DeleteObjectParams{
 		RefName: refName,
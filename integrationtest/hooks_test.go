@@ -0,0 +1,175 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+const labelsHook = `on: [pre-commit, pre-merge]
+branches: [main]
+validators:
+  - type: json_schema
+    paths: ["labels/**"]
+    schema: {type: object, required: [label]}
+  - type: required_files
+    files: [README.md]
+`
+
+func HooksSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	var mainCommit string
+	return func(c convey.C) {
+		userName := "hookman"
+		repoName := "hookrepo"
+		featBranch := "feat/labels"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", ".jiaozifs/hooks/labels.yaml", labelsHook)
+			uploadContent(ctx, client, userName, repoName, "main", "README.md", "labels")
+			_ = commitWip(ctx, client, userName, repoName, "main", "add hooks")
+			mainCommit = getBranch(ctx, client, userName, repoName, "main").CommitHash
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			uploadContent(ctx, client, userName, repoName, featBranch, "labels/a.json", `{"name":"a"}`)
+			// hook only applies to main
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add invalid label")
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+		})
+
+		c.Convey("pre-commit hook", func(c convey.C) {
+			c.Convey("fail to commit invalid label", func() {
+				uploadContent(ctx, client, userName, repoName, "main", "labels/b.json", `{"name":"b"}`)
+				resp, err := client.CommitWip(ctx, userName, repoName, &api.CommitWipParams{
+					RefName: "main",
+					Msg:     "add invalid label",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusPreconditionFailed)
+
+				result, err := api.ParseCommitWipResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON412.Event, convey.ShouldEqual, api.PreCommit)
+				convey.So(result.JSON412.Passed, convey.ShouldBeFalse)
+				convey.So(result.JSON412.Results, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON412.Results[0].Hook, convey.ShouldEqual, ".jiaozifs/hooks/labels.yaml")
+				convey.So(result.JSON412.Results[0].Violations, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON412.Results[0].Violations[0].Path, convey.ShouldEqual, "labels/b.json")
+				convey.So(getBranch(ctx, client, userName, repoName, "main").CommitHash, convey.ShouldEqual, mainCommit)
+			})
+
+			c.Convey("fail to remove required file", func() {
+				uploadContent(ctx, client, userName, repoName, "main", "labels/b.json", `{"label":"b"}`)
+				deleteObject(ctx, client, userName, repoName, "main", "README.md")
+				resp, err := client.CommitWip(ctx, userName, repoName, &api.CommitWipParams{
+					RefName: "main",
+					Msg:     "remove readme",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusPreconditionFailed)
+
+				result, err := api.ParseCommitWipResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON412.Results[0].Violations[0].Path, convey.ShouldEqual, "README.md")
+			})
+
+			c.Convey("fail to remove hook in the commit it rejects", func() {
+				uploadContent(ctx, client, userName, repoName, "main", "README.md", "labels")
+				uploadContent(ctx, client, userName, repoName, "main", "labels/c.json", `{"name":"c"}`)
+				deleteObject(ctx, client, userName, repoName, "main", ".jiaozifs/hooks/labels.yaml")
+				resp, err := client.CommitWip(ctx, userName, repoName, &api.CommitWipParams{
+					RefName: "main",
+					Msg:     "remove hook",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusPreconditionFailed)
+
+				result, err := api.ParseCommitWipResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON412.Results[0].Violations[0].Path, convey.ShouldEqual, "labels/c.json")
+			})
+
+			c.Convey("success to commit valid label", func() {
+				uploadContent(ctx, client, userName, repoName, "main", ".jiaozifs/hooks/labels.yaml", labelsHook)
+				deleteObject(ctx, client, userName, repoName, "main", "labels/c.json")
+				_ = commitWip(ctx, client, userName, repoName, "main", "add valid label")
+				mainCommit = getBranch(ctx, client, userName, repoName, "main").CommitHash
+			})
+		})
+
+		c.Convey("pre-merge hook", func(c convey.C) {
+			c.Convey("fail to merge invalid label", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge labels",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusPreconditionFailed)
+
+				result, err := api.ParseMergeResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON412.Event, convey.ShouldEqual, api.PreMerge)
+				convey.So(result.JSON412.Results[0].Violations[0].Path, convey.ShouldEqual, "labels/a.json")
+				convey.So(getBranch(ctx, client, userName, repoName, "main").CommitHash, convey.ShouldEqual, mainCommit)
+			})
+
+			c.Convey("fail to merge branch removing hook", func() {
+				deleteObject(ctx, client, userName, repoName, featBranch, ".jiaozifs/hooks/labels.yaml")
+				_ = commitWip(ctx, client, userName, repoName, featBranch, "remove hook")
+
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge labels",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusPreconditionFailed)
+				convey.So(getBranch(ctx, client, userName, repoName, "main").CommitHash, convey.ShouldEqual, mainCommit)
+			})
+
+			c.Convey("auto merge record hook failure", func() {
+				resp, err := client.EnableAutoMerge(ctx, userName, repoName, mrSeq, api.EnableAutoMergeJSONRequestBody{
+					Msg: "auto merge labels",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				var reason *string
+				for i := 0; i < 30 && reason == nil; i++ {
+					resp, err := client.GetAutoMerge(ctx, userName, repoName, mrSeq)
+					convey.So(err, convey.ShouldBeNil)
+					result, err := api.ParseGetAutoMergeResponse(resp)
+					convey.So(err, convey.ShouldBeNil)
+					reason = result.JSON200.Reason
+					time.Sleep(time.Second)
+				}
+				convey.So(reason, convey.ShouldNotBeNil)
+				convey.So(*reason, convey.ShouldContainSubstring, "rejected by hooks")
+				convey.So(getBranch(ctx, client, userName, repoName, "main").CommitHash, convey.ShouldEqual, mainCommit)
+
+				resp, err = client.DisableAutoMerge(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+
+			c.Convey("success to merge after fix", func() {
+				uploadContent(ctx, client, userName, repoName, featBranch, ".jiaozifs/hooks/labels.yaml", labelsHook)
+				uploadContent(ctx, client, userName, repoName, featBranch, "labels/a.json", `{"label":"a"}`)
+				_ = commitWip(ctx, client, userName, repoName, featBranch, "fix label")
+
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge labels",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package integrationtest
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func IpfsSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var dirCid string
+	return func(c convey.C) {
+		userName := "ipfsman"
+		repoName := "ipfsrepo"
+		branchName := "main"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, branchName)
+			_ = uploadObject(ctx, client, userName, repoName, branchName, "g/x.dat", true)
+			_ = uploadObject(ctx, client, userName, repoName, branchName, "g/m.dat", true)
+		})
+
+		c.Convey("cid in entries", func() {
+			resp, err := client.GetEntriesInRef(ctx, userName, repoName, &api.GetEntriesInRefParams{
+				Ref:  utils.String(branchName),
+				Type: api.RefTypeWip,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseGetEntriesInRefResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(*result.JSON200, convey.ShouldHaveLength, 1)
+			convey.So((*result.JSON200)[0].Cid, convey.ShouldNotBeNil)
+			dirCid = *(*result.JSON200)[0].Cid
+		})
+
+		c.Convey("get ipfs content", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.GetIpfsContent(ctx, dirCid, &api.GetIpfsContentParams{})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to get invalid cid", func() {
+				resp, err := client.GetIpfsContent(ctx, "invalid", &api.GetIpfsContentParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to get non exit path", func() {
+				resp, err := client.GetIpfsContent(ctx, dirCid, &api.GetIpfsContentParams{
+					Path: utils.String("fake.dat"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("list directory", func() {
+				resp, err := client.GetIpfsContent(ctx, dirCid, &api.GetIpfsContentParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetIpfsContentResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON200, convey.ShouldHaveLength, 2)
+				convey.So((*result.JSON200)[0].Name, convey.ShouldEqual, "m.dat")
+				convey.So((*result.JSON200)[0].Cid, convey.ShouldNotBeNil)
+			})
+
+			c.Convey("get file through gateway", func() {
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr+"/ipfs/"+dirCid+"/x.dat", nil)
+				convey.So(err, convey.ShouldBeNil)
+				for _, editor := range client.RequestEditors {
+					convey.So(editor(ctx, req), convey.ShouldBeNil)
+				}
+
+				resp, err := http.DefaultClient.Do(req)
+				convey.So(err, convey.ShouldBeNil)
+				defer resp.Body.Close() //nolint
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				data, err := io.ReadAll(resp.Body)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(data, convey.ShouldHaveLength, 100)
+			})
+
+			c.Convey("fail to get cid in private repo of other user", func() {
+				_ = createUser(ctx, client, "ipfsguest")
+				loginAndSwitch(ctx, client, "ipfsguest", false)
+				defer loginAndSwitch(ctx, client, userName, false)
+
+				resp, err := client.GetIpfsContent(ctx, dirCid, &api.GetIpfsContentParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package integrationtest
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func MergeAttributesSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	var mainLock string
+	return func(c convey.C) {
+		userName := "attributesman"
+		repoName := "attributesrepo"
+		featBranch := "feat/attributes"
+
+		readContent := func(refName string, path string) string {
+			resp, err := client.GetObject(ctx, userName, repoName, &api.GetObjectParams{
+				RefName: refName,
+				Path:    path,
+				Type:    api.RefTypeBranch,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			data, err := io.ReadAll(resp.Body)
+			convey.So(err, convey.ShouldBeNil)
+			return string(data)
+		}
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", ".jzattributes", "*.lock merge=ours\n*.log merge=union-lines\n")
+			uploadContent(ctx, client, userName, repoName, "main", "a.lock", "v1")
+			uploadContent(ctx, client, userName, repoName, "main", "x.log", "init\n")
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			uploadContent(ctx, client, userName, repoName, featBranch, "a.lock", "v2 of feat")
+			uploadContent(ctx, client, userName, repoName, featBranch, "x.log", "init\nfeat\n")
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "update in feat")
+
+			uploadContent(ctx, client, userName, repoName, "main", "a.lock", "v2 of main")
+			uploadContent(ctx, client, userName, repoName, "main", "x.log", "init\nmain\n")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "update in main")
+			mainLock = readContent("main", "a.lock")
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+		})
+
+		c.Convey("merge with attributes", func(c convey.C) {
+			c.Convey("conflict with merge driver not block merging", func() {
+				resp, err := client.GetMergeRequest(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetMergeRequestResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.ConflictCount, convey.ShouldEqual, 3)
+				convey.So(result.JSON200.Mergeable, convey.ShouldBeFalse)
+
+				drivers := map[string]string{}
+				for _, change := range result.JSON200.Changes {
+					if change.IsConflict {
+						drivers[change.Path] = utils.StringValue(change.MergeDriver)
+					}
+				}
+				convey.So(drivers, convey.ShouldResemble, map[string]string{
+					"a.lock": "ours",
+					"b.txt":  "",
+					"x.log":  "union-lines",
+				})
+			})
+
+			c.Convey("fail to merge with conflict without driver", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusConflict)
+			})
+
+			c.Convey("success to merge", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg:             "merge",
+					ConflictResolve: &map[string]string{"b.txt": "left"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+
+			c.Convey("merge result use merge driver", func() {
+				convey.So(readContent("main", "a.lock"), convey.ShouldEqual, mainLock)
+				convey.So(readContent("main", "x.log"), convey.ShouldEqual, "init\nmain\nfeat\n")
+			})
+		})
+	}
+}
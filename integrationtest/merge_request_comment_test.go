@@ -0,0 +1,306 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func MergeRequestCommentSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	var threadID, replyID openapi_types.UUID
+	return func(c convey.C) {
+		userName := "commentman"
+		mateName := "commentmate"
+		repoName := "commentrepo"
+		featBranch := "feat/comment"
+
+		c.Convey("init", func(_ convey.C) {
+			mate := createUser(ctx, client, mateName)
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add b")
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+
+			_, writeGroup, _, err := getGroup(ctx, client)
+			convey.So(err, convey.ShouldBeNil)
+			resp, err := client.InviteMember(ctx, userName, repoName, &api.InviteMemberParams{
+				UserId:  mate.Id,
+				GroupId: writeGroup.Id,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+		})
+
+		c.Convey("create comment", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body: "hello",
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to comment on non exit merge request", func() {
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, 100, api.CreateMergeRequestCommentJSONRequestBody{
+					Body: "hello",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("fail to comment with empty body", func() {
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body: " ",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to anchor unchanged path", func() {
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body: "hello",
+					Path: utils.String("a.txt"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to anchor invalid line range", func() {
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body:      "hello",
+					Path:      utils.String("b.txt"),
+					StartLine: utils.Int(5),
+					EndLine:   utils.Int(2),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to create anchored thread", func() {
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body:      "why add b",
+					Path:      utils.String("b.txt"),
+					StartLine: utils.Int(2),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseCreateMergeRequestCommentResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON201.Path, convey.ShouldEqual, "b.txt")
+				convey.So(*result.JSON201.EndLine, convey.ShouldEqual, 2)
+				convey.So(result.JSON201.AuthorName, convey.ShouldEqual, userName)
+				threadID = result.JSON201.Id
+			})
+
+			c.Convey("success to create top level thread", func() {
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body: "please review",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+			})
+
+			c.Convey("success to reply thread", func() {
+				loginAndSwitch(ctx, client, mateName, false)
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body:     "b is needed",
+					ParentId: &threadID,
+				})
+				loginAndSwitch(ctx, client, userName, false)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseCreateMergeRequestCommentResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON201.ParentId, convey.ShouldEqual, threadID)
+				replyID = result.JSON201.Id
+			})
+
+			c.Convey("fail to reply a reply", func() {
+				resp, err := client.CreateMergeRequestComment(ctx, userName, repoName, mrSeq, api.CreateMergeRequestCommentJSONRequestBody{
+					Body:     "nested",
+					ParentId: &replyID,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+		})
+
+		c.Convey("list comments", func(c convey.C) {
+			c.Convey("success to list threads", func() {
+				resp, err := client.ListMergeRequestComments(ctx, userName, repoName, mrSeq, &api.ListMergeRequestCommentsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListMergeRequestCommentsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 2)
+				convey.So(result.JSON200.Results[0].Id, convey.ShouldEqual, threadID)
+			})
+
+			c.Convey("success to list threads by page", func() {
+				resp, err := client.ListMergeRequestComments(ctx, userName, repoName, mrSeq, &api.ListMergeRequestCommentsParams{
+					Amount: utils.Int(1),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				result, err := api.ParseListMergeRequestCommentsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.Pagination.HasMore, convey.ShouldBeTrue)
+
+				after := result.JSON200.Results[0].CreatedAt
+				resp, err = client.ListMergeRequestComments(ctx, userName, repoName, mrSeq, &api.ListMergeRequestCommentsParams{
+					After:  &after,
+					Amount: utils.Int(1),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				result, err = api.ParseListMergeRequestCommentsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.Results[0].Id, convey.ShouldNotEqual, threadID)
+			})
+
+			c.Convey("success to list threads of path", func() {
+				resp, err := client.ListMergeRequestComments(ctx, userName, repoName, mrSeq, &api.ListMergeRequestCommentsParams{
+					Path: utils.String("b.txt"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				result, err := api.ParseListMergeRequestCommentsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+			})
+
+			c.Convey("success to list replies", func() {
+				resp, err := client.ListMergeRequestComments(ctx, userName, repoName, mrSeq, &api.ListMergeRequestCommentsParams{
+					ParentId: &threadID,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				result, err := api.ParseListMergeRequestCommentsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.Results[0].AuthorName, convey.ShouldEqual, mateName)
+			})
+		})
+
+		c.Convey("update comment", func(c convey.C) {
+			c.Convey("fail to edit others comment", func() {
+				resp, err := client.UpdateMergeRequestComment(ctx, userName, repoName, mrSeq, replyID, api.UpdateMergeRequestCommentJSONRequestBody{
+					Body: "edited",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("success to edit comment", func() {
+				resp, err := client.UpdateMergeRequestComment(ctx, userName, repoName, mrSeq, threadID, api.UpdateMergeRequestCommentJSONRequestBody{
+					Body: "why add b.txt",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseUpdateMergeRequestCommentResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Body, convey.ShouldEqual, "why add b.txt")
+			})
+
+			c.Convey("fail to resolve reply", func() {
+				resp, err := client.ResolveMergeRequestComment(ctx, userName, repoName, mrSeq, replyID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to resolve and unresolve thread", func() {
+				loginAndSwitch(ctx, client, mateName, false)
+				resp, err := client.ResolveMergeRequestComment(ctx, userName, repoName, mrSeq, threadID)
+				loginAndSwitch(ctx, client, userName, false)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseResolveMergeRequestCommentResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Resolved, convey.ShouldBeTrue)
+				convey.So(result.JSON200.ResolvedBy, convey.ShouldNotBeNil)
+
+				listResp, err := client.ListMergeRequestComments(ctx, userName, repoName, mrSeq, &api.ListMergeRequestCommentsParams{
+					Resolved: utils.Bool(false),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				list, err := api.ParseListMergeRequestCommentsResponse(listResp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(list.JSON200.Results, convey.ShouldHaveLength, 1)
+
+				resp, err = client.UnresolveMergeRequestComment(ctx, userName, repoName, mrSeq, threadID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				unresolved, err := api.ParseUnresolveMergeRequestCommentResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(unresolved.JSON200.Resolved, convey.ShouldBeFalse)
+				convey.So(unresolved.JSON200.ResolvedBy, convey.ShouldBeNil)
+			})
+		})
+
+		c.Convey("delete comment", func(c convey.C) {
+			c.Convey("fail to delete others comment", func() {
+				resp, err := client.DeleteMergeRequestComment(ctx, userName, repoName, mrSeq, replyID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("success to delete thread with replies", func() {
+				resp, err := client.DeleteMergeRequestComment(ctx, userName, repoName, mrSeq, threadID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				getResp, err := client.GetMergeRequestComment(ctx, userName, repoName, mrSeq, threadID)
+				convey.So(err, convey.ShouldBeNil)
+				result, err := api.ParseGetMergeRequestCommentResponse(getResp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Deleted, convey.ShouldBeTrue)
+				convey.So(result.JSON200.Body, convey.ShouldBeEmpty)
+
+				resp, err = client.GetMergeRequestComment(ctx, userName, repoName, mrSeq, replyID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+
+			c.Convey("fail to update deleted thread", func() {
+				resp, err := client.UpdateMergeRequestComment(ctx, userName, repoName, mrSeq, threadID, api.UpdateMergeRequestCommentJSONRequestBody{Body: "again"})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("delete deleted thread with its last reply", func() {
+				loginAndSwitch(ctx, client, mateName, false)
+				resp, err := client.DeleteMergeRequestComment(ctx, userName, repoName, mrSeq, replyID)
+				loginAndSwitch(ctx, client, userName, false)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				resp, err = client.GetMergeRequestComment(ctx, userName, repoName, mrSeq, threadID)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func MergeRequestLifecycleSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	return func(c convey.C) {
+		userName := "lifecycleman"
+		assigneeName := "lifecyclemate"
+		repoName := "lifecyclerepo"
+		featBranch := "feat/lifecycle"
+
+		listMergeRequests := func(params *api.ListMergeRequestsParams) []api.MergeRequest {
+			resp, err := client.ListMergeRequests(ctx, userName, repoName, params)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseListMergeRequestsResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			return result.JSON200.Results
+		}
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, assigneeName)
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add b")
+		})
+
+		c.Convey("labels", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.CreateLabel(ctx, userName, repoName, api.CreateLabelJSONRequestBody{
+					Name:  "bug",
+					Color: "#d73a4a",
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to create label with invalid color", func() {
+				resp, err := client.CreateLabel(ctx, userName, repoName, api.CreateLabelJSONRequestBody{
+					Name:  "bug",
+					Color: "red",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to create labels", func() {
+				for _, name := range []string{"bug", "dataset"} {
+					resp, err := client.CreateLabel(ctx, userName, repoName, api.CreateLabelJSONRequestBody{
+						Name:  name,
+						Color: "#d73a4a",
+					})
+					convey.So(err, convey.ShouldBeNil)
+					convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+				}
+			})
+
+			c.Convey("fail to create duplicate label", func() {
+				resp, err := client.CreateLabel(ctx, userName, repoName, api.CreateLabelJSONRequestBody{
+					Name:  "bug",
+					Color: "#d73a4a",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusConflict)
+			})
+
+			c.Convey("success to update label", func() {
+				resp, err := client.UpdateLabel(ctx, userName, repoName, "bug", api.UpdateLabelJSONRequestBody{
+					Color:       utils.String("#0e8a16"),
+					Description: utils.String("something is wrong"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseUpdateLabelResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Color, convey.ShouldEqual, "#0e8a16")
+			})
+
+			c.Convey("success to list labels", func() {
+				resp, err := client.ListLabels(ctx, userName, repoName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListLabelsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON200, convey.ShouldHaveLength, 2)
+				convey.So((*result.JSON200)[0].Name, convey.ShouldEqual, "bug")
+			})
+		})
+
+		c.Convey("draft merge request", func(c convey.C) {
+			c.Convey("fail to create merge request with non exit label", func() {
+				resp, err := client.CreateMergeRequest(ctx, userName, repoName, api.CreateMergeRequestJSONRequestBody{
+					SourceBranchName: featBranch,
+					TargetBranchName: "main",
+					Title:            "lifecycle",
+					Labels:           &[]string{"feature"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to create draft merge request", func() {
+				resp, err := client.CreateMergeRequest(ctx, userName, repoName, api.CreateMergeRequestJSONRequestBody{
+					SourceBranchName: featBranch,
+					TargetBranchName: "main",
+					Title:            "lifecycle",
+					Draft:            utils.Bool(true),
+					Assignees:        &[]string{assigneeName},
+					Labels:           &[]string{"bug"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseCreateMergeRequestResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.Draft, convey.ShouldBeTrue)
+				mrSeq = result.JSON201.Sequence
+
+				getResp, err := client.GetMergeRequest(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(getResp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				getResult, err := api.ParseGetMergeRequestResponse(getResp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(getResult.JSON200.Assignees, convey.ShouldHaveLength, 1)
+				convey.So(getResult.JSON200.Labels, convey.ShouldHaveLength, 1)
+			})
+
+			c.Convey("fail to merge draft merge request", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge draft",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to mark ready", func() {
+				resp, err := client.UpdateMergeRequest(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestJSONRequestBody{
+					Draft: utils.Bool(false),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+		})
+
+		c.Convey("assignees and labels of merge request", func(c convey.C) {
+			c.Convey("success to assign user", func() {
+				resp, err := client.AssignUsers(ctx, userName, repoName, mrSeq, api.AssignUsersJSONRequestBody{
+					Assignees: []string{userName, assigneeName},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseAssignUsersResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON201, convey.ShouldHaveLength, 2)
+			})
+
+			c.Convey("fail to assign non exit user", func() {
+				resp, err := client.AssignUsers(ctx, userName, repoName, mrSeq, api.AssignUsersJSONRequestBody{
+					Assignees: []string{"nobody"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to unassign user", func() {
+				resp, err := client.UnassignUser(ctx, userName, repoName, mrSeq, userName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				resp, err = client.UnassignUser(ctx, userName, repoName, mrSeq, userName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("success to attach and detach label", func() {
+				resp, err := client.AttachLabels(ctx, userName, repoName, mrSeq, api.AttachLabelsJSONRequestBody{
+					Labels: []string{"dataset"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseAttachLabelsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON201, convey.ShouldHaveLength, 2)
+
+				resp, err = client.DetachLabel(ctx, userName, repoName, mrSeq, "dataset")
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+
+			c.Convey("filter merge requests", func() {
+				convey.So(listMergeRequests(&api.ListMergeRequestsParams{Author: utils.String(userName)}), convey.ShouldHaveLength, 1)
+				convey.So(listMergeRequests(&api.ListMergeRequestsParams{Assignee: utils.String(assigneeName)}), convey.ShouldHaveLength, 1)
+				convey.So(listMergeRequests(&api.ListMergeRequestsParams{Assignee: utils.String(userName)}), convey.ShouldHaveLength, 0)
+				convey.So(listMergeRequests(&api.ListMergeRequestsParams{Label: utils.String("bug")}), convey.ShouldHaveLength, 1)
+				convey.So(listMergeRequests(&api.ListMergeRequestsParams{Label: utils.String("dataset")}), convey.ShouldHaveLength, 0)
+				convey.So(listMergeRequests(&api.ListMergeRequestsParams{Label: utils.String("feature")}), convey.ShouldHaveLength, 0)
+			})
+		})
+
+		c.Convey("close and reopen", func(c convey.C) {
+			c.Convey("success to close merge request", func() {
+				resp, err := client.UpdateMergeRequest(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestJSONRequestBody{
+					Status: utils.Int(int(models.MergeStateClosed)),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+
+			c.Convey("fail to mark closed merge request merged", func() {
+				resp, err := client.UpdateMergeRequest(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestJSONRequestBody{
+					Status: utils.Int(int(models.MergeStateMerged)),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to reopen merge request", func() {
+				resp, err := client.UpdateMergeRequest(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestJSONRequestBody{
+					Status: utils.Int(int(models.MergeStateInit)),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				convey.So(listMergeRequests(&api.ListMergeRequestsParams{State: utils.Int(int(models.MergeStateInit))}), convey.ShouldHaveLength, 1)
+			})
+
+			c.Convey("success to merge", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge lifecycle",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+		})
+
+		c.Convey("timeline", func(c convey.C) {
+			c.Convey("success to list timeline", func() {
+				resp, err := client.ListMergeRequestTimeline(ctx, userName, repoName, mrSeq, &api.ListMergeRequestTimelineParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListMergeRequestTimelineResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+
+				actions := make([]api.TimelineEventAction, 0, len(result.JSON200.Results))
+				for _, event := range result.JSON200.Results {
+					actions = append(actions, event.Action)
+				}
+				convey.So(actions, convey.ShouldResemble, []api.TimelineEventAction{
+					api.Opened,
+					api.Assigned,
+					api.Labeled,
+					api.ReadyForReview,
+					api.Assigned,
+					api.Unassigned,
+					api.Labeled,
+					api.Unlabeled,
+					api.Closed,
+					api.Reopened,
+					api.Merged,
+				})
+				convey.So(result.JSON200.Results[0].ActorName, convey.ShouldEqual, userName)
+			})
+		})
+	}
+}
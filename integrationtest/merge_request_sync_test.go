@@ -0,0 +1,190 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func MergeRequestSyncSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	return func(c convey.C) {
+		userName := "syncman"
+		repoName := "syncrepo"
+		featBranch := "feat/sync"
+
+		getMergeRequest := func() *api.MergeRequestFullState {
+			resp, err := client.GetMergeRequest(ctx, userName, repoName, mrSeq)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseGetMergeRequestResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			return result.JSON200
+		}
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add b")
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+		})
+
+		c.Convey("mergeability", func(c convey.C) {
+			c.Convey("up to date merge request", func() {
+				mergeRequest := getMergeRequest()
+				convey.So(mergeRequest.Mergeable, convey.ShouldBeTrue)
+				convey.So(mergeRequest.BehindBy, convey.ShouldEqual, 0)
+				convey.So(mergeRequest.ConflictCount, convey.ShouldEqual, 0)
+			})
+
+			c.Convey("recompute after target moved", func() {
+				_ = uploadObject(ctx, client, userName, repoName, "main", "c.txt", true)
+				_ = commitWip(ctx, client, userName, repoName, "main", "add c")
+
+				mergeRequest := getMergeRequest()
+				convey.So(mergeRequest.Mergeable, convey.ShouldBeTrue)
+				convey.So(mergeRequest.BehindBy, convey.ShouldEqual, 1)
+				convey.So(mergeRequest.ConflictCount, convey.ShouldEqual, 0)
+			})
+
+			c.Convey("mergeability in list", func() {
+				resp, err := client.ListMergeRequests(ctx, userName, repoName, &api.ListMergeRequestsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListMergeRequestsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.Results[0].BehindBy, convey.ShouldEqual, 1)
+				convey.So(result.JSON200.Results[0].Mergeable, convey.ShouldBeTrue)
+				convey.So(result.JSON200.Results[0].MergeabilityOutdated, convey.ShouldBeFalse)
+			})
+
+			c.Convey("outdated mergeability in list after target moved", func() {
+				_ = uploadObject(ctx, client, userName, repoName, "main", "d.txt", true)
+				_ = commitWip(ctx, client, userName, repoName, "main", "add d")
+
+				resp, err := client.ListMergeRequests(ctx, userName, repoName, &api.ListMergeRequestsParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListMergeRequestsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.Results[0].MergeabilityOutdated, convey.ShouldBeTrue)
+				convey.So(*result.JSON200.Results[0].CheckedTargetHash, convey.ShouldNotEqual, getBranch(ctx, client, userName, repoName, "main").CommitHash)
+			})
+		})
+
+		c.Convey("update branch", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.UpdateMergeRequestBranch(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestBranchJSONRequestBody{})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to update branch of non exit merge request", func() {
+				resp, err := client.UpdateMergeRequestBranch(ctx, userName, repoName, 100, api.UpdateMergeRequestBranchJSONRequestBody{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("success to update branch", func() {
+				resp, err := client.UpdateMergeRequestBranch(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestBranchJSONRequestBody{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseUpdateMergeRequestBranchResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Hash, convey.ShouldEqual, getBranch(ctx, client, userName, repoName, featBranch).CommitHash)
+
+				mergeRequest := getMergeRequest()
+				convey.So(mergeRequest.BehindBy, convey.ShouldEqual, 0)
+				convey.So(mergeRequest.Mergeable, convey.ShouldBeTrue)
+			})
+
+			c.Convey("fail to update up to date branch", func() {
+				resp, err := client.UpdateMergeRequestBranch(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestBranchJSONRequestBody{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+		})
+
+		c.Convey("conflict", func(c convey.C) {
+			c.Convey("count conflicts after both branches changed", func() {
+				_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+				_ = commitWip(ctx, client, userName, repoName, "main", "update a in main")
+				_ = uploadObject(ctx, client, userName, repoName, featBranch, "a.txt", true)
+				_ = commitWip(ctx, client, userName, repoName, featBranch, "update a in feat")
+
+				mergeRequest := getMergeRequest()
+				convey.So(mergeRequest.Mergeable, convey.ShouldBeFalse)
+				convey.So(mergeRequest.BehindBy, convey.ShouldEqual, 1)
+				convey.So(mergeRequest.ConflictCount, convey.ShouldEqual, 1)
+			})
+
+			c.Convey("fail to update branch with unresolved conflict", func() {
+				resp, err := client.UpdateMergeRequestBranch(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestBranchJSONRequestBody{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusConflict)
+			})
+
+			c.Convey("success to update branch with resolved conflict", func() {
+				resp, err := client.UpdateMergeRequestBranch(ctx, userName, repoName, mrSeq, api.UpdateMergeRequestBranchJSONRequestBody{
+					Msg:             "sync main",
+					ConflictResolve: &map[string]string{"a.txt": "left"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				mergeRequest := getMergeRequest()
+				convey.So(mergeRequest.Mergeable, convey.ShouldBeTrue)
+				convey.So(mergeRequest.BehindBy, convey.ShouldEqual, 0)
+				convey.So(mergeRequest.ConflictCount, convey.ShouldEqual, 0)
+			})
+		})
+
+		c.Convey("list merge request of deleted branch", func() {
+			goneBranch := "feat/gone"
+			_ = createBranch(ctx, client, userName, repoName, "main", goneBranch)
+			_ = createWip(ctx, client, userName, repoName, goneBranch)
+			_ = uploadObject(ctx, client, userName, repoName, goneBranch, "gone.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, goneBranch, "add gone")
+			_ = createMergeRequest(ctx, client, userName, repoName, goneBranch, "main")
+
+			resp, err := client.DeleteBranch(ctx, userName, repoName, &api.DeleteBranchParams{RefName: goneBranch})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			resp, err = client.ListMergeRequests(ctx, userName, repoName, &api.ListMergeRequestsParams{})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseListMergeRequestsResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(result.JSON200.Results, convey.ShouldHaveLength, 2)
+			for _, mergeRequest := range result.JSON200.Results {
+				if mergeRequest.SourceBranch != getBranch(ctx, client, userName, repoName, featBranch).Id {
+					convey.So(mergeRequest.MergeabilityOutdated, convey.ShouldBeTrue)
+				}
+			}
+		})
+	}
+}
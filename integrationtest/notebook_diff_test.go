@@ -0,0 +1,95 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func NotebookDiffSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var baseCommit, headCommit string
+	return func(c convey.C) {
+		userName := "notebookman"
+		repoName := "notebookrepo"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "a.ipynb", `{"cells":[{"cell_type":"code","execution_count":1,"metadata":{},"outputs":[],"source":"x = 1"}],"metadata":{}}`)
+			_ = commitWip(ctx, client, userName, repoName, "main", "add notebook")
+			baseCommit = getBranch(ctx, client, userName, repoName, "main").CommitHash
+
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "a.ipynb", `{"cells":[{"cell_type":"code","execution_count":2,"metadata":{},"outputs":[],"source":"x = 1"},{"cell_type":"markdown","metadata":{},"source":"done"}],"metadata":{}}`)
+			uploadContent(ctx, client, userName, repoName, "main", "b.txt", "b")
+			_ = commitWip(ctx, client, userName, repoName, "main", "update notebook")
+			headCommit = getBranch(ctx, client, userName, repoName, "main").CommitHash
+		})
+
+		c.Convey("notebook diff", func(c convey.C) {
+			findChange := func(changes []api.Change, path string) *api.Change {
+				for _, change := range changes {
+					if change.Path == path {
+						return &change
+					}
+				}
+				return nil
+			}
+
+			c.Convey("success to get commit changes with notebook diff", func() {
+				resp, err := client.GetCommitChanges(ctx, userName, repoName, headCommit, &api.GetCommitChangesParams{
+					Notebook: utils.Bool(true),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetCommitChangesResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+
+				change := findChange(*result.JSON200, "a.ipynb")
+				convey.So(change, convey.ShouldNotBeNil)
+				convey.So(change.Notebook, convey.ShouldNotBeNil)
+				convey.So(change.Notebook.Cells, convey.ShouldHaveLength, 2)
+				convey.So(change.Notebook.Cells[0].Type, convey.ShouldEqual, api.NotebookCellDiffTypeModified)
+				convey.So(change.Notebook.Cells[0].Outputs, convey.ShouldHaveLength, 1)
+				convey.So(change.Notebook.Cells[1].Type, convey.ShouldEqual, api.NotebookCellDiffTypeAdded)
+
+				convey.So(findChange(*result.JSON200, "b.txt").Notebook, convey.ShouldBeNil)
+			})
+
+			c.Convey("success to compare commit ignore outputs", func() {
+				resp, err := client.CompareCommit(ctx, userName, repoName, baseCommit+"..."+headCommit, &api.CompareCommitParams{
+					Notebook:      utils.Bool(true),
+					IgnoreOutputs: utils.Bool(true),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseCompareCommitResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+
+				change := findChange(*result.JSON200, "a.ipynb")
+				convey.So(change, convey.ShouldNotBeNil)
+				convey.So(change.Notebook.Cells, convey.ShouldHaveLength, 1)
+				convey.So(change.Notebook.Cells[0].Type, convey.ShouldEqual, api.NotebookCellDiffTypeAdded)
+			})
+
+			c.Convey("no notebook diff by default", func() {
+				resp, err := client.GetCommitChanges(ctx, userName, repoName, headCommit, &api.GetCommitChangesParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetCommitChangesResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(findChange(*result.JSON200, "a.ipynb").Notebook, convey.ShouldBeNil)
+			})
+		})
+	}
+}
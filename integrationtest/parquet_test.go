@@ -0,0 +1,115 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// parquet files only contains footer, v2 widen id from INT32 to INT64, remove name and add score
+const (
+	parquetV1 = "PAR1column data\x15\x02\x19<H\x06schema\x15\x04\x00\x15\x02%\x00\x18\x02id\x00\x15\f%\x02\x18\x04name%\x00\x00\x16\x04\x19\f\x00*\x00\x00\x00PAR1"
+	parquetV2 = "PAR1column data\x15\x02\x19<H\x06schema\x15\x04\x00\x15\x04%\x00\x18\x02id\x00\x15\n%\x02\x18\x05score\x00\x16\x04\x19\f\x00)\x00\x00\x00PAR1"
+)
+
+func ParquetSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var baseCommit, headCommit string
+	return func(c convey.C) {
+		userName := "parquetman"
+		repoName := "parquetrepo"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "data/a.parquet", parquetV1)
+			uploadContent(ctx, client, userName, repoName, "main", "data/b.txt", "not parquet")
+			_ = commitWip(ctx, client, userName, repoName, "main", "add parquet")
+			baseCommit = getBranch(ctx, client, userName, repoName, "main").CommitHash
+
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "data/a.parquet", parquetV2)
+			_ = commitWip(ctx, client, userName, repoName, "main", "change parquet schema")
+			headCommit = getBranch(ctx, client, userName, repoName, "main").CommitHash
+		})
+
+		c.Convey("parquet metadata", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.GetParquetMetadata(ctx, userName, repoName, &api.GetParquetMetadataParams{
+					RefName: "main",
+					Path:    "data/a.parquet",
+					Type:    api.RefTypeBranch,
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to read non exit object", func() {
+				resp, err := client.GetParquetMetadata(ctx, userName, repoName, &api.GetParquetMetadataParams{
+					RefName: "main",
+					Path:    "data/c.parquet",
+					Type:    api.RefTypeBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("fail to read invalid parquet", func() {
+				resp, err := client.GetParquetMetadata(ctx, userName, repoName, &api.GetParquetMetadataParams{
+					RefName: "main",
+					Path:    "data/b.txt",
+					Type:    api.RefTypeBranch,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to read parquet metadata in commit", func() {
+				resp, err := client.GetParquetMetadata(ctx, userName, repoName, &api.GetParquetMetadataParams{
+					RefName: baseCommit,
+					Path:    "data/a.parquet",
+					Type:    api.RefTypeCommit,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetParquetMetadataResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.NumRows, convey.ShouldEqual, 2)
+				convey.So(result.JSON200.Columns, convey.ShouldResemble, []api.ParquetColumn{
+					{Name: "id", PhysicalType: "INT32", Repetition: api.REQUIRED},
+					{Name: "name", PhysicalType: "BYTE_ARRAY", LogicalType: utils.String("STRING"), Repetition: api.OPTIONAL},
+				})
+			})
+		})
+
+		c.Convey("compare parquet schema", func(c convey.C) {
+			resp, err := client.CompareCommit(ctx, userName, repoName, baseCommit+"..."+headCommit, &api.CompareCommitParams{
+				ParquetSchema: utils.Bool(true),
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseCompareCommitResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(*result.JSON200, convey.ShouldHaveLength, 1)
+
+			schemaChanges := (*result.JSON200)[0].SchemaChanges
+			convey.So(schemaChanges, convey.ShouldNotBeNil)
+			convey.So(*schemaChanges, convey.ShouldResemble, []api.SchemaChange{
+				{Type: api.TypeChanged, Column: "id", Old: utils.String("INT32"), New: utils.String("INT64"), Breaking: false},
+				{Type: api.ColumnRemoved, Column: "name", Old: utils.String("BYTE_ARRAY(STRING)"), Breaking: true},
+				{Type: api.ColumnAdded, Column: "score", New: utils.String("DOUBLE"), Breaking: false},
+			})
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func PreviewSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	return func(c convey.C) {
+		userName := "previewman"
+		repoName := "previewrepo"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "data/a.csv", "id,name,score\n1,a,1.5\n2,b,2\n3,c,\n")
+			uploadContent(ctx, client, userName, repoName, "main", "data/b.jsonl", "{\"id\":1,\"tags\":[\"x\"]}\n{\"id\":2,\"name\":\"b\"}\n")
+			uploadContent(ctx, client, userName, repoName, "main", "data/c.txt", "a\tb\n1\t2\n")
+			uploadContent(ctx, client, userName, repoName, "main", "data/d.jsonl", "not json\n")
+			_ = commitWip(ctx, client, userName, repoName, "main", "add tables")
+		})
+
+		c.Convey("no auth", func() {
+			re := client.RequestEditors
+			client.RequestEditors = nil
+			resp, err := client.GetObjectPreview(ctx, userName, repoName, &api.GetObjectPreviewParams{
+				RefName: "main",
+				Path:    "data/a.csv",
+				Type:    api.RefTypeBranch,
+			})
+			client.RequestEditors = re
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+		})
+
+		c.Convey("fail to preview non exit object", func() {
+			resp, err := client.GetObjectPreview(ctx, userName, repoName, &api.GetObjectPreviewParams{
+				RefName: "main",
+				Path:    "data/e.csv",
+				Type:    api.RefTypeBranch,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+		})
+
+		c.Convey("fail to preview object in unknown format", func() {
+			resp, err := client.GetObjectPreview(ctx, userName, repoName, &api.GetObjectPreviewParams{
+				RefName: "main",
+				Path:    "data/c.txt",
+				Type:    api.RefTypeBranch,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+		})
+
+		c.Convey("fail to preview invalid jsonl", func() {
+			resp, err := client.GetObjectPreview(ctx, userName, repoName, &api.GetObjectPreviewParams{
+				RefName: "main",
+				Path:    "data/d.jsonl",
+				Type:    api.RefTypeBranch,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+		})
+
+		c.Convey("success to preview csv page", func() {
+			resp, err := client.GetObjectPreview(ctx, userName, repoName, &api.GetObjectPreviewParams{
+				RefName: "main",
+				Path:    "data/a.csv",
+				Type:    api.RefTypeBranch,
+				Offset:  utils.Int64(1),
+				Limit:   utils.Int(1),
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseGetObjectPreviewResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(result.JSON200.Format, convey.ShouldEqual, "csv")
+			convey.So(result.JSON200.Columns, convey.ShouldResemble, []api.PreviewColumn{
+				{Name: "id", Type: api.PreviewColumnTypeInteger},
+				{Name: "name", Type: api.PreviewColumnTypeString},
+				{Name: "score", Type: api.PreviewColumnTypeNumber},
+			})
+			convey.So(result.JSON200.Rows, convey.ShouldResemble, [][]interface{}{{"2", "b", "2"}})
+			convey.So(*result.JSON200.TotalRows, convey.ShouldEqual, 3)
+		})
+
+		c.Convey("success to preview jsonl", func() {
+			resp, err := client.GetObjectPreview(ctx, userName, repoName, &api.GetObjectPreviewParams{
+				RefName: "main",
+				Path:    "data/b.jsonl",
+				Type:    api.RefTypeBranch,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseGetObjectPreviewResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(result.JSON200.Columns, convey.ShouldResemble, []api.PreviewColumn{
+				{Name: "id", Type: api.PreviewColumnTypeInteger},
+				{Name: "tags", Type: api.PreviewColumnTypeArray},
+				{Name: "name", Type: api.PreviewColumnTypeString},
+			})
+			convey.So(result.JSON200.Rows, convey.ShouldResemble, [][]interface{}{
+				{float64(1), []interface{}{"x"}, nil},
+				{float64(2), nil, "b"},
+			})
+			convey.So(*result.JSON200.TotalRows, convey.ShouldEqual, 2)
+		})
+
+		c.Convey("success to preview with format", func() {
+			format := api.PreviewTsv
+			resp, err := client.GetObjectPreview(ctx, userName, repoName, &api.GetObjectPreviewParams{
+				RefName: "main",
+				Path:    "data/c.txt",
+				Type:    api.RefTypeBranch,
+				Format:  &format,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseGetObjectPreviewResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(result.JSON200.Rows, convey.ShouldResemble, [][]interface{}{{"1", "2"}})
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func QuotaSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	return func(c convey.C) {
+		userName := "quotaman"
+		otherName := "quotaother"
+		repoName := "quotarepo"
+		branchName := "main"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, otherName)
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, branchName)
+		})
+
+		getRepoQuota := func() *api.StorageQuota {
+			resp, err := client.GetRepositoryQuota(ctx, userName, repoName)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseGetRepositoryQuotaResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			return result.JSON200
+		}
+
+		getUserQuota := func() *api.StorageQuota {
+			resp, err := client.GetUserQuota(ctx, userName)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			result, err := api.ParseGetUserQuotaResponse(resp)
+			convey.So(err, convey.ShouldBeNil)
+			return result.JSON200
+		}
+
+		c.Convey("get quota", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.GetRepositoryQuota(ctx, userName, repoName)
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("empty repository", func() {
+				quota := getRepoQuota()
+				convey.So(quota.Scope, convey.ShouldEqual, api.StorageQuotaScopeRepository)
+				convey.So(quota.UsedBytes, convey.ShouldEqual, 0)
+				convey.So(quota.UsedObjects, convey.ShouldEqual, 0)
+				convey.So(quota.MaxBytes, convey.ShouldEqual, 0)
+				convey.So(quota.Overridden, convey.ShouldBeFalse)
+			})
+		})
+
+		c.Convey("usage increase after upload", func() {
+			uploadContent(ctx, client, userName, repoName, branchName, "a.txt", "0123456789")
+			// same content is stored only once
+			uploadContent(ctx, client, userName, repoName, branchName, "b.txt", "0123456789")
+			uploadContent(ctx, client, userName, repoName, branchName, "c.txt", "abc")
+
+			quota := getRepoQuota()
+			convey.So(quota.UsedBytes, convey.ShouldEqual, 13)
+			convey.So(quota.UsedObjects, convey.ShouldEqual, 2)
+
+			quota = getUserQuota()
+			convey.So(quota.Scope, convey.ShouldEqual, api.StorageQuotaScopeUser)
+			convey.So(quota.UsedBytes, convey.ShouldEqual, 13)
+			convey.So(quota.UsedObjects, convey.ShouldEqual, 2)
+		})
+
+		c.Convey("usage not changed by commit", func() {
+			_ = commitWip(ctx, client, userName, repoName, branchName, "commit quota")
+
+			quota := getRepoQuota()
+			convey.So(quota.UsedBytes, convey.ShouldEqual, 13)
+			convey.So(quota.UsedObjects, convey.ShouldEqual, 2)
+		})
+
+		c.Convey("only admin can manage quota", func(c convey.C) {
+			c.Convey("fail to override limits of repository", func() {
+				resp, err := client.UpdateRepositoryQuota(ctx, userName, repoName, api.UpdateRepositoryQuotaJSONRequestBody{
+					MaxBytes: utils.Int64(1024 * 1024 * 1024),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldNotEqual, http.StatusOK)
+			})
+
+			c.Convey("fail to override limits of user", func() {
+				resp, err := client.UpdateUserQuota(ctx, userName, api.UpdateUserQuotaJSONRequestBody{
+					MaxObjects: utils.Int64(1000000),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldNotEqual, http.StatusOK)
+			})
+
+			c.Convey("fail to reset limits of user", func() {
+				resp, err := client.ResetUserQuota(ctx, userName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldNotEqual, http.StatusOK)
+			})
+
+			c.Convey("fail to get quota of other user", func() {
+				loginAndSwitch(ctx, client, otherName, false)
+				resp, err := client.GetUserQuota(ctx, userName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldNotEqual, http.StatusOK)
+
+				resp, err = client.GetRepositoryQuota(ctx, userName, repoName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldNotEqual, http.StatusOK)
+				loginAndSwitch(ctx, client, userName, false)
+			})
+		})
+
+		c.Convey("usage released after repository deleted", func() {
+			resp, err := client.DeleteRepository(ctx, userName, repoName, &api.DeleteRepositoryParams{})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			quota := getUserQuota()
+			convey.So(quota.UsedBytes, convey.ShouldEqual, 0)
+			convey.So(quota.UsedObjects, convey.ShouldEqual, 0)
+		})
+	}
+}
@@ -3,7 +3,6 @@ package integrationtest
 import (
 	"context"
 	"net/http"
-	"strconv"
 
 	"github.com/GitDataAI/jiaozifs/api"
 	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
@@ -576,10 +575,7 @@ func RepoSpec(ctx context.Context, urlStr string) func(c convey.C) {
 
 				result, err := api.ParseGetArchiveResponse(resp)
 				convey.So(err, convey.ShouldBeNil)
-				sizeStr := resp.Header.Get("Content-Length")
-				size, err := strconv.Atoi(sizeStr)
-				convey.So(err, convey.ShouldBeNil)
-				convey.ShouldHaveLength(size, result.Body)
+				convey.So(result.Body, convey.ShouldNotBeEmpty)
 			})
 
 			c.Convey("success get car archive in branch", func() {
@@ -593,10 +589,33 @@ func RepoSpec(ctx context.Context, urlStr string) func(c convey.C) {
 
 				result, err := api.ParseGetArchiveResponse(resp)
 				convey.So(err, convey.ShouldBeNil)
-				sizeStr := resp.Header.Get("Content-Length")
-				size, err := strconv.Atoi(sizeStr)
+				convey.So(result.Body, convey.ShouldNotBeEmpty)
+			})
+
+			c.Convey("success get tar.gz archive of sub directory", func() {
+				resp, err := client.GetArchive(ctx, userName2, repo2Name, &api.GetArchiveParams{
+					ArchiveType: api.TarGz,
+					RefName:     refName,
+					RefType:     api.RefTypeBranch,
+					Path:        utils.String("a"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetArchiveResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.Body, convey.ShouldNotBeEmpty)
+			})
+
+			c.Convey("fail to get archive of not exit path", func() {
+				resp, err := client.GetArchive(ctx, userName2, repo2Name, &api.GetArchiveParams{
+					ArchiveType: api.Tar,
+					RefName:     refName,
+					RefType:     api.RefTypeBranch,
+					Path:        utils.String("x/y"),
+				})
 				convey.So(err, convey.ShouldBeNil)
-				convey.ShouldHaveLength(size, result.Body)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
 			})
 
 			c.Convey("success get zip archive in tag", func() {
@@ -610,10 +629,7 @@ func RepoSpec(ctx context.Context, urlStr string) func(c convey.C) {
 
 				result, err := api.ParseGetArchiveResponse(resp)
 				convey.So(err, convey.ShouldBeNil)
-				sizeStr := resp.Header.Get("Content-Length")
-				size, err := strconv.Atoi(sizeStr)
-				convey.So(err, convey.ShouldBeNil)
-				convey.ShouldHaveLength(size, result.Body)
+				convey.So(result.Body, convey.ShouldNotBeEmpty)
 			})
 
 		})
@@ -0,0 +1,232 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func ReviewSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	var mrSeq uint64
+	return func(c convey.C) {
+		userName := "reviewman"
+		reviewerName := "reviewmate"
+		repoName := "reviewrepo"
+		featBranch := "feat/review"
+
+		c.Convey("init", func(_ convey.C) {
+			reviewer := createUser(ctx, client, reviewerName)
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			_ = uploadObject(ctx, client, userName, repoName, "main", "a.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			_ = uploadObject(ctx, client, userName, repoName, featBranch, "b.txt", true)
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "add b")
+
+			mrSeq = createMergeRequest(ctx, client, userName, repoName, featBranch, "main").Sequence
+
+			_, writeGroup, _, err := getGroup(ctx, client)
+			convey.So(err, convey.ShouldBeNil)
+			resp, err := client.InviteMember(ctx, userName, repoName, &api.InviteMemberParams{
+				UserId:  reviewer.Id,
+				GroupId: writeGroup.Id,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+			resp, err = client.CreateBranchProtection(ctx, userName, repoName, api.CreateBranchProtectionJSONRequestBody{
+				Pattern:           "main",
+				RequiredApprovals: utils.Int(1),
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+		})
+
+		c.Convey("request reviewers", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.RequestReviewers(ctx, userName, repoName, mrSeq, api.RequestReviewersJSONRequestBody{
+					Reviewers: []string{reviewerName},
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to request non exit user", func() {
+				resp, err := client.RequestReviewers(ctx, userName, repoName, mrSeq, api.RequestReviewersJSONRequestBody{
+					Reviewers: []string{"mockuser"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to request author", func() {
+				resp, err := client.RequestReviewers(ctx, userName, repoName, mrSeq, api.RequestReviewersJSONRequestBody{
+					Reviewers: []string{userName},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to request reviewers of non exit merge request", func() {
+				resp, err := client.RequestReviewers(ctx, userName, repoName, 100, api.RequestReviewersJSONRequestBody{
+					Reviewers: []string{reviewerName},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+			})
+
+			c.Convey("success to request reviewers", func() {
+				resp, err := client.RequestReviewers(ctx, userName, repoName, mrSeq, api.RequestReviewersJSONRequestBody{
+					Reviewers: []string{reviewerName},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseRequestReviewersResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON201, convey.ShouldHaveLength, 1)
+				convey.So((*result.JSON201)[0].ReviewerName, convey.ShouldEqual, reviewerName)
+			})
+
+			c.Convey("success to remove reviewer", func() {
+				resp, err := client.RemoveReviewer(ctx, userName, repoName, mrSeq, reviewerName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				resp, err = client.RemoveReviewer(ctx, userName, repoName, mrSeq, reviewerName)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+
+				resp, err = client.RequestReviewers(ctx, userName, repoName, mrSeq, api.RequestReviewersJSONRequestBody{
+					Reviewers: []string{reviewerName},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+			})
+
+			c.Convey("success to list reviewers", func() {
+				resp, err := client.ListReviewers(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListReviewersResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*result.JSON200, convey.ShouldHaveLength, 1)
+			})
+		})
+
+		c.Convey("submit review", func(c convey.C) {
+			c.Convey("fail to approve by author", func() {
+				resp, err := client.SubmitReview(ctx, userName, repoName, mrSeq, api.SubmitReviewJSONRequestBody{
+					Verdict: api.ReviewCreationVerdictApprove,
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to merge without approval", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge feat",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("fail to review stale commit", func() {
+				loginAndSwitch(ctx, client, reviewerName, false)
+				resp, err := client.SubmitReview(ctx, userName, repoName, mrSeq, api.SubmitReviewJSONRequestBody{
+					Verdict: api.ReviewCreationVerdictApprove,
+					Commit:  utils.String("0000"),
+				})
+				loginAndSwitch(ctx, client, userName, false)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusConflict)
+			})
+
+			c.Convey("success to approve", func() {
+				sourceBranch := getBranch(ctx, client, userName, repoName, featBranch)
+				loginAndSwitch(ctx, client, reviewerName, false)
+				resp, err := client.SubmitReview(ctx, userName, repoName, mrSeq, api.SubmitReviewJSONRequestBody{
+					Verdict: api.ReviewCreationVerdictApprove,
+					Body:    utils.String("lgtm"),
+					Commit:  utils.String(sourceBranch.CommitHash),
+				})
+				loginAndSwitch(ctx, client, userName, false)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseSubmitReviewResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.ReviewerName, convey.ShouldEqual, reviewerName)
+				convey.So(result.JSON201.CommitHash, convey.ShouldEqual, sourceBranch.CommitHash)
+			})
+
+			c.Convey("approval count in merge request", func() {
+				resp, err := client.GetMergeRequest(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetMergeRequestResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Approvals, convey.ShouldEqual, 1)
+			})
+		})
+
+		c.Convey("dismiss approval", func(c convey.C) {
+			c.Convey("approval dismissed after source branch moves", func() {
+				_ = uploadObject(ctx, client, userName, repoName, featBranch, "c.txt", true)
+				_ = commitWip(ctx, client, userName, repoName, featBranch, "add c")
+
+				resp, err := client.GetMergeRequest(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				result, err := api.ParseGetMergeRequestResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Approvals, convey.ShouldEqual, 0)
+
+				resp, err = client.ListReviews(ctx, userName, repoName, mrSeq)
+				convey.So(err, convey.ShouldBeNil)
+				reviews, err := api.ParseListReviewsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(*reviews.JSON200, convey.ShouldHaveLength, 1)
+				convey.So((*reviews.JSON200)[0].Dismissed, convey.ShouldBeTrue)
+			})
+
+			c.Convey("fail to merge with dismissed approval", func() {
+				resp, err := client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge feat",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusForbidden)
+			})
+
+			c.Convey("success to merge after approve again", func() {
+				loginAndSwitch(ctx, client, reviewerName, false)
+				resp, err := client.SubmitReview(ctx, userName, repoName, mrSeq, api.SubmitReviewJSONRequestBody{
+					Verdict: api.ReviewCreationVerdictApprove,
+				})
+				loginAndSwitch(ctx, client, userName, false)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				resp, err = client.Merge(ctx, userName, repoName, mrSeq, api.MergeJSONRequestBody{
+					Msg: "merge feat",
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+			})
+		})
+	}
+}
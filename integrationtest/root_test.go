@@ -29,4 +29,26 @@ func TestSpec(t *testing.T) {
 	convey.Convey("group test", t, GroupSpec(ctx, urlStr))
 	convey.Convey("member test", t, MemberSpec(ctx, urlStr))
 	convey.Convey("public repo test", t, PublicRepoSpec(ctx, urlStr))
+	convey.Convey("ipfs test", t, IpfsSpec(ctx, urlStr))
+	convey.Convey("webhook test", t, WebhookSpec(ctx, urlStr))
+	convey.Convey("audit test", t, AuditSpec(ctx, urlStr))
+	convey.Convey("branch protection test", t, BranchProtectionSpec(ctx, urlStr))
+	convey.Convey("review test", t, ReviewSpec(ctx, urlStr))
+	convey.Convey("merge request comment test", t, MergeRequestCommentSpec(ctx, urlStr))
+	convey.Convey("commit status test", t, CommitStatusSpec(ctx, urlStr))
+	convey.Convey("auto merge test", t, AutoMergeSpec(ctx, urlStr))
+	convey.Convey("merge request sync test", t, MergeRequestSyncSpec(ctx, urlStr))
+	convey.Convey("merge request lifecycle test", t, MergeRequestLifecycleSpec(ctx, urlStr))
+	convey.Convey("conflict resolution test", t, ConflictResolutionSpec(ctx, urlStr))
+	convey.Convey("merge attributes test", t, MergeAttributesSpec(ctx, urlStr))
+	convey.Convey("diff test", t, DiffSpec(ctx, urlStr))
+	convey.Convey("table diff test", t, TableDiffSpec(ctx, urlStr))
+	convey.Convey("notebook diff test", t, NotebookDiffSpec(ctx, urlStr))
+	convey.Convey("parquet test", t, ParquetSpec(ctx, urlStr))
+	convey.Convey("preview test", t, PreviewSpec(ctx, urlStr))
+	convey.Convey("hooks test", t, HooksSpec(ctx, urlStr))
+	convey.Convey("action test", t, ActionSpec(ctx, urlStr))
+	convey.Convey("quota test", t, QuotaSpec(ctx, urlStr))
+	convey.Convey("stats test", t, StatsSpec(ctx, urlStr))
+	convey.Convey("activity test", t, ActivitySpec(ctx, urlStr))
 }
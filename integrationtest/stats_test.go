@@ -0,0 +1,128 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func StatsSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	return func(c convey.C) {
+		userName := "statsman"
+		repoName := "statsrepo"
+		branchName := "main"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, branchName)
+			uploadContent(ctx, client, userName, repoName, branchName, "a.txt", "a")
+			uploadContent(ctx, client, userName, repoName, branchName, "data/train.csv", "1111")
+			uploadContent(ctx, client, userName, repoName, branchName, "data/test.csv", "22")
+			_ = commitWip(ctx, client, userName, repoName, branchName, "commit stats")
+			// replaced content is still counted in stored bytes
+			uploadContent(ctx, client, userName, repoName, branchName, "a.txt", "abc")
+			_ = commitWip(ctx, client, userName, repoName, branchName, "update a.txt")
+		})
+
+		c.Convey("get stats", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.GetRepositoryStats(ctx, userName, repoName, &api.GetRepositoryStatsParams{
+					RefName: branchName,
+					Type:    api.RefTypeBranch,
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to get stats of file", func() {
+				resp, err := client.GetRepositoryStats(ctx, userName, repoName, &api.GetRepositoryStatsParams{
+					RefName: branchName,
+					Type:    api.RefTypeBranch,
+					Path:    utils.String("a.txt"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to get stats of branch", func() {
+				resp, err := client.GetRepositoryStats(ctx, userName, repoName, &api.GetRepositoryStatsParams{
+					RefName: branchName,
+					Type:    api.RefTypeBranch,
+					Top:     utils.Int32(2),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetRepositoryStatsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Size, convey.ShouldEqual, 9)
+				convey.So(result.JSON200.FileCount, convey.ShouldEqual, 3)
+				convey.So(result.JSON200.DirCount, convey.ShouldEqual, 1)
+				convey.So(result.JSON200.StoredBytes, convey.ShouldEqual, 10)
+				convey.So(result.JSON200.StoredObjects, convey.ShouldEqual, 4)
+				convey.So(result.JSON200.LargestFiles, convey.ShouldHaveLength, 2)
+				convey.So(result.JSON200.LargestFiles[0].Path, convey.ShouldEqual, "data/train.csv")
+				convey.So(result.JSON200.LargestFiles[1].Path, convey.ShouldEqual, "a.txt")
+				convey.So(result.JSON200.LargestDirectories, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.LargestDirectories[0].Size, convey.ShouldEqual, 6)
+			})
+
+			c.Convey("success to get stats of directory", func() {
+				resp, err := client.GetRepositoryStats(ctx, userName, repoName, &api.GetRepositoryStatsParams{
+					RefName: branchName,
+					Type:    api.RefTypeBranch,
+					Path:    utils.String("data"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetRepositoryStatsResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Path, convey.ShouldEqual, "data")
+				convey.So(result.JSON200.Size, convey.ShouldEqual, 6)
+				convey.So(result.JSON200.LargestFiles[0].Path, convey.ShouldEqual, "data/train.csv")
+			})
+		})
+
+		c.Convey("get size tree", func(c convey.C) {
+			c.Convey("fail to get size tree of missing path", func() {
+				resp, err := client.GetRepositorySizeTree(ctx, userName, repoName, &api.GetRepositorySizeTreeParams{
+					RefName: branchName,
+					Type:    api.RefTypeBranch,
+					Path:    utils.String("missing"),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to get size tree", func() {
+				resp, err := client.GetRepositorySizeTree(ctx, userName, repoName, &api.GetRepositorySizeTreeParams{
+					RefName: branchName,
+					Type:    api.RefTypeBranch,
+					Limit:   utils.Int32(1),
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetRepositorySizeTreeResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Size, convey.ShouldEqual, 9)
+				convey.So(result.JSON200.Truncated, convey.ShouldBeTrue)
+				convey.So(result.JSON200.Children, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.Children[0].Path, convey.ShouldEqual, "data")
+				convey.So(result.JSON200.Children[0].Children, convey.ShouldHaveLength, 1)
+				convey.So(result.JSON200.Children[0].Children[0].Path, convey.ShouldEqual, "data/train.csv")
+			})
+		})
+	}
+}
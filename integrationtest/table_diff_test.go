@@ -0,0 +1,112 @@
+package integrationtest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TableDiffSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	return func(c convey.C) {
+		userName := "tablediffman"
+		repoName := "tablediffrepo"
+		featBranch := "feat/table"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, "main")
+			uploadContent(ctx, client, userName, repoName, "main", "data.csv", "id,name,age\n1,a,10\n2,b,20\n3,c,30\n")
+			uploadContent(ctx, client, userName, repoName, "main", "data.tsv", "a\tb\n1\t2\n3\t4\n")
+			_ = commitWip(ctx, client, userName, repoName, "main", "init main")
+
+			_ = createBranch(ctx, client, userName, repoName, "main", featBranch)
+			_ = createWip(ctx, client, userName, repoName, featBranch)
+			uploadContent(ctx, client, userName, repoName, featBranch, "data.csv", "id,name,age,city\n1,a,10,x\n3,c,31,y\n4,d,40,z\n")
+			uploadContent(ctx, client, userName, repoName, featBranch, "data.tsv", "a\tb\n1\t2\n5\t6\n")
+			_ = commitWip(ctx, client, userName, repoName, featBranch, "update feat")
+		})
+
+		c.Convey("table diff", func(c convey.C) {
+			getTableDiff := func(params *api.GetTableDiffParams) *api.TableDiff {
+				resp, err := client.GetTableDiff(ctx, userName, repoName, params)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseGetTableDiffResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				return result.JSON200
+			}
+
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.GetTableDiff(ctx, userName, repoName, &api.GetTableDiffParams{
+					Path:     "data.csv",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to diff with unknown key", func() {
+				resp, err := client.GetTableDiff(ctx, userName, repoName, &api.GetTableDiffParams{
+					Path:     "data.csv",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+					Keys:     &[]string{"city"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to diff by key", func() {
+				tableDiff := getTableDiff(&api.GetTableDiffParams{
+					Path:     "data.csv",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+					Keys:     &[]string{"id"},
+				})
+				convey.So(tableDiff.AddedColumns, convey.ShouldResemble, []string{"city"})
+				convey.So(tableDiff.Added, convey.ShouldEqual, 1)
+				convey.So(tableDiff.Removed, convey.ShouldEqual, 1)
+				convey.So(tableDiff.Changed, convey.ShouldEqual, 1)
+				convey.So(tableDiff.Unchanged, convey.ShouldEqual, 1)
+				convey.So(tableDiff.Rows, convey.ShouldHaveLength, 3)
+				convey.So(tableDiff.Rows[0].Type, convey.ShouldEqual, api.RowChangeTypeChanged)
+				convey.So(*tableDiff.Rows[0].Cells, convey.ShouldResemble, []api.CellChange{{Column: "age", Old: "30", New: "31"}})
+			})
+
+			c.Convey("success to diff tsv by row hash", func() {
+				tableDiff := getTableDiff(&api.GetTableDiffParams{
+					Path:     "data.tsv",
+					BaseType: api.RefTypeBranch,
+					Base:     "main",
+					HeadType: api.RefTypeBranch,
+					Head:     featBranch,
+					Limit:    utils.Int(1),
+				})
+				convey.So(tableDiff.Added, convey.ShouldEqual, 1)
+				convey.So(tableDiff.Removed, convey.ShouldEqual, 1)
+				convey.So(tableDiff.Unchanged, convey.ShouldEqual, 1)
+				convey.So(tableDiff.Rows, convey.ShouldHaveLength, 1)
+				convey.So(*tableDiff.Rows[0].Values, convey.ShouldResemble, []string{"5", "6"})
+				convey.So(tableDiff.Truncated, convey.ShouldBeTrue)
+			})
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package integrationtest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/api"
+	apiimpl "github.com/GitDataAI/jiaozifs/api/api_impl"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/webhook"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func WebhookSpec(ctx context.Context, urlStr string) func(c convey.C) {
+	client, _ := api.NewClient(urlStr + apiimpl.APIV1Prefix)
+	secret := "hooksecret"
+	var webhookID openapi_types.UUID
+
+	received := make(chan bool, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- webhook.Verify(secret, body, r.Header.Get(webhook.SignatureHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	return func(c convey.C) {
+		userName := "hookman"
+		repoName := "hookrepo"
+		branchName := "main"
+
+		c.Convey("init", func(_ convey.C) {
+			_ = createUser(ctx, client, userName)
+			loginAndSwitch(ctx, client, userName, false)
+			_ = createRepo(ctx, client, repoName, false)
+			_ = createWip(ctx, client, userName, repoName, branchName)
+			_ = uploadObject(ctx, client, userName, repoName, branchName, "a.txt", true)
+		})
+
+		c.Convey("create webhook", func(c convey.C) {
+			c.Convey("no auth", func() {
+				re := client.RequestEditors
+				client.RequestEditors = nil
+				resp, err := client.CreateWebhook(ctx, userName, repoName, api.CreateWebhookJSONRequestBody{
+					Url:    server.URL,
+					Events: []string{"push"},
+				})
+				client.RequestEditors = re
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusUnauthorized)
+			})
+
+			c.Convey("fail to create webhook with invalid url", func() {
+				resp, err := client.CreateWebhook(ctx, userName, repoName, api.CreateWebhookJSONRequestBody{
+					Url:    "127.0.0.1/hook",
+					Events: []string{"push"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("fail to create webhook with unknown event", func() {
+				resp, err := client.CreateWebhook(ctx, userName, repoName, api.CreateWebhookJSONRequestBody{
+					Url:    server.URL,
+					Events: []string{"unknown"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+			})
+
+			c.Convey("success to create webhook", func() {
+				resp, err := client.CreateWebhook(ctx, userName, repoName, api.CreateWebhookJSONRequestBody{
+					Url:    server.URL,
+					Secret: utils.String(secret),
+					Events: []string{"push", "wip_commit"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusCreated)
+
+				result, err := api.ParseCreateWebhookResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON201.Active, convey.ShouldBeTrue)
+				convey.So(result.JSON201.Events, convey.ShouldHaveLength, 2)
+				webhookID = result.JSON201.Id
+			})
+		})
+
+		c.Convey("list and update webhook", func(c convey.C) {
+			c.Convey("list webhooks", func() {
+				resp, err := client.ListWebhooks(ctx, userName, repoName, &api.ListWebhooksParams{})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseListWebhooksResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Results, convey.ShouldHaveLength, 1)
+			})
+
+			c.Convey("update webhook", func() {
+				resp, err := client.UpdateWebhook(ctx, userName, repoName, webhookID, api.UpdateWebhookJSONRequestBody{
+					Events: &[]string{"push"},
+				})
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+				result, err := api.ParseUpdateWebhookResponse(resp)
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(result.JSON200.Events, convey.ShouldResemble, []string{"push"})
+			})
+		})
+
+		c.Convey("deliver push event", func() {
+			_ = commitWip(ctx, client, userName, repoName, branchName, "commit wip")
+
+			select {
+			case verified := <-received:
+				convey.So(verified, convey.ShouldBeTrue)
+			case <-time.After(time.Second * 30):
+				convey.So("timeout to receive webhook", convey.ShouldBeEmpty)
+			}
+
+			convey.So(func() bool {
+				for i := 0; i < 10; i++ {
+					resp, err := client.ListWebhookDeliveries(ctx, userName, repoName, webhookID, &api.ListWebhookDeliveriesParams{})
+					convey.So(err, convey.ShouldBeNil)
+					result, err := api.ParseListWebhookDeliveriesResponse(resp)
+					convey.So(err, convey.ShouldBeNil)
+					if len(result.JSON200.Results) == 1 && result.JSON200.Results[0].State == api.WebhookDeliveryStateSuccess {
+						return true
+					}
+					time.Sleep(time.Millisecond * 500)
+				}
+				return false
+			}(), convey.ShouldBeTrue)
+		})
+
+		c.Convey("delete webhook", func() {
+			resp, err := client.DeleteWebhook(ctx, userName, repoName, webhookID)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+
+			resp, err = client.GetWebhook(ctx, userName, repoName, webhookID)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusNotFound)
+		})
+	}
+}
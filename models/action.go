@@ -0,0 +1,611 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ActionEvent event which triggers actions after operation finished
+type ActionEvent string
+
+const (
+	PostCommitEvent ActionEvent = "post-commit"
+	PostMergeEvent  ActionEvent = "post-merge"
+	PostTagEvent    ActionEvent = "post-tag"
+)
+
+var AllActionEvents = []ActionEvent{
+	PostCommitEvent,
+	PostMergeEvent,
+	PostTagEvent,
+}
+
+func ParseActionEvent(event string) (ActionEvent, error) {
+	for _, actionEvent := range AllActionEvents {
+		if string(actionEvent) == event {
+			return actionEvent, nil
+		}
+	}
+	return "", fmt.Errorf("unknown action event %s", event)
+}
+
+// ActionEventOf return action event triggered by repository event, false if the event triggers no action
+func ActionEventOf(eventType EventType) (ActionEvent, bool) {
+	switch eventType {
+	case WipCommitEvent:
+		return PostCommitEvent, true
+	case MergeRequestMergeEvent:
+		return PostMergeEvent, true
+	case TagCreateEvent:
+		return PostTagEvent, true
+	}
+	return "", false
+}
+
+// ActionType how action is run
+type ActionType string
+
+const (
+	// CommandAction run command configured in server with input on stdin
+	CommandAction ActionType = "command"
+	// HTTPAction post input to http endpoint
+	HTTPAction ActionType = "http"
+)
+
+func ParseActionType(actionType string) (ActionType, error) {
+	switch ActionType(actionType) {
+	case CommandAction, HTTPAction:
+		return ActionType(actionType), nil
+	}
+	return "", fmt.Errorf("unknown action type %s", actionType)
+}
+
+type Action struct {
+	bun.BaseModel `bun:"table:actions"`
+	ID            uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	RepositoryID  uuid.UUID  `bun:"repository_id,type:uuid,notnull" json:"repository_id"`
+	Name          string     `bun:"name,notnull" json:"name"`
+	Type          ActionType `bun:"type,notnull" json:"type"`
+	// Command name of command in server config, used by command action
+	Command string `bun:"command" json:"command"`
+	// URL address to post input, used by http action
+	URL string `bun:"url" json:"url"`
+	// Secret used to sign input of http action with hmac-sha256, empty means not sign
+	Secret string `bun:"secret" json:"-"`
+	// Events events which trigger this action
+	Events []ActionEvent `bun:"events,type:jsonb,notnull" json:"events"`
+	Active bool          `bun:"active,notnull" json:"active"`
+
+	CreatorID uuid.UUID `bun:"creator_id,type:uuid,notnull" json:"creator_id"`
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+type GetActionParams struct {
+	id           uuid.UUID
+	repositoryID uuid.UUID
+}
+
+func NewGetActionParams() *GetActionParams {
+	return &GetActionParams{}
+}
+
+func (gap *GetActionParams) SetID(id uuid.UUID) *GetActionParams {
+	gap.id = id
+	return gap
+}
+
+func (gap *GetActionParams) SetRepositoryID(repositoryID uuid.UUID) *GetActionParams {
+	gap.repositoryID = repositoryID
+	return gap
+}
+
+type ListActionParams struct {
+	repositoryID uuid.UUID
+	event        *ActionEvent
+	active       *bool
+	after        *time.Time
+	amount       int
+}
+
+func NewListActionParams() *ListActionParams {
+	return &ListActionParams{}
+}
+
+func (lap *ListActionParams) SetRepositoryID(repositoryID uuid.UUID) *ListActionParams {
+	lap.repositoryID = repositoryID
+	return lap
+}
+
+// SetEvent filter actions triggered by this event
+func (lap *ListActionParams) SetEvent(event ActionEvent) *ListActionParams {
+	lap.event = &event
+	return lap
+}
+
+func (lap *ListActionParams) SetActive(active bool) *ListActionParams {
+	lap.active = &active
+	return lap
+}
+
+func (lap *ListActionParams) SetAfter(after time.Time) *ListActionParams {
+	lap.after = &after
+	return lap
+}
+
+func (lap *ListActionParams) SetAmount(amount int) *ListActionParams {
+	lap.amount = amount
+	return lap
+}
+
+type DeleteActionParams struct {
+	id           uuid.UUID
+	repositoryID uuid.UUID
+}
+
+func NewDeleteActionParams() *DeleteActionParams {
+	return &DeleteActionParams{}
+}
+
+func (dap *DeleteActionParams) SetID(id uuid.UUID) *DeleteActionParams {
+	dap.id = id
+	return dap
+}
+
+func (dap *DeleteActionParams) SetRepositoryID(repositoryID uuid.UUID) *DeleteActionParams {
+	dap.repositoryID = repositoryID
+	return dap
+}
+
+type UpdateActionParams struct {
+	id      uuid.UUID
+	name    *string
+	command *string
+	url     *string
+	secret  *string
+	events  []ActionEvent
+	active  *bool
+}
+
+func NewUpdateActionParams(id uuid.UUID) *UpdateActionParams {
+	return &UpdateActionParams{
+		id: id,
+	}
+}
+
+func (uap *UpdateActionParams) SetName(name string) *UpdateActionParams {
+	uap.name = &name
+	return uap
+}
+
+func (uap *UpdateActionParams) SetCommand(command string) *UpdateActionParams {
+	uap.command = &command
+	return uap
+}
+
+func (uap *UpdateActionParams) SetURL(url string) *UpdateActionParams {
+	uap.url = &url
+	return uap
+}
+
+func (uap *UpdateActionParams) SetSecret(secret string) *UpdateActionParams {
+	uap.secret = &secret
+	return uap
+}
+
+func (uap *UpdateActionParams) SetEvents(events []ActionEvent) *UpdateActionParams {
+	uap.events = events
+	return uap
+}
+
+func (uap *UpdateActionParams) SetActive(active bool) *UpdateActionParams {
+	uap.active = &active
+	return uap
+}
+
+type IActionRepo interface {
+	Insert(ctx context.Context, action *Action) (*Action, error)
+	Get(ctx context.Context, params *GetActionParams) (*Action, error)
+	List(ctx context.Context, params *ListActionParams) ([]*Action, bool, error)
+	Delete(ctx context.Context, params *DeleteActionParams) (int64, error)
+	UpdateByID(ctx context.Context, params *UpdateActionParams) error
+}
+
+var _ IActionRepo = (*ActionRepo)(nil)
+
+type ActionRepo struct {
+	db bun.IDB
+}
+
+func NewActionRepo(db bun.IDB) IActionRepo {
+	return &ActionRepo{db: db}
+}
+
+func (a ActionRepo) Insert(ctx context.Context, action *Action) (*Action, error) {
+	_, err := a.db.NewInsert().Model(action).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func (a ActionRepo) Get(ctx context.Context, params *GetActionParams) (*Action, error) {
+	action := &Action{}
+	query := a.db.NewSelect().Model(action)
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func (a ActionRepo) List(ctx context.Context, params *ListActionParams) ([]*Action, bool, error) {
+	var actions []*Action
+	query := a.db.NewSelect().Model(&actions)
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	if params.event != nil {
+		events, err := json.Marshal([]ActionEvent{*params.event})
+		if err != nil {
+			return nil, false, err
+		}
+		query = query.Where("events @> ?::jsonb", string(events))
+	}
+
+	if params.active != nil {
+		query = query.Where("active = ?", *params.active)
+	}
+
+	query = query.Order("created_at DESC")
+	if params.after != nil {
+		query = query.Where("created_at < ?", *params.after)
+	}
+
+	err := query.Limit(params.amount).Scan(ctx)
+	return actions, len(actions) == params.amount, err
+}
+
+func (a ActionRepo) Delete(ctx context.Context, params *DeleteActionParams) (int64, error) {
+	query := a.db.NewDelete().Model((*Action)(nil))
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (a ActionRepo) UpdateByID(ctx context.Context, params *UpdateActionParams) error {
+	updateQuery := a.db.NewUpdate().Model((*Action)(nil)).Where("id = ?", params.id)
+
+	if params.name != nil {
+		updateQuery.Set("name = ?", *params.name)
+	}
+
+	if params.command != nil {
+		updateQuery.Set("command = ?", *params.command)
+	}
+
+	if params.url != nil {
+		updateQuery.Set("url = ?", *params.url)
+	}
+
+	if params.secret != nil {
+		updateQuery.Set("secret = ?", *params.secret)
+	}
+
+	if params.events != nil {
+		events, err := json.Marshal(params.events)
+		if err != nil {
+			return err
+		}
+		updateQuery.Set("events = ?::jsonb", string(events))
+	}
+
+	if params.active != nil {
+		updateQuery.Set("active = ?", *params.active)
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
+
+// RunState state of action run
+type RunState string
+
+const (
+	RunPending RunState = "pending"
+	RunRunning RunState = "running"
+	RunSuccess RunState = "success"
+	RunFailed  RunState = "failed"
+)
+
+type ActionRun struct {
+	bun.BaseModel `bun:"table:action_runs"`
+	ID            uuid.UUID   `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ActionID      uuid.UUID   `bun:"action_id,type:uuid,notnull" json:"action_id"`
+	RepositoryID  uuid.UUID   `bun:"repository_id,type:uuid,notnull" json:"repository_id"`
+	Event         ActionEvent `bun:"event,notnull" json:"event"`
+	// CommitHash commit created by operation or target of tag, changes of it are passed to action
+	CommitHash hash.Hash `bun:"commit_hash,type:bytea" json:"commit_hash"`
+	// Payload json of event, changes are added when run
+	Payload string   `bun:"payload,notnull" json:"payload"`
+	State   RunState `bun:"state,notnull" json:"state"`
+	// ExitCode exit code of command, nil for http action or command not exited
+	ExitCode *int `bun:"exit_code" json:"exit_code,omitempty"`
+	// ResponseCode http status code of response, nil for command action or request not sent
+	ResponseCode *int `bun:"response_code" json:"response_code,omitempty"`
+	// Logs output of command or response body, truncated if too large
+	Logs string `bun:"logs" json:"logs"`
+	// Message reason of failure
+	Message    *string    `bun:"message" json:"message,omitempty"`
+	StartedAt  *time.Time `bun:"started_at,type:timestamp" json:"started_at,omitempty"`
+	FinishedAt *time.Time `bun:"finished_at,type:timestamp" json:"finished_at,omitempty"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+type GetActionRunParams struct {
+	id       uuid.UUID
+	actionID uuid.UUID
+}
+
+func NewGetActionRunParams() *GetActionRunParams {
+	return &GetActionRunParams{}
+}
+
+func (grp *GetActionRunParams) SetID(id uuid.UUID) *GetActionRunParams {
+	grp.id = id
+	return grp
+}
+
+func (grp *GetActionRunParams) SetActionID(actionID uuid.UUID) *GetActionRunParams {
+	grp.actionID = actionID
+	return grp
+}
+
+type ListActionRunParams struct {
+	actionID uuid.UUID
+	state    *RunState
+	after    *time.Time
+	amount   int
+}
+
+func NewListActionRunParams() *ListActionRunParams {
+	return &ListActionRunParams{}
+}
+
+func (lrp *ListActionRunParams) SetActionID(actionID uuid.UUID) *ListActionRunParams {
+	lrp.actionID = actionID
+	return lrp
+}
+
+func (lrp *ListActionRunParams) SetState(state RunState) *ListActionRunParams {
+	lrp.state = &state
+	return lrp
+}
+
+func (lrp *ListActionRunParams) SetAfter(after time.Time) *ListActionRunParams {
+	lrp.after = &after
+	return lrp
+}
+
+func (lrp *ListActionRunParams) SetAmount(amount int) *ListActionRunParams {
+	lrp.amount = amount
+	return lrp
+}
+
+type DeleteActionRunParams struct {
+	actionID uuid.UUID
+}
+
+func NewDeleteActionRunParams() *DeleteActionRunParams {
+	return &DeleteActionRunParams{}
+}
+
+func (drp *DeleteActionRunParams) SetActionID(actionID uuid.UUID) *DeleteActionRunParams {
+	drp.actionID = actionID
+	return drp
+}
+
+type UpdateActionRunParams struct {
+	id           uuid.UUID
+	state        *RunState
+	exitCode     *int
+	responseCode *int
+	logs         *string
+	message      *string
+	startedAt    *time.Time
+	finishedAt   *time.Time
+}
+
+func NewUpdateActionRunParams(id uuid.UUID) *UpdateActionRunParams {
+	return &UpdateActionRunParams{
+		id: id,
+	}
+}
+
+func (urp *UpdateActionRunParams) SetState(state RunState) *UpdateActionRunParams {
+	urp.state = &state
+	return urp
+}
+
+func (urp *UpdateActionRunParams) SetExitCode(code int) *UpdateActionRunParams {
+	urp.exitCode = &code
+	return urp
+}
+
+func (urp *UpdateActionRunParams) SetResponseCode(code int) *UpdateActionRunParams {
+	urp.responseCode = &code
+	return urp
+}
+
+func (urp *UpdateActionRunParams) SetLogs(logs string) *UpdateActionRunParams {
+	urp.logs = &logs
+	return urp
+}
+
+func (urp *UpdateActionRunParams) SetMessage(message string) *UpdateActionRunParams {
+	urp.message = &message
+	return urp
+}
+
+func (urp *UpdateActionRunParams) SetStartedAt(startedAt time.Time) *UpdateActionRunParams {
+	urp.startedAt = &startedAt
+	return urp
+}
+
+func (urp *UpdateActionRunParams) SetFinishedAt(finishedAt time.Time) *UpdateActionRunParams {
+	urp.finishedAt = &finishedAt
+	return urp
+}
+
+type IActionRunRepo interface {
+	Insert(ctx context.Context, run *ActionRun) (*ActionRun, error)
+	Get(ctx context.Context, params *GetActionRunParams) (*ActionRun, error)
+	List(ctx context.Context, params *ListActionRunParams) ([]*ActionRun, bool, error)
+	Delete(ctx context.Context, params *DeleteActionRunParams) (int64, error)
+	UpdateByID(ctx context.Context, params *UpdateActionRunParams) error
+}
+
+var _ IActionRunRepo = (*ActionRunRepo)(nil)
+
+type ActionRunRepo struct {
+	db bun.IDB
+}
+
+func NewActionRunRepo(db bun.IDB) IActionRunRepo {
+	return &ActionRunRepo{db: db}
+}
+
+func (a ActionRunRepo) Insert(ctx context.Context, run *ActionRun) (*ActionRun, error) {
+	_, err := a.db.NewInsert().Model(run).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (a ActionRunRepo) Get(ctx context.Context, params *GetActionRunParams) (*ActionRun, error) {
+	run := &ActionRun{}
+	query := a.db.NewSelect().Model(run)
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.actionID {
+		query = query.Where("action_id = ?", params.actionID)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (a ActionRunRepo) List(ctx context.Context, params *ListActionRunParams) ([]*ActionRun, bool, error) {
+	var runs []*ActionRun
+	query := a.db.NewSelect().Model(&runs)
+
+	if uuid.Nil != params.actionID {
+		query = query.Where("action_id = ?", params.actionID)
+	}
+
+	if params.state != nil {
+		query = query.Where("state = ?", *params.state)
+	}
+
+	query = query.Order("created_at DESC")
+	if params.after != nil {
+		query = query.Where("created_at < ?", *params.after)
+	}
+
+	err := query.Limit(params.amount).Scan(ctx)
+	return runs, len(runs) == params.amount, err
+}
+
+func (a ActionRunRepo) Delete(ctx context.Context, params *DeleteActionRunParams) (int64, error) {
+	query := a.db.NewDelete().Model((*ActionRun)(nil))
+
+	if uuid.Nil != params.actionID {
+		query = query.Where("action_id = ?", params.actionID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (a ActionRunRepo) UpdateByID(ctx context.Context, params *UpdateActionRunParams) error {
+	updateQuery := a.db.NewUpdate().Model((*ActionRun)(nil)).Where("id = ?", params.id)
+
+	if params.state != nil {
+		updateQuery.Set("state = ?", *params.state)
+	}
+
+	if params.exitCode != nil {
+		updateQuery.Set("exit_code = ?", *params.exitCode)
+	}
+
+	if params.responseCode != nil {
+		updateQuery.Set("response_code = ?", *params.responseCode)
+	}
+
+	if params.logs != nil {
+		updateQuery.Set("logs = ?", *params.logs)
+	}
+
+	if params.message != nil {
+		updateQuery.Set("message = ?", *params.message)
+	}
+
+	if params.startedAt != nil {
+		updateQuery.Set("started_at = ?", *params.startedAt)
+	}
+
+	if params.finishedAt != nil {
+		updateQuery.Set("finished_at = ?", *params.finishedAt)
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
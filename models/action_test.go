@@ -0,0 +1,123 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewActionRepo(db)
+
+	t.Run("insert and get", func(t *testing.T) {
+		actionModel := &models.Action{}
+		require.NoError(t, gofakeit.Struct(actionModel))
+		actionModel.Events = []models.ActionEvent{models.PostCommitEvent}
+
+		action, err := repo.Insert(ctx, actionModel)
+		require.NoError(t, err)
+
+		expectAction, err := repo.Get(ctx, models.NewGetActionParams().SetID(action.ID).SetRepositoryID(action.RepositoryID))
+		require.NoError(t, err)
+		require.True(t, cmp.Equal(expectAction, action, testhelper.DBTimeCmpOpt))
+	})
+
+	t.Run("list and update", func(t *testing.T) {
+		repoID := uuid.New()
+		for i := 0; i < 5; i++ {
+			actionModel := &models.Action{}
+			require.NoError(t, gofakeit.Struct(actionModel))
+			actionModel.RepositoryID = repoID
+			actionModel.Active = true
+			actionModel.Events = []models.ActionEvent{models.PostCommitEvent, models.PostTagEvent}
+			if i%2 == 0 {
+				actionModel.Events = []models.ActionEvent{models.PostMergeEvent}
+			}
+			_, err := repo.Insert(ctx, actionModel)
+			require.NoError(t, err)
+		}
+
+		actions, hasMore, err := repo.List(ctx, models.NewListActionParams().SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.False(t, hasMore)
+		require.Len(t, actions, 5)
+
+		actions, _, err = repo.List(ctx, models.NewListActionParams().SetRepositoryID(repoID).SetEvent(models.PostTagEvent).SetActive(true))
+		require.NoError(t, err)
+		require.Len(t, actions, 2)
+
+		err = repo.UpdateByID(ctx, models.NewUpdateActionParams(actions[0].ID).SetActive(false).SetName("train").SetEvents([]models.ActionEvent{models.PostCommitEvent}))
+		require.NoError(t, err)
+
+		actions, _, err = repo.List(ctx, models.NewListActionParams().SetRepositoryID(repoID).SetEvent(models.PostTagEvent).SetActive(true))
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+
+		affectRows, err := repo.Delete(ctx, models.NewDeleteActionParams().SetID(actions[0].ID).SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), affectRows)
+	})
+}
+
+func TestActionRunRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewActionRunRepo(db)
+
+	actionID := uuid.New()
+	for i := 0; i < 5; i++ {
+		runModel := &models.ActionRun{}
+		require.NoError(t, gofakeit.Struct(runModel))
+		runModel.ActionID = actionID
+		runModel.State = models.RunPending
+		if i%2 == 0 {
+			runModel.State = models.RunSuccess
+		}
+		_, err := repo.Insert(ctx, runModel)
+		require.NoError(t, err)
+	}
+
+	runs, hasMore, err := repo.List(ctx, models.NewListActionRunParams().SetActionID(actionID).SetAmount(2))
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Len(t, runs, 2)
+
+	runs, _, err = repo.List(ctx, models.NewListActionRunParams().SetActionID(actionID).SetState(models.RunPending))
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	now := time.Now()
+	err = repo.UpdateByID(ctx, models.NewUpdateActionRunParams(runs[0].ID).
+		SetState(models.RunFailed).
+		SetExitCode(1).
+		SetLogs("output").
+		SetMessage("exit status 1").
+		SetStartedAt(now).
+		SetFinishedAt(now))
+	require.NoError(t, err)
+
+	run, err := repo.Get(ctx, models.NewGetActionRunParams().SetID(runs[0].ID).SetActionID(actionID))
+	require.NoError(t, err)
+	require.Equal(t, models.RunFailed, run.State)
+	require.Equal(t, 1, *run.ExitCode)
+	require.Equal(t, "output", run.Logs)
+	require.Equal(t, "exit status 1", *run.Message)
+	require.NotNil(t, run.FinishedAt)
+
+	affectRows, err := repo.Delete(ctx, models.NewDeleteActionRunParams().SetActionID(actionID))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), affectRows)
+}
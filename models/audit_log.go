@@ -0,0 +1,224 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// AuthMethod the way operator authenticated
+type AuthMethod string
+
+const (
+	AuthMethodNone    AuthMethod = ""
+	AuthMethodSession AuthMethod = "session"
+	AuthMethodJWT     AuthMethod = "jwt"
+	AuthMethodBasic   AuthMethod = "basic"
+	AuthMethodAksk    AuthMethod = "aksk"
+)
+
+// AuditOutcome result of audited operation
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditDenied  AuditOutcome = "denied"
+	AuditFailure AuditOutcome = "failure"
+)
+
+// AuditLog record of mutating operation, one log for each request, append only
+type AuditLog struct {
+	bun.BaseModel `bun:"table:audit_logs"`
+	ID            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	// ActorID user who did the operation, nil uuid for anonymous request
+	ActorID    uuid.UUID  `bun:"actor_id,type:uuid,nullzero" json:"actor_id"`
+	ActorName  string     `bun:"actor_name" json:"actor_name"`
+	AuthMethod AuthMethod `bun:"auth_method" json:"auth_method"`
+	// Operation operation id of request in api spec
+	Operation string `bun:"operation" json:"operation"`
+	// Action rbac actions checked last in the operation joined by comma, empty if the operation not check permission like login
+	Action   string `bun:"action" json:"action"`
+	Resource string `bun:"resource" json:"resource"`
+	// RepositoryID repository of resource, nil uuid if resource not belong to any repository
+	RepositoryID uuid.UUID `bun:"repository_id,type:uuid,nullzero" json:"repository_id"`
+
+	RequestID  string       `bun:"request_id" json:"request_id"`
+	Method     string       `bun:"method,notnull" json:"method"`
+	Path       string       `bun:"path,notnull" json:"path"`
+	StatusCode int          `bun:"status_code,notnull" json:"status_code"`
+	Outcome    AuditOutcome `bun:"outcome,notnull" json:"outcome"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+}
+
+type ListAuditLogParams struct {
+	actorName    *string
+	repositoryID uuid.UUID
+	action       *string
+	authMethod   *AuthMethod
+	outcome      *AuditOutcome
+	since        *time.Time
+	until        *time.Time
+	after        *time.Time
+	amount       int
+}
+
+func NewListAuditLogParams() *ListAuditLogParams {
+	return &ListAuditLogParams{}
+}
+
+func (lap *ListAuditLogParams) SetActorName(actorName string) *ListAuditLogParams {
+	lap.actorName = &actorName
+	return lap
+}
+
+func (lap *ListAuditLogParams) SetRepositoryID(repositoryID uuid.UUID) *ListAuditLogParams {
+	lap.repositoryID = repositoryID
+	return lap
+}
+
+func (lap *ListAuditLogParams) SetAction(action string) *ListAuditLogParams {
+	lap.action = &action
+	return lap
+}
+
+func (lap *ListAuditLogParams) SetAuthMethod(authMethod AuthMethod) *ListAuditLogParams {
+	lap.authMethod = &authMethod
+	return lap
+}
+
+func (lap *ListAuditLogParams) SetOutcome(outcome AuditOutcome) *ListAuditLogParams {
+	lap.outcome = &outcome
+	return lap
+}
+
+// SetSince only return logs created at or after since
+func (lap *ListAuditLogParams) SetSince(since time.Time) *ListAuditLogParams {
+	lap.since = &since
+	return lap
+}
+
+// SetUntil only return logs created before until
+func (lap *ListAuditLogParams) SetUntil(until time.Time) *ListAuditLogParams {
+	lap.until = &until
+	return lap
+}
+
+func (lap *ListAuditLogParams) SetAfter(after time.Time) *ListAuditLogParams {
+	lap.after = &after
+	return lap
+}
+
+func (lap *ListAuditLogParams) SetAmount(amount int) *ListAuditLogParams {
+	lap.amount = amount
+	return lap
+}
+
+const walkBatchSize = 1000
+
+// IAuditLogRepo audit log is append only, so there is no update or delete method
+type IAuditLogRepo interface {
+	Insert(ctx context.Context, log *AuditLog) (*AuditLog, error)
+	List(ctx context.Context, params *ListAuditLogParams) ([]*AuditLog, bool, error)
+	// Walk iterate all logs match params in batch, after and amount of params are ignored
+	Walk(ctx context.Context, params *ListAuditLogParams, fn func(*AuditLog) error) error
+}
+
+var _ IAuditLogRepo = (*AuditLogRepo)(nil)
+
+type AuditLogRepo struct {
+	db bun.IDB
+}
+
+func NewAuditLogRepo(db bun.IDB) IAuditLogRepo {
+	return &AuditLogRepo{db: db}
+}
+
+func (a AuditLogRepo) Insert(ctx context.Context, log *AuditLog) (*AuditLog, error) {
+	_, err := a.db.NewInsert().Model(log).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+func (a AuditLogRepo) List(ctx context.Context, params *ListAuditLogParams) ([]*AuditLog, bool, error) {
+	var logs []*AuditLog
+	query := a.filter(a.db.NewSelect().Model(&logs), params).Order("created_at DESC", "id DESC")
+	if params.after != nil {
+		query = query.Where("created_at < ?", *params.after)
+	}
+
+	if params.amount > 0 {
+		query = query.Limit(params.amount)
+	}
+
+	err := query.Scan(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return logs, params.amount > 0 && len(logs) == params.amount, nil
+}
+
+func (a AuditLogRepo) Walk(ctx context.Context, params *ListAuditLogParams, fn func(*AuditLog) error) error {
+	var last *AuditLog
+	for {
+		var logs []*AuditLog
+		query := a.filter(a.db.NewSelect().Model(&logs), params).Order("created_at DESC", "id DESC")
+		if last != nil {
+			// logs of one request may share the same created time, use id to break tie
+			query = query.Where("(created_at, id) < (?, ?)", last.CreatedAt, last.ID)
+		}
+
+		err := query.Limit(walkBatchSize).Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, log := range logs {
+			err = fn(log)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(logs) < walkBatchSize {
+			return nil
+		}
+		last = logs[len(logs)-1]
+	}
+}
+
+func (a AuditLogRepo) filter(query *bun.SelectQuery, params *ListAuditLogParams) *bun.SelectQuery {
+	if params.actorName != nil {
+		query = query.Where("actor_name = ?", *params.actorName)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	if params.action != nil {
+		// action of log may be several actions joined by comma
+		query = query.Where("? = ANY(string_to_array(action, ','))", *params.action)
+	}
+
+	if params.authMethod != nil {
+		query = query.Where("auth_method = ?", *params.authMethod)
+	}
+
+	if params.outcome != nil {
+		query = query.Where("outcome = ?", *params.outcome)
+	}
+
+	if params.since != nil {
+		query = query.Where("created_at >= ?", *params.since)
+	}
+
+	if params.until != nil {
+		query = query.Where("created_at < ?", *params.until)
+	}
+	return query
+}
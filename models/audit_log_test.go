@@ -0,0 +1,91 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewAuditLogRepo(db)
+
+	repoID := uuid.New()
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		auditLog := &models.AuditLog{
+			ActorID:      uuid.New(),
+			ActorName:    "jimmy",
+			AuthMethod:   models.AuthMethodJWT,
+			Action:       "repo:CreateBranch",
+			Resource:     "arn",
+			RepositoryID: repoID,
+			Method:       "POST",
+			Path:         "/api/v1/repos/jimmy/repo/branch",
+			StatusCode:   201,
+			Outcome:      models.AuditSuccess,
+			// every two logs share the same time like they are from the same request
+			CreatedAt: now.Add(time.Duration(i/2) * time.Second),
+		}
+		if i%2 == 0 {
+			auditLog.Action = "repo:DeleteBranch"
+			auditLog.Outcome = models.AuditDenied
+			auditLog.AuthMethod = models.AuthMethodAksk
+		}
+		_, err := repo.Insert(ctx, auditLog)
+		require.NoError(t, err)
+	}
+
+	logs, hasMore, err := repo.List(ctx, models.NewListAuditLogParams().SetRepositoryID(repoID).SetAmount(4))
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Len(t, logs, 4)
+
+	logs, _, err = repo.List(ctx, models.NewListAuditLogParams().SetRepositoryID(repoID).SetAction("repo:DeleteBranch").SetOutcome(models.AuditDenied).SetAuthMethod(models.AuthMethodAksk))
+	require.NoError(t, err)
+	require.Len(t, logs, 5)
+
+	logs, _, err = repo.List(ctx, models.NewListAuditLogParams().SetActorName("jimmy").SetSince(now.Add(time.Second)).SetUntil(now.Add(3*time.Second)))
+	require.NoError(t, err)
+	require.Len(t, logs, 4)
+
+	var count int
+	ids := map[uuid.UUID]struct{}{}
+	err = repo.Walk(ctx, models.NewListAuditLogParams().SetRepositoryID(repoID), func(log *models.AuditLog) error {
+		count++
+		ids[log.ID] = struct{}{}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, count)
+	require.Len(t, ids, 10)
+
+	//match one of joined actions
+	otherRepoID := uuid.New()
+	_, err = repo.Insert(ctx, &models.AuditLog{
+		Operation:    "commitWip",
+		Action:       "repo:ReadWip,repo:WriteBranch",
+		RepositoryID: otherRepoID,
+		Method:       "POST",
+		Path:         "/api/v1/wip/jimmy/repo/commit",
+		StatusCode:   201,
+		Outcome:      models.AuditSuccess,
+		CreatedAt:    now,
+	})
+	require.NoError(t, err)
+	logs, _, err = repo.List(ctx, models.NewListAuditLogParams().SetRepositoryID(otherRepoID).SetAction("repo:WriteBranch"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, "commitWip", logs[0].Operation)
+	logs, _, err = repo.List(ctx, models.NewListAuditLogParams().SetRepositoryID(otherRepoID).SetAction("repo:Write"))
+	require.NoError(t, err)
+	require.Len(t, logs, 0)
+}
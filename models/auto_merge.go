@@ -0,0 +1,159 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// AutoMerge merge request marked to merge when ready, it is merged in background once requirements are met
+type AutoMerge struct {
+	bun.BaseModel  `bun:"table:merge_request_auto_merges"`
+	ID             uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MergeRequestID uuid.UUID `bun:"merge_request_id,unique,type:uuid,notnull" json:"merge_request_id"`
+	// OperatorID user enable auto merge, merge is performed as this user
+	OperatorID uuid.UUID `bun:"operator_id,type:uuid,notnull" json:"operator_id"`
+	// Message commit message of merge
+	Message string `bun:"message,notnull" json:"message"`
+	// ConflictResolve how to resolve conflict paths, path to "left" or "right"
+	ConflictResolve map[string]string `bun:"conflict_resolve,type:jsonb" json:"conflict_resolve,omitempty"`
+	// Reason why merge request can not be merged in the last attempt, nil if not attempted yet
+	Reason *string `bun:"reason" json:"reason,omitempty"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+type GetAutoMergeParams struct {
+	mergeRequestID uuid.UUID
+}
+
+func NewGetAutoMergeParams() *GetAutoMergeParams {
+	return &GetAutoMergeParams{}
+}
+
+func (gap *GetAutoMergeParams) SetMergeRequestID(mergeRequestID uuid.UUID) *GetAutoMergeParams {
+	gap.mergeRequestID = mergeRequestID
+	return gap
+}
+
+type DeleteAutoMergeParams struct {
+	mergeRequestID uuid.UUID
+}
+
+func NewDeleteAutoMergeParams() *DeleteAutoMergeParams {
+	return &DeleteAutoMergeParams{}
+}
+
+func (dap *DeleteAutoMergeParams) SetMergeRequestID(mergeRequestID uuid.UUID) *DeleteAutoMergeParams {
+	dap.mergeRequestID = mergeRequestID
+	return dap
+}
+
+type UpdateAutoMergeParams struct {
+	id     uuid.UUID
+	reason *string
+}
+
+func NewUpdateAutoMergeParams(id uuid.UUID) *UpdateAutoMergeParams {
+	return &UpdateAutoMergeParams{
+		id: id,
+	}
+}
+
+func (uap *UpdateAutoMergeParams) SetReason(reason string) *UpdateAutoMergeParams {
+	uap.reason = &reason
+	return uap
+}
+
+type IAutoMergeRepo interface {
+	// Upsert enable auto merge of merge request, replace message and conflict resolve if already enabled
+	Upsert(ctx context.Context, autoMerge *AutoMerge) (*AutoMerge, error)
+	Get(ctx context.Context, params *GetAutoMergeParams) (*AutoMerge, error)
+	// List return all auto merges sorted by created time
+	List(ctx context.Context) ([]*AutoMerge, error)
+	Delete(ctx context.Context, params *DeleteAutoMergeParams) (int64, error)
+	UpdateByID(ctx context.Context, params *UpdateAutoMergeParams) error
+}
+
+var _ IAutoMergeRepo = (*AutoMergeRepo)(nil)
+
+type AutoMergeRepo struct {
+	db bun.IDB
+}
+
+func NewAutoMergeRepo(db bun.IDB) IAutoMergeRepo {
+	return &AutoMergeRepo{db: db}
+}
+
+func (r AutoMergeRepo) Upsert(ctx context.Context, autoMerge *AutoMerge) (*AutoMerge, error) {
+	_, err := r.db.NewInsert().
+		Model(autoMerge).
+		On("CONFLICT (merge_request_id) DO UPDATE").
+		Set("operator_id = EXCLUDED.operator_id").
+		Set("message = EXCLUDED.message").
+		Set("conflict_resolve = EXCLUDED.conflict_resolve").
+		Set("reason = NULL").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return autoMerge, nil
+}
+
+func (r AutoMergeRepo) Get(ctx context.Context, params *GetAutoMergeParams) (*AutoMerge, error) {
+	autoMerge := &AutoMerge{}
+	query := r.db.NewSelect().Model(autoMerge)
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return autoMerge, nil
+}
+
+func (r AutoMergeRepo) List(ctx context.Context) ([]*AutoMerge, error) {
+	var autoMerges []*AutoMerge
+	err := r.db.NewSelect().Model(&autoMerges).Order("created_at ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return autoMerges, nil
+}
+
+func (r AutoMergeRepo) Delete(ctx context.Context, params *DeleteAutoMergeParams) (int64, error) {
+	query := r.db.NewDelete().Model((*AutoMerge)(nil))
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (r AutoMergeRepo) UpdateByID(ctx context.Context, params *UpdateAutoMergeParams) error {
+	updateQuery := r.db.NewUpdate().Model((*AutoMerge)(nil)).Where("id = ?", params.id)
+
+	if params.reason != nil {
+		updateQuery.Set("reason = ?", *params.reason)
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
@@ -0,0 +1,61 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoMergeRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewAutoMergeRepo(db)
+	mergeRequestID := uuid.New()
+
+	autoMerge, err := repo.Upsert(ctx, &models.AutoMerge{
+		MergeRequestID: mergeRequestID,
+		OperatorID:     uuid.New(),
+		Message:        "merge",
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	})
+	require.NoError(t, err)
+
+	err = repo.UpdateByID(ctx, models.NewUpdateAutoMergeParams(autoMerge.ID).SetReason("check not passed"))
+	require.NoError(t, err)
+
+	autoMerge, err = repo.Get(ctx, models.NewGetAutoMergeParams().SetMergeRequestID(mergeRequestID))
+	require.NoError(t, err)
+	require.Equal(t, "check not passed", *autoMerge.Reason)
+
+	autoMerge, err = repo.Upsert(ctx, &models.AutoMerge{
+		MergeRequestID:  mergeRequestID,
+		OperatorID:      uuid.New(),
+		Message:         "merge again",
+		ConflictResolve: map[string]string{"a.txt": "left"},
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "merge again", autoMerge.Message)
+	require.Nil(t, autoMerge.Reason)
+
+	autoMerges, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, autoMerges, 1)
+	require.Equal(t, "left", autoMerges[0].ConflictResolve["a.txt"])
+
+	affectedRows, err := repo.Delete(ctx, models.NewDeleteAutoMergeParams().SetMergeRequestID(mergeRequestID))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affectedRows)
+
+	_, err = repo.Get(ctx, models.NewGetAutoMergeParams().SetMergeRequestID(mergeRequestID))
+	require.ErrorIs(t, err, models.ErrNotFound)
+}
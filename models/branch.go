@@ -30,6 +30,7 @@ type GetBranchParams struct {
 	id           uuid.UUID
 	repositoryID uuid.UUID
 	name         *string
+	forUpdate    bool
 }
 
 func NewGetBranchParams() *GetBranchParams {
@@ -51,6 +52,12 @@ func (gup *GetBranchParams) SetName(name string) *GetBranchParams {
 	return gup
 }
 
+// SetForUpdate lock branch until transaction end, head of branch can not be moved by others meanwhile
+func (gup *GetBranchParams) SetForUpdate() *GetBranchParams {
+	gup.forUpdate = true
+	return gup
+}
+
 type DeleteBranchParams struct {
 	id           uuid.UUID
 	repositoryID uuid.UUID
@@ -165,6 +172,10 @@ func (r BranchRepo) Get(ctx context.Context, params *GetBranchParams) (*Branch,
 		query = query.Where("name = ?", *params.name)
 	}
 
+	if params.forUpdate {
+		query = query.For("UPDATE")
+	}
+
 	err := query.Limit(1).Scan(ctx)
 	if err != nil {
 		return nil, err
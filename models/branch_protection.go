@@ -0,0 +1,286 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// BranchProtection rule protect branches which name match the pattern
+type BranchProtection struct {
+	bun.BaseModel `bun:"table:branch_protections"`
+	ID            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	RepositoryID  uuid.UUID `bun:"repository_id,unique:repo_pattern,type:uuid,notnull" json:"repository_id"`
+	// Pattern glob pattern of branch name, eg main, release/*, feat/**
+	Pattern string `bun:"pattern,unique:repo_pattern,notnull" json:"pattern"`
+	// RequireMergeRequest changes must be merged from merge request, direct commit and object write are rejected
+	RequireMergeRequest bool `bun:"require_merge_request,notnull" json:"require_merge_request"`
+	BlockDeletion       bool `bun:"block_deletion,notnull" json:"block_deletion"`
+	// BlockForcePush reject updates which not fast-forward the branch
+	BlockForcePush bool `bun:"block_force_push,notnull" json:"block_force_push"`
+	// RequiredApprovals count of approvals merge request need before merge
+	RequiredApprovals int `bun:"required_approvals,notnull" json:"required_approvals"`
+	// RequiredStatusChecks context of status checks must be success before merge
+	RequiredStatusChecks []string `bun:"required_status_checks,type:jsonb,notnull" json:"required_status_checks"`
+	// AllowedMergers users allowed to merge into branch, empty means anyone has merge permission
+	AllowedMergers []uuid.UUID `bun:"allowed_mergers,type:jsonb,notnull" json:"allowed_mergers"`
+
+	CreatorID uuid.UUID `bun:"creator_id,type:uuid,notnull" json:"creator_id"`
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+// CanMerge check whether user is allowed to merge into protected branch
+func (protection *BranchProtection) CanMerge(userID uuid.UUID) bool {
+	if len(protection.AllowedMergers) == 0 {
+		return true
+	}
+	for _, merger := range protection.AllowedMergers {
+		if merger == userID {
+			return true
+		}
+	}
+	return false
+}
+
+type GetBranchProtectionParams struct {
+	id           uuid.UUID
+	repositoryID uuid.UUID
+	pattern      *string
+}
+
+func NewGetBranchProtectionParams() *GetBranchProtectionParams {
+	return &GetBranchProtectionParams{}
+}
+
+func (gbp *GetBranchProtectionParams) SetID(id uuid.UUID) *GetBranchProtectionParams {
+	gbp.id = id
+	return gbp
+}
+
+func (gbp *GetBranchProtectionParams) SetRepositoryID(repositoryID uuid.UUID) *GetBranchProtectionParams {
+	gbp.repositoryID = repositoryID
+	return gbp
+}
+
+func (gbp *GetBranchProtectionParams) SetPattern(pattern string) *GetBranchProtectionParams {
+	gbp.pattern = &pattern
+	return gbp
+}
+
+type ListBranchProtectionParams struct {
+	repositoryID uuid.UUID
+}
+
+func NewListBranchProtectionParams() *ListBranchProtectionParams {
+	return &ListBranchProtectionParams{}
+}
+
+func (lbp *ListBranchProtectionParams) SetRepositoryID(repositoryID uuid.UUID) *ListBranchProtectionParams {
+	lbp.repositoryID = repositoryID
+	return lbp
+}
+
+type DeleteBranchProtectionParams struct {
+	id           uuid.UUID
+	repositoryID uuid.UUID
+}
+
+func NewDeleteBranchProtectionParams() *DeleteBranchProtectionParams {
+	return &DeleteBranchProtectionParams{}
+}
+
+func (dbp *DeleteBranchProtectionParams) SetID(id uuid.UUID) *DeleteBranchProtectionParams {
+	dbp.id = id
+	return dbp
+}
+
+func (dbp *DeleteBranchProtectionParams) SetRepositoryID(repositoryID uuid.UUID) *DeleteBranchProtectionParams {
+	dbp.repositoryID = repositoryID
+	return dbp
+}
+
+type UpdateBranchProtectionParams struct {
+	id                   uuid.UUID
+	pattern              *string
+	requireMergeRequest  *bool
+	blockDeletion        *bool
+	blockForcePush       *bool
+	requiredApprovals    *int
+	requiredStatusChecks []string
+	allowedMergers       []uuid.UUID
+}
+
+func NewUpdateBranchProtectionParams(id uuid.UUID) *UpdateBranchProtectionParams {
+	return &UpdateBranchProtectionParams{
+		id: id,
+	}
+}
+
+func (ubp *UpdateBranchProtectionParams) SetPattern(pattern string) *UpdateBranchProtectionParams {
+	ubp.pattern = &pattern
+	return ubp
+}
+
+func (ubp *UpdateBranchProtectionParams) SetRequireMergeRequest(requireMergeRequest bool) *UpdateBranchProtectionParams {
+	ubp.requireMergeRequest = &requireMergeRequest
+	return ubp
+}
+
+func (ubp *UpdateBranchProtectionParams) SetBlockDeletion(blockDeletion bool) *UpdateBranchProtectionParams {
+	ubp.blockDeletion = &blockDeletion
+	return ubp
+}
+
+func (ubp *UpdateBranchProtectionParams) SetBlockForcePush(blockForcePush bool) *UpdateBranchProtectionParams {
+	ubp.blockForcePush = &blockForcePush
+	return ubp
+}
+
+func (ubp *UpdateBranchProtectionParams) SetRequiredApprovals(requiredApprovals int) *UpdateBranchProtectionParams {
+	ubp.requiredApprovals = &requiredApprovals
+	return ubp
+}
+
+func (ubp *UpdateBranchProtectionParams) SetRequiredStatusChecks(requiredStatusChecks []string) *UpdateBranchProtectionParams {
+	ubp.requiredStatusChecks = requiredStatusChecks
+	return ubp
+}
+
+func (ubp *UpdateBranchProtectionParams) SetAllowedMergers(allowedMergers []uuid.UUID) *UpdateBranchProtectionParams {
+	ubp.allowedMergers = allowedMergers
+	return ubp
+}
+
+type IBranchProtectionRepo interface {
+	Insert(ctx context.Context, protection *BranchProtection) (*BranchProtection, error)
+	Get(ctx context.Context, params *GetBranchProtectionParams) (*BranchProtection, error)
+	List(ctx context.Context, params *ListBranchProtectionParams) ([]*BranchProtection, error)
+	Delete(ctx context.Context, params *DeleteBranchProtectionParams) (int64, error)
+	UpdateByID(ctx context.Context, params *UpdateBranchProtectionParams) error
+}
+
+var _ IBranchProtectionRepo = (*BranchProtectionRepo)(nil)
+
+type BranchProtectionRepo struct {
+	db bun.IDB
+}
+
+func NewBranchProtectionRepo(db bun.IDB) IBranchProtectionRepo {
+	return &BranchProtectionRepo{db: db}
+}
+
+func (b BranchProtectionRepo) Insert(ctx context.Context, protection *BranchProtection) (*BranchProtection, error) {
+	_, err := b.db.NewInsert().Model(protection).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return protection, nil
+}
+
+func (b BranchProtectionRepo) Get(ctx context.Context, params *GetBranchProtectionParams) (*BranchProtection, error) {
+	protection := &BranchProtection{}
+	query := b.db.NewSelect().Model(protection)
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	if params.pattern != nil {
+		query = query.Where("pattern = ?", *params.pattern)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return protection, nil
+}
+
+// List return all rules of repository, the count of rules in one repository is small, so not paginate it
+func (b BranchProtectionRepo) List(ctx context.Context, params *ListBranchProtectionParams) ([]*BranchProtection, error) {
+	var protections []*BranchProtection
+	query := b.db.NewSelect().Model(&protections)
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	err := query.Order("created_at ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return protections, nil
+}
+
+func (b BranchProtectionRepo) Delete(ctx context.Context, params *DeleteBranchProtectionParams) (int64, error) {
+	query := b.db.NewDelete().Model((*BranchProtection)(nil))
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (b BranchProtectionRepo) UpdateByID(ctx context.Context, params *UpdateBranchProtectionParams) error {
+	updateQuery := b.db.NewUpdate().Model((*BranchProtection)(nil)).Where("id = ?", params.id)
+
+	if params.pattern != nil {
+		updateQuery.Set("pattern = ?", *params.pattern)
+	}
+
+	if params.requireMergeRequest != nil {
+		updateQuery.Set("require_merge_request = ?", *params.requireMergeRequest)
+	}
+
+	if params.blockDeletion != nil {
+		updateQuery.Set("block_deletion = ?", *params.blockDeletion)
+	}
+
+	if params.blockForcePush != nil {
+		updateQuery.Set("block_force_push = ?", *params.blockForcePush)
+	}
+
+	if params.requiredApprovals != nil {
+		updateQuery.Set("required_approvals = ?", *params.requiredApprovals)
+	}
+
+	if params.requiredStatusChecks != nil {
+		checks, err := json.Marshal(params.requiredStatusChecks)
+		if err != nil {
+			return err
+		}
+		updateQuery.Set("required_status_checks = ?::jsonb", string(checks))
+	}
+
+	if params.allowedMergers != nil {
+		mergers, err := json.Marshal(params.allowedMergers)
+		if err != nil {
+			return err
+		}
+		updateQuery.Set("allowed_mergers = ?::jsonb", string(mergers))
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
@@ -0,0 +1,88 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranchProtectionRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewBranchProtectionRepo(db)
+
+	repoID := uuid.New()
+	t.Run("insert and get", func(t *testing.T) {
+		protectionModel := &models.BranchProtection{}
+		require.NoError(t, gofakeit.Struct(protectionModel))
+		protectionModel.RepositoryID = repoID
+		protectionModel.Pattern = "main"
+		protectionModel.RequiredStatusChecks = []string{"ci/test"}
+		protectionModel.AllowedMergers = []uuid.UUID{uuid.New()}
+
+		protection, err := repo.Insert(ctx, protectionModel)
+		require.NoError(t, err)
+
+		expectProtection, err := repo.Get(ctx, models.NewGetBranchProtectionParams().SetID(protection.ID).SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.True(t, cmp.Equal(expectProtection, protection, testhelper.DBTimeCmpOpt))
+
+		_, err = repo.Get(ctx, models.NewGetBranchProtectionParams().SetRepositoryID(repoID).SetPattern("main"))
+		require.NoError(t, err)
+
+		_, err = repo.Insert(ctx, protectionModel)
+		require.Error(t, err)
+	})
+
+	t.Run("list update and delete", func(t *testing.T) {
+		protectionModel := &models.BranchProtection{}
+		require.NoError(t, gofakeit.Struct(protectionModel))
+		protectionModel.RepositoryID = repoID
+		protectionModel.Pattern = "release/*"
+		protectionModel.RequiredStatusChecks = []string{}
+		protectionModel.AllowedMergers = []uuid.UUID{}
+		protection, err := repo.Insert(ctx, protectionModel)
+		require.NoError(t, err)
+
+		protections, err := repo.List(ctx, models.NewListBranchProtectionParams().SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.Len(t, protections, 2)
+
+		merger := uuid.New()
+		err = repo.UpdateByID(ctx, models.NewUpdateBranchProtectionParams(protection.ID).
+			SetPattern("release/**").
+			SetRequireMergeRequest(true).
+			SetBlockDeletion(true).
+			SetBlockForcePush(true).
+			SetRequiredApprovals(2).
+			SetRequiredStatusChecks([]string{"ci/build", "ci/test"}).
+			SetAllowedMergers([]uuid.UUID{merger}))
+		require.NoError(t, err)
+
+		updated, err := repo.Get(ctx, models.NewGetBranchProtectionParams().SetID(protection.ID))
+		require.NoError(t, err)
+		require.Equal(t, "release/**", updated.Pattern)
+		require.True(t, updated.RequireMergeRequest)
+		require.True(t, updated.BlockDeletion)
+		require.True(t, updated.BlockForcePush)
+		require.Equal(t, 2, updated.RequiredApprovals)
+		require.Equal(t, []string{"ci/build", "ci/test"}, updated.RequiredStatusChecks)
+		require.True(t, updated.CanMerge(merger))
+		require.False(t, updated.CanMerge(uuid.New()))
+
+		affected, err := repo.Delete(ctx, models.NewDeleteBranchProtectionParams().SetID(protection.ID).SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), affected)
+
+		_, err = repo.Get(ctx, models.NewGetBranchProtectionParams().SetID(protection.ID))
+		require.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
@@ -0,0 +1,274 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ActivityBucket time unit activities are aggregated by, value is a precision of postgres date_trunc
+type ActivityBucket string
+
+const (
+	DayActivityBucket   ActivityBucket = "day"
+	WeekActivityBucket  ActivityBucket = "week"
+	MonthActivityBucket ActivityBucket = "month"
+)
+
+// CommitStats files and bytes changed by commit, computed once when commit is created.
+// modified file count its new size as added bytes and its old size as removed bytes
+type CommitStats struct {
+	bun.BaseModel `bun:"table:commit_stats"`
+	RepositoryID  uuid.UUID `bun:"repository_id,pk,type:uuid,notnull" json:"repository_id"`
+	CommitHash    hash.Hash `bun:"commit_hash,pk,type:bytea" json:"commit_hash"`
+	AuthorName    string    `bun:"author_name,notnull" json:"author_name"`
+	AuthorEmail   string    `bun:"author_email,notnull" json:"author_email"`
+	// IsMerge commit is created by merge, changes are computed against the branch merged into
+	IsMerge       bool  `bun:"is_merge,notnull,default:false" json:"is_merge"`
+	FilesAdded    int64 `bun:"files_added,notnull" json:"files_added"`
+	FilesModified int64 `bun:"files_modified,notnull" json:"files_modified"`
+	FilesRemoved  int64 `bun:"files_removed,notnull" json:"files_removed"`
+	BytesAdded    int64 `bun:"bytes_added,notnull" json:"bytes_added"`
+	BytesRemoved  int64 `bun:"bytes_removed,notnull" json:"bytes_removed"`
+
+	CommittedAt time.Time `bun:"committed_at,type:timestamp,notnull" json:"committed_at"`
+	CreatedAt   time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+}
+
+// CommitActivity commits and changes of author in one time bucket
+type CommitActivity struct {
+	Author        string    `bun:"author"`
+	Bucket        time.Time `bun:"bucket"`
+	Commits       int64     `bun:"commits"`
+	FilesAdded    int64     `bun:"files_added"`
+	FilesModified int64     `bun:"files_modified"`
+	FilesRemoved  int64     `bun:"files_removed"`
+	BytesAdded    int64     `bun:"bytes_added"`
+	BytesRemoved  int64     `bun:"bytes_removed"`
+}
+
+type GetCommitStatsParams struct {
+	repositoryID uuid.UUID
+	commitHash   hash.Hash
+}
+
+func NewGetCommitStatsParams() *GetCommitStatsParams {
+	return &GetCommitStatsParams{}
+}
+
+func (gcp *GetCommitStatsParams) SetRepositoryID(repositoryID uuid.UUID) *GetCommitStatsParams {
+	gcp.repositoryID = repositoryID
+	return gcp
+}
+
+func (gcp *GetCommitStatsParams) SetCommitHash(commitHash hash.Hash) *GetCommitStatsParams {
+	gcp.commitHash = commitHash
+	return gcp
+}
+
+type ListCommitStatsParams struct {
+	repositoryID uuid.UUID
+	author       *string
+	since        *time.Time
+	until        *time.Time
+	after        *time.Time
+	amount       int
+}
+
+func NewListCommitStatsParams() *ListCommitStatsParams {
+	return &ListCommitStatsParams{}
+}
+
+func (lcp *ListCommitStatsParams) SetRepositoryID(repositoryID uuid.UUID) *ListCommitStatsParams {
+	lcp.repositoryID = repositoryID
+	return lcp
+}
+
+func (lcp *ListCommitStatsParams) SetAuthor(author string) *ListCommitStatsParams {
+	lcp.author = &author
+	return lcp
+}
+
+// SetSince only include commits committed at or after since
+func (lcp *ListCommitStatsParams) SetSince(since time.Time) *ListCommitStatsParams {
+	lcp.since = &since
+	return lcp
+}
+
+// SetUntil only include commits committed before until
+func (lcp *ListCommitStatsParams) SetUntil(until time.Time) *ListCommitStatsParams {
+	lcp.until = &until
+	return lcp
+}
+
+func (lcp *ListCommitStatsParams) SetAfter(after time.Time) *ListCommitStatsParams {
+	lcp.after = &after
+	return lcp
+}
+
+func (lcp *ListCommitStatsParams) SetAmount(amount int) *ListCommitStatsParams {
+	lcp.amount = amount
+	return lcp
+}
+
+type AggregateCommitStatsParams struct {
+	repositoryID uuid.UUID
+	bucket       ActivityBucket
+	author       *string
+	since        *time.Time
+	until        *time.Time
+}
+
+func NewAggregateCommitStatsParams(repositoryID uuid.UUID, bucket ActivityBucket) *AggregateCommitStatsParams {
+	return &AggregateCommitStatsParams{
+		repositoryID: repositoryID,
+		bucket:       bucket,
+	}
+}
+
+func (acp *AggregateCommitStatsParams) SetAuthor(author string) *AggregateCommitStatsParams {
+	acp.author = &author
+	return acp
+}
+
+// SetSince only include commits committed at or after since
+func (acp *AggregateCommitStatsParams) SetSince(since time.Time) *AggregateCommitStatsParams {
+	acp.since = &since
+	return acp
+}
+
+// SetUntil only include commits committed before until
+func (acp *AggregateCommitStatsParams) SetUntil(until time.Time) *AggregateCommitStatsParams {
+	acp.until = &until
+	return acp
+}
+
+type DeleteCommitStatsParams struct {
+	repositoryID uuid.UUID
+}
+
+func NewDeleteCommitStatsParams() *DeleteCommitStatsParams {
+	return &DeleteCommitStatsParams{}
+}
+
+func (dcp *DeleteCommitStatsParams) SetRepositoryID(repositoryID uuid.UUID) *DeleteCommitStatsParams {
+	dcp.repositoryID = repositoryID
+	return dcp
+}
+
+type ICommitStatsRepo interface {
+	// Insert save stats of commit, stats already saved are kept because commit is immutable
+	Insert(ctx context.Context, stats *CommitStats) error
+	Get(ctx context.Context, params *GetCommitStatsParams) (*CommitStats, error)
+	// List stats ordered by committed time desc
+	List(ctx context.Context, params *ListCommitStatsParams) ([]*CommitStats, bool, error)
+	// Aggregate sum stats by author and time bucket, ordered by bucket and author
+	Aggregate(ctx context.Context, params *AggregateCommitStatsParams) ([]*CommitActivity, error)
+	Delete(ctx context.Context, params *DeleteCommitStatsParams) (int64, error)
+}
+
+var _ ICommitStatsRepo = (*CommitStatsRepo)(nil)
+
+type CommitStatsRepo struct {
+	db bun.IDB
+}
+
+func NewCommitStatsRepo(db bun.IDB) ICommitStatsRepo {
+	return &CommitStatsRepo{db: db}
+}
+
+func (r CommitStatsRepo) Insert(ctx context.Context, stats *CommitStats) error {
+	_, err := r.db.NewInsert().
+		Model(stats).
+		On("CONFLICT (repository_id, commit_hash) DO NOTHING").
+		Exec(ctx)
+	return err
+}
+
+func (r CommitStatsRepo) Get(ctx context.Context, params *GetCommitStatsParams) (*CommitStats, error) {
+	stats := &CommitStats{}
+	err := r.db.NewSelect().
+		Model(stats).
+		Where("repository_id = ?", params.repositoryID).
+		Where("commit_hash = ?", params.commitHash).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (r CommitStatsRepo) List(ctx context.Context, params *ListCommitStatsParams) ([]*CommitStats, bool, error) {
+	stats := make([]*CommitStats, 0)
+	query := r.db.NewSelect().
+		Model(&stats).
+		Where("repository_id = ?", params.repositoryID)
+
+	if params.author != nil {
+		query = query.Where("author_name = ?", *params.author)
+	}
+	if params.since != nil {
+		query = query.Where("committed_at >= ?", *params.since)
+	}
+	if params.until != nil {
+		query = query.Where("committed_at < ?", *params.until)
+	}
+	if params.after != nil {
+		query = query.Where("committed_at < ?", *params.after)
+	}
+
+	err := query.Order("committed_at DESC").Limit(params.amount).Scan(ctx)
+	return stats, len(stats) == params.amount, err
+}
+
+func (r CommitStatsRepo) Aggregate(ctx context.Context, params *AggregateCommitStatsParams) ([]*CommitActivity, error) {
+	activities := make([]*CommitActivity, 0)
+	query := r.db.NewSelect().
+		Model((*CommitStats)(nil)).
+		ColumnExpr("author_name AS author").
+		ColumnExpr("date_trunc(?, committed_at) AS bucket", string(params.bucket)).
+		ColumnExpr("count(*) AS commits").
+		ColumnExpr("sum(files_added) AS files_added").
+		ColumnExpr("sum(files_modified) AS files_modified").
+		ColumnExpr("sum(files_removed) AS files_removed").
+		ColumnExpr("sum(bytes_added) AS bytes_added").
+		ColumnExpr("sum(bytes_removed) AS bytes_removed").
+		Where("repository_id = ?", params.repositoryID)
+
+	if params.author != nil {
+		query = query.Where("author_name = ?", *params.author)
+	}
+	if params.since != nil {
+		query = query.Where("committed_at >= ?", *params.since)
+	}
+	if params.until != nil {
+		query = query.Where("committed_at < ?", *params.until)
+	}
+
+	err := query.GroupExpr("author, bucket").
+		OrderExpr("bucket ASC, author ASC").
+		Scan(ctx, &activities)
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+func (r CommitStatsRepo) Delete(ctx context.Context, params *DeleteCommitStatsParams) (int64, error) {
+	sqlResult, err := r.db.NewDelete().
+		Model((*CommitStats)(nil)).
+		Where("repository_id = ?", params.repositoryID).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
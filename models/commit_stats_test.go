@@ -0,0 +1,91 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitStatsRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewCommitStatsRepo(db)
+
+	repoID := uuid.New()
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	insert := func(commitHash string, author string, committedAt time.Time, bytesAdded int64) {
+		require.NoError(t, repo.Insert(ctx, &models.CommitStats{
+			RepositoryID: repoID,
+			CommitHash:   hash.Hash(commitHash),
+			AuthorName:   author,
+			AuthorEmail:  author + "@example.com",
+			FilesAdded:   1,
+			BytesAdded:   bytesAdded,
+			CommittedAt:  committedAt,
+			CreatedAt:    time.Now(),
+		}))
+	}
+	insert("c1", "alice", day.Add(time.Hour), 10)
+	insert("c2", "alice", day.Add(2*time.Hour), 20)
+	insert("c3", "bob", day.Add(3*time.Hour), 30)
+	insert("c4", "alice", day.Add(48*time.Hour), 40)
+	// stats of commit is saved once
+	insert("c1", "alice", day.Add(time.Hour), 100)
+
+	stats, err := repo.Get(ctx, models.NewGetCommitStatsParams().SetRepositoryID(repoID).SetCommitHash(hash.Hash("c1")))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), stats.BytesAdded)
+
+	_, err = repo.Get(ctx, models.NewGetCommitStatsParams().SetRepositoryID(uuid.New()).SetCommitHash(hash.Hash("c1")))
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	t.Run("list", func(t *testing.T) {
+		list, hasMore, err := repo.List(ctx, models.NewListCommitStatsParams().SetRepositoryID(repoID).SetAmount(2))
+		require.NoError(t, err)
+		require.True(t, hasMore)
+		require.Len(t, list, 2)
+		require.Equal(t, hash.Hash("c4"), list[0].CommitHash)
+		require.Equal(t, hash.Hash("c3"), list[1].CommitHash)
+
+		list, _, err = repo.List(ctx, models.NewListCommitStatsParams().SetRepositoryID(repoID).SetAfter(list[1].CommittedAt).SetAmount(10))
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.Equal(t, hash.Hash("c2"), list[0].CommitHash)
+
+		list, _, err = repo.List(ctx, models.NewListCommitStatsParams().SetRepositoryID(repoID).SetAuthor("alice").SetUntil(day.Add(24*time.Hour)).SetAmount(10))
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		activities, err := repo.Aggregate(ctx, models.NewAggregateCommitStatsParams(repoID, models.DayActivityBucket))
+		require.NoError(t, err)
+		require.Len(t, activities, 3)
+		require.Equal(t, "alice", activities[0].Author)
+		require.True(t, day.Equal(activities[0].Bucket))
+		require.Equal(t, int64(2), activities[0].Commits)
+		require.Equal(t, int64(30), activities[0].BytesAdded)
+		require.Equal(t, "bob", activities[1].Author)
+		require.Equal(t, int64(40), activities[2].BytesAdded)
+
+		activities, err = repo.Aggregate(ctx, models.NewAggregateCommitStatsParams(repoID, models.MonthActivityBucket).SetAuthor("alice").SetSince(day.Add(90*time.Minute)))
+		require.NoError(t, err)
+		require.Len(t, activities, 1)
+		require.Equal(t, int64(2), activities[0].Commits)
+		require.Equal(t, int64(60), activities[0].BytesAdded)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		affectedRows, err := repo.Delete(ctx, models.NewDeleteCommitStatsParams().SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.Equal(t, int64(4), affectedRows)
+	})
+}
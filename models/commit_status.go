@@ -0,0 +1,147 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CommitStatusState state of check reported by external validator
+type CommitStatusState string
+
+const (
+	CommitStatusPending CommitStatusState = "pending"
+	CommitStatusSuccess CommitStatusState = "success"
+	CommitStatusFailure CommitStatusState = "failure"
+	CommitStatusError   CommitStatusState = "error"
+)
+
+func ParseCommitStatusState(state string) (CommitStatusState, error) {
+	switch CommitStatusState(state) {
+	case CommitStatusPending, CommitStatusSuccess, CommitStatusFailure, CommitStatusError:
+		return CommitStatusState(state), nil
+	}
+	return "", fmt.Errorf("unknown commit status state %s", state)
+}
+
+// CombineStatuses combine state of checks on commit, failure if any check fail or error, pending if any check pending or no check reported,
+// otherwise success
+func CombineStatuses(statuses []*CommitStatus) CommitStatusState {
+	if len(statuses) == 0 {
+		return CommitStatusPending
+	}
+
+	combined := CommitStatusSuccess
+	for _, status := range statuses {
+		switch status.State {
+		case CommitStatusFailure, CommitStatusError:
+			return CommitStatusFailure
+		case CommitStatusPending:
+			combined = CommitStatusPending
+		}
+	}
+	return combined
+}
+
+// CommitStatus state of named check on commit, the latest report of same check overwrite the previous one
+type CommitStatus struct {
+	bun.BaseModel `bun:"table:commit_statuses"`
+	ID            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	RepositoryID  uuid.UUID `bun:"repository_id,unique:repo_commit_context,type:uuid,notnull" json:"repository_id"`
+	CommitHash    hash.Hash `bun:"commit_hash,unique:repo_commit_context,type:bytea,notnull" json:"commit_hash"`
+	// Context name of check, e.g. data-quality/null-check
+	Context     string            `bun:"context,unique:repo_commit_context,notnull" json:"context"`
+	State       CommitStatusState `bun:"state,notnull" json:"state"`
+	TargetURL   *string           `bun:"target_url" json:"target_url,omitempty"`
+	Description *string           `bun:"description" json:"description,omitempty"`
+
+	CreatorID uuid.UUID `bun:"creator_id,type:uuid,notnull" json:"creator_id"`
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+type ListCommitStatusParams struct {
+	repositoryID uuid.UUID
+	commitHash   hash.Hash
+	contexts     []string
+}
+
+func NewListCommitStatusParams() *ListCommitStatusParams {
+	return &ListCommitStatusParams{}
+}
+
+func (lsp *ListCommitStatusParams) SetRepositoryID(repositoryID uuid.UUID) *ListCommitStatusParams {
+	lsp.repositoryID = repositoryID
+	return lsp
+}
+
+func (lsp *ListCommitStatusParams) SetCommitHash(commitHash hash.Hash) *ListCommitStatusParams {
+	lsp.commitHash = commitHash
+	return lsp
+}
+
+func (lsp *ListCommitStatusParams) SetContexts(contexts ...string) *ListCommitStatusParams {
+	lsp.contexts = contexts
+	return lsp
+}
+
+type ICommitStatusRepo interface {
+	// Upsert insert status of check, or update state of check already reported on the commit
+	Upsert(ctx context.Context, status *CommitStatus) (*CommitStatus, error)
+	// List return statuses sorted by context
+	List(ctx context.Context, params *ListCommitStatusParams) ([]*CommitStatus, error)
+}
+
+var _ ICommitStatusRepo = (*CommitStatusRepo)(nil)
+
+type CommitStatusRepo struct {
+	db bun.IDB
+}
+
+func NewCommitStatusRepo(db bun.IDB) ICommitStatusRepo {
+	return &CommitStatusRepo{db: db}
+}
+
+func (r CommitStatusRepo) Upsert(ctx context.Context, status *CommitStatus) (*CommitStatus, error) {
+	_, err := r.db.NewInsert().
+		Model(status).
+		On("CONFLICT (repository_id, commit_hash, context) DO UPDATE").
+		Set("state = EXCLUDED.state").
+		Set("target_url = EXCLUDED.target_url").
+		Set("description = EXCLUDED.description").
+		Set("creator_id = EXCLUDED.creator_id").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (r CommitStatusRepo) List(ctx context.Context, params *ListCommitStatusParams) ([]*CommitStatus, error) {
+	var statuses []*CommitStatus
+	query := r.db.NewSelect().Model(&statuses)
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	if params.commitHash != nil {
+		query = query.Where("commit_hash = ?", params.commitHash)
+	}
+
+	if len(params.contexts) > 0 {
+		query = query.Where("context IN (?)", bun.In(params.contexts))
+	}
+
+	err := query.Order("context ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
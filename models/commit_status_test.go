@@ -0,0 +1,76 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineStatuses(t *testing.T) {
+	require.Equal(t, models.CommitStatusPending, models.CombineStatuses(nil))
+	require.Equal(t, models.CommitStatusSuccess, models.CombineStatuses([]*models.CommitStatus{
+		{State: models.CommitStatusSuccess},
+		{State: models.CommitStatusSuccess},
+	}))
+	require.Equal(t, models.CommitStatusPending, models.CombineStatuses([]*models.CommitStatus{
+		{State: models.CommitStatusSuccess},
+		{State: models.CommitStatusPending},
+	}))
+	require.Equal(t, models.CommitStatusFailure, models.CombineStatuses([]*models.CommitStatus{
+		{State: models.CommitStatusPending},
+		{State: models.CommitStatusError},
+	}))
+}
+
+func TestCommitStatusRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewCommitStatusRepo(db)
+	repositoryID := uuid.New()
+	commitHash := hash.Hash("commit")
+
+	for _, checkName := range []string{"quality", "lint"} {
+		_, err := repo.Upsert(ctx, &models.CommitStatus{
+			RepositoryID: repositoryID,
+			CommitHash:   commitHash,
+			Context:      checkName,
+			State:        models.CommitStatusPending,
+			CreatorID:    uuid.New(),
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	status, err := repo.Upsert(ctx, &models.CommitStatus{
+		RepositoryID: repositoryID,
+		CommitHash:   commitHash,
+		Context:      "quality",
+		State:        models.CommitStatusSuccess,
+		TargetURL:    utils.String("http://ci.example.com/1"),
+		CreatorID:    uuid.New(),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, status.ID)
+
+	statuses, err := repo.List(ctx, models.NewListCommitStatusParams().SetRepositoryID(repositoryID).SetCommitHash(commitHash))
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.Equal(t, "lint", statuses[0].Context)
+	require.Equal(t, models.CommitStatusSuccess, statuses[1].State)
+
+	statuses, err = repo.List(ctx, models.NewListCommitStatusParams().SetRepositoryID(repositoryID).SetCommitHash(commitHash).SetContexts("quality"))
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+}
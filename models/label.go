@@ -0,0 +1,272 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Label repository scoped tag used to categorize merge requests
+type Label struct {
+	bun.BaseModel `bun:"table:labels"`
+	ID            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	RepositoryID  uuid.UUID `bun:"repository_id,unique:repo_label,type:uuid,notnull" json:"repository_id"`
+	Name          string    `bun:"name,unique:repo_label,notnull" json:"name"`
+	// Color hex color of label, example #d73a4a
+	Color       string    `bun:"color,notnull" json:"color"`
+	Description *string   `bun:"description" json:"description,omitempty"`
+	CreatorID   uuid.UUID `bun:"creator_id,type:uuid,notnull" json:"creator_id"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+// MergeRequestLabel label attached to merge request
+type MergeRequestLabel struct {
+	bun.BaseModel  `bun:"table:merge_request_labels"`
+	ID             uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MergeRequestID uuid.UUID `bun:"merge_request_id,unique:mr_label,type:uuid,notnull" json:"merge_request_id"`
+	LabelID        uuid.UUID `bun:"label_id,unique:mr_label,type:uuid,notnull" json:"label_id"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+}
+
+type GetLabelParams struct {
+	id           uuid.UUID
+	repositoryID uuid.UUID
+	name         *string
+}
+
+func NewGetLabelParams() *GetLabelParams {
+	return &GetLabelParams{}
+}
+
+func (glp *GetLabelParams) SetID(id uuid.UUID) *GetLabelParams {
+	glp.id = id
+	return glp
+}
+
+func (glp *GetLabelParams) SetRepositoryID(repositoryID uuid.UUID) *GetLabelParams {
+	glp.repositoryID = repositoryID
+	return glp
+}
+
+func (glp *GetLabelParams) SetName(name string) *GetLabelParams {
+	glp.name = &name
+	return glp
+}
+
+type ListLabelParams struct {
+	repositoryID   uuid.UUID
+	mergeRequestID uuid.UUID
+}
+
+func NewListLabelParams() *ListLabelParams {
+	return &ListLabelParams{}
+}
+
+func (llp *ListLabelParams) SetRepositoryID(repositoryID uuid.UUID) *ListLabelParams {
+	llp.repositoryID = repositoryID
+	return llp
+}
+
+// SetMergeRequestID list labels attached to merge request
+func (llp *ListLabelParams) SetMergeRequestID(mergeRequestID uuid.UUID) *ListLabelParams {
+	llp.mergeRequestID = mergeRequestID
+	return llp
+}
+
+type UpdateLabelParams struct {
+	id          uuid.UUID
+	name        *string
+	color       *string
+	description *string
+}
+
+func NewUpdateLabelParams(id uuid.UUID) *UpdateLabelParams {
+	return &UpdateLabelParams{
+		id: id,
+	}
+}
+
+func (ulp *UpdateLabelParams) SetName(name string) *UpdateLabelParams {
+	ulp.name = &name
+	return ulp
+}
+
+func (ulp *UpdateLabelParams) SetColor(color string) *UpdateLabelParams {
+	ulp.color = &color
+	return ulp
+}
+
+func (ulp *UpdateLabelParams) SetDescription(description string) *UpdateLabelParams {
+	ulp.description = &description
+	return ulp
+}
+
+type DeleteMergeRequestLabelParams struct {
+	mergeRequestID uuid.UUID
+	labelID        uuid.UUID
+}
+
+func NewDeleteMergeRequestLabelParams() *DeleteMergeRequestLabelParams {
+	return &DeleteMergeRequestLabelParams{}
+}
+
+func (dlp *DeleteMergeRequestLabelParams) SetMergeRequestID(mergeRequestID uuid.UUID) *DeleteMergeRequestLabelParams {
+	dlp.mergeRequestID = mergeRequestID
+	return dlp
+}
+
+func (dlp *DeleteMergeRequestLabelParams) SetLabelID(labelID uuid.UUID) *DeleteMergeRequestLabelParams {
+	dlp.labelID = labelID
+	return dlp
+}
+
+type ILabelRepo interface {
+	Insert(ctx context.Context, label *Label) (*Label, error)
+	Get(ctx context.Context, params *GetLabelParams) (*Label, error)
+	// List return labels sorted by name
+	List(ctx context.Context, params *ListLabelParams) ([]*Label, error)
+	UpdateByID(ctx context.Context, params *UpdateLabelParams) error
+	// Delete remove label and detach it from all merge requests
+	Delete(ctx context.Context, id uuid.UUID) (int64, error)
+
+	// Attach attach labels to merge request, return labels newly attached, labels already attached are ignored
+	Attach(ctx context.Context, mergeRequestLabels ...*MergeRequestLabel) ([]*MergeRequestLabel, error)
+	Detach(ctx context.Context, params *DeleteMergeRequestLabelParams) (int64, error)
+}
+
+var _ ILabelRepo = (*LabelRepo)(nil)
+
+type LabelRepo struct {
+	db bun.IDB
+}
+
+func NewLabelRepo(db bun.IDB) ILabelRepo {
+	return &LabelRepo{db: db}
+}
+
+func (r LabelRepo) Insert(ctx context.Context, label *Label) (*Label, error) {
+	_, err := r.db.NewInsert().Model(label).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return label, nil
+}
+
+func (r LabelRepo) Get(ctx context.Context, params *GetLabelParams) (*Label, error) {
+	label := &Label{}
+	query := r.db.NewSelect().Model(label)
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	if params.name != nil {
+		query = query.Where("name = ?", *params.name)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return label, nil
+}
+
+func (r LabelRepo) List(ctx context.Context, params *ListLabelParams) ([]*Label, error) {
+	var labels []*Label
+	query := r.db.NewSelect().Model(&labels)
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("id IN (?)", r.db.NewSelect().Model((*MergeRequestLabel)(nil)).Column("label_id").Where("merge_request_id = ?", params.mergeRequestID))
+	}
+
+	err := query.Order("name ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (r LabelRepo) UpdateByID(ctx context.Context, params *UpdateLabelParams) error {
+	updateQuery := r.db.NewUpdate().Model((*Label)(nil)).Where("id = ?", params.id)
+
+	if params.name != nil {
+		updateQuery.Set("name = ?", *params.name)
+	}
+	if params.color != nil {
+		updateQuery.Set("color = ?", *params.color)
+	}
+	if params.description != nil {
+		updateQuery.Set("description = ?", *params.description)
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
+
+func (r LabelRepo) Delete(ctx context.Context, id uuid.UUID) (int64, error) {
+	_, err := r.db.NewDelete().Model((*MergeRequestLabel)(nil)).Where("label_id = ?", id).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlResult, err := r.db.NewDelete().Model((*Label)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (r LabelRepo) Attach(ctx context.Context, mergeRequestLabels ...*MergeRequestLabel) ([]*MergeRequestLabel, error) {
+	if len(mergeRequestLabels) == 0 {
+		return nil, nil
+	}
+	var inserted []*MergeRequestLabel
+	_, err := r.db.NewInsert().
+		Model(&mergeRequestLabels).
+		On("CONFLICT (merge_request_id, label_id) DO NOTHING").
+		Returning("*").
+		Exec(ctx, &inserted)
+	if err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+func (r LabelRepo) Detach(ctx context.Context, params *DeleteMergeRequestLabelParams) (int64, error) {
+	query := r.db.NewDelete().Model((*MergeRequestLabel)(nil))
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	if uuid.Nil != params.labelID {
+		query = query.Where("label_id = ?", params.labelID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
@@ -0,0 +1,81 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewLabelRepo(db)
+	repositoryID := uuid.New()
+	mergeRequestID := uuid.New()
+
+	var labels []*models.Label
+	for _, name := range []string{"bug", "data"} {
+		label, err := repo.Insert(ctx, &models.Label{
+			RepositoryID: repositoryID,
+			Name:         name,
+			Color:        "#d73a4a",
+			CreatorID:    uuid.New(),
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		})
+		require.NoError(t, err)
+		labels = append(labels, label)
+	}
+
+	err := repo.UpdateByID(ctx, models.NewUpdateLabelParams(labels[0].ID).SetName("defect").SetColor("#000000"))
+	require.NoError(t, err)
+
+	label, err := repo.Get(ctx, models.NewGetLabelParams().SetRepositoryID(repositoryID).SetName("defect"))
+	require.NoError(t, err)
+	require.Equal(t, "#000000", label.Color)
+
+	attached, err := repo.Attach(ctx, &models.MergeRequestLabel{
+		MergeRequestID: mergeRequestID,
+		LabelID:        labels[0].ID,
+		CreatedAt:      time.Now(),
+	})
+	require.NoError(t, err)
+	require.Len(t, attached, 1)
+
+	attached, err = repo.Attach(ctx, &models.MergeRequestLabel{
+		MergeRequestID: mergeRequestID,
+		LabelID:        labels[0].ID,
+		CreatedAt:      time.Now(),
+	}, &models.MergeRequestLabel{
+		MergeRequestID: mergeRequestID,
+		LabelID:        labels[1].ID,
+		CreatedAt:      time.Now(),
+	})
+	require.NoError(t, err)
+	require.Len(t, attached, 1)
+	require.Equal(t, labels[1].ID, attached[0].LabelID)
+
+	mrLabels, err := repo.List(ctx, models.NewListLabelParams().SetMergeRequestID(mergeRequestID))
+	require.NoError(t, err)
+	require.Len(t, mrLabels, 2)
+	require.Equal(t, "data", mrLabels[0].Name)
+
+	affectedRows, err := repo.Delete(ctx, labels[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affectedRows)
+
+	mrLabels, err = repo.List(ctx, models.NewListLabelParams().SetMergeRequestID(mergeRequestID))
+	require.NoError(t, err)
+	require.Len(t, mrLabels, 1)
+
+	affectedRows, err = repo.Detach(ctx, models.NewDeleteMergeRequestLabelParams().SetMergeRequestID(mergeRequestID).SetLabelID(labels[0].ID))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affectedRows)
+}
@@ -1,9 +1,12 @@
 package models
 
 import (
+	"bytes"
 	"context"
+	"sort"
 	"time"
 
+	"github.com/GitDataAI/jiaozifs/utils/hash"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
@@ -28,13 +31,27 @@ type MergeRequest struct {
 	Title          string     `bun:"title,notnull" json:"title"`
 	MergeState     MergeState `bun:"merge_state,notnull" json:"merge_state"`
 	Description    *string    `bun:"description" json:"description"`
+	// Draft merge request is still in progress and can not be merged
+	Draft bool `bun:"draft,notnull,default:false" json:"draft"`
 
 	AuthorID uuid.UUID `bun:"author_id,type:bytea,notnull" json:"author_id"`
 
+	// CheckedSourceHash and CheckedTargetHash branch heads the cached mergeability computed from
+	CheckedSourceHash hash.Hash `bun:"checked_source_hash,type:bytea" json:"checked_source_hash,omitempty"`
+	CheckedTargetHash hash.Hash `bun:"checked_target_hash,type:bytea" json:"checked_target_hash,omitempty"`
+	Mergeable         bool      `bun:"mergeable,notnull,default:false" json:"mergeable"`
+	BehindBy          int       `bun:"behind_by,notnull,default:0" json:"behind_by"`
+	ConflictCount     int       `bun:"conflict_count,notnull,default:0" json:"conflict_count"`
+
 	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
 	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
 }
 
+// MergeabilityOutdated return true if source or target branch head changed since mergeability computed
+func (mr *MergeRequest) MergeabilityOutdated(sourceHash, targetHash hash.Hash) bool {
+	return mr.CheckedSourceHash == nil || !bytes.Equal(mr.CheckedSourceHash, sourceHash) || !bytes.Equal(mr.CheckedTargetHash, targetHash)
+}
+
 type GetMergeRequestParams struct {
 	id             uuid.UUID
 	sequence       *uint64
@@ -104,6 +121,7 @@ type UpdateMergeRequestParams struct {
 	title       *string
 	description *string
 	state       *MergeState
+	draft       *bool
 }
 
 func NewUpdateMergeRequestParams(targetRepoID uuid.UUID, sequence uint64) *UpdateMergeRequestParams {
@@ -129,11 +147,19 @@ func (u *UpdateMergeRequestParams) SetState(state MergeState) *UpdateMergeReques
 	return u
 }
 
+func (u *UpdateMergeRequestParams) SetDraft(draft bool) *UpdateMergeRequestParams {
+	u.draft = &draft
+	return u
+}
+
 type ListMergeRequestParams struct {
 	after        *time.Time
 	amount       int
 	targetRepoID uuid.UUID
 	state        *MergeState
+	authorID     uuid.UUID
+	assigneeID   uuid.UUID
+	labelID      uuid.UUID
 }
 
 func NewListMergeRequestParams() *ListMergeRequestParams {
@@ -160,12 +186,74 @@ func (lmr *ListMergeRequestParams) SetMergeState(state MergeState) *ListMergeReq
 	return lmr
 }
 
+func (lmr *ListMergeRequestParams) SetAuthorID(authorID uuid.UUID) *ListMergeRequestParams {
+	lmr.authorID = authorID
+	return lmr
+}
+
+// SetAssigneeID list merge requests assigned to user
+func (lmr *ListMergeRequestParams) SetAssigneeID(assigneeID uuid.UUID) *ListMergeRequestParams {
+	lmr.assigneeID = assigneeID
+	return lmr
+}
+
+// SetLabelID list merge requests attached with label
+func (lmr *ListMergeRequestParams) SetLabelID(labelID uuid.UUID) *ListMergeRequestParams {
+	lmr.labelID = labelID
+	return lmr
+}
+
+// MergeRequestActivity merge requests of author opened and merged in one time bucket
+type MergeRequestActivity struct {
+	AuthorID uuid.UUID `bun:"author_id"`
+	Bucket   time.Time `bun:"bucket"`
+	Opened   int64     `bun:"opened"`
+	Merged   int64     `bun:"merged"`
+}
+
+type MergeRequestActivityParams struct {
+	targetRepoID uuid.UUID
+	bucket       ActivityBucket
+	authorID     uuid.UUID
+	since        *time.Time
+	until        *time.Time
+}
+
+func NewMergeRequestActivityParams(targetRepoID uuid.UUID, bucket ActivityBucket) *MergeRequestActivityParams {
+	return &MergeRequestActivityParams{
+		targetRepoID: targetRepoID,
+		bucket:       bucket,
+	}
+}
+
+func (mra *MergeRequestActivityParams) SetAuthorID(authorID uuid.UUID) *MergeRequestActivityParams {
+	mra.authorID = authorID
+	return mra
+}
+
+// SetSince only count merge requests opened or merged at or after since
+func (mra *MergeRequestActivityParams) SetSince(since time.Time) *MergeRequestActivityParams {
+	mra.since = &since
+	return mra
+}
+
+// SetUntil only count merge requests opened or merged before until
+func (mra *MergeRequestActivityParams) SetUntil(until time.Time) *MergeRequestActivityParams {
+	mra.until = &until
+	return mra
+}
+
 type IMergeRequestRepo interface {
 	Insert(ctx context.Context, ref *MergeRequest) (*MergeRequest, error)
 	Get(ctx context.Context, params *GetMergeRequestParams) (*MergeRequest, error)
 	List(ctx context.Context, params *ListMergeRequestParams) ([]MergeRequest, bool, error)
 	UpdateByID(ctx context.Context, params *UpdateMergeRequestParams) error
+	// UpdateMergeability save cached mergeability of merge request, updated time is not changed
+	UpdateMergeability(ctx context.Context, mr *MergeRequest) error
 	Delete(ctx context.Context, params *DeleteMergeRequestParams) (int64, error)
+	// Activity count merge requests into target repository opened and merged by author and time bucket, ordered by bucket.
+	// merged merge requests are counted for their author in the bucket they are merged
+	Activity(ctx context.Context, params *MergeRequestActivityParams) ([]*MergeRequestActivity, error)
 }
 
 var _ IMergeRequestRepo = (*MergeRequestRepo)(nil)
@@ -183,11 +271,11 @@ func (m MergeRequestRepo) Insert(ctx context.Context, mr *MergeRequest) (*MergeR
 		WITH INCNUMBER AS (
 				SELECT MAX(mr_sequence) as max_seq from merge_requests WHERE merge_requests.target_repo_id  = ?
 			)
-		INSERT INTO merge_requests (mr_sequence, target_branch_id,source_branch_id,source_repo_id,target_repo_id,title,merge_state,description,author_id,created_at,updated_at)
-		SELECT COALESCE(max_seq,0)+1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? FROM INCNUMBER
+		INSERT INTO merge_requests (mr_sequence, target_branch_id,source_branch_id,source_repo_id,target_repo_id,title,merge_state,description,draft,author_id,created_at,updated_at)
+		SELECT COALESCE(max_seq,0)+1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? FROM INCNUMBER
 		RETURNING merge_requests.id, merge_requests.mr_sequence;
 `,
-		mr.TargetRepoID, mr.TargetBranchID, mr.SourceBranchID, mr.SourceRepoID, mr.TargetRepoID, mr.Title, mr.MergeState, mr.Description, mr.AuthorID, mr.CreatedAt, mr.UpdatedAt,
+		mr.TargetRepoID, mr.TargetBranchID, mr.SourceBranchID, mr.SourceRepoID, mr.TargetRepoID, mr.Title, mr.MergeState, mr.Description, mr.Draft, mr.AuthorID, mr.CreatedAt, mr.UpdatedAt,
 	).Exec(ctx, mr)
 	if err != nil {
 		return nil, err
@@ -235,6 +323,15 @@ func (m MergeRequestRepo) List(ctx context.Context, params *ListMergeRequestPara
 	if params.state != nil {
 		query = query.Where("merge_state = ?", params.state)
 	}
+	if params.authorID != uuid.Nil {
+		query = query.Where("author_id = ?", params.authorID)
+	}
+	if params.assigneeID != uuid.Nil {
+		query = query.Where("id IN (?)", m.db.NewSelect().Model((*MergeRequestAssignee)(nil)).Column("merge_request_id").Where("assignee_id = ?", params.assigneeID))
+	}
+	if params.labelID != uuid.Nil {
+		query = query.Where("id IN (?)", m.db.NewSelect().Model((*MergeRequestLabel)(nil)).Column("merge_request_id").Where("label_id = ?", params.labelID))
+	}
 
 	query = query.Order("updated_at DESC")
 	if params.after != nil {
@@ -280,6 +377,93 @@ func (m MergeRequestRepo) UpdateByID(ctx context.Context, updateModel *UpdateMer
 	if updateModel.state != nil {
 		updateQuery.Set("merge_state = ?", *updateModel.state)
 	}
+	if updateModel.draft != nil {
+		updateQuery.Set("draft = ?", *updateModel.draft)
+	}
 	_, err := updateQuery.Exec(ctx)
 	return err
 }
+
+func (m MergeRequestRepo) UpdateMergeability(ctx context.Context, mr *MergeRequest) error {
+	_, err := m.db.NewUpdate().
+		Model(mr).
+		Column("checked_source_hash", "checked_target_hash", "mergeable", "behind_by", "conflict_count").
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+func (m MergeRequestRepo) Activity(ctx context.Context, params *MergeRequestActivityParams) ([]*MergeRequestActivity, error) {
+	opened := make([]*MergeRequestActivity, 0)
+	openedQuery := m.db.NewSelect().
+		Model((*MergeRequest)(nil)).
+		ColumnExpr("author_id").
+		ColumnExpr("date_trunc(?, created_at) AS bucket", string(params.bucket)).
+		ColumnExpr("count(*) AS opened").
+		Where("target_repo_id = ?", params.targetRepoID)
+	if params.authorID != uuid.Nil {
+		openedQuery = openedQuery.Where("author_id = ?", params.authorID)
+	}
+	if params.since != nil {
+		openedQuery = openedQuery.Where("created_at >= ?", *params.since)
+	}
+	if params.until != nil {
+		openedQuery = openedQuery.Where("created_at < ?", *params.until)
+	}
+	err := openedQuery.GroupExpr("author_id, bucket").Scan(ctx, &opened)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make([]*MergeRequestActivity, 0)
+	mergedQuery := m.db.NewSelect().
+		TableExpr("merge_request_timelines AS timeline").
+		Join("JOIN merge_requests AS mr ON mr.id = timeline.merge_request_id").
+		ColumnExpr("mr.author_id AS author_id").
+		ColumnExpr("date_trunc(?, timeline.created_at) AS bucket", string(params.bucket)).
+		ColumnExpr("count(DISTINCT timeline.merge_request_id) AS merged").
+		Where("mr.target_repo_id = ?", params.targetRepoID).
+		Where("timeline.action = ?", TimelineMerged)
+	if params.authorID != uuid.Nil {
+		mergedQuery = mergedQuery.Where("mr.author_id = ?", params.authorID)
+	}
+	if params.since != nil {
+		mergedQuery = mergedQuery.Where("timeline.created_at >= ?", *params.since)
+	}
+	if params.until != nil {
+		mergedQuery = mergedQuery.Where("timeline.created_at < ?", *params.until)
+	}
+	err = mergedQuery.GroupExpr("mr.author_id, bucket").Scan(ctx, &merged)
+	if err != nil {
+		return nil, err
+	}
+
+	type activityKey struct {
+		authorID uuid.UUID
+		bucket   int64
+	}
+	activities := make(map[activityKey]*MergeRequestActivity, len(opened)+len(merged))
+	for _, activity := range opened {
+		activities[activityKey{authorID: activity.AuthorID, bucket: activity.Bucket.Unix()}] = activity
+	}
+	for _, activity := range merged {
+		key := activityKey{authorID: activity.AuthorID, bucket: activity.Bucket.Unix()}
+		if exist, ok := activities[key]; ok {
+			exist.Merged = activity.Merged
+			continue
+		}
+		activities[key] = activity
+	}
+
+	result := make([]*MergeRequestActivity, 0, len(activities))
+	for _, activity := range activities {
+		result = append(result, activity)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bucket.Equal(result[j].Bucket) {
+			return result[i].AuthorID.String() < result[j].AuthorID.String()
+		}
+		return result[i].Bucket.Before(result[j].Bucket)
+	})
+	return result, nil
+}
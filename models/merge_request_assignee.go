@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// MergeRequestAssignee user responsible for pushing merge request forward
+type MergeRequestAssignee struct {
+	bun.BaseModel  `bun:"table:merge_request_assignees"`
+	ID             uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MergeRequestID uuid.UUID `bun:"merge_request_id,unique:mr_assignee,type:uuid,notnull" json:"merge_request_id"`
+	AssigneeID     uuid.UUID `bun:"assignee_id,unique:mr_assignee,type:uuid,notnull" json:"assignee_id"`
+	// AssignerID who assign the merge request
+	AssignerID uuid.UUID `bun:"assigner_id,type:uuid,notnull" json:"assigner_id"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+}
+
+type ListAssigneeParams struct {
+	mergeRequestID uuid.UUID
+	assigneeID     uuid.UUID
+}
+
+func NewListAssigneeParams() *ListAssigneeParams {
+	return &ListAssigneeParams{}
+}
+
+func (lap *ListAssigneeParams) SetMergeRequestID(mergeRequestID uuid.UUID) *ListAssigneeParams {
+	lap.mergeRequestID = mergeRequestID
+	return lap
+}
+
+func (lap *ListAssigneeParams) SetAssigneeID(assigneeID uuid.UUID) *ListAssigneeParams {
+	lap.assigneeID = assigneeID
+	return lap
+}
+
+type DeleteAssigneeParams struct {
+	mergeRequestID uuid.UUID
+	assigneeID     uuid.UUID
+}
+
+func NewDeleteAssigneeParams() *DeleteAssigneeParams {
+	return &DeleteAssigneeParams{}
+}
+
+func (dap *DeleteAssigneeParams) SetMergeRequestID(mergeRequestID uuid.UUID) *DeleteAssigneeParams {
+	dap.mergeRequestID = mergeRequestID
+	return dap
+}
+
+func (dap *DeleteAssigneeParams) SetAssigneeID(assigneeID uuid.UUID) *DeleteAssigneeParams {
+	dap.assigneeID = assigneeID
+	return dap
+}
+
+type IAssigneeRepo interface {
+	// Insert add assignees of merge request, return assignees newly added, assignees already assigned are ignored
+	Insert(ctx context.Context, assignees ...*MergeRequestAssignee) ([]*MergeRequestAssignee, error)
+	List(ctx context.Context, params *ListAssigneeParams) ([]*MergeRequestAssignee, error)
+	Delete(ctx context.Context, params *DeleteAssigneeParams) (int64, error)
+}
+
+var _ IAssigneeRepo = (*AssigneeRepo)(nil)
+
+type AssigneeRepo struct {
+	db bun.IDB
+}
+
+func NewAssigneeRepo(db bun.IDB) IAssigneeRepo {
+	return &AssigneeRepo{db: db}
+}
+
+func (r AssigneeRepo) Insert(ctx context.Context, assignees ...*MergeRequestAssignee) ([]*MergeRequestAssignee, error) {
+	if len(assignees) == 0 {
+		return nil, nil
+	}
+	var inserted []*MergeRequestAssignee
+	_, err := r.db.NewInsert().
+		Model(&assignees).
+		On("CONFLICT (merge_request_id, assignee_id) DO NOTHING").
+		Returning("*").
+		Exec(ctx, &inserted)
+	if err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+func (r AssigneeRepo) List(ctx context.Context, params *ListAssigneeParams) ([]*MergeRequestAssignee, error) {
+	var assignees []*MergeRequestAssignee
+	query := r.db.NewSelect().Model(&assignees)
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	if uuid.Nil != params.assigneeID {
+		query = query.Where("assignee_id = ?", params.assigneeID)
+	}
+
+	err := query.Order("created_at ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return assignees, nil
+}
+
+func (r AssigneeRepo) Delete(ctx context.Context, params *DeleteAssigneeParams) (int64, error) {
+	query := r.db.NewDelete().Model((*MergeRequestAssignee)(nil))
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	if uuid.Nil != params.assigneeID {
+		query = query.Where("assignee_id = ?", params.assigneeID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// MergeRequestComment comment of merge request, comment without parent start a thread and others reply to the thread.
+// thread may anchor to a changed path of merge request, and optionally a range of lines or rows in the file
+type MergeRequestComment struct {
+	bun.BaseModel  `bun:"table:merge_request_comments"`
+	ID             uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MergeRequestID uuid.UUID `bun:"merge_request_id,type:uuid,notnull" json:"merge_request_id"`
+	// ParentID thread this comment reply to, nil for comment start a thread
+	ParentID uuid.UUID `bun:"parent_id,type:uuid,nullzero" json:"parent_id,omitempty"`
+	AuthorID uuid.UUID `bun:"author_id,type:uuid,notnull" json:"author_id"`
+	Body     string    `bun:"body,notnull" json:"body"`
+	// Path changed path of merge request this thread anchor to
+	Path *string `bun:"path" json:"path,omitempty"`
+	// StartLine and EndLine range of lines(or rows) anchored in path, both inclusive
+	StartLine *int `bun:"start_line" json:"start_line,omitempty"`
+	EndLine   *int `bun:"end_line" json:"end_line,omitempty"`
+	// CommitHash head commit of source branch when comment created
+	CommitHash hash.Hash `bun:"commit_hash,type:bytea" json:"commit_hash"`
+	Resolved   bool      `bun:"resolved,notnull" json:"resolved"`
+	ResolvedBy uuid.UUID `bun:"resolved_by,type:uuid,nullzero" json:"resolved_by,omitempty"`
+	// Deleted thread deleted by author while it still has replies, body is cleared and thread kept as placeholder of replies
+	Deleted bool `bun:"deleted,notnull" json:"deleted"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+// IsThread check whether comment start a thread
+func (comment *MergeRequestComment) IsThread() bool {
+	return comment.ParentID == uuid.Nil
+}
+
+type GetCommentParams struct {
+	id             uuid.UUID
+	mergeRequestID uuid.UUID
+}
+
+func NewGetCommentParams() *GetCommentParams {
+	return &GetCommentParams{}
+}
+
+func (gcp *GetCommentParams) SetID(id uuid.UUID) *GetCommentParams {
+	gcp.id = id
+	return gcp
+}
+
+func (gcp *GetCommentParams) SetMergeRequestID(mergeRequestID uuid.UUID) *GetCommentParams {
+	gcp.mergeRequestID = mergeRequestID
+	return gcp
+}
+
+type ListCommentParams struct {
+	mergeRequestID uuid.UUID
+	parentID       uuid.UUID
+	path           *string
+	resolved       *bool
+	after          *time.Time
+	amount         int
+}
+
+func NewListCommentParams() *ListCommentParams {
+	return &ListCommentParams{}
+}
+
+func (lcp *ListCommentParams) SetMergeRequestID(mergeRequestID uuid.UUID) *ListCommentParams {
+	lcp.mergeRequestID = mergeRequestID
+	return lcp
+}
+
+// SetParentID list replies of thread, threads are listed if parent not set
+func (lcp *ListCommentParams) SetParentID(parentID uuid.UUID) *ListCommentParams {
+	lcp.parentID = parentID
+	return lcp
+}
+
+func (lcp *ListCommentParams) SetPath(path string) *ListCommentParams {
+	lcp.path = &path
+	return lcp
+}
+
+func (lcp *ListCommentParams) SetResolved(resolved bool) *ListCommentParams {
+	lcp.resolved = &resolved
+	return lcp
+}
+
+func (lcp *ListCommentParams) SetAfter(after time.Time) *ListCommentParams {
+	lcp.after = &after
+	return lcp
+}
+
+func (lcp *ListCommentParams) SetAmount(amount int) *ListCommentParams {
+	lcp.amount = amount
+	return lcp
+}
+
+type DeleteCommentParams struct {
+	id             uuid.UUID
+	mergeRequestID uuid.UUID
+}
+
+func NewDeleteCommentParams() *DeleteCommentParams {
+	return &DeleteCommentParams{}
+}
+
+func (dcp *DeleteCommentParams) SetID(id uuid.UUID) *DeleteCommentParams {
+	dcp.id = id
+	return dcp
+}
+
+func (dcp *DeleteCommentParams) SetMergeRequestID(mergeRequestID uuid.UUID) *DeleteCommentParams {
+	dcp.mergeRequestID = mergeRequestID
+	return dcp
+}
+
+type UpdateCommentParams struct {
+	id         uuid.UUID
+	body       *string
+	resolved   *bool
+	resolvedBy uuid.UUID
+	deleted    bool
+}
+
+func NewUpdateCommentParams(id uuid.UUID) *UpdateCommentParams {
+	return &UpdateCommentParams{
+		id: id,
+	}
+}
+
+func (ucp *UpdateCommentParams) SetBody(body string) *UpdateCommentParams {
+	ucp.body = &body
+	return ucp
+}
+
+// SetResolved resolve or unresolve thread, resolvedBy is cleared when unresolve
+func (ucp *UpdateCommentParams) SetResolved(resolved bool, resolvedBy uuid.UUID) *UpdateCommentParams {
+	ucp.resolved = &resolved
+	ucp.resolvedBy = resolvedBy
+	return ucp
+}
+
+// SetDeleted mark thread deleted and clear its body, replies of thread are kept
+func (ucp *UpdateCommentParams) SetDeleted() *UpdateCommentParams {
+	ucp.deleted = true
+	return ucp
+}
+
+type IMergeRequestCommentRepo interface {
+	Insert(ctx context.Context, comment *MergeRequestComment) (*MergeRequestComment, error)
+	Get(ctx context.Context, params *GetCommentParams) (*MergeRequestComment, error)
+	// List return comments sorted by created time, the earliest first
+	List(ctx context.Context, params *ListCommentParams) ([]*MergeRequestComment, bool, error)
+	// Delete delete comments, thread with replies should be marked deleted by UpdateByID instead
+	Delete(ctx context.Context, params *DeleteCommentParams) (int64, error)
+	UpdateByID(ctx context.Context, params *UpdateCommentParams) error
+}
+
+var _ IMergeRequestCommentRepo = (*MergeRequestCommentRepo)(nil)
+
+type MergeRequestCommentRepo struct {
+	db bun.IDB
+}
+
+func NewMergeRequestCommentRepo(db bun.IDB) IMergeRequestCommentRepo {
+	return &MergeRequestCommentRepo{db: db}
+}
+
+func (r MergeRequestCommentRepo) Insert(ctx context.Context, comment *MergeRequestComment) (*MergeRequestComment, error) {
+	_, err := r.db.NewInsert().Model(comment).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r MergeRequestCommentRepo) Get(ctx context.Context, params *GetCommentParams) (*MergeRequestComment, error) {
+	comment := &MergeRequestComment{}
+	query := r.db.NewSelect().Model(comment)
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r MergeRequestCommentRepo) List(ctx context.Context, params *ListCommentParams) ([]*MergeRequestComment, bool, error) {
+	var comments []*MergeRequestComment
+	query := r.db.NewSelect().Model(&comments)
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	if uuid.Nil != params.parentID {
+		query = query.Where("parent_id = ?", params.parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+
+	if params.path != nil {
+		query = query.Where("path = ?", *params.path)
+	}
+
+	if params.resolved != nil {
+		query = query.Where("resolved = ?", *params.resolved)
+	}
+
+	query = query.Order("created_at ASC")
+	if params.after != nil {
+		// offset is in millisecond, skip the rest of the millisecond to avoid return the last comment of previous page again
+		query = query.Where("created_at >= ?", params.after.Truncate(time.Millisecond).Add(time.Millisecond))
+	}
+
+	err := query.Limit(params.amount).Scan(ctx)
+	return comments, len(comments) == params.amount, err
+}
+
+func (r MergeRequestCommentRepo) Delete(ctx context.Context, params *DeleteCommentParams) (int64, error) {
+	query := r.db.NewDelete().Model((*MergeRequestComment)(nil))
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (r MergeRequestCommentRepo) UpdateByID(ctx context.Context, params *UpdateCommentParams) error {
+	updateQuery := r.db.NewUpdate().Model((*MergeRequestComment)(nil)).Where("id = ?", params.id)
+
+	if params.body != nil {
+		updateQuery.Set("body = ?", *params.body)
+	}
+
+	if params.resolved != nil {
+		updateQuery.Set("resolved = ?", *params.resolved)
+		if *params.resolved {
+			updateQuery.Set("resolved_by = ?", params.resolvedBy)
+		} else {
+			updateQuery.Set("resolved_by = NULL")
+		}
+	}
+
+	if params.deleted {
+		updateQuery.Set("deleted = ?", true).Set("body = ?", "")
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
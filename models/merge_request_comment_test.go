@@ -0,0 +1,125 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeRequestCommentRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewMergeRequestCommentRepo(db)
+	mergeRequestID := uuid.New()
+
+	var threads []*models.MergeRequestComment
+	for i := 0; i < 3; i++ {
+		comment := &models.MergeRequestComment{
+			MergeRequestID: mergeRequestID,
+			AuthorID:       uuid.New(),
+			Body:           "thread",
+			CreatedAt:      time.Now().Add(time.Duration(i) * time.Second),
+			UpdatedAt:      time.Now(),
+		}
+		if i == 0 {
+			comment.Path = utils.String("a.txt")
+			comment.StartLine = utils.Int(1)
+			comment.EndLine = utils.Int(3)
+		}
+		thread, err := repo.Insert(ctx, comment)
+		require.NoError(t, err)
+		threads = append(threads, thread)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.Insert(ctx, &models.MergeRequestComment{
+			MergeRequestID: mergeRequestID,
+			ParentID:       threads[0].ID,
+			AuthorID:       uuid.New(),
+			Body:           "reply",
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("list threads", func(t *testing.T) {
+		comments, hasMore, err := repo.List(ctx, models.NewListCommentParams().SetMergeRequestID(mergeRequestID).SetAmount(2))
+		require.NoError(t, err)
+		require.True(t, hasMore)
+		require.Len(t, comments, 2)
+		require.Equal(t, threads[0].ID, comments[0].ID)
+
+		comments, hasMore, err = repo.List(ctx, models.NewListCommentParams().SetMergeRequestID(mergeRequestID).SetAfter(time.UnixMilli(comments[1].CreatedAt.UnixMilli())).SetAmount(2))
+		require.NoError(t, err)
+		require.False(t, hasMore)
+		require.Len(t, comments, 1)
+		require.Equal(t, threads[2].ID, comments[0].ID)
+
+		comments, _, err = repo.List(ctx, models.NewListCommentParams().SetMergeRequestID(mergeRequestID).SetPath("a.txt").SetAmount(10))
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+	})
+
+	t.Run("list replies", func(t *testing.T) {
+		comments, _, err := repo.List(ctx, models.NewListCommentParams().SetMergeRequestID(mergeRequestID).SetParentID(threads[0].ID).SetAmount(10))
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		require.False(t, comments[0].IsThread())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		resolver := uuid.New()
+		err := repo.UpdateByID(ctx, models.NewUpdateCommentParams(threads[1].ID).SetBody("edited").SetResolved(true, resolver))
+		require.NoError(t, err)
+
+		comment, err := repo.Get(ctx, models.NewGetCommentParams().SetID(threads[1].ID).SetMergeRequestID(mergeRequestID))
+		require.NoError(t, err)
+		require.Equal(t, "edited", comment.Body)
+		require.True(t, comment.Resolved)
+		require.Equal(t, resolver, comment.ResolvedBy)
+
+		comments, _, err := repo.List(ctx, models.NewListCommentParams().SetMergeRequestID(mergeRequestID).SetResolved(false).SetAmount(10))
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+
+		err = repo.UpdateByID(ctx, models.NewUpdateCommentParams(threads[1].ID).SetResolved(false, uuid.Nil))
+		require.NoError(t, err)
+		comment, err = repo.Get(ctx, models.NewGetCommentParams().SetID(threads[1].ID))
+		require.NoError(t, err)
+		require.False(t, comment.Resolved)
+		require.Equal(t, uuid.Nil, comment.ResolvedBy)
+	})
+
+	t.Run("mark thread deleted", func(t *testing.T) {
+		err := repo.UpdateByID(ctx, models.NewUpdateCommentParams(threads[0].ID).SetDeleted())
+		require.NoError(t, err)
+
+		comment, err := repo.Get(ctx, models.NewGetCommentParams().SetID(threads[0].ID))
+		require.NoError(t, err)
+		require.True(t, comment.Deleted)
+		require.Empty(t, comment.Body)
+
+		comments, _, err := repo.List(ctx, models.NewListCommentParams().SetMergeRequestID(mergeRequestID).SetParentID(threads[0].ID).SetAmount(10))
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		affectedRows, err := repo.Delete(ctx, models.NewDeleteCommentParams().SetID(threads[0].ID).SetMergeRequestID(mergeRequestID))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), affectedRows)
+
+		comments, _, err := repo.List(ctx, models.NewListCommentParams().SetMergeRequestID(mergeRequestID).SetParentID(threads[0].ID).SetAmount(10))
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+	})
+}
@@ -110,3 +110,52 @@ func TestMergeRequestRepoInsert(t *testing.T) {
 		require.Equal(t, models.MergeStateClosed, mrModel.MergeState)
 	})
 }
+
+func TestMergeRequestRepoActivity(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	mrRepo := models.NewMergeRequestRepo(db)
+	timelineRepo := models.NewTimelineRepo(db)
+
+	targetRepoID := uuid.New()
+	authorID := uuid.New()
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		mrModel := &models.MergeRequest{}
+		require.NoError(t, gofakeit.Struct(mrModel))
+		mrModel.TargetRepoID = targetRepoID
+		mrModel.AuthorID = authorID
+		mrModel.CreatedAt = day.Add(time.Duration(i) * time.Hour)
+		mrModel, err := mrRepo.Insert(ctx, mrModel)
+		require.NoError(t, err)
+
+		if i == 0 {
+			timeline := models.NewMergeRequestTimeline(mrModel.ID, uuid.New(), models.TimelineMerged, nil)
+			timeline.CreatedAt = day.Add(24 * time.Hour)
+			err = timelineRepo.Insert(ctx, timeline)
+			require.NoError(t, err)
+		}
+	}
+
+	activities, err := mrRepo.Activity(ctx, models.NewMergeRequestActivityParams(targetRepoID, models.DayActivityBucket))
+	require.NoError(t, err)
+	require.Len(t, activities, 2)
+	require.Equal(t, authorID, activities[0].AuthorID)
+	require.True(t, day.Equal(activities[0].Bucket))
+	require.Equal(t, int64(3), activities[0].Opened)
+	require.Equal(t, int64(0), activities[0].Merged)
+	require.Equal(t, int64(0), activities[1].Opened)
+	require.Equal(t, int64(1), activities[1].Merged)
+
+	activities, err = mrRepo.Activity(ctx, models.NewMergeRequestActivityParams(targetRepoID, models.WeekActivityBucket).SetUntil(day.Add(90*time.Minute)))
+	require.NoError(t, err)
+	require.Len(t, activities, 1)
+	require.Equal(t, int64(2), activities[0].Opened)
+	require.Equal(t, int64(0), activities[0].Merged)
+
+	activities, err = mrRepo.Activity(ctx, models.NewMergeRequestActivityParams(targetRepoID, models.DayActivityBucket).SetAuthorID(uuid.New()))
+	require.NoError(t, err)
+	require.Empty(t, activities)
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// TimelineAction what happened to merge request
+type TimelineAction string
+
+const (
+	TimelineOpened        TimelineAction = "opened"
+	TimelineClosed        TimelineAction = "closed"
+	TimelineReopened      TimelineAction = "reopened"
+	TimelineMerged        TimelineAction = "merged"
+	TimelineDraft         TimelineAction = "converted_to_draft"
+	TimelineReady         TimelineAction = "ready_for_review"
+	TimelineAssigned      TimelineAction = "assigned"
+	TimelineUnassigned    TimelineAction = "unassigned"
+	TimelineLabeled       TimelineAction = "labeled"
+	TimelineUnlabeled     TimelineAction = "unlabeled"
+	TimelineBranchUpdated TimelineAction = "branch_updated"
+)
+
+// MergeRequestTimeline one change of merge request lifecycle
+type MergeRequestTimeline struct {
+	bun.BaseModel  `bun:"table:merge_request_timelines"`
+	ID             uuid.UUID      `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MergeRequestID uuid.UUID      `bun:"merge_request_id,type:uuid,notnull" json:"merge_request_id"`
+	ActorID        uuid.UUID      `bun:"actor_id,type:uuid,notnull" json:"actor_id"`
+	Action         TimelineAction `bun:"action,notnull" json:"action"`
+	// Detail subject of action, user name of assigned/unassigned, label name of labeled/unlabeled, commit hash of merged/branch_updated
+	Detail *string `bun:"detail" json:"detail,omitempty"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+}
+
+// NewMergeRequestTimeline create timeline of merge request happened now
+func NewMergeRequestTimeline(mergeRequestID, actorID uuid.UUID, action TimelineAction, detail *string) *MergeRequestTimeline {
+	return &MergeRequestTimeline{
+		MergeRequestID: mergeRequestID,
+		ActorID:        actorID,
+		Action:         action,
+		Detail:         detail,
+		CreatedAt:      time.Now(),
+	}
+}
+
+type ListTimelineParams struct {
+	mergeRequestID uuid.UUID
+	after          *time.Time
+	amount         int
+}
+
+func NewListTimelineParams() *ListTimelineParams {
+	return &ListTimelineParams{}
+}
+
+func (ltp *ListTimelineParams) SetMergeRequestID(mergeRequestID uuid.UUID) *ListTimelineParams {
+	ltp.mergeRequestID = mergeRequestID
+	return ltp
+}
+
+func (ltp *ListTimelineParams) SetAfter(after time.Time) *ListTimelineParams {
+	ltp.after = &after
+	return ltp
+}
+
+func (ltp *ListTimelineParams) SetAmount(amount int) *ListTimelineParams {
+	ltp.amount = amount
+	return ltp
+}
+
+type ITimelineRepo interface {
+	Insert(ctx context.Context, timelines ...*MergeRequestTimeline) error
+	// List return timeline of merge request sorted by created time
+	List(ctx context.Context, params *ListTimelineParams) ([]*MergeRequestTimeline, bool, error)
+}
+
+var _ ITimelineRepo = (*TimelineRepo)(nil)
+
+type TimelineRepo struct {
+	db bun.IDB
+}
+
+func NewTimelineRepo(db bun.IDB) ITimelineRepo {
+	return &TimelineRepo{db: db}
+}
+
+func (r TimelineRepo) Insert(ctx context.Context, timelines ...*MergeRequestTimeline) error {
+	if len(timelines) == 0 {
+		return nil
+	}
+	_, err := r.db.NewInsert().Model(&timelines).Exec(ctx)
+	return err
+}
+
+func (r TimelineRepo) List(ctx context.Context, params *ListTimelineParams) ([]*MergeRequestTimeline, bool, error) {
+	var timelines []*MergeRequestTimeline
+	query := r.db.NewSelect().Model(&timelines)
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	query = query.Order("created_at ASC")
+	if params.after != nil {
+		// offset is in millisecond, skip the rest of the millisecond to avoid return the last event of previous page again
+		query = query.Where("created_at >= ?", params.after.Truncate(time.Millisecond).Add(time.Millisecond))
+	}
+
+	err := query.Limit(params.amount).Scan(ctx)
+	return timelines, len(timelines) == params.amount, err
+}
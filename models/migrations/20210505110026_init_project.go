@@ -81,6 +81,15 @@ func init() {
 		if err != nil {
 			return err
 		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.FileTree)(nil)).
+			Index("cid_idx").
+			Column("cid").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
 		//aksk
 		_, err = db.NewCreateTable().
 			Model((*models.AkSk)(nil)).
@@ -89,6 +98,217 @@ func init() {
 			return err
 		}
 
+		//webhook
+		_, err = db.NewCreateTable().
+			Model((*models.Webhook)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateTable().
+			Model((*models.WebhookDelivery)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.WebhookDelivery)(nil)).
+			Index("webhook_delivery_state_idx").
+			Column("state", "next_attempt_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//action
+		_, err = db.NewCreateTable().
+			Model((*models.Action)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateTable().
+			Model((*models.ActionRun)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.ActionRun)(nil)).
+			Index("action_run_state_idx").
+			Column("state", "created_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//quota
+		_, err = db.NewCreateTable().
+			Model((*models.Quota)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateTable().
+			Model((*models.QuotaCharge)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//commit stats
+		_, err = db.NewCreateTable().
+			Model((*models.CommitStats)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.CommitStats)(nil)).
+			Index("commit_stats_committed_at_idx").
+			Column("repository_id", "committed_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//branch protection
+		_, err = db.NewCreateTable().
+			Model((*models.BranchProtection)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//merge request review
+		_, err = db.NewCreateTable().
+			Model((*models.MergeRequestReviewer)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateTable().
+			Model((*models.Review)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.Review)(nil)).
+			Index("review_merge_request_idx").
+			Column("merge_request_id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//merge request comment
+		_, err = db.NewCreateTable().
+			Model((*models.MergeRequestComment)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.MergeRequestComment)(nil)).
+			Index("comment_merge_request_idx").
+			Column("merge_request_id", "parent_id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//commit status
+		_, err = db.NewCreateTable().
+			Model((*models.CommitStatus)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//auto merge
+		_, err = db.NewCreateTable().
+			Model((*models.AutoMerge)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//merge request assignee
+		_, err = db.NewCreateTable().
+			Model((*models.MergeRequestAssignee)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//label
+		_, err = db.NewCreateTable().
+			Model((*models.Label)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateTable().
+			Model((*models.MergeRequestLabel)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//merge request timeline
+		_, err = db.NewCreateTable().
+			Model((*models.MergeRequestTimeline)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.MergeRequestTimeline)(nil)).
+			Index("timeline_merge_request_idx").
+			Column("merge_request_id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		//audit log
+		_, err = db.NewCreateTable().
+			Model((*models.AuditLog)(nil)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.AuditLog)(nil)).
+			Index("audit_log_repo_created_idx").
+			Column("repository_id", "created_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*models.AuditLog)(nil)).
+			Index("audit_log_created_idx").
+			Column("created_at").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
 		_, err = db.NewCreateTable().
 			Model((*models.Member)(nil)).
 			Exec(ctx)
@@ -0,0 +1,299 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// QuotaScope what quota limits
+type QuotaScope string
+
+const (
+	// UserQuotaScope quota of all repositories owned by user
+	UserQuotaScope QuotaScope = "user"
+	// RepositoryQuotaScope quota of one repository
+	RepositoryQuotaScope QuotaScope = "repository"
+)
+
+// Quota storage usage of user or repository and limits overriding default limits in config
+type Quota struct {
+	bun.BaseModel `bun:"table:quotas,alias:quotas"`
+	Scope         QuotaScope `bun:"scope,pk" json:"scope"`
+	// TargetID id of user or repository
+	TargetID uuid.UUID `bun:"target_id,pk,type:uuid" json:"target_id"`
+	// UsedBytes size of objects stored
+	UsedBytes int64 `bun:"used_bytes,notnull" json:"used_bytes"`
+	// UsedObjects count of objects stored
+	UsedObjects int64 `bun:"used_objects,notnull" json:"used_objects"`
+	// MaxBytes MaxObjects limits set by admin, nil means default limits are used and non-positive means unlimited
+	MaxBytes   *int64 `bun:"max_bytes" json:"max_bytes,omitempty"`
+	MaxObjects *int64 `bun:"max_objects" json:"max_objects,omitempty"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+// QuotaCharge blob whose size has been added to usage of repository, blobs are addressed by checksum so each one is charged once
+type QuotaCharge struct {
+	bun.BaseModel `bun:"table:quota_charges,alias:quota_charges"`
+	RepositoryID  uuid.UUID `bun:"repository_id,pk,type:uuid" json:"repository_id"`
+	CheckSum      hash.Hash `bun:"check_sum,pk,type:bytea" json:"check_sum"`
+	Size          int64     `bun:"size,notnull" json:"size"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+}
+
+type GetQuotaParams struct {
+	scope    QuotaScope
+	targetID uuid.UUID
+}
+
+func NewGetQuotaParams() *GetQuotaParams {
+	return &GetQuotaParams{}
+}
+
+func (gqp *GetQuotaParams) SetScope(scope QuotaScope) *GetQuotaParams {
+	gqp.scope = scope
+	return gqp
+}
+
+func (gqp *GetQuotaParams) SetTargetID(targetID uuid.UUID) *GetQuotaParams {
+	gqp.targetID = targetID
+	return gqp
+}
+
+type UpdateQuotaLimitsParams struct {
+	scope      QuotaScope
+	targetID   uuid.UUID
+	maxBytes   *int64
+	maxObjects *int64
+	clear      bool
+}
+
+func NewUpdateQuotaLimitsParams(scope QuotaScope, targetID uuid.UUID) *UpdateQuotaLimitsParams {
+	return &UpdateQuotaLimitsParams{
+		scope:    scope,
+		targetID: targetID,
+	}
+}
+
+func (uqp *UpdateQuotaLimitsParams) SetMaxBytes(maxBytes int64) *UpdateQuotaLimitsParams {
+	uqp.maxBytes = &maxBytes
+	return uqp
+}
+
+func (uqp *UpdateQuotaLimitsParams) SetMaxObjects(maxObjects int64) *UpdateQuotaLimitsParams {
+	uqp.maxObjects = &maxObjects
+	return uqp
+}
+
+// SetClear remove limits so that default limits are used
+func (uqp *UpdateQuotaLimitsParams) SetClear() *UpdateQuotaLimitsParams {
+	uqp.clear = true
+	return uqp
+}
+
+type DeleteQuotaParams struct {
+	scope    QuotaScope
+	targetID uuid.UUID
+}
+
+func NewDeleteQuotaParams() *DeleteQuotaParams {
+	return &DeleteQuotaParams{}
+}
+
+func (dqp *DeleteQuotaParams) SetScope(scope QuotaScope) *DeleteQuotaParams {
+	dqp.scope = scope
+	return dqp
+}
+
+func (dqp *DeleteQuotaParams) SetTargetID(targetID uuid.UUID) *DeleteQuotaParams {
+	dqp.targetID = targetID
+	return dqp
+}
+
+type IQuotaRepo interface {
+	Get(ctx context.Context, params *GetQuotaParams) (*Quota, error)
+	// IncreaseUsage add bytes and objects to usage, quota is created if not exist. negative values decrease usage
+	IncreaseUsage(ctx context.Context, scope QuotaScope, targetID uuid.UUID, bytes int64, objects int64) error
+	// ReserveUsage add bytes and objects to usage only if limits are not exceeded, limits overridden in quota take precedence over
+	// defaultMaxBytes and defaultMaxObjects. return false if usage is not changed because limits would be exceeded
+	ReserveUsage(ctx context.Context, scope QuotaScope, targetID uuid.UUID, bytes int64, objects int64, defaultMaxBytes int64, defaultMaxObjects int64) (bool, error)
+	// UpdateLimits set or clear limits, quota is created if not exist
+	UpdateLimits(ctx context.Context, params *UpdateQuotaLimitsParams) error
+	Delete(ctx context.Context, params *DeleteQuotaParams) (int64, error)
+	// Charge record blob of repository is charged, return false if it has been charged already.
+	// concurrent charges of the same blob wait for each other, only one of them succeed
+	Charge(ctx context.Context, repositoryID uuid.UUID, checkSum hash.Hash, size int64) (bool, error)
+	// Uncharge remove charge of blob, return false if blob is not charged
+	Uncharge(ctx context.Context, repositoryID uuid.UUID, checkSum hash.Hash) (bool, error)
+	// DeleteCharges remove all charges of repository
+	DeleteCharges(ctx context.Context, repositoryID uuid.UUID) (int64, error)
+}
+
+var _ IQuotaRepo = (*QuotaRepo)(nil)
+
+type QuotaRepo struct {
+	db bun.IDB
+}
+
+func NewQuotaRepo(db bun.IDB) IQuotaRepo {
+	return &QuotaRepo{db: db}
+}
+
+func (r QuotaRepo) Get(ctx context.Context, params *GetQuotaParams) (*Quota, error) {
+	quota := &Quota{}
+	err := r.db.NewSelect().
+		Model(quota).
+		Where("scope = ?", params.scope).
+		Where("target_id = ?", params.targetID).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+func (r QuotaRepo) IncreaseUsage(ctx context.Context, scope QuotaScope, targetID uuid.UUID, bytes int64, objects int64) error {
+	_, err := r.db.NewInsert().
+		Model(&Quota{
+			Scope:       scope,
+			TargetID:    targetID,
+			UsedBytes:   max(bytes, 0),
+			UsedObjects: max(objects, 0),
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}).
+		On("CONFLICT (scope, target_id) DO UPDATE").
+		Set("used_bytes = GREATEST(quotas.used_bytes + ?, 0)", bytes).
+		Set("used_objects = GREATEST(quotas.used_objects + ?, 0)", objects).
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
+
+func (r QuotaRepo) ReserveUsage(ctx context.Context, scope QuotaScope, targetID uuid.UUID, bytes int64, objects int64, defaultMaxBytes int64, defaultMaxObjects int64) (bool, error) {
+	// make sure quota exit, so that checking and increasing usage is done by one conditional update
+	err := r.IncreaseUsage(ctx, scope, targetID, 0, 0)
+	if err != nil {
+		return false, err
+	}
+
+	sqlResult, err := r.db.NewUpdate().
+		Model((*Quota)(nil)).
+		Set("used_bytes = used_bytes + ?", bytes).
+		Set("used_objects = used_objects + ?", objects).
+		Set("updated_at = ?", time.Now()).
+		Where("scope = ?", scope).
+		Where("target_id = ?", targetID).
+		Where("(COALESCE(max_bytes, ?) <= 0 OR used_bytes + ? <= COALESCE(max_bytes, ?))", defaultMaxBytes, bytes, defaultMaxBytes).
+		Where("(COALESCE(max_objects, ?) <= 0 OR used_objects + ? <= COALESCE(max_objects, ?))", defaultMaxObjects, objects, defaultMaxObjects).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affectedRows > 0, nil
+}
+
+func (r QuotaRepo) UpdateLimits(ctx context.Context, params *UpdateQuotaLimitsParams) error {
+	query := r.db.NewInsert().
+		Model(&Quota{
+			Scope:      params.scope,
+			TargetID:   params.targetID,
+			MaxBytes:   params.maxBytes,
+			MaxObjects: params.maxObjects,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}).
+		On("CONFLICT (scope, target_id) DO UPDATE")
+
+	if params.clear {
+		query = query.Set("max_bytes = NULL").Set("max_objects = NULL")
+	}
+
+	if params.maxBytes != nil {
+		query = query.Set("max_bytes = EXCLUDED.max_bytes")
+	}
+
+	if params.maxObjects != nil {
+		query = query.Set("max_objects = EXCLUDED.max_objects")
+	}
+
+	_, err := query.Set("updated_at = EXCLUDED.updated_at").Exec(ctx)
+	return err
+}
+
+func (r QuotaRepo) Delete(ctx context.Context, params *DeleteQuotaParams) (int64, error) {
+	sqlResult, err := r.db.NewDelete().
+		Model((*Quota)(nil)).
+		Where("scope = ?", params.scope).
+		Where("target_id = ?", params.targetID).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (r QuotaRepo) Charge(ctx context.Context, repositoryID uuid.UUID, checkSum hash.Hash, size int64) (bool, error) {
+	sqlResult, err := r.db.NewInsert().
+		Model(&QuotaCharge{
+			RepositoryID: repositoryID,
+			CheckSum:     checkSum,
+			Size:         size,
+			CreatedAt:    time.Now(),
+		}).
+		On("CONFLICT (repository_id, check_sum) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affectedRows > 0, nil
+}
+
+func (r QuotaRepo) Uncharge(ctx context.Context, repositoryID uuid.UUID, checkSum hash.Hash) (bool, error) {
+	sqlResult, err := r.db.NewDelete().
+		Model((*QuotaCharge)(nil)).
+		Where("repository_id = ?", repositoryID).
+		Where("check_sum = ?", checkSum).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affectedRows > 0, nil
+}
+
+func (r QuotaRepo) DeleteCharges(ctx context.Context, repositoryID uuid.UUID) (int64, error) {
+	sqlResult, err := r.db.NewDelete().
+		Model((*QuotaCharge)(nil)).
+		Where("repository_id = ?", repositoryID).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
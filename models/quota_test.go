@@ -0,0 +1,131 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewQuotaRepo(db)
+
+	targetID := uuid.New()
+	getParams := models.NewGetQuotaParams().SetScope(models.RepositoryQuotaScope).SetTargetID(targetID)
+	_, err := repo.Get(ctx, getParams)
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	require.NoError(t, repo.IncreaseUsage(ctx, models.RepositoryQuotaScope, targetID, 100, 1))
+	require.NoError(t, repo.IncreaseUsage(ctx, models.RepositoryQuotaScope, targetID, 50, 2))
+	quota, err := repo.Get(ctx, getParams)
+	require.NoError(t, err)
+	require.Equal(t, int64(150), quota.UsedBytes)
+	require.Equal(t, int64(3), quota.UsedObjects)
+	require.Nil(t, quota.MaxBytes)
+
+	// usage never be negative
+	require.NoError(t, repo.IncreaseUsage(ctx, models.RepositoryQuotaScope, targetID, -200, -1))
+	quota, err = repo.Get(ctx, getParams)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), quota.UsedBytes)
+	require.Equal(t, int64(2), quota.UsedObjects)
+
+	// other scope with same id is separated
+	_, err = repo.Get(ctx, models.NewGetQuotaParams().SetScope(models.UserQuotaScope).SetTargetID(targetID))
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	t.Run("update limits", func(t *testing.T) {
+		require.NoError(t, repo.UpdateLimits(ctx, models.NewUpdateQuotaLimitsParams(models.RepositoryQuotaScope, targetID).SetMaxBytes(1024)))
+		quota, err := repo.Get(ctx, getParams)
+		require.NoError(t, err)
+		require.Equal(t, int64(1024), *quota.MaxBytes)
+		require.Nil(t, quota.MaxObjects)
+		require.Equal(t, int64(2), quota.UsedObjects)
+
+		require.NoError(t, repo.UpdateLimits(ctx, models.NewUpdateQuotaLimitsParams(models.RepositoryQuotaScope, targetID).SetMaxObjects(10)))
+		quota, err = repo.Get(ctx, getParams)
+		require.NoError(t, err)
+		require.Equal(t, int64(1024), *quota.MaxBytes)
+		require.Equal(t, int64(10), *quota.MaxObjects)
+
+		require.NoError(t, repo.UpdateLimits(ctx, models.NewUpdateQuotaLimitsParams(models.RepositoryQuotaScope, targetID).SetClear()))
+		quota, err = repo.Get(ctx, getParams)
+		require.NoError(t, err)
+		require.Nil(t, quota.MaxBytes)
+		require.Nil(t, quota.MaxObjects)
+
+		// limits of user without usage
+		userID := uuid.New()
+		require.NoError(t, repo.UpdateLimits(ctx, models.NewUpdateQuotaLimitsParams(models.UserQuotaScope, userID).SetMaxBytes(0)))
+		quota, err = repo.Get(ctx, models.NewGetQuotaParams().SetScope(models.UserQuotaScope).SetTargetID(userID))
+		require.NoError(t, err)
+		require.Equal(t, int64(0), *quota.MaxBytes)
+		require.Equal(t, int64(0), quota.UsedBytes)
+	})
+
+	t.Run("reserve usage", func(t *testing.T) {
+		reserveID := uuid.New()
+		reserveParams := models.NewGetQuotaParams().SetScope(models.RepositoryQuotaScope).SetTargetID(reserveID)
+
+		ok, err := repo.ReserveUsage(ctx, models.RepositoryQuotaScope, reserveID, 80, 1, 100, 0)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = repo.ReserveUsage(ctx, models.RepositoryQuotaScope, reserveID, 30, 1, 100, 0)
+		require.NoError(t, err)
+		require.False(t, ok)
+		quota, err := repo.Get(ctx, reserveParams)
+		require.NoError(t, err)
+		require.Equal(t, int64(80), quota.UsedBytes)
+		require.Equal(t, int64(1), quota.UsedObjects)
+
+		// overridden limits take precedence over default limits
+		require.NoError(t, repo.UpdateLimits(ctx, models.NewUpdateQuotaLimitsParams(models.RepositoryQuotaScope, reserveID).SetMaxBytes(0).SetMaxObjects(1)))
+		ok, err = repo.ReserveUsage(ctx, models.RepositoryQuotaScope, reserveID, 30, 1, 100, 0)
+		require.NoError(t, err)
+		require.False(t, ok)
+		ok, err = repo.ReserveUsage(ctx, models.RepositoryQuotaScope, reserveID, 30, 0, 100, 0)
+		require.NoError(t, err)
+		require.True(t, ok)
+		quota, err = repo.Get(ctx, reserveParams)
+		require.NoError(t, err)
+		require.Equal(t, int64(110), quota.UsedBytes)
+	})
+
+	affectRows, err := repo.Delete(ctx, models.NewDeleteQuotaParams().SetScope(models.RepositoryQuotaScope).SetTargetID(targetID))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affectRows)
+
+	t.Run("charge", func(t *testing.T) {
+		repositoryID := uuid.New()
+		charged, err := repo.Charge(ctx, repositoryID, hash.Hash("blob"), 10)
+		require.NoError(t, err)
+		require.True(t, charged)
+		charged, err = repo.Charge(ctx, repositoryID, hash.Hash("blob"), 10)
+		require.NoError(t, err)
+		require.False(t, charged)
+		charged, err = repo.Charge(ctx, uuid.New(), hash.Hash("blob"), 10)
+		require.NoError(t, err)
+		require.True(t, charged)
+
+		uncharged, err := repo.Uncharge(ctx, repositoryID, hash.Hash("blob"))
+		require.NoError(t, err)
+		require.True(t, uncharged)
+		uncharged, err = repo.Uncharge(ctx, repositoryID, hash.Hash("blob"))
+		require.NoError(t, err)
+		require.False(t, uncharged)
+
+		_, err = repo.Charge(ctx, repositoryID, hash.Hash("other"), 10)
+		require.NoError(t, err)
+		deleted, err := repo.DeleteCharges(ctx, repositoryID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+	})
+}
@@ -33,11 +33,17 @@ var Actions = []string{
 	"repo:DeleteWip",
 	"repo:ReadConfig",
 	"repo:WriteConfig",
+	"repo:QueryAuditLog",
 	"repo:CreateMergeRequest",
 	"repo:ReadMergeRequest",
 	"repo:UpdateMergeRequest",
 	"repo:ListMergeRequest",
 	"repo:MergeMergeRequest",
+	"repo:ReviewMergeRequest",
+	"repo:ReadCommitStatus",
+	"repo:WriteCommitStatus",
+	"repo:ReadLabel",
+	"repo:WriteLabel",
 	"repo:AddGroupMember",
 	"repo:RemoveGroupMember",
 	"repo:GetGroupMember",
@@ -53,6 +59,8 @@ var Actions = []string{
 	"auth:ListPolicies",
 	"auth:AttachPolicy",
 	"auth:DetachPolicy",
+	"auth:QueryAuditLog",
+	"auth:ManageQuota",
 	"user:UserProfile",
 	"user:ReadUser",
 	"user:ListUsers",
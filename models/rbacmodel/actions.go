@@ -52,11 +52,21 @@ const (
 	ReadConfigAction  = "repo:ReadConfig"
 	WriteConfigAction = "repo:WriteConfig"
 
+	// QueryRepoAuditLogAction not prefixed with Read/List, so that repo reader can not see audit logs
+	QueryRepoAuditLogAction = "repo:QueryAuditLog"
+
 	CreateMergeRequestAction = "repo:CreateMergeRequest"
 	ReadMergeRequestAction   = "repo:ReadMergeRequest"
 	UpdateMergeRequestAction = "repo:UpdateMergeRequest"
 	ListMergeRequestAction   = "repo:ListMergeRequest"
 	MergeMergeRequestAction  = "repo:MergeMergeRequest"
+	ReviewMergeRequestAction = "repo:ReviewMergeRequest"
+
+	ReadCommitStatusAction  = "repo:ReadCommitStatus"
+	WriteCommitStatusAction = "repo:WriteCommitStatus"
+
+	ReadLabelAction  = "repo:ReadLabel"
+	WriteLabelAction = "repo:WriteLabel"
 
 	AddGroupMemberAction    = "repo:AddGroupMember"
 	RemoveGroupMemberAction = "repo:RemoveGroupMember"
@@ -75,6 +85,10 @@ const (
 	AttachPolicyAction = "auth:AttachPolicy"
 	DetachPolicyAction = "auth:DetachPolicy"
 
+	QueryAuditLogAction = "auth:QueryAuditLog"
+	// ManageQuotaAction set storage limits of users and repositories, and read usage of other users
+	ManageQuotaAction = "auth:ManageQuota"
+
 	UserProfileAction       = "user:UserProfile"
 	ReadUserAction          = "user:ReadUser"
 	ListUsersAction         = "user:ListUsers"
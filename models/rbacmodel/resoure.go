@@ -61,3 +61,13 @@ func GroupArn(groupID string) Resource {
 func PolicyArn(policyID string) Resource {
 	return Resource(fmt.Sprintf("%spolicy/%s", authArnPrefix, policyID))
 }
+
+// QuotaArn storage quotas of whole system
+func QuotaArn() Resource {
+	return Resource(fmt.Sprintf("%squota", authArnPrefix))
+}
+
+// AuditLogArn audit logs of whole system
+func AuditLogArn() Resource {
+	return Resource(fmt.Sprintf("%saudit", authArnPrefix))
+}
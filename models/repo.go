@@ -37,6 +37,22 @@ type IRepo interface {
 	RepositoryRepo() IRepositoryRepo
 	WipRepo() IWipRepo
 	AkskRepo() IAkskRepo
+	WebhookRepo() IWebhookRepo
+	WebhookDeliveryRepo() IWebhookDeliveryRepo
+	ActionRepo() IActionRepo
+	ActionRunRepo() IActionRunRepo
+	QuotaRepo() IQuotaRepo
+	CommitStatsRepo() ICommitStatsRepo
+	AuditLogRepo() IAuditLogRepo
+	BranchProtectionRepo() IBranchProtectionRepo
+	ReviewerRepo() IReviewerRepo
+	ReviewRepo() IReviewRepo
+	MergeRequestCommentRepo() IMergeRequestCommentRepo
+	CommitStatusRepo() ICommitStatusRepo
+	AutoMergeRepo() IAutoMergeRepo
+	AssigneeRepo() IAssigneeRepo
+	LabelRepo() ILabelRepo
+	TimelineRepo() ITimelineRepo
 
 	MemberRepo() IMemberRepo
 	GroupRepo() rbacmodel.IGroupRepo
@@ -104,6 +120,70 @@ func (repo *PgRepo) AkskRepo() IAkskRepo {
 	return NewAkskRepo(repo.db)
 }
 
+func (repo *PgRepo) WebhookRepo() IWebhookRepo {
+	return NewWebhookRepo(repo.db)
+}
+
+func (repo *PgRepo) WebhookDeliveryRepo() IWebhookDeliveryRepo {
+	return NewWebhookDeliveryRepo(repo.db)
+}
+
+func (repo *PgRepo) ActionRepo() IActionRepo {
+	return NewActionRepo(repo.db)
+}
+
+func (repo *PgRepo) ActionRunRepo() IActionRunRepo {
+	return NewActionRunRepo(repo.db)
+}
+
+func (repo *PgRepo) QuotaRepo() IQuotaRepo {
+	return NewQuotaRepo(repo.db)
+}
+
+func (repo *PgRepo) CommitStatsRepo() ICommitStatsRepo {
+	return NewCommitStatsRepo(repo.db)
+}
+
+func (repo *PgRepo) AuditLogRepo() IAuditLogRepo {
+	return NewAuditLogRepo(repo.db)
+}
+
+func (repo *PgRepo) BranchProtectionRepo() IBranchProtectionRepo {
+	return NewBranchProtectionRepo(repo.db)
+}
+
+func (repo *PgRepo) ReviewerRepo() IReviewerRepo {
+	return NewReviewerRepo(repo.db)
+}
+
+func (repo *PgRepo) ReviewRepo() IReviewRepo {
+	return NewReviewRepo(repo.db)
+}
+
+func (repo *PgRepo) MergeRequestCommentRepo() IMergeRequestCommentRepo {
+	return NewMergeRequestCommentRepo(repo.db)
+}
+
+func (repo *PgRepo) CommitStatusRepo() ICommitStatusRepo {
+	return NewCommitStatusRepo(repo.db)
+}
+
+func (repo *PgRepo) AutoMergeRepo() IAutoMergeRepo {
+	return NewAutoMergeRepo(repo.db)
+}
+
+func (repo *PgRepo) AssigneeRepo() IAssigneeRepo {
+	return NewAssigneeRepo(repo.db)
+}
+
+func (repo *PgRepo) LabelRepo() ILabelRepo {
+	return NewLabelRepo(repo.db)
+}
+
+func (repo *PgRepo) TimelineRepo() ITimelineRepo {
+	return NewTimelineRepo(repo.db)
+}
+
 func (repo *PgRepo) MemberRepo() IMemberRepo {
 	return NewMemberRepo(repo.db)
 }
@@ -68,6 +68,7 @@ type ListRepoParams struct {
 	name      *string
 	nameMatch MatchMode
 	visible   *bool
+	cid       *string
 
 	after  *time.Time
 	amount int
@@ -102,6 +103,12 @@ func (lrp *ListRepoParams) SetVisible(visible bool) *ListRepoParams {
 	return lrp
 }
 
+// SetCid filter repositories which contain object with this cid
+func (lrp *ListRepoParams) SetCid(cid string) *ListRepoParams {
+	lrp.cid = &cid
+	return lrp
+}
+
 func (lrp *ListRepoParams) SetAfter(after time.Time) *ListRepoParams {
 	lrp.after = &after
 	return lrp
@@ -235,6 +242,10 @@ func (r *RepositoryRepo) List(ctx context.Context, params *ListRepoParams) ([]*R
 		query = query.Where("visible = ?", *params.visible)
 	}
 
+	if params.cid != nil {
+		query = query.Where("id IN (?)", r.db.NewSelect().Model((*FileTree)(nil)).Column("repository_id").Where("cid = ?", *params.cid))
+	}
+
 	if params.name != nil {
 		switch params.nameMatch {
 		case ExactMatch:
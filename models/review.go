@@ -0,0 +1,306 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ReviewVerdict conclusion of review
+type ReviewVerdict string
+
+const (
+	ReviewApprove        ReviewVerdict = "approve"
+	ReviewRequestChanges ReviewVerdict = "request_changes"
+	ReviewComment        ReviewVerdict = "comment"
+)
+
+func ParseReviewVerdict(verdict string) (ReviewVerdict, error) {
+	switch ReviewVerdict(verdict) {
+	case ReviewApprove, ReviewRequestChanges, ReviewComment:
+		return ReviewVerdict(verdict), nil
+	}
+	return "", fmt.Errorf("unknown review verdict %s", verdict)
+}
+
+// Approvers return users whose latest approve or request changes review is approve, comment and dismissed reviews are ignored.
+// reviews must be sorted by created time
+func Approvers(reviews []*Review) []uuid.UUID {
+	latest := make(map[uuid.UUID]ReviewVerdict)
+	var reviewers []uuid.UUID
+	for _, review := range reviews {
+		if review.Dismissed || review.Verdict == ReviewComment {
+			continue
+		}
+		if _, ok := latest[review.ReviewerID]; !ok {
+			reviewers = append(reviewers, review.ReviewerID)
+		}
+		latest[review.ReviewerID] = review.Verdict
+	}
+
+	approvers := make([]uuid.UUID, 0)
+	for _, reviewer := range reviewers {
+		if latest[reviewer] == ReviewApprove {
+			approvers = append(approvers, reviewer)
+		}
+	}
+	return approvers
+}
+
+// MergeRequestReviewer user requested to review merge request
+type MergeRequestReviewer struct {
+	bun.BaseModel  `bun:"table:merge_request_reviewers"`
+	ID             uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MergeRequestID uuid.UUID `bun:"merge_request_id,unique:mr_reviewer,type:uuid,notnull" json:"merge_request_id"`
+	ReviewerID     uuid.UUID `bun:"reviewer_id,unique:mr_reviewer,type:uuid,notnull" json:"reviewer_id"`
+	// RequesterID who request the review
+	RequesterID uuid.UUID `bun:"requester_id,type:uuid,notnull" json:"requester_id"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+}
+
+type ListReviewerParams struct {
+	mergeRequestID uuid.UUID
+	reviewerID     uuid.UUID
+}
+
+func NewListReviewerParams() *ListReviewerParams {
+	return &ListReviewerParams{}
+}
+
+func (lrp *ListReviewerParams) SetMergeRequestID(mergeRequestID uuid.UUID) *ListReviewerParams {
+	lrp.mergeRequestID = mergeRequestID
+	return lrp
+}
+
+func (lrp *ListReviewerParams) SetReviewerID(reviewerID uuid.UUID) *ListReviewerParams {
+	lrp.reviewerID = reviewerID
+	return lrp
+}
+
+type DeleteReviewerParams struct {
+	mergeRequestID uuid.UUID
+	reviewerID     uuid.UUID
+}
+
+func NewDeleteReviewerParams() *DeleteReviewerParams {
+	return &DeleteReviewerParams{}
+}
+
+func (drp *DeleteReviewerParams) SetMergeRequestID(mergeRequestID uuid.UUID) *DeleteReviewerParams {
+	drp.mergeRequestID = mergeRequestID
+	return drp
+}
+
+func (drp *DeleteReviewerParams) SetReviewerID(reviewerID uuid.UUID) *DeleteReviewerParams {
+	drp.reviewerID = reviewerID
+	return drp
+}
+
+type IReviewerRepo interface {
+	// Insert add reviewers of merge request, reviewers already requested are ignored
+	Insert(ctx context.Context, reviewers ...*MergeRequestReviewer) error
+	List(ctx context.Context, params *ListReviewerParams) ([]*MergeRequestReviewer, error)
+	Delete(ctx context.Context, params *DeleteReviewerParams) (int64, error)
+}
+
+var _ IReviewerRepo = (*ReviewerRepo)(nil)
+
+type ReviewerRepo struct {
+	db bun.IDB
+}
+
+func NewReviewerRepo(db bun.IDB) IReviewerRepo {
+	return &ReviewerRepo{db: db}
+}
+
+func (r ReviewerRepo) Insert(ctx context.Context, reviewers ...*MergeRequestReviewer) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+	_, err := r.db.NewInsert().Model(&reviewers).On("CONFLICT (merge_request_id, reviewer_id) DO NOTHING").Exec(ctx)
+	return err
+}
+
+func (r ReviewerRepo) List(ctx context.Context, params *ListReviewerParams) ([]*MergeRequestReviewer, error) {
+	var reviewers []*MergeRequestReviewer
+	query := r.db.NewSelect().Model(&reviewers)
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	if uuid.Nil != params.reviewerID {
+		query = query.Where("reviewer_id = ?", params.reviewerID)
+	}
+
+	err := query.Order("created_at ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reviewers, nil
+}
+
+func (r ReviewerRepo) Delete(ctx context.Context, params *DeleteReviewerParams) (int64, error) {
+	query := r.db.NewDelete().Model((*MergeRequestReviewer)(nil))
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	if uuid.Nil != params.reviewerID {
+		query = query.Where("reviewer_id = ?", params.reviewerID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+// Review verdict of reviewer on merge request
+type Review struct {
+	bun.BaseModel  `bun:"table:merge_request_reviews"`
+	ID             uuid.UUID     `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MergeRequestID uuid.UUID     `bun:"merge_request_id,type:uuid,notnull" json:"merge_request_id"`
+	ReviewerID     uuid.UUID     `bun:"reviewer_id,type:uuid,notnull" json:"reviewer_id"`
+	Verdict        ReviewVerdict `bun:"verdict,notnull" json:"verdict"`
+	// CommitHash head commit of source branch when review submitted
+	CommitHash hash.Hash `bun:"commit_hash,type:bytea,notnull" json:"commit_hash"`
+	Body       *string   `bun:"body" json:"body,omitempty"`
+	// Dismissed approval is dismissed when source branch moves
+	Dismissed bool `bun:"dismissed,notnull" json:"dismissed"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+type ListReviewParams struct {
+	mergeRequestID uuid.UUID
+	reviewerID     uuid.UUID
+	verdict        *ReviewVerdict
+	dismissed      *bool
+	commitHash     hash.Hash
+}
+
+func NewListReviewParams() *ListReviewParams {
+	return &ListReviewParams{}
+}
+
+func (lrp *ListReviewParams) SetMergeRequestID(mergeRequestID uuid.UUID) *ListReviewParams {
+	lrp.mergeRequestID = mergeRequestID
+	return lrp
+}
+
+func (lrp *ListReviewParams) SetReviewerID(reviewerID uuid.UUID) *ListReviewParams {
+	lrp.reviewerID = reviewerID
+	return lrp
+}
+
+func (lrp *ListReviewParams) SetVerdict(verdict ReviewVerdict) *ListReviewParams {
+	lrp.verdict = &verdict
+	return lrp
+}
+
+func (lrp *ListReviewParams) SetDismissed(dismissed bool) *ListReviewParams {
+	lrp.dismissed = &dismissed
+	return lrp
+}
+
+// SetCommitHash list reviews submitted when head of source branch is commitHash
+func (lrp *ListReviewParams) SetCommitHash(commitHash hash.Hash) *ListReviewParams {
+	lrp.commitHash = commitHash
+	return lrp
+}
+
+type IReviewRepo interface {
+	Insert(ctx context.Context, review *Review) (*Review, error)
+	// List return reviews sorted by created time
+	List(ctx context.Context, params *ListReviewParams) ([]*Review, error)
+	// DismissStaleApprovals dismiss approvals of open merge requests from source branch which not review the head commit
+	DismissStaleApprovals(ctx context.Context, sourceBranchID uuid.UUID, headCommit hash.Hash) (int64, error)
+}
+
+var _ IReviewRepo = (*ReviewRepo)(nil)
+
+type ReviewRepo struct {
+	db bun.IDB
+}
+
+func NewReviewRepo(db bun.IDB) IReviewRepo {
+	return &ReviewRepo{db: db}
+}
+
+func (r ReviewRepo) Insert(ctx context.Context, review *Review) (*Review, error) {
+	_, err := r.db.NewInsert().Model(review).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r ReviewRepo) List(ctx context.Context, params *ListReviewParams) ([]*Review, error) {
+	var reviews []*Review
+	query := r.db.NewSelect().Model(&reviews)
+
+	if uuid.Nil != params.mergeRequestID {
+		query = query.Where("merge_request_id = ?", params.mergeRequestID)
+	}
+
+	if uuid.Nil != params.reviewerID {
+		query = query.Where("reviewer_id = ?", params.reviewerID)
+	}
+
+	if params.verdict != nil {
+		query = query.Where("verdict = ?", *params.verdict)
+	}
+
+	if params.dismissed != nil {
+		query = query.Where("dismissed = ?", *params.dismissed)
+	}
+
+	if params.commitHash != nil {
+		query = query.Where("commit_hash = ?", params.commitHash)
+	}
+
+	err := query.Order("created_at ASC").Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r ReviewRepo) DismissStaleApprovals(ctx context.Context, sourceBranchID uuid.UUID, headCommit hash.Hash) (int64, error) {
+	openMergeRequests := r.db.NewSelect().
+		Model((*MergeRequest)(nil)).
+		Column("id").
+		Where("source_branch_id = ?", sourceBranchID).
+		Where("merge_state = ?", MergeStateInit)
+
+	sqlResult, err := r.db.NewUpdate().
+		Model((*Review)(nil)).
+		Set("dismissed = ?", true).
+		Set("updated_at = ?", time.Now()).
+		Where("verdict = ?", ReviewApprove).
+		Where("dismissed = ?", false).
+		Where("commit_hash != ?", headCommit).
+		Where("merge_request_id IN (?)", openMergeRequests).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
@@ -0,0 +1,109 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovers(t *testing.T) {
+	alice, bob, carl := uuid.New(), uuid.New(), uuid.New()
+	reviews := []*models.Review{
+		{ReviewerID: alice, Verdict: models.ReviewApprove},
+		{ReviewerID: bob, Verdict: models.ReviewApprove},
+		{ReviewerID: bob, Verdict: models.ReviewRequestChanges},
+		{ReviewerID: carl, Verdict: models.ReviewApprove, Dismissed: true},
+		{ReviewerID: alice, Verdict: models.ReviewComment},
+	}
+	require.Equal(t, []uuid.UUID{alice}, models.Approvers(reviews))
+	require.Empty(t, models.Approvers(nil))
+}
+
+func TestReviewRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	mrRepo := models.NewMergeRequestRepo(db)
+	reviewerRepo := models.NewReviewerRepo(db)
+	reviewRepo := models.NewReviewRepo(db)
+
+	sourceBranchID := uuid.New()
+	mr, err := mrRepo.Insert(ctx, &models.MergeRequest{
+		TargetBranchID: uuid.New(),
+		SourceBranchID: sourceBranchID,
+		SourceRepoID:   uuid.New(),
+		TargetRepoID:   uuid.New(),
+		Title:          "feat",
+		MergeState:     models.MergeStateInit,
+		AuthorID:       uuid.New(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	})
+	require.NoError(t, err)
+
+	t.Run("reviewers", func(t *testing.T) {
+		reviewerID := uuid.New()
+		reviewer := &models.MergeRequestReviewer{MergeRequestID: mr.ID, ReviewerID: reviewerID, RequesterID: uuid.New(), CreatedAt: time.Now()}
+		require.NoError(t, reviewerRepo.Insert(ctx, reviewer))
+		// request again is ignored
+		require.NoError(t, reviewerRepo.Insert(ctx, &models.MergeRequestReviewer{MergeRequestID: mr.ID, ReviewerID: reviewerID, RequesterID: uuid.New(), CreatedAt: time.Now()},
+			&models.MergeRequestReviewer{MergeRequestID: mr.ID, ReviewerID: uuid.New(), RequesterID: uuid.New(), CreatedAt: time.Now()}))
+
+		reviewers, err := reviewerRepo.List(ctx, models.NewListReviewerParams().SetMergeRequestID(mr.ID))
+		require.NoError(t, err)
+		require.Len(t, reviewers, 2)
+
+		affected, err := reviewerRepo.Delete(ctx, models.NewDeleteReviewerParams().SetMergeRequestID(mr.ID).SetReviewerID(reviewerID))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), affected)
+	})
+
+	t.Run("reviews", func(t *testing.T) {
+		oldCommit := hash.Hash("old commit")
+		newCommit := hash.Hash("new commit")
+		for _, verdict := range []models.ReviewVerdict{models.ReviewApprove, models.ReviewComment, models.ReviewApprove} {
+			_, err := reviewRepo.Insert(ctx, &models.Review{
+				MergeRequestID: mr.ID,
+				ReviewerID:     uuid.New(),
+				Verdict:        verdict,
+				CommitHash:     oldCommit,
+				CreatedAt:      time.Now(),
+				UpdatedAt:      time.Now(),
+			})
+			require.NoError(t, err)
+		}
+		_, err := reviewRepo.Insert(ctx, &models.Review{
+			MergeRequestID: mr.ID,
+			ReviewerID:     uuid.New(),
+			Verdict:        models.ReviewApprove,
+			CommitHash:     newCommit,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		})
+		require.NoError(t, err)
+
+		reviews, err := reviewRepo.List(ctx, models.NewListReviewParams().SetMergeRequestID(mr.ID).SetVerdict(models.ReviewApprove))
+		require.NoError(t, err)
+		require.Len(t, reviews, 3)
+
+		reviews, err = reviewRepo.List(ctx, models.NewListReviewParams().SetMergeRequestID(mr.ID).SetCommitHash(newCommit))
+		require.NoError(t, err)
+		require.Len(t, reviews, 1)
+
+		dismissed, err := reviewRepo.DismissStaleApprovals(ctx, sourceBranchID, newCommit)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), dismissed)
+
+		reviews, err = reviewRepo.List(ctx, models.NewListReviewParams().SetMergeRequestID(mr.ID).SetDismissed(false))
+		require.NoError(t, err)
+		require.Len(t, reviews, 2)
+		require.Len(t, models.Approvers(reviews), 1)
+	})
+}
@@ -10,6 +10,10 @@ import (
 	"github.com/GitDataAI/jiaozifs/models/filemode"
 	"github.com/GitDataAI/jiaozifs/utils/hash"
 	"github.com/google/uuid"
+	dag "github.com/ipfs/boxo/ipld/merkledag"
+	ft "github.com/ipfs/boxo/ipld/unixfs"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
 	"github.com/uptrace/bun"
 )
 
@@ -32,6 +36,9 @@ type TreeEntry struct {
 	Name  string    `bun:"name" json:"name"`
 	IsDir bool      `bun:"is_dir" json:"is_dir"`
 	Hash  hash.Hash `bun:"hash" json:"hash"`
+	// Cid unixfs cid of entry, CidSize is the cumulative size of its dag, both are empty for object created before cid support
+	Cid     string `bun:"cid" json:"cid,omitempty"`
+	CidSize uint64 `bun:"cid_size" json:"cid_size,omitempty"`
 }
 
 func SortSubObjects(subObjects []TreeEntry) []TreeEntry {
@@ -82,6 +89,8 @@ type Blob struct {
 	Type          ObjectType `bun:"type,notnull"`
 	Size          int64      `bun:"size"`
 	Properties    Property   `bun:"properties,type:jsonb,notnull"`
+	Cid           string     `bun:"cid"`
+	CidSize       uint64     `bun:"cid_size"`
 
 	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull"`
 	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull"`
@@ -140,6 +149,8 @@ func (blob *Blob) FileTree() *FileTree {
 		Size:         blob.Size,
 		CheckSum:     blob.CheckSum,
 		Properties:   blob.Properties,
+		Cid:          blob.Cid,
+		CidSize:      blob.CidSize,
 		CreatedAt:    blob.CreatedAt,
 		UpdatedAt:    blob.UpdatedAt,
 	}
@@ -153,6 +164,8 @@ type TreeNode struct {
 	Type       ObjectType  `bun:"type,notnull" json:"type"`
 	SubObjects []TreeEntry `bun:"sub_objects,type:jsonb" json:"sub_objects"`
 	Properties Property    `bun:"properties,type:jsonb,notnull" json:"properties"`
+	Cid        string      `bun:"cid" json:"cid,omitempty"`
+	CidSize    uint64      `bun:"cid_size" json:"cid_size,omitempty"`
 
 	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
 	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
@@ -175,6 +188,11 @@ func NewTreeNode(props Property, repoID uuid.UUID, subObjects ...TreeEntry) (*Tr
 		return nil, err
 	}
 	newTree.Hash = hash
+
+	newTree.Cid, newTree.CidSize, err = newTree.calculateCid()
+	if err != nil {
+		return nil, err
+	}
 	return newTree, nil
 }
 
@@ -185,6 +203,8 @@ func (tn *TreeNode) FileTree() *FileTree {
 		Type:         tn.Type,
 		SubObjects:   tn.SubObjects,
 		Properties:   tn.Properties,
+		Cid:          tn.Cid,
+		CidSize:      tn.CidSize,
 		CreatedAt:    tn.CreatedAt,
 		UpdatedAt:    tn.UpdatedAt,
 	}
@@ -221,6 +241,35 @@ func (tn *TreeNode) calculateHash() (hash.Hash, error) {
 	return hasher.Md5.Sum(nil), nil
 }
 
+// calculateCid build unixfs directory node from cid of sub objects, the result is the same as the directory added by ipfs.
+// cid is left empty if any sub object has no cid
+func (tn *TreeNode) calculateCid() (string, uint64, error) {
+	dirNode := dag.NodeWithData(ft.FolderPBData())
+	for _, obj := range tn.SubObjects {
+		if len(obj.Cid) == 0 {
+			return "", 0, nil
+		}
+		subCid, err := cid.Decode(obj.Cid)
+		if err != nil {
+			return "", 0, err
+		}
+		err = dirNode.AddRawLink(obj.Name, &ipld.Link{
+			Name: obj.Name,
+			Size: obj.CidSize,
+			Cid:  subCid,
+		})
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	size, err := dirNode.Size()
+	if err != nil {
+		return "", 0, err
+	}
+	return dirNode.Cid().String(), size, nil
+}
+
 type FileTree struct {
 	bun.BaseModel `bun:"table:trees"`
 	Hash          hash.Hash  `bun:"hash,pk,type:bytea"`
@@ -229,6 +278,8 @@ type FileTree struct {
 	Type          ObjectType `bun:"type,notnull"`
 	Size          int64      `bun:"size"`
 	Properties    Property   `bun:"properties,type:jsonb,notnull"`
+	Cid           string     `bun:"cid"`
+	CidSize       uint64     `bun:"cid_size"`
 	//tree
 	SubObjects []TreeEntry `bun:"sub_objects,type:jsonb,notnull" json:"sub_objects"`
 
@@ -244,6 +295,8 @@ func (fileTree *FileTree) Blob() *Blob {
 		Size:         fileTree.Size,
 		Properties:   fileTree.Properties,
 		CheckSum:     fileTree.CheckSum,
+		Cid:          fileTree.Cid,
+		CidSize:      fileTree.CidSize,
 		CreatedAt:    fileTree.CreatedAt,
 		UpdatedAt:    fileTree.UpdatedAt,
 	}
@@ -256,6 +309,8 @@ func (fileTree *FileTree) TreeNode() *TreeNode {
 		Properties:   fileTree.Properties,
 		RepositoryID: fileTree.RepositoryID,
 		SubObjects:   fileTree.SubObjects,
+		Cid:          fileTree.Cid,
+		CidSize:      fileTree.CidSize,
 		CreatedAt:    fileTree.CreatedAt,
 		UpdatedAt:    fileTree.UpdatedAt,
 	}
@@ -263,6 +318,7 @@ func (fileTree *FileTree) TreeNode() *TreeNode {
 
 type GetObjParams struct {
 	hash hash.Hash
+	cid  *string
 }
 
 func NewGetObjParams() *GetObjParams {
@@ -274,6 +330,11 @@ func (gop *GetObjParams) SetHash(hash hash.Hash) *GetObjParams {
 	return gop
 }
 
+func (gop *GetObjParams) SetCid(cid string) *GetObjParams {
+	gop.cid = &cid
+	return gop
+}
+
 type DeleteTreeParams struct {
 	hash hash.Hash
 }
@@ -295,6 +356,9 @@ type IFileTreeRepo interface {
 	List(ctx context.Context) ([]FileTree, error)
 	Blob(ctx context.Context, hash hash.Hash) (*Blob, error)
 	TreeNode(ctx context.Context, hash hash.Hash) (*TreeNode, error)
+	// StoredBlobs return total size and count of distinct content of all blobs uploaded to repository,
+	// blobs not reachable from any commit are counted too
+	StoredBlobs(ctx context.Context) (int64, int64, error)
 	Delete(ctx context.Context, params *DeleteTreeParams) (int64, error)
 }
 
@@ -335,6 +399,10 @@ func (o FileTreeRepo) Get(ctx context.Context, params *GetObjParams) (*FileTree,
 		query = query.Where("hash = ?", params.hash)
 	}
 
+	if params.cid != nil {
+		query = query.Where("cid = ?", *params.cid)
+	}
+
 	err := query.Limit(1).Scan(ctx, repo)
 	if err != nil {
 		return nil, err
@@ -368,6 +436,26 @@ func (o FileTreeRepo) TreeNode(ctx context.Context, hash hash.Hash) (*TreeNode,
 	return tree, nil
 }
 
+func (o FileTreeRepo) StoredBlobs(ctx context.Context) (int64, int64, error) {
+	distinctBlobs := o.db.NewSelect().
+		Model((*Blob)(nil)).
+		DistinctOn("check_sum").
+		Column("check_sum", "size").
+		Where("repository_id = ?", o.repositoryID).
+		Where("type = ?", BlobObject)
+
+	var size, count int64
+	err := o.db.NewSelect().
+		TableExpr("(?) AS blobs", distinctBlobs).
+		ColumnExpr("COALESCE(SUM(blobs.size), 0)").
+		ColumnExpr("COUNT(*)").
+		Scan(ctx, &size, &count)
+	if err != nil {
+		return 0, 0, err
+	}
+	return size, count, nil
+}
+
 func (o FileTreeRepo) Count(ctx context.Context) (int, error) {
 	return o.db.NewSelect().
 		Model((*FileTree)(nil)).
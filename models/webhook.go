@@ -0,0 +1,552 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// EventType type of event happened in repository
+type EventType string
+
+const (
+	PushEvent               EventType = "push"
+	BranchCreateEvent       EventType = "branch_create"
+	BranchDeleteEvent       EventType = "branch_delete"
+	TagCreateEvent          EventType = "tag_create"
+	MergeRequestOpenEvent   EventType = "merge_request_open"
+	MergeRequestMergeEvent  EventType = "merge_request_merge"
+	MergeRequestCloseEvent  EventType = "merge_request_close"
+	MergeRequestReopenEvent EventType = "merge_request_reopen"
+	WipCommitEvent          EventType = "wip_commit"
+)
+
+var AllEventTypes = []EventType{
+	PushEvent,
+	BranchCreateEvent,
+	BranchDeleteEvent,
+	TagCreateEvent,
+	MergeRequestOpenEvent,
+	MergeRequestMergeEvent,
+	MergeRequestCloseEvent,
+	MergeRequestReopenEvent,
+	WipCommitEvent,
+}
+
+func ParseEventType(event string) (EventType, error) {
+	for _, eventType := range AllEventTypes {
+		if string(eventType) == event {
+			return eventType, nil
+		}
+	}
+	return "", fmt.Errorf("unknown event type %s", event)
+}
+
+type Webhook struct {
+	bun.BaseModel `bun:"table:webhooks"`
+	ID            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	RepositoryID  uuid.UUID `bun:"repository_id,type:uuid,notnull" json:"repository_id"`
+	// URL address to post event payload
+	URL string `bun:"url,notnull" json:"url"`
+	// Secret used to sign payload with hmac-sha256, empty means not sign
+	Secret string `bun:"secret" json:"-"`
+	// Events event types this webhook subscribe
+	Events []EventType `bun:"events,type:jsonb,notnull" json:"events"`
+	Active bool        `bun:"active,notnull" json:"active"`
+
+	CreatorID uuid.UUID `bun:"creator_id,type:uuid,notnull" json:"creator_id"`
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+type GetWebhookParams struct {
+	id           uuid.UUID
+	repositoryID uuid.UUID
+}
+
+func NewGetWebhookParams() *GetWebhookParams {
+	return &GetWebhookParams{}
+}
+
+func (gwp *GetWebhookParams) SetID(id uuid.UUID) *GetWebhookParams {
+	gwp.id = id
+	return gwp
+}
+
+func (gwp *GetWebhookParams) SetRepositoryID(repositoryID uuid.UUID) *GetWebhookParams {
+	gwp.repositoryID = repositoryID
+	return gwp
+}
+
+type ListWebhookParams struct {
+	repositoryID uuid.UUID
+	event        *EventType
+	active       *bool
+	after        *time.Time
+	amount       int
+}
+
+func NewListWebhookParams() *ListWebhookParams {
+	return &ListWebhookParams{}
+}
+
+func (lwp *ListWebhookParams) SetRepositoryID(repositoryID uuid.UUID) *ListWebhookParams {
+	lwp.repositoryID = repositoryID
+	return lwp
+}
+
+// SetEvent filter webhooks which subscribe this event
+func (lwp *ListWebhookParams) SetEvent(event EventType) *ListWebhookParams {
+	lwp.event = &event
+	return lwp
+}
+
+func (lwp *ListWebhookParams) SetActive(active bool) *ListWebhookParams {
+	lwp.active = &active
+	return lwp
+}
+
+func (lwp *ListWebhookParams) SetAfter(after time.Time) *ListWebhookParams {
+	lwp.after = &after
+	return lwp
+}
+
+func (lwp *ListWebhookParams) SetAmount(amount int) *ListWebhookParams {
+	lwp.amount = amount
+	return lwp
+}
+
+type DeleteWebhookParams struct {
+	id           uuid.UUID
+	repositoryID uuid.UUID
+}
+
+func NewDeleteWebhookParams() *DeleteWebhookParams {
+	return &DeleteWebhookParams{}
+}
+
+func (dwp *DeleteWebhookParams) SetID(id uuid.UUID) *DeleteWebhookParams {
+	dwp.id = id
+	return dwp
+}
+
+func (dwp *DeleteWebhookParams) SetRepositoryID(repositoryID uuid.UUID) *DeleteWebhookParams {
+	dwp.repositoryID = repositoryID
+	return dwp
+}
+
+type UpdateWebhookParams struct {
+	id     uuid.UUID
+	url    *string
+	secret *string
+	events []EventType
+	active *bool
+}
+
+func NewUpdateWebhookParams(id uuid.UUID) *UpdateWebhookParams {
+	return &UpdateWebhookParams{
+		id: id,
+	}
+}
+
+func (uwp *UpdateWebhookParams) SetURL(url string) *UpdateWebhookParams {
+	uwp.url = &url
+	return uwp
+}
+
+func (uwp *UpdateWebhookParams) SetSecret(secret string) *UpdateWebhookParams {
+	uwp.secret = &secret
+	return uwp
+}
+
+func (uwp *UpdateWebhookParams) SetEvents(events []EventType) *UpdateWebhookParams {
+	uwp.events = events
+	return uwp
+}
+
+func (uwp *UpdateWebhookParams) SetActive(active bool) *UpdateWebhookParams {
+	uwp.active = &active
+	return uwp
+}
+
+type IWebhookRepo interface {
+	Insert(ctx context.Context, webhook *Webhook) (*Webhook, error)
+	Get(ctx context.Context, params *GetWebhookParams) (*Webhook, error)
+	List(ctx context.Context, params *ListWebhookParams) ([]*Webhook, bool, error)
+	Delete(ctx context.Context, params *DeleteWebhookParams) (int64, error)
+	UpdateByID(ctx context.Context, params *UpdateWebhookParams) error
+}
+
+var _ IWebhookRepo = (*WebhookRepo)(nil)
+
+type WebhookRepo struct {
+	db bun.IDB
+}
+
+func NewWebhookRepo(db bun.IDB) IWebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+func (w WebhookRepo) Insert(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	_, err := w.db.NewInsert().Model(webhook).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (w WebhookRepo) Get(ctx context.Context, params *GetWebhookParams) (*Webhook, error) {
+	webhook := &Webhook{}
+	query := w.db.NewSelect().Model(webhook)
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (w WebhookRepo) List(ctx context.Context, params *ListWebhookParams) ([]*Webhook, bool, error) {
+	var webhooks []*Webhook
+	query := w.db.NewSelect().Model(&webhooks)
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	if params.event != nil {
+		events, err := json.Marshal([]EventType{*params.event})
+		if err != nil {
+			return nil, false, err
+		}
+		query = query.Where("events @> ?::jsonb", string(events))
+	}
+
+	if params.active != nil {
+		query = query.Where("active = ?", *params.active)
+	}
+
+	query = query.Order("created_at DESC")
+	if params.after != nil {
+		query = query.Where("created_at < ?", *params.after)
+	}
+
+	err := query.Limit(params.amount).Scan(ctx)
+	return webhooks, len(webhooks) == params.amount, err
+}
+
+func (w WebhookRepo) Delete(ctx context.Context, params *DeleteWebhookParams) (int64, error) {
+	query := w.db.NewDelete().Model((*Webhook)(nil))
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.repositoryID {
+		query = query.Where("repository_id = ?", params.repositoryID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (w WebhookRepo) UpdateByID(ctx context.Context, params *UpdateWebhookParams) error {
+	updateQuery := w.db.NewUpdate().Model((*Webhook)(nil)).Where("id = ?", params.id)
+
+	if params.url != nil {
+		updateQuery.Set("url = ?", *params.url)
+	}
+
+	if params.secret != nil {
+		updateQuery.Set("secret = ?", *params.secret)
+	}
+
+	if params.events != nil {
+		events, err := json.Marshal(params.events)
+		if err != nil {
+			return err
+		}
+		updateQuery.Set("events = ?::jsonb", string(events))
+	}
+
+	if params.active != nil {
+		updateQuery.Set("active = ?", *params.active)
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
+
+// DeliveryState state of webhook delivery
+type DeliveryState string
+
+const (
+	DeliveryPending DeliveryState = "pending"
+	DeliverySuccess DeliveryState = "success"
+	DeliveryFailed  DeliveryState = "failed"
+)
+
+type WebhookDelivery struct {
+	bun.BaseModel `bun:"table:webhook_deliveries"`
+	ID            uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	WebhookID     uuid.UUID `bun:"webhook_id,type:uuid,notnull" json:"webhook_id"`
+	RepositoryID  uuid.UUID `bun:"repository_id,type:uuid,notnull" json:"repository_id"`
+	Event         EventType `bun:"event,notnull" json:"event"`
+	// Payload json content post to webhook
+	Payload string        `bun:"payload,notnull" json:"payload"`
+	State   DeliveryState `bun:"state,notnull" json:"state"`
+	// Attempts count of deliveries already tried
+	Attempts      int       `bun:"attempts,notnull" json:"attempts"`
+	NextAttemptAt time.Time `bun:"next_attempt_at,type:timestamp,notnull" json:"next_attempt_at"`
+	// ResponseCode http status code of last attempt, zero if request not sent
+	ResponseCode int `bun:"response_code" json:"response_code"`
+	// Message response body or error message of last attempt
+	Message *string `bun:"message" json:"message,omitempty"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamp,notnull" json:"created_at"`
+	UpdatedAt time.Time `bun:"updated_at,type:timestamp,notnull" json:"updated_at"`
+}
+
+type GetWebhookDeliveryParams struct {
+	id        uuid.UUID
+	webhookID uuid.UUID
+}
+
+func NewGetWebhookDeliveryParams() *GetWebhookDeliveryParams {
+	return &GetWebhookDeliveryParams{}
+}
+
+func (gdp *GetWebhookDeliveryParams) SetID(id uuid.UUID) *GetWebhookDeliveryParams {
+	gdp.id = id
+	return gdp
+}
+
+func (gdp *GetWebhookDeliveryParams) SetWebhookID(webhookID uuid.UUID) *GetWebhookDeliveryParams {
+	gdp.webhookID = webhookID
+	return gdp
+}
+
+type ListWebhookDeliveryParams struct {
+	webhookID         uuid.UUID
+	state             *DeliveryState
+	nextAttemptBefore *time.Time
+	after             *time.Time
+	amount            int
+}
+
+func NewListWebhookDeliveryParams() *ListWebhookDeliveryParams {
+	return &ListWebhookDeliveryParams{}
+}
+
+func (ldp *ListWebhookDeliveryParams) SetWebhookID(webhookID uuid.UUID) *ListWebhookDeliveryParams {
+	ldp.webhookID = webhookID
+	return ldp
+}
+
+func (ldp *ListWebhookDeliveryParams) SetState(state DeliveryState) *ListWebhookDeliveryParams {
+	ldp.state = &state
+	return ldp
+}
+
+// SetNextAttemptBefore filter deliveries which should be attempted before this time
+func (ldp *ListWebhookDeliveryParams) SetNextAttemptBefore(before time.Time) *ListWebhookDeliveryParams {
+	ldp.nextAttemptBefore = &before
+	return ldp
+}
+
+func (ldp *ListWebhookDeliveryParams) SetAfter(after time.Time) *ListWebhookDeliveryParams {
+	ldp.after = &after
+	return ldp
+}
+
+func (ldp *ListWebhookDeliveryParams) SetAmount(amount int) *ListWebhookDeliveryParams {
+	ldp.amount = amount
+	return ldp
+}
+
+type DeleteWebhookDeliveryParams struct {
+	webhookID uuid.UUID
+}
+
+func NewDeleteWebhookDeliveryParams() *DeleteWebhookDeliveryParams {
+	return &DeleteWebhookDeliveryParams{}
+}
+
+func (ddp *DeleteWebhookDeliveryParams) SetWebhookID(webhookID uuid.UUID) *DeleteWebhookDeliveryParams {
+	ddp.webhookID = webhookID
+	return ddp
+}
+
+type UpdateWebhookDeliveryParams struct {
+	id            uuid.UUID
+	state         *DeliveryState
+	attempts      *int
+	nextAttemptAt *time.Time
+	responseCode  *int
+	message       *string
+}
+
+func NewUpdateWebhookDeliveryParams(id uuid.UUID) *UpdateWebhookDeliveryParams {
+	return &UpdateWebhookDeliveryParams{
+		id: id,
+	}
+}
+
+func (udp *UpdateWebhookDeliveryParams) SetState(state DeliveryState) *UpdateWebhookDeliveryParams {
+	udp.state = &state
+	return udp
+}
+
+func (udp *UpdateWebhookDeliveryParams) SetAttempts(attempts int) *UpdateWebhookDeliveryParams {
+	udp.attempts = &attempts
+	return udp
+}
+
+func (udp *UpdateWebhookDeliveryParams) SetNextAttemptAt(nextAttemptAt time.Time) *UpdateWebhookDeliveryParams {
+	udp.nextAttemptAt = &nextAttemptAt
+	return udp
+}
+
+func (udp *UpdateWebhookDeliveryParams) SetResponseCode(code int) *UpdateWebhookDeliveryParams {
+	udp.responseCode = &code
+	return udp
+}
+
+func (udp *UpdateWebhookDeliveryParams) SetMessage(message string) *UpdateWebhookDeliveryParams {
+	udp.message = &message
+	return udp
+}
+
+type IWebhookDeliveryRepo interface {
+	Insert(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error)
+	Get(ctx context.Context, params *GetWebhookDeliveryParams) (*WebhookDelivery, error)
+	List(ctx context.Context, params *ListWebhookDeliveryParams) ([]*WebhookDelivery, bool, error)
+	Delete(ctx context.Context, params *DeleteWebhookDeliveryParams) (int64, error)
+	UpdateByID(ctx context.Context, params *UpdateWebhookDeliveryParams) error
+}
+
+var _ IWebhookDeliveryRepo = (*WebhookDeliveryRepo)(nil)
+
+type WebhookDeliveryRepo struct {
+	db bun.IDB
+}
+
+func NewWebhookDeliveryRepo(db bun.IDB) IWebhookDeliveryRepo {
+	return &WebhookDeliveryRepo{db: db}
+}
+
+func (w WebhookDeliveryRepo) Insert(ctx context.Context, delivery *WebhookDelivery) (*WebhookDelivery, error) {
+	_, err := w.db.NewInsert().Model(delivery).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (w WebhookDeliveryRepo) Get(ctx context.Context, params *GetWebhookDeliveryParams) (*WebhookDelivery, error) {
+	delivery := &WebhookDelivery{}
+	query := w.db.NewSelect().Model(delivery)
+
+	if uuid.Nil != params.id {
+		query = query.Where("id = ?", params.id)
+	}
+
+	if uuid.Nil != params.webhookID {
+		query = query.Where("webhook_id = ?", params.webhookID)
+	}
+
+	err := query.Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (w WebhookDeliveryRepo) List(ctx context.Context, params *ListWebhookDeliveryParams) ([]*WebhookDelivery, bool, error) {
+	var deliveries []*WebhookDelivery
+	query := w.db.NewSelect().Model(&deliveries)
+
+	if uuid.Nil != params.webhookID {
+		query = query.Where("webhook_id = ?", params.webhookID)
+	}
+
+	if params.state != nil {
+		query = query.Where("state = ?", *params.state)
+	}
+
+	if params.nextAttemptBefore != nil {
+		query = query.Where("next_attempt_at <= ?", *params.nextAttemptBefore)
+	}
+
+	query = query.Order("created_at DESC")
+	if params.after != nil {
+		query = query.Where("created_at < ?", *params.after)
+	}
+
+	err := query.Limit(params.amount).Scan(ctx)
+	return deliveries, len(deliveries) == params.amount, err
+}
+
+func (w WebhookDeliveryRepo) Delete(ctx context.Context, params *DeleteWebhookDeliveryParams) (int64, error) {
+	query := w.db.NewDelete().Model((*WebhookDelivery)(nil))
+
+	if uuid.Nil != params.webhookID {
+		query = query.Where("webhook_id = ?", params.webhookID)
+	}
+
+	sqlResult, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	affectedRows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affectedRows, err
+}
+
+func (w WebhookDeliveryRepo) UpdateByID(ctx context.Context, params *UpdateWebhookDeliveryParams) error {
+	updateQuery := w.db.NewUpdate().Model((*WebhookDelivery)(nil)).Where("id = ?", params.id)
+
+	if params.state != nil {
+		updateQuery.Set("state = ?", *params.state)
+	}
+
+	if params.attempts != nil {
+		updateQuery.Set("attempts = ?", *params.attempts)
+	}
+
+	if params.nextAttemptAt != nil {
+		updateQuery.Set("next_attempt_at = ?", *params.nextAttemptAt)
+	}
+
+	if params.responseCode != nil {
+		updateQuery.Set("response_code = ?", *params.responseCode)
+	}
+
+	if params.message != nil {
+		updateQuery.Set("message = ?", *params.message)
+	}
+
+	_, err := updateQuery.Set("updated_at = ?", time.Now()).Exec(ctx)
+	return err
+}
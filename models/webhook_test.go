@@ -0,0 +1,117 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewWebhookRepo(db)
+
+	t.Run("insert and get", func(t *testing.T) {
+		webhookModel := &models.Webhook{}
+		require.NoError(t, gofakeit.Struct(webhookModel))
+		webhookModel.Events = []models.EventType{models.PushEvent}
+
+		webhook, err := repo.Insert(ctx, webhookModel)
+		require.NoError(t, err)
+
+		expectWebhook, err := repo.Get(ctx, models.NewGetWebhookParams().SetID(webhook.ID).SetRepositoryID(webhook.RepositoryID))
+		require.NoError(t, err)
+		require.True(t, cmp.Equal(expectWebhook, webhook, testhelper.DBTimeCmpOpt))
+	})
+
+	t.Run("list and update", func(t *testing.T) {
+		repoID := uuid.New()
+		for i := 0; i < 5; i++ {
+			webhookModel := &models.Webhook{}
+			require.NoError(t, gofakeit.Struct(webhookModel))
+			webhookModel.RepositoryID = repoID
+			webhookModel.Active = true
+			webhookModel.Events = []models.EventType{models.PushEvent, models.TagCreateEvent}
+			if i%2 == 0 {
+				webhookModel.Events = []models.EventType{models.BranchCreateEvent}
+			}
+			_, err := repo.Insert(ctx, webhookModel)
+			require.NoError(t, err)
+		}
+
+		webhooks, hasMore, err := repo.List(ctx, models.NewListWebhookParams().SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.False(t, hasMore)
+		require.Len(t, webhooks, 5)
+
+		webhooks, _, err = repo.List(ctx, models.NewListWebhookParams().SetRepositoryID(repoID).SetEvent(models.TagCreateEvent).SetActive(true))
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+
+		err = repo.UpdateByID(ctx, models.NewUpdateWebhookParams(webhooks[0].ID).SetActive(false).SetURL("http://127.0.0.1").SetEvents([]models.EventType{models.PushEvent}))
+		require.NoError(t, err)
+
+		webhooks, _, err = repo.List(ctx, models.NewListWebhookParams().SetRepositoryID(repoID).SetEvent(models.PushEvent).SetActive(true))
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+
+		affectRows, err := repo.Delete(ctx, models.NewDeleteWebhookParams().SetID(webhooks[0].ID).SetRepositoryID(repoID))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), affectRows)
+	})
+}
+
+func TestWebhookDeliveryRepo(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewWebhookDeliveryRepo(db)
+
+	webhookID := uuid.New()
+	for i := 0; i < 5; i++ {
+		deliveryModel := &models.WebhookDelivery{}
+		require.NoError(t, gofakeit.Struct(deliveryModel))
+		deliveryModel.WebhookID = webhookID
+		deliveryModel.State = models.DeliveryPending
+		deliveryModel.NextAttemptAt = time.Now().Add(time.Duration(i-2) * time.Hour)
+		_, err := repo.Insert(ctx, deliveryModel)
+		require.NoError(t, err)
+	}
+
+	deliveries, hasMore, err := repo.List(ctx, models.NewListWebhookDeliveryParams().SetWebhookID(webhookID).SetAmount(2))
+	require.NoError(t, err)
+	require.True(t, hasMore)
+	require.Len(t, deliveries, 2)
+
+	deliveries, _, err = repo.List(ctx, models.NewListWebhookDeliveryParams().SetState(models.DeliveryPending).SetWebhookID(webhookID).SetNextAttemptBefore(time.Now()))
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+
+	err = repo.UpdateByID(ctx, models.NewUpdateWebhookDeliveryParams(deliveries[0].ID).
+		SetState(models.DeliverySuccess).
+		SetAttempts(1).
+		SetResponseCode(200).
+		SetMessage("ok"))
+	require.NoError(t, err)
+
+	delivery, err := repo.Get(ctx, models.NewGetWebhookDeliveryParams().SetID(deliveries[0].ID).SetWebhookID(webhookID))
+	require.NoError(t, err)
+	require.Equal(t, models.DeliverySuccess, delivery.State)
+	require.Equal(t, 1, delivery.Attempts)
+	require.Equal(t, 200, delivery.ResponseCode)
+	require.Equal(t, "ok", *delivery.Message)
+
+	affectRows, err := repo.Delete(ctx, models.NewDeleteWebhookDeliveryParams().SetWebhookID(webhookID))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), affectRows)
+}
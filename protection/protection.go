@@ -0,0 +1,167 @@
+package protection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/gobwas/glob"
+	"github.com/google/uuid"
+)
+
+var ErrBranchProtected = errors.New("branch protected")
+
+// ValidatePattern check whether pattern is a valid glob of branch name, "*" match one level of branch name and "**" match all levels
+func ValidatePattern(pattern string) error {
+	if len(strings.TrimSpace(pattern)) == 0 {
+		return errors.New("pattern must not be empty")
+	}
+	_, err := glob.Compile(pattern, '/')
+	return err
+}
+
+// Match find the rule protect branch, rule whose pattern equal to branch name take precedence, then the longest matched pattern.
+// return nil if no rule match the branch
+func Match(rules []*models.BranchProtection, branchName string) (*models.BranchProtection, error) {
+	var matched *models.BranchProtection
+	for _, rule := range rules {
+		if rule.Pattern == branchName {
+			return rule, nil
+		}
+
+		g, err := glob.Compile(rule.Pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s of branch protection %w", rule.Pattern, err)
+		}
+		if !g.Match(branchName) {
+			continue
+		}
+		if matched == nil || len(rule.Pattern) > len(matched.Pattern) {
+			matched = rule
+		}
+	}
+	return matched, nil
+}
+
+// Checker check operations on branches against protection rules of repository
+type Checker struct {
+	repo models.IRepo
+}
+
+func NewChecker(repo models.IRepo) *Checker {
+	return &Checker{repo: repo}
+}
+
+// Rule return the rule protect branch, nil if branch is not protected
+func (checker *Checker) Rule(ctx context.Context, repositoryID uuid.UUID, branchName string) (*models.BranchProtection, error) {
+	rules, err := checker.repo.BranchProtectionRepo().List(ctx, models.NewListBranchProtectionParams().SetRepositoryID(repositoryID))
+	if err != nil {
+		return nil, err
+	}
+	return Match(rules, branchName)
+}
+
+// CheckWrite check whether changes can be written to branch directly, include commit wip and write objects
+func (checker *Checker) CheckWrite(ctx context.Context, repositoryID uuid.UUID, branchName string) error {
+	rule, err := checker.Rule(ctx, repositoryID, branchName)
+	if err != nil {
+		return err
+	}
+	if rule != nil && rule.RequireMergeRequest {
+		return fmt.Errorf("%w: changes of branch %s must be merged from merge request", ErrBranchProtected, branchName)
+	}
+	return nil
+}
+
+// CheckDelete check whether branch can be deleted
+func (checker *Checker) CheckDelete(ctx context.Context, repositoryID uuid.UUID, branchName string) error {
+	rule, err := checker.Rule(ctx, repositoryID, branchName)
+	if err != nil {
+		return err
+	}
+	if rule != nil && rule.BlockDeletion {
+		return fmt.Errorf("%w: branch %s can not be deleted", ErrBranchProtected, branchName)
+	}
+	return nil
+}
+
+// CheckForcePush check whether branch can be moved to new head, isFastForward report whether new head descends from the old one,
+// it is only called when branch block force push because walking history is expensive
+func (checker *Checker) CheckForcePush(ctx context.Context, repositoryID uuid.UUID, branchName string, isFastForward func(ctx context.Context) (bool, error)) error {
+	rule, err := checker.Rule(ctx, repositoryID, branchName)
+	if err != nil {
+		return err
+	}
+	if rule == nil || !rule.BlockForcePush {
+		return nil
+	}
+
+	fastForward, err := isFastForward(ctx)
+	if err != nil {
+		return err
+	}
+	if !fastForward {
+		return fmt.Errorf("%w: branch %s can only be fast-forwarded", ErrBranchProtected, branchName)
+	}
+	return nil
+}
+
+// CheckMerge check whether operator can merge sourceHead of the merge request into target branch,
+// only approvals and status checks of sourceHead are counted
+func (checker *Checker) CheckMerge(ctx context.Context, mergeRequest *models.MergeRequest, targetBranchName string, sourceHead hash.Hash, operator *models.User) error {
+	rule, err := checker.Rule(ctx, mergeRequest.TargetRepoID, targetBranchName)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return nil
+	}
+
+	if !rule.CanMerge(operator.ID) {
+		return fmt.Errorf("%w: user %s is not allowed to merge into branch %s", ErrBranchProtected, operator.Name, targetBranchName)
+	}
+
+	if rule.RequiredApprovals > 0 {
+		reviews, err := checker.repo.ReviewRepo().List(ctx, models.NewListReviewParams().SetMergeRequestID(mergeRequest.ID).SetDismissed(false).SetCommitHash(sourceHead))
+		if err != nil {
+			return err
+		}
+		approvals := len(models.Approvers(reviews))
+		if approvals < rule.RequiredApprovals {
+			return fmt.Errorf("%w: branch %s require %d approvals, but got %d", ErrBranchProtected, targetBranchName, rule.RequiredApprovals, approvals)
+		}
+	}
+
+	if len(rule.RequiredStatusChecks) > 0 {
+		statuses, err := checker.repo.CommitStatusRepo().List(ctx, models.NewListCommitStatusParams().
+			SetRepositoryID(mergeRequest.SourceRepoID).
+			SetCommitHash(sourceHead).
+			SetContexts(rule.RequiredStatusChecks...))
+		if err != nil {
+			return err
+		}
+		if unpassed := UnpassedChecks(rule.RequiredStatusChecks, statuses); len(unpassed) > 0 {
+			return fmt.Errorf("%w: branch %s require status checks %s passed", ErrBranchProtected, targetBranchName, strings.Join(unpassed, ","))
+		}
+	}
+	return nil
+}
+
+// UnpassedChecks return required checks which not reported or not success in statuses
+func UnpassedChecks(requiredChecks []string, statuses []*models.CommitStatus) []string {
+	passed := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		passed[status.Context] = status.State == models.CommitStatusSuccess
+	}
+
+	var unpassed []string
+	for _, check := range requiredChecks {
+		if !passed[check] {
+			unpassed = append(unpassed, check)
+		}
+	}
+	return unpassed
+}
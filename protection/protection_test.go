@@ -0,0 +1,57 @@
+package protection
+
+import (
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePattern(t *testing.T) {
+	require.NoError(t, ValidatePattern("main"))
+	require.NoError(t, ValidatePattern("release/*"))
+	require.NoError(t, ValidatePattern("feat/**"))
+	require.Error(t, ValidatePattern(""))
+	require.Error(t, ValidatePattern("release/[a"))
+}
+
+func TestMatch(t *testing.T) {
+	rules := []*models.BranchProtection{
+		{Pattern: "**"},
+		{Pattern: "release/*"},
+		{Pattern: "release/v1.*"},
+		{Pattern: "release/v1.0"},
+	}
+
+	testCases := []struct {
+		branch  string
+		pattern string
+	}{
+		{branch: "main", pattern: "**"},
+		{branch: "feat/a/b", pattern: "**"},
+		{branch: "release/v2", pattern: "release/*"},
+		{branch: "release/v1.1", pattern: "release/v1.*"},
+		{branch: "release/v1.0", pattern: "release/v1.0"},
+		{branch: "release/v2/hotfix", pattern: "**"},
+	}
+	for _, tc := range testCases {
+		rule, err := Match(rules, tc.branch)
+		require.NoError(t, err)
+		require.NotNil(t, rule, tc.branch)
+		require.Equal(t, tc.pattern, rule.Pattern, tc.branch)
+	}
+
+	rule, err := Match(rules[1:], "main")
+	require.NoError(t, err)
+	require.Nil(t, rule)
+}
+
+func TestUnpassedChecks(t *testing.T) {
+	statuses := []*models.CommitStatus{
+		{Context: "lint", State: models.CommitStatusSuccess},
+		{Context: "quality", State: models.CommitStatusFailure},
+		{Context: "schema", State: models.CommitStatusPending},
+	}
+	require.Equal(t, []string{"quality", "schema", "missing"}, UnpassedChecks([]string{"lint", "quality", "schema", "missing"}, statuses))
+	require.Empty(t, UnpassedChecks([]string{"lint"}, statuses))
+}
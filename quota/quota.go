@@ -0,0 +1,192 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+)
+
+// ErrQuotaExceeded content is rejected because storage limits of repository or owner would be exceeded
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Usage storage consumption and effective limits of user or repository. non-positive limit means unlimited
+type Usage struct {
+	Scope       models.QuotaScope
+	TargetID    uuid.UUID
+	UsedBytes   int64
+	UsedObjects int64
+	MaxBytes    int64
+	MaxObjects  int64
+	// Overridden limits are set by admin rather than default limits in config
+	Overridden bool
+}
+
+func (usage *Usage) check(name string, bytes int64, objects int64) error {
+	if usage.MaxBytes > 0 && usage.UsedBytes+bytes > usage.MaxBytes {
+		return fmt.Errorf("%w: %s %s has used %d of %d bytes, %d more bytes are not allowed", ErrQuotaExceeded, usage.Scope, name, usage.UsedBytes, usage.MaxBytes, bytes)
+	}
+	if usage.MaxObjects > 0 && usage.UsedObjects+objects > usage.MaxObjects {
+		return fmt.Errorf("%w: %s %s has stored %d of %d objects, %d more objects are not allowed", ErrQuotaExceeded, usage.Scope, name, usage.UsedObjects, usage.MaxObjects, objects)
+	}
+	return nil
+}
+
+// Manager account storage usage of repositories in public storage and their owners, and reject writes exceeding limits.
+// storage of repositories using their own storage is paid by themselves, so it is not limited.
+// only blobs take space in storage, commits and trees are kept in database, and blobs are never removed from storage
+// until repository is deleted, so usage is reserved when blob is written and released when repository is deleted
+type Manager struct {
+	repo models.IRepo
+	cfg  *config.QuotaConfig
+}
+
+func NewManager(repo models.IRepo, cfg *config.QuotaConfig) *Manager {
+	return &Manager{repo: repo, cfg: cfg}
+}
+
+// Enabled check whether usage of repository is limited
+func (manager *Manager) Enabled(repository *models.Repository) bool {
+	return repository.UsePublicStorage
+}
+
+// Usage get usage of user or repository
+func (manager *Manager) Usage(ctx context.Context, scope models.QuotaScope, targetID uuid.UUID) (*Usage, error) {
+	usage := &Usage{
+		Scope:    scope,
+		TargetID: targetID,
+	}
+	switch scope {
+	case models.UserQuotaScope:
+		usage.MaxBytes, usage.MaxObjects = manager.cfg.UserMaxBytes, manager.cfg.UserMaxObjects
+	case models.RepositoryQuotaScope:
+		usage.MaxBytes, usage.MaxObjects = manager.cfg.RepositoryMaxBytes, manager.cfg.RepositoryMaxObjects
+	default:
+		return nil, fmt.Errorf("unknown quota scope %s", scope)
+	}
+
+	quota, err := manager.repo.QuotaRepo().Get(ctx, models.NewGetQuotaParams().SetScope(scope).SetTargetID(targetID))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return usage, nil
+		}
+		return nil, err
+	}
+
+	usage.UsedBytes, usage.UsedObjects = quota.UsedBytes, quota.UsedObjects
+	if quota.MaxBytes != nil {
+		usage.MaxBytes = *quota.MaxBytes
+		usage.Overridden = true
+	}
+	if quota.MaxObjects != nil {
+		usage.MaxObjects = *quota.MaxObjects
+		usage.Overridden = true
+	}
+	return usage, nil
+}
+
+// Reserve add blob to be stored in repository to usage of repository and its owner, ErrQuotaExceeded is returned
+// and usage is not changed if limits would be exceeded. blobs are addressed by checksum, a blob already charged to repository
+// take no more space, so false is returned without changing usage. charging blob, checking and increasing usage are done in one
+// transaction, so that concurrent writes can not exceed limits together nor charge the same blob twice. call Unreserve if blob
+// reserved is not stored at last
+func (manager *Manager) Reserve(ctx context.Context, repository *models.Repository, checkSum hash.Hash, bytes int64) (bool, error) {
+	if !manager.Enabled(repository) {
+		return false, nil
+	}
+
+	reserved := false
+	err := manager.repo.Transaction(ctx, func(repo models.IRepo) error {
+		charged, err := repo.QuotaRepo().Charge(ctx, repository.ID, checkSum, bytes)
+		if err != nil {
+			return err
+		}
+		if !charged {
+			return nil
+		}
+
+		ok, err := repo.QuotaRepo().ReserveUsage(ctx, models.RepositoryQuotaScope, repository.ID, bytes, 1, manager.cfg.RepositoryMaxBytes, manager.cfg.RepositoryMaxObjects)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return manager.exceeded(ctx, models.RepositoryQuotaScope, repository.ID, repository.Name, bytes, 1)
+		}
+
+		ok, err = repo.QuotaRepo().ReserveUsage(ctx, models.UserQuotaScope, repository.OwnerID, bytes, 1, manager.cfg.UserMaxBytes, manager.cfg.UserMaxObjects)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return manager.exceeded(ctx, models.UserQuotaScope, repository.OwnerID, "owner of "+repository.Name, bytes, 1)
+		}
+		reserved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return reserved, nil
+}
+
+// Unreserve give back usage reserved for blob which failed to store
+func (manager *Manager) Unreserve(ctx context.Context, repository *models.Repository, checkSum hash.Hash, bytes int64) error {
+	if !manager.Enabled(repository) {
+		return nil
+	}
+
+	return manager.repo.Transaction(ctx, func(repo models.IRepo) error {
+		charged, err := repo.QuotaRepo().Uncharge(ctx, repository.ID, checkSum)
+		if err != nil {
+			return err
+		}
+		if !charged {
+			return nil
+		}
+		err = repo.QuotaRepo().IncreaseUsage(ctx, models.RepositoryQuotaScope, repository.ID, -bytes, -1)
+		if err != nil {
+			return err
+		}
+		return repo.QuotaRepo().IncreaseUsage(ctx, models.UserQuotaScope, repository.OwnerID, -bytes, -1)
+	})
+}
+
+// exceeded build error describing which limit is exceeded
+func (manager *Manager) exceeded(ctx context.Context, scope models.QuotaScope, targetID uuid.UUID, name string, bytes int64, objects int64) error {
+	usage, err := manager.Usage(ctx, scope, targetID)
+	if err != nil {
+		return err
+	}
+	err = usage.check(name, bytes, objects)
+	if err == nil {
+		// usage changed after reserving
+		err = fmt.Errorf("%w: %s %s", ErrQuotaExceeded, scope, name)
+	}
+	return err
+}
+
+// Release remove usage of deleted repository from usage of its owner, call it in the transaction deleting repository
+func Release(ctx context.Context, repo models.IRepo, repository *models.Repository) error {
+	quota, err := repo.QuotaRepo().Get(ctx, models.NewGetQuotaParams().SetScope(models.RepositoryQuotaScope).SetTargetID(repository.ID))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	err = repo.QuotaRepo().IncreaseUsage(ctx, models.UserQuotaScope, repository.OwnerID, -quota.UsedBytes, -quota.UsedObjects)
+	if err != nil {
+		return err
+	}
+	_, err = repo.QuotaRepo().Delete(ctx, models.NewDeleteQuotaParams().SetScope(models.RepositoryQuotaScope).SetTargetID(repository.ID))
+	if err != nil {
+		return err
+	}
+	_, err = repo.QuotaRepo().DeleteCharges(ctx, repository.ID)
+	return err
+}
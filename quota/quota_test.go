@@ -0,0 +1,143 @@
+package quota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageCheck(t *testing.T) {
+	usage := &Usage{Scope: models.RepositoryQuotaScope, UsedBytes: 100, UsedObjects: 1, MaxBytes: 150, MaxObjects: 2}
+	require.NoError(t, usage.check("repo", 50, 1))
+	require.ErrorIs(t, usage.check("repo", 51, 1), ErrQuotaExceeded)
+	require.ErrorIs(t, usage.check("repo", 1, 2), ErrQuotaExceeded)
+	require.EqualError(t, usage.check("repo", 51, 1), "storage quota exceeded: repository repo has used 100 of 150 bytes, 51 more bytes are not allowed")
+
+	unlimited := &Usage{UsedBytes: 100, UsedObjects: 1}
+	require.NoError(t, unlimited.check("repo", 1<<40, 1<<20))
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewRepo(db)
+	manager := NewManager(repo, &config.QuotaConfig{
+		RepositoryMaxBytes: 100,
+		UserMaxBytes:       150,
+		UserMaxObjects:     10,
+	})
+
+	ownerID := uuid.New()
+	repository := &models.Repository{ID: uuid.New(), Name: "repo", OwnerID: ownerID, UsePublicStorage: true}
+	otherRepository := &models.Repository{ID: uuid.New(), Name: "other", OwnerID: ownerID, UsePublicStorage: true}
+
+	_, err := manager.Reserve(ctx, repository, hash.Hash("large"), 101)
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+
+	reserved, err := manager.Reserve(ctx, repository, hash.Hash("a"), 80)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	// blob is charged once however many times it is written
+	reserved, err = manager.Reserve(ctx, repository, hash.Hash("a"), 80)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	reserved, err = manager.Reserve(ctx, otherRepository, hash.Hash("a"), 60)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	usage, err := manager.Usage(ctx, models.RepositoryQuotaScope, repository.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(80), usage.UsedBytes)
+	require.Equal(t, int64(100), usage.MaxBytes)
+	require.False(t, usage.Overridden)
+
+	usage, err = manager.Usage(ctx, models.UserQuotaScope, ownerID)
+	require.NoError(t, err)
+	require.Equal(t, int64(140), usage.UsedBytes)
+	require.Equal(t, int64(2), usage.UsedObjects)
+
+	// repository allow 20 bytes, owner allow 10 bytes
+	_, err = manager.Reserve(ctx, repository, hash.Hash("c"), 20)
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	reserved, err = manager.Reserve(ctx, repository, hash.Hash("c"), 10)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.NoError(t, manager.Unreserve(ctx, repository, hash.Hash("c"), 10))
+
+	t.Run("override limits", func(t *testing.T) {
+		require.NoError(t, repo.QuotaRepo().UpdateLimits(ctx, models.NewUpdateQuotaLimitsParams(models.UserQuotaScope, ownerID).SetMaxBytes(0)))
+		usage, err := manager.Usage(ctx, models.UserQuotaScope, ownerID)
+		require.NoError(t, err)
+		require.True(t, usage.Overridden)
+		require.Equal(t, int64(0), usage.MaxBytes)
+		require.Equal(t, int64(10), usage.MaxObjects)
+		reserved, err := manager.Reserve(ctx, repository, hash.Hash("c"), 20)
+		require.NoError(t, err)
+		require.True(t, reserved)
+		require.NoError(t, manager.Unreserve(ctx, repository, hash.Hash("c"), 20))
+	})
+
+	t.Run("reserve", func(t *testing.T) {
+		_, err := manager.Reserve(ctx, repository, hash.Hash("b"), 21)
+		require.ErrorIs(t, err, ErrQuotaExceeded)
+		usage, err := manager.Usage(ctx, models.RepositoryQuotaScope, repository.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(80), usage.UsedBytes)
+
+		reserved, err := manager.Reserve(ctx, repository, hash.Hash("b"), 20)
+		require.NoError(t, err)
+		require.True(t, reserved)
+		usage, err = manager.Usage(ctx, models.UserQuotaScope, ownerID)
+		require.NoError(t, err)
+		require.Equal(t, int64(160), usage.UsedBytes)
+		require.Equal(t, int64(3), usage.UsedObjects)
+
+		require.NoError(t, manager.Unreserve(ctx, repository, hash.Hash("b"), 20))
+		// blob not charged is given back nothing
+		require.NoError(t, manager.Unreserve(ctx, repository, hash.Hash("c"), 20))
+		usage, err = manager.Usage(ctx, models.RepositoryQuotaScope, repository.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(80), usage.UsedBytes)
+		require.Equal(t, int64(1), usage.UsedObjects)
+
+		// blob failed to store is charged again when written next time
+		reserved, err = manager.Reserve(ctx, repository, hash.Hash("b"), 20)
+		require.NoError(t, err)
+		require.True(t, reserved)
+		require.NoError(t, manager.Unreserve(ctx, repository, hash.Hash("b"), 20))
+	})
+
+	t.Run("private storage not limited", func(t *testing.T) {
+		privateRepository := &models.Repository{ID: uuid.New(), Name: "private", OwnerID: ownerID}
+		reserved, err := manager.Reserve(ctx, privateRepository, hash.Hash("a"), 1000)
+		require.NoError(t, err)
+		require.False(t, reserved)
+		_, err = repo.QuotaRepo().Get(ctx, models.NewGetQuotaParams().SetScope(models.RepositoryQuotaScope).SetTargetID(privateRepository.ID))
+		require.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		require.NoError(t, Release(ctx, repo, repository))
+		usage, err := manager.Usage(ctx, models.UserQuotaScope, ownerID)
+		require.NoError(t, err)
+		require.Equal(t, int64(60), usage.UsedBytes)
+		require.Equal(t, int64(1), usage.UsedObjects)
+
+		usage, err = manager.Usage(ctx, models.RepositoryQuotaScope, repository.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), usage.UsedBytes)
+
+		// charges are released with usage
+		reserved, err := manager.Reserve(ctx, repository, hash.Hash("a"), 80)
+		require.NoError(t, err)
+		require.True(t, reserved)
+	})
+}
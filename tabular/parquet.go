@@ -0,0 +1,375 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrInvalidParquet = errors.New("invalid parquet")
+
+// MaxParquetFooterSize footer larger than this size is rejected to bound memory usage
+var MaxParquetFooterSize int64 = 64 << 20
+
+var parquetMagic = []byte("PAR1")
+
+// parquetTailSize 4 bytes footer length and 4 bytes magic
+const parquetTailSize = 8
+
+var physicalTypes = []string{"BOOLEAN", "INT32", "INT64", "INT96", "FLOAT", "DOUBLE", "BYTE_ARRAY", "FIXED_LEN_BYTE_ARRAY"}
+
+var repetitionTypes = []string{"REQUIRED", "OPTIONAL", "REPEATED"}
+
+var convertedTypes = []string{"UTF8", "MAP", "MAP_KEY_VALUE", "LIST", "ENUM", "DECIMAL", "DATE", "TIME_MILLIS", "TIME_MICROS",
+	"TIMESTAMP_MILLIS", "TIMESTAMP_MICROS", "UINT_8", "UINT_16", "UINT_32", "UINT_64", "INT_8", "INT_16", "INT_32", "INT_64", "JSON", "BSON", "INTERVAL"}
+
+// convertedLogicalTypes deprecated converted type in the same form of logical type, so that files written by old writers are comparable
+var convertedLogicalTypes = map[string]string{
+	"UTF8":             "STRING",
+	"TIME_MILLIS":      "TIME(MILLIS,true)",
+	"TIME_MICROS":      "TIME(MICROS,true)",
+	"TIMESTAMP_MILLIS": "TIMESTAMP(MILLIS,true)",
+	"TIMESTAMP_MICROS": "TIMESTAMP(MICROS,true)",
+	"UINT_8":           "INT(8,false)",
+	"UINT_16":          "INT(16,false)",
+	"UINT_32":          "INT(32,false)",
+	"UINT_64":          "INT(64,false)",
+	"INT_8":            "INT(8,true)",
+	"INT_16":           "INT(16,true)",
+	"INT_32":           "INT(32,true)",
+	"INT_64":           "INT(64,true)",
+}
+
+var compressionCodecs = []string{"UNCOMPRESSED", "SNAPPY", "GZIP", "LZO", "BROTLI", "LZ4", "ZSTD", "LZ4_RAW"}
+
+func enumName(names []string, value int64) string {
+	if value >= 0 && value < int64(len(names)) {
+		return names[value]
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+// ParquetColumn leaf column of parquet schema, Name is dot separated path of column
+type ParquetColumn struct {
+	Name         string
+	PhysicalType string
+	LogicalType  string
+	Repetition   string
+	TypeLength   int64
+	Precision    int64
+	Scale        int64
+}
+
+// Type return physical type with logical type, like INT64 or BYTE_ARRAY(STRING)
+func (column ParquetColumn) Type() string {
+	if len(column.LogicalType) == 0 {
+		return column.PhysicalType
+	}
+	return fmt.Sprintf("%s(%s)", column.PhysicalType, column.LogicalType)
+}
+
+// ColumnStatistics statistics of column chunk, Min and Max are formatted by physical type of column
+type ColumnStatistics struct {
+	NullCount     *int64
+	DistinctCount *int64
+	Min           *string
+	Max           *string
+}
+
+// ColumnChunk column chunk in row group
+type ColumnChunk struct {
+	Column           string
+	Codec            string
+	NumValues        int64
+	CompressedSize   int64
+	UncompressedSize int64
+	Statistics       *ColumnStatistics
+	// DataPageOffset and DictionaryPageOffset locate pages of chunk, DictionaryPageOffset is 0 if chunk has no dictionary
+	DataPageOffset       int64
+	DictionaryPageOffset int64
+}
+
+// RowGroup row group of parquet file
+type RowGroup struct {
+	NumRows       int64
+	TotalByteSize int64
+	Columns       []ColumnChunk
+}
+
+// ParquetMetadata metadata decoded from parquet footer
+type ParquetMetadata struct {
+	Version   int64
+	NumRows   int64
+	CreatedBy string
+	Columns   []ParquetColumn
+	RowGroups []RowGroup
+}
+
+// ReadParquetMetadata read footer of parquet file, only tail of file is read so that reader could be backed by range read
+func ReadParquetMetadata(reader io.ReaderAt, size int64) (*ParquetMetadata, error) {
+	if size < parquetTailSize+int64(len(parquetMagic)) {
+		return nil, fmt.Errorf("file too small %w", ErrInvalidParquet)
+	}
+
+	tail := make([]byte, parquetTailSize)
+	_, err := reader.ReadAt(tail, size-parquetTailSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !bytes.Equal(tail[4:], parquetMagic) {
+		return nil, fmt.Errorf("magic number not found %w", ErrInvalidParquet)
+	}
+
+	footerSize := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if footerSize > size-parquetTailSize-int64(len(parquetMagic)) || footerSize > MaxParquetFooterSize {
+		return nil, fmt.Errorf("invalid footer size %d %w", footerSize, ErrInvalidParquet)
+	}
+
+	footer := make([]byte, footerSize)
+	_, err = reader.ReadAt(footer, size-parquetTailSize-footerSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return DecodeParquetFooter(footer)
+}
+
+// DecodeParquetFooter decode FileMetaData of parquet, see https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+func DecodeParquetFooter(footer []byte) (*ParquetMetadata, error) {
+	reader := &compactReader{data: footer}
+	fileMeta, err := reader.readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &ParquetMetadata{
+		CreatedBy: fileMeta.string(6),
+		Columns:   []ParquetColumn{},
+		RowGroups: []RowGroup{},
+	}
+	metadata.Version, _ = fileMeta.int(1)
+	metadata.NumRows, _ = fileMeta.int(3)
+
+	elements := fileMeta.list(2)
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("schema not found %w", ErrInvalidParquet)
+	}
+	pos := 1
+	root, _ := elements[0].(thriftStruct)
+	rootChildren, _ := root.int(5)
+	for i := int64(0); i < rootChildren; i++ {
+		pos, err = flattenSchema(elements, pos, "", 1, &metadata.Columns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	columnTypes := make(map[string]string, len(metadata.Columns))
+	for _, column := range metadata.Columns {
+		columnTypes[column.Name] = column.PhysicalType
+	}
+	for _, value := range fileMeta.list(4) {
+		rowGroupMeta, ok := value.(thriftStruct)
+		if !ok {
+			return nil, fmt.Errorf("invalid row group %w", ErrInvalidParquet)
+		}
+		metadata.RowGroups = append(metadata.RowGroups, decodeRowGroup(rowGroupMeta, columnTypes))
+	}
+	return metadata, nil
+}
+
+// flattenSchema walk schema elements in depth first order, return position of next element
+func flattenSchema(elements []interface{}, pos int, prefix string, depth int, columns *[]ParquetColumn) (int, error) {
+	if depth > maxThriftDepth {
+		return 0, fmt.Errorf("schema nested too deep %w", ErrInvalidParquet)
+	}
+	if pos >= len(elements) {
+		return 0, fmt.Errorf("schema element missing %w", ErrInvalidParquet)
+	}
+	element, ok := elements[pos].(thriftStruct)
+	if !ok {
+		return 0, fmt.Errorf("invalid schema element %w", ErrInvalidParquet)
+	}
+
+	name := element.string(4)
+	if len(prefix) > 0 {
+		name = prefix + "." + name
+	}
+	pos++
+
+	numChildren, _ := element.int(5)
+	if numChildren > 0 {
+		var err error
+		for i := int64(0); i < numChildren; i++ {
+			pos, err = flattenSchema(elements, pos, name, depth+1, columns)
+			if err != nil {
+				return 0, err
+			}
+		}
+		return pos, nil
+	}
+
+	column := ParquetColumn{Name: name}
+	physicalType, _ := element.int(1)
+	column.PhysicalType = enumName(physicalTypes, physicalType)
+	repetition, _ := element.int(3)
+	column.Repetition = enumName(repetitionTypes, repetition)
+	column.TypeLength, _ = element.int(2)
+	column.Scale, _ = element.int(7)
+	column.Precision, _ = element.int(8)
+	if logicalType, ok := element.structField(10); ok {
+		column.LogicalType = logicalTypeName(logicalType)
+	} else if convertedType, ok := element.int(6); ok {
+		column.LogicalType = enumName(convertedTypes, convertedType)
+		if logicalType, ok := convertedLogicalTypes[column.LogicalType]; ok {
+			column.LogicalType = logicalType
+		}
+		if column.LogicalType == "DECIMAL" {
+			column.LogicalType = fmt.Sprintf("DECIMAL(%d,%d)", column.Precision, column.Scale)
+		}
+	}
+	*columns = append(*columns, column)
+	return pos, nil
+}
+
+var timeUnits = map[int16]string{1: "MILLIS", 2: "MICROS", 3: "NANOS"}
+
+// logicalTypeName format LogicalType union
+func logicalTypeName(logicalType thriftStruct) string {
+	for id, value := range logicalType {
+		detail, _ := value.(thriftStruct)
+		switch id {
+		case 1:
+			return "STRING"
+		case 2:
+			return "MAP"
+		case 3:
+			return "LIST"
+		case 4:
+			return "ENUM"
+		case 5:
+			scale, _ := detail.int(1)
+			precision, _ := detail.int(2)
+			return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+		case 6:
+			return "DATE"
+		case 7, 8:
+			name := "TIME"
+			if id == 8 {
+				name = "TIMESTAMP"
+			}
+			unit := ""
+			if unitStruct, ok := detail.structField(2); ok {
+				for unitID := range unitStruct {
+					unit = timeUnits[unitID]
+				}
+			}
+			adjusted, _ := detail.bool(1)
+			return fmt.Sprintf("%s(%s,%t)", name, unit, adjusted)
+		case 10:
+			bitWidth, _ := detail.int(1)
+			signed, _ := detail.bool(2)
+			return fmt.Sprintf("INT(%d,%t)", bitWidth, signed)
+		case 11:
+			return "NULL"
+		case 12:
+			return "JSON"
+		case 13:
+			return "BSON"
+		case 14:
+			return "UUID"
+		case 15:
+			return "FLOAT16"
+		}
+	}
+	return ""
+}
+
+func decodeRowGroup(rowGroupMeta thriftStruct, columnTypes map[string]string) RowGroup {
+	rowGroup := RowGroup{Columns: []ColumnChunk{}}
+	rowGroup.TotalByteSize, _ = rowGroupMeta.int(2)
+	rowGroup.NumRows, _ = rowGroupMeta.int(3)
+
+	for _, value := range rowGroupMeta.list(1) {
+		chunkMeta, _ := value.(thriftStruct)
+		columnMeta, ok := chunkMeta.structField(3)
+		if !ok {
+			continue
+		}
+
+		pathInSchema := columnMeta.list(3)
+		path := make([]string, len(pathInSchema))
+		for i, part := range pathInSchema {
+			partBytes, _ := part.([]byte)
+			path[i] = string(partBytes)
+		}
+		chunk := ColumnChunk{Column: strings.Join(path, ".")}
+		codec, _ := columnMeta.int(4)
+		chunk.Codec = enumName(compressionCodecs, codec)
+		chunk.NumValues, _ = columnMeta.int(5)
+		chunk.UncompressedSize, _ = columnMeta.int(6)
+		chunk.CompressedSize, _ = columnMeta.int(7)
+		chunk.DataPageOffset, _ = columnMeta.int(9)
+		chunk.DictionaryPageOffset, _ = columnMeta.int(11)
+		if statistics, ok := columnMeta.structField(12); ok {
+			chunk.Statistics = decodeStatistics(statistics, columnTypes[chunk.Column])
+		}
+		rowGroup.Columns = append(rowGroup.Columns, chunk)
+	}
+	return rowGroup
+}
+
+func decodeStatistics(statistics thriftStruct, physicalType string) *ColumnStatistics {
+	result := &ColumnStatistics{}
+	if nullCount, ok := statistics.int(3); ok {
+		result.NullCount = &nullCount
+	}
+	if distinctCount, ok := statistics.int(4); ok {
+		result.DistinctCount = &distinctCount
+	}
+
+	// min_value and max_value take precedence over deprecated min and max
+	minValue, ok := statistics.binary(6)
+	if !ok {
+		minValue, ok = statistics.binary(2)
+	}
+	if ok {
+		result.Min = formatStatValue(minValue, physicalType)
+	}
+	maxValue, ok := statistics.binary(5)
+	if !ok {
+		maxValue, ok = statistics.binary(1)
+	}
+	if ok {
+		result.Max = formatStatValue(maxValue, physicalType)
+	}
+	return result
+}
+
+// formatStatValue format plain encoded statistic value, binary value not in utf8 is formatted in hex
+func formatStatValue(value []byte, physicalType string) *string {
+	var formatted string
+	switch {
+	case physicalType == "BOOLEAN" && len(value) == 1:
+		formatted = strconv.FormatBool(value[0] != 0)
+	case physicalType == "INT32" && len(value) == 4:
+		formatted = strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(value))), 10)
+	case physicalType == "INT64" && len(value) == 8:
+		formatted = strconv.FormatInt(int64(binary.LittleEndian.Uint64(value)), 10)
+	case physicalType == "FLOAT" && len(value) == 4:
+		formatted = strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(value))), 'g', -1, 32)
+	case physicalType == "DOUBLE" && len(value) == 8:
+		formatted = strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(value)), 'g', -1, 64)
+	case physicalType == "BYTE_ARRAY" && utf8.Valid(value):
+		formatted = string(value)
+	default:
+		formatted = hex.EncodeToString(value)
+	}
+	return &formatted
+}
@@ -0,0 +1,526 @@
+package tabular
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// MaxParquetChunkSize column chunk larger than this size is not read in preview
+var MaxParquetChunkSize int64 = 64 << 20
+
+// page types of parquet
+const (
+	dataPage       = 0
+	dictionaryPage = 2
+	dataPageV2     = 3
+)
+
+// maxPageValues limit values in one page to bound memory used by malformed header
+const maxPageValues = 1 << 22
+
+// encodings of parquet
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLEDictionary   = 8
+)
+
+// PreviewParquet read rows in page of parquet, only column chunks of row groups in page are read.
+// nested and repeated columns are not supported
+func PreviewParquet(reader io.ReaderAt, size int64, opt PreviewOption) (*Preview, error) {
+	opt = opt.normalize()
+	metadata, err := ReadParquetMetadata(reader, size)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &Preview{
+		Columns:   make([]PreviewColumn, len(metadata.Columns)),
+		Rows:      [][]interface{}{},
+		TotalRows: &metadata.NumRows,
+	}
+	for i, column := range metadata.Columns {
+		if column.Repetition == "REPEATED" || strings.Contains(column.Name, ".") {
+			return nil, fmt.Errorf("nested column %s %w", column.Name, ErrUnsupportedTable)
+		}
+		preview.Columns[i] = PreviewColumn{Name: column.Name, Type: parquetColumnType(column)}
+	}
+
+	var firstRow int64
+	for _, rowGroup := range metadata.RowGroups {
+		start := firstRow
+		firstRow += rowGroup.NumRows
+		if firstRow <= opt.Offset || len(preview.Rows) >= opt.Limit {
+			continue
+		}
+		if len(rowGroup.Columns) != len(metadata.Columns) {
+			return nil, fmt.Errorf("column chunks not match schema %w", ErrInvalidParquet)
+		}
+
+		skip := int64(0)
+		if opt.Offset > start {
+			skip = opt.Offset - start
+		}
+		count := rowGroup.NumRows - skip
+		if remain := int64(opt.Limit - len(preview.Rows)); count > remain {
+			count = remain
+		}
+
+		rows := make([][]interface{}, count)
+		for i := range rows {
+			rows[i] = make([]interface{}, len(metadata.Columns))
+		}
+		for col, chunk := range rowGroup.Columns {
+			values, err := readColumnChunk(reader, size, chunk, metadata.Columns[col], skip+count)
+			if err != nil {
+				return nil, err
+			}
+			for i := int64(0); i < count; i++ {
+				rows[i][col] = values[skip+i]
+			}
+		}
+		preview.Rows = append(preview.Rows, rows...)
+	}
+	return preview, nil
+}
+
+// parquetColumnType map parquet type to preview column type
+func parquetColumnType(column ParquetColumn) ColumnType {
+	switch {
+	case column.LogicalType == "STRING" || column.LogicalType == "ENUM" || column.LogicalType == "JSON" || column.LogicalType == "UUID":
+		return TypeString
+	case column.LogicalType == "DATE":
+		return TypeDate
+	case strings.HasPrefix(column.LogicalType, "TIMESTAMP"):
+		return TypeTimestamp
+	case strings.HasPrefix(column.LogicalType, "DECIMAL"):
+		return TypeNumber
+	}
+	switch column.PhysicalType {
+	case "BOOLEAN":
+		return TypeBoolean
+	case "INT32", "INT64":
+		return TypeInteger
+	case "FLOAT", "DOUBLE":
+		return TypeNumber
+	case "INT96":
+		return TypeTimestamp
+	}
+	return TypeBinary
+}
+
+// readColumnChunk decode first count values of column chunk by range read, null value is nil
+func readColumnChunk(reader io.ReaderAt, fileSize int64, chunk ColumnChunk, column ParquetColumn, count int64) ([]interface{}, error) {
+	offset := chunk.DataPageOffset
+	if chunk.DictionaryPageOffset > 0 && chunk.DictionaryPageOffset < offset {
+		offset = chunk.DictionaryPageOffset
+	}
+	if chunk.CompressedSize > MaxParquetChunkSize {
+		return nil, fmt.Errorf("column chunk %s size %d exceed limit %w", column.Name, chunk.CompressedSize, ErrUnsupportedTable)
+	}
+	if offset < 0 || chunk.CompressedSize < 0 || offset+chunk.CompressedSize > fileSize {
+		return nil, fmt.Errorf("column chunk %s out of file %w", column.Name, ErrInvalidParquet)
+	}
+
+	data := make([]byte, chunk.CompressedSize)
+	if _, err := reader.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	decoder := &columnDecoder{column: column, codec: chunk.Codec}
+	values := make([]interface{}, 0, count)
+	pos := 0
+	for int64(len(values)) < count {
+		if pos >= len(data) {
+			return nil, fmt.Errorf("column chunk %s has less values than rows %w", column.Name, ErrInvalidParquet)
+		}
+		headerReader := &compactReader{data: data[pos:]}
+		header, err := headerReader.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		pos += headerReader.pos
+
+		compressedSize, _ := header.int(3)
+		if compressedSize < 0 || int64(pos)+compressedSize > int64(len(data)) {
+			return nil, fmt.Errorf("page out of column chunk %w", ErrInvalidParquet)
+		}
+		pageData := data[pos : pos+int(compressedSize)]
+		pos += int(compressedSize)
+
+		values, err = decoder.decodePage(header, pageData, values)
+		if err != nil {
+			return nil, fmt.Errorf("decode column %s %w", column.Name, err)
+		}
+	}
+	return values[:count], nil
+}
+
+type columnDecoder struct {
+	column     ParquetColumn
+	codec      string
+	dictionary []interface{}
+}
+
+func (decoder *columnDecoder) decompress(data []byte, uncompressedSize int64) ([]byte, error) {
+	if uncompressedSize < 0 || uncompressedSize > MaxParquetChunkSize {
+		return nil, fmt.Errorf("invalid page size %d %w", uncompressedSize, ErrInvalidParquet)
+	}
+	switch decoder.codec {
+	case "UNCOMPRESSED":
+		return data, nil
+	case "SNAPPY":
+		length, err := snappy.DecodedLen(data)
+		if err != nil || int64(length) > MaxParquetChunkSize {
+			return nil, fmt.Errorf("invalid snappy page %w", ErrInvalidParquet)
+		}
+		return snappy.Decode(nil, data)
+	case "GZIP":
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip page %v %w", err, ErrInvalidParquet)
+		}
+		return io.ReadAll(io.LimitReader(reader, uncompressedSize))
+	case "ZSTD":
+		reader, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(MaxParquetChunkSize)))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return reader.DecodeAll(data, make([]byte, 0, uncompressedSize))
+	}
+	return nil, fmt.Errorf("compression codec %s %w", decoder.codec, ErrUnsupportedTable)
+}
+
+func (decoder *columnDecoder) decodePage(header thriftStruct, data []byte, values []interface{}) ([]interface{}, error) {
+	pageType, _ := header.int(1)
+	uncompressedSize, _ := header.int(2)
+	optional := decoder.column.Repetition == "OPTIONAL"
+
+	switch pageType {
+	case dictionaryPage:
+		dictHeader, _ := header.structField(7)
+		numValues, _ := dictHeader.int(1)
+		if numValues < 0 || numValues > maxPageValues {
+			return nil, fmt.Errorf("invalid number of values %d %w", numValues, ErrInvalidParquet)
+		}
+		page, err := decoder.decompress(data, uncompressedSize)
+		if err != nil {
+			return nil, err
+		}
+		decoder.dictionary, _, err = decoder.decodePlain(page, int(numValues))
+		return values, err
+	case dataPage:
+		pageHeader, _ := header.structField(5)
+		numValues, _ := pageHeader.int(1)
+		if numValues < 0 || numValues > maxPageValues {
+			return nil, fmt.Errorf("invalid number of values %d %w", numValues, ErrInvalidParquet)
+		}
+		encoding, _ := pageHeader.int(2)
+		page, err := decoder.decompress(data, uncompressedSize)
+		if err != nil {
+			return nil, err
+		}
+
+		var defLevels []int
+		if optional {
+			if len(page) < 4 {
+				return nil, fmt.Errorf("definition levels missing %w", ErrInvalidParquet)
+			}
+			length := int(binary.LittleEndian.Uint32(page))
+			if length < 0 || 4+length > len(page) {
+				return nil, fmt.Errorf("invalid definition levels length %w", ErrInvalidParquet)
+			}
+			defLevels, err = decodeHybrid(page[4:4+length], 1, int(numValues))
+			if err != nil {
+				return nil, err
+			}
+			page = page[4+length:]
+		}
+		return decoder.decodeValues(page, encoding, int(numValues), defLevels, values)
+	case dataPageV2:
+		pageHeader, _ := header.structField(8)
+		numValues, _ := pageHeader.int(1)
+		if numValues < 0 || numValues > maxPageValues {
+			return nil, fmt.Errorf("invalid number of values %d %w", numValues, ErrInvalidParquet)
+		}
+		encoding, _ := pageHeader.int(4)
+		defLength, _ := pageHeader.int(5)
+		repLength, _ := pageHeader.int(6)
+		if defLength < 0 || repLength < 0 || defLength+repLength > int64(len(data)) {
+			return nil, fmt.Errorf("invalid levels length %w", ErrInvalidParquet)
+		}
+
+		var defLevels []int
+		var err error
+		if optional {
+			defLevels, err = decodeHybrid(data[repLength:repLength+defLength], 1, int(numValues))
+			if err != nil {
+				return nil, err
+			}
+		}
+		page := data[repLength+defLength:]
+		if compressed, ok := pageHeader.bool(7); !ok || compressed {
+			page, err = decoder.decompress(page, uncompressedSize-defLength-repLength)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return decoder.decodeValues(page, encoding, int(numValues), defLevels, values)
+	}
+	// skip index page and unknown page
+	return values, nil
+}
+
+// decodeValues decode non null values and place them by definition levels
+func (decoder *columnDecoder) decodeValues(page []byte, encoding int64, numValues int, defLevels []int, values []interface{}) ([]interface{}, error) {
+	nonNull := numValues
+	if defLevels != nil {
+		nonNull = 0
+		for _, level := range defLevels {
+			nonNull += level
+		}
+	}
+
+	var decoded []interface{}
+	var err error
+	switch encoding {
+	case encodingPlain:
+		decoded, _, err = decoder.decodePlain(page, nonNull)
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if len(page) == 0 {
+			return nil, fmt.Errorf("dictionary indices missing %w", ErrInvalidParquet)
+		}
+		var indices []int
+		indices, err = decodeHybrid(page[1:], int(page[0]), nonNull)
+		if err != nil {
+			return nil, err
+		}
+		decoded = make([]interface{}, nonNull)
+		for i, index := range indices {
+			if index < 0 || index >= len(decoder.dictionary) {
+				return nil, fmt.Errorf("dictionary index %d out of range %w", index, ErrInvalidParquet)
+			}
+			decoded[i] = decoder.dictionary[index]
+		}
+	default:
+		return nil, fmt.Errorf("encoding %d %w", encoding, ErrUnsupportedTable)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if defLevels == nil {
+		return append(values, decoded...), nil
+	}
+	next := 0
+	for _, level := range defLevels {
+		if level == 0 {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, decoded[next])
+		next++
+	}
+	return values, nil
+}
+
+// decodePlain decode count values in plain encoding, return values and bytes consumed
+func (decoder *columnDecoder) decodePlain(data []byte, count int) ([]interface{}, int, error) {
+	values := make([]interface{}, 0, count)
+	pos := 0
+	need := func(n int) error {
+		if n < 0 || pos+n > len(data) {
+			return fmt.Errorf("plain values out of page %w", ErrInvalidParquet)
+		}
+		return nil
+	}
+
+	for i := 0; i < count; i++ {
+		switch decoder.column.PhysicalType {
+		case "BOOLEAN":
+			// booleans are bit packed
+			if i%8 == 0 {
+				if err := need(1); err != nil {
+					return nil, 0, err
+				}
+			}
+			values = append(values, data[i/8]>>(i%8)&1 == 1)
+			pos = i/8 + 1
+		case "INT32":
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, decoder.convertInt(int64(int32(binary.LittleEndian.Uint32(data[pos:])))))
+			pos += 4
+		case "INT64":
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, decoder.convertInt(int64(binary.LittleEndian.Uint64(data[pos:]))))
+			pos += 8
+		case "INT96":
+			if err := need(12); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, int96ToTime(data[pos:pos+12]))
+			pos += 12
+		case "FLOAT":
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, float64(math.Float32frombits(binary.LittleEndian.Uint32(data[pos:]))))
+			pos += 4
+		case "DOUBLE":
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(data[pos:])))
+			pos += 8
+		case "BYTE_ARRAY":
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			length := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if err := need(length); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, decoder.convertBytes(data[pos:pos+length]))
+			pos += length
+		case "FIXED_LEN_BYTE_ARRAY":
+			length := int(decoder.column.TypeLength)
+			if err := need(length); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, decoder.convertBytes(data[pos:pos+length]))
+			pos += length
+		default:
+			return nil, 0, fmt.Errorf("physical type %s %w", decoder.column.PhysicalType, ErrUnsupportedTable)
+		}
+	}
+	return values, pos, nil
+}
+
+var timestampUnits = map[string]time.Duration{
+	"TIMESTAMP(MILLIS,true)":  time.Millisecond,
+	"TIMESTAMP(MILLIS,false)": time.Millisecond,
+	"TIMESTAMP(MICROS,true)":  time.Microsecond,
+	"TIMESTAMP(MICROS,false)": time.Microsecond,
+	"TIMESTAMP(NANOS,true)":   time.Nanosecond,
+	"TIMESTAMP(NANOS,false)":  time.Nanosecond,
+}
+
+// convertInt convert int by logical type, date and timestamp are formatted in RFC3339, decimal is formatted as string
+func (decoder *columnDecoder) convertInt(value int64) interface{} {
+	logicalType := decoder.column.LogicalType
+	if logicalType == "DATE" {
+		return time.Unix(value*24*60*60, 0).UTC().Format(time.DateOnly)
+	}
+	if unit, ok := timestampUnits[logicalType]; ok {
+		return time.Unix(0, 0).Add(time.Duration(value) * unit).UTC().Format(time.RFC3339Nano)
+	}
+	if decoder.column.Scale > 0 && strings.HasPrefix(logicalType, "DECIMAL") {
+		return formatDecimal(big.NewInt(value), decoder.column.Scale)
+	}
+	return value
+}
+
+// convertBytes convert binary by logical type, binary not in utf8 is formatted in hex
+func (decoder *columnDecoder) convertBytes(value []byte) interface{} {
+	logicalType := decoder.column.LogicalType
+	if strings.HasPrefix(logicalType, "DECIMAL") {
+		// big endian two's complement
+		unscaled := new(big.Int).SetBytes(value)
+		if len(value) > 0 && value[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(value)*8)))
+		}
+		return formatDecimal(unscaled, decoder.column.Scale)
+	}
+	if utf8.Valid(value) {
+		return string(value)
+	}
+	return hex.EncodeToString(value)
+}
+
+func formatDecimal(unscaled *big.Int, scale int64) string {
+	return new(big.Float).SetPrec(256).Quo(
+		new(big.Float).SetInt(unscaled),
+		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(scale), nil)),
+	).Text('f', int(scale))
+}
+
+// int96ToTime convert legacy int96 timestamp, nanoseconds of day followed by julian day
+func int96ToTime(value []byte) string {
+	nanos := int64(binary.LittleEndian.Uint64(value[:8]))
+	julianDay := int64(binary.LittleEndian.Uint32(value[8:]))
+	const julianUnixEpoch = 2440588
+	return time.Unix((julianDay-julianUnixEpoch)*24*60*60, nanos).UTC().Format(time.RFC3339Nano)
+}
+
+// decodeHybrid decode count values in rle/bit-packing hybrid encoding without length prefix
+func decodeHybrid(data []byte, bitWidth int, count int) ([]int, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, fmt.Errorf("invalid bit width %d %w", bitWidth, ErrInvalidParquet)
+	}
+	values := make([]int, 0, count)
+	pos := 0
+	byteWidth := (bitWidth + 7) / 8
+	for len(values) < count {
+		header, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid hybrid header %w", ErrInvalidParquet)
+		}
+		pos += n
+
+		if header&1 == 0 {
+			// rle run
+			runLength := int(header >> 1)
+			if pos+byteWidth > len(data) {
+				return nil, fmt.Errorf("rle value out of data %w", ErrInvalidParquet)
+			}
+			value := 0
+			for i := 0; i < byteWidth; i++ {
+				value |= int(data[pos+i]) << (8 * i)
+			}
+			pos += byteWidth
+			for i := 0; i < runLength && len(values) < count; i++ {
+				values = append(values, value)
+			}
+			continue
+		}
+
+		// bit packed groups of 8 values
+		groups := int(header >> 1)
+		byteCount := groups * bitWidth
+		if byteCount < 0 || pos+byteCount > len(data) {
+			return nil, fmt.Errorf("bit packed values out of data %w", ErrInvalidParquet)
+		}
+		packed := data[pos : pos+byteCount]
+		pos += byteCount
+		for i := 0; i < groups*8 && len(values) < count; i++ {
+			value := 0
+			for bit := 0; bit < bitWidth; bit++ {
+				bitPos := i*bitWidth + bit
+				if packed[bitPos/8]>>(bitPos%8)&1 == 1 {
+					value |= 1 << bit
+				}
+			}
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
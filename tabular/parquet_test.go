@@ -0,0 +1,181 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// thrift compact encoder for test, value is bool, int32, int64, string, tList or tStruct
+type tField struct {
+	id    int16
+	value interface{}
+}
+
+type tStruct []tField
+
+type tList struct {
+	elemType byte
+	items    []interface{}
+}
+
+func compactType(value interface{}) byte {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return compactBooleanTrue
+		}
+		return compactBooleanFalse
+	case int32:
+		return compactI32
+	case int64:
+		return compactI64
+	case string:
+		return compactBinary
+	case tList:
+		return compactList
+	case tStruct:
+		return compactStruct
+	}
+	panic("unsupported type")
+}
+
+func writeVarint(buf *bytes.Buffer, value uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], value)])
+}
+
+func writeZigzag(buf *bytes.Buffer, value int64) {
+	writeVarint(buf, uint64((value<<1)^(value>>63)))
+}
+
+func writeValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case int32:
+		writeZigzag(buf, int64(v))
+	case int64:
+		writeZigzag(buf, v)
+	case string:
+		writeVarint(buf, uint64(len(v)))
+		buf.WriteString(v)
+	case tList:
+		if len(v.items) < 15 {
+			buf.WriteByte(byte(len(v.items))<<4 | v.elemType)
+		} else {
+			buf.WriteByte(0xf0 | v.elemType)
+			writeVarint(buf, uint64(len(v.items)))
+		}
+		for _, item := range v.items {
+			writeValue(buf, item)
+		}
+	case tStruct:
+		var lastID int16
+		for _, field := range v {
+			fieldType := compactType(field.value)
+			if delta := field.id - lastID; delta > 0 && delta <= 15 {
+				buf.WriteByte(byte(delta)<<4 | fieldType)
+			} else {
+				buf.WriteByte(fieldType)
+				writeZigzag(buf, int64(field.id))
+			}
+			if fieldType != compactBooleanTrue && fieldType != compactBooleanFalse {
+				writeValue(buf, field.value)
+			}
+			lastID = field.id
+		}
+		buf.WriteByte(compactStop)
+	}
+}
+
+func int32Bytes(value int32) string {
+	return string(binary.LittleEndian.AppendUint32(nil, uint32(value)))
+}
+
+func buildParquet(t *testing.T, fileMeta tStruct) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(parquetMagic)
+	buf.WriteString("column data")
+	footer := &bytes.Buffer{}
+	writeValue(footer, fileMeta)
+	buf.Write(footer.Bytes())
+	require.NoError(t, binary.Write(buf, binary.LittleEndian, uint32(footer.Len())))
+	buf.Write(parquetMagic)
+	return buf.Bytes()
+}
+
+func testFileMeta() tStruct {
+	return tStruct{
+		{1, int32(2)},
+		{2, tList{compactStruct, []interface{}{
+			tStruct{{4, "schema"}, {5, int32(3)}},
+			tStruct{{1, int32(1)}, {3, int32(0)}, {4, "id"}},
+			tStruct{{1, int32(6)}, {3, int32(1)}, {4, "name"}, {6, int32(0)}},
+			tStruct{{3, int32(1)}, {4, "location"}, {5, int32(2)}},
+			tStruct{{1, int32(5)}, {3, int32(1)}, {4, "lat"}},
+			tStruct{{1, int32(2)}, {3, int32(1)}, {4, "ts"}, {10, tStruct{{8, tStruct{{1, true}, {2, tStruct{{2, tStruct{}}}}}}}}},
+		}}},
+		{3, int64(3)},
+		{4, tList{compactStruct, []interface{}{
+			tStruct{
+				{1, tList{compactStruct, []interface{}{
+					tStruct{{2, int64(4)}, {3, tStruct{
+						{1, int32(1)},
+						{3, tList{compactBinary, []interface{}{"id"}}},
+						{4, int32(1)},
+						{5, int64(3)},
+						{6, int64(20)},
+						{7, int64(15)},
+						{12, tStruct{{3, int64(0)}, {5, int32Bytes(9)}, {6, int32Bytes(-1)}}},
+					}}},
+				}}},
+				{2, int64(100)},
+				{3, int64(3)},
+			},
+		}}},
+		{6, "test writer"},
+	}
+}
+
+func TestReadParquetMetadata(t *testing.T) {
+	data := buildParquet(t, testFileMeta())
+	metadata, err := ReadParquetMetadata(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	require.Equal(t, int64(2), metadata.Version)
+	require.Equal(t, int64(3), metadata.NumRows)
+	require.Equal(t, "test writer", metadata.CreatedBy)
+	require.Equal(t, []ParquetColumn{
+		{Name: "id", PhysicalType: "INT32", Repetition: "REQUIRED"},
+		{Name: "name", PhysicalType: "BYTE_ARRAY", LogicalType: "STRING", Repetition: "OPTIONAL"},
+		{Name: "location.lat", PhysicalType: "DOUBLE", Repetition: "OPTIONAL"},
+		{Name: "location.ts", PhysicalType: "INT64", LogicalType: "TIMESTAMP(MICROS,true)", Repetition: "OPTIONAL"},
+	}, metadata.Columns)
+	require.Equal(t, "BYTE_ARRAY(STRING)", metadata.Columns[1].Type())
+
+	require.Len(t, metadata.RowGroups, 1)
+	rowGroup := metadata.RowGroups[0]
+	require.Equal(t, int64(3), rowGroup.NumRows)
+	require.Equal(t, int64(100), rowGroup.TotalByteSize)
+	require.Len(t, rowGroup.Columns, 1)
+	require.Equal(t, "id", rowGroup.Columns[0].Column)
+	require.Equal(t, "SNAPPY", rowGroup.Columns[0].Codec)
+	require.Equal(t, int64(15), rowGroup.Columns[0].CompressedSize)
+	require.Equal(t, "-1", *rowGroup.Columns[0].Statistics.Min)
+	require.Equal(t, "9", *rowGroup.Columns[0].Statistics.Max)
+	require.Equal(t, int64(0), *rowGroup.Columns[0].Statistics.NullCount)
+	require.Nil(t, rowGroup.Columns[0].Statistics.DistinctCount)
+}
+
+func TestReadInvalidParquet(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("PAR1"),
+		[]byte("PAR1 not parquet file"),
+		append([]byte("PAR1"), 0xff, 0xff, 0xff, 0x00, 'P', 'A', 'R', '1'),
+		append([]byte("PAR1\x19\x3c"), 0x02, 0x00, 0x00, 0x00, 'P', 'A', 'R', '1'),
+	} {
+		_, err := ReadParquetMetadata(bytes.NewReader(data), int64(len(data)))
+		require.ErrorIs(t, err, ErrInvalidParquet)
+	}
+}
@@ -0,0 +1,317 @@
+package tabular
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidTable     = errors.New("invalid table")
+	ErrUnsupportedTable = errors.New("unsupported table")
+)
+
+// MaxPreviewScanSize csv and jsonl are read by range up to this size, rows after it are not previewed and total rows is unknown
+var MaxPreviewScanSize int64 = 16 << 20
+
+const (
+	DefaultPreviewLimit = 50
+	MaxPreviewLimit     = 1000
+)
+
+// Format format of table
+type Format string
+
+const (
+	FormatCSV       Format = "csv"
+	FormatTSV       Format = "tsv"
+	FormatJSONLines Format = "jsonl"
+	FormatParquet   Format = "parquet"
+)
+
+// DetectFormat detect table format by extension of path
+func DetectFormat(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, true
+	case ".tsv":
+		return FormatTSV, true
+	case ".jsonl", ".ndjson":
+		return FormatJSONLines, true
+	case ".parquet":
+		return FormatParquet, true
+	}
+	return "", false
+}
+
+// ColumnType inferred type of column
+type ColumnType string
+
+const (
+	TypeNull      ColumnType = "null"
+	TypeBoolean   ColumnType = "boolean"
+	TypeInteger   ColumnType = "integer"
+	TypeNumber    ColumnType = "number"
+	TypeString    ColumnType = "string"
+	TypeDate      ColumnType = "date"
+	TypeTimestamp ColumnType = "timestamp"
+	TypeBinary    ColumnType = "binary"
+	TypeObject    ColumnType = "object"
+	TypeArray     ColumnType = "array"
+	TypeMixed     ColumnType = "mixed"
+)
+
+// mergeType merge types of two values in same column, incompatible types become fallback
+func mergeType(current, next, fallback ColumnType) ColumnType {
+	switch {
+	case len(current) == 0 || current == TypeNull:
+		return next
+	case next == TypeNull || current == next:
+		return current
+	case (current == TypeInteger && next == TypeNumber) || (current == TypeNumber && next == TypeInteger):
+		return TypeNumber
+	}
+	return fallback
+}
+
+// PreviewColumn column of preview
+type PreviewColumn struct {
+	Name string
+	Type ColumnType
+}
+
+// Preview rows in page of table, value of row is nil, bool, string, json.Number or decoded json value
+type Preview struct {
+	Columns []PreviewColumn
+	Rows    [][]interface{}
+	// TotalRows number of rows, nil if not all rows are read
+	TotalRows *int64
+}
+
+// PreviewOption page of preview
+type PreviewOption struct {
+	Offset int64
+	Limit  int
+}
+
+func (opt PreviewOption) normalize() PreviewOption {
+	if opt.Offset < 0 {
+		opt.Offset = 0
+	}
+	if opt.Limit <= 0 {
+		opt.Limit = DefaultPreviewLimit
+	}
+	if opt.Limit > MaxPreviewLimit {
+		opt.Limit = MaxPreviewLimit
+	}
+	return opt
+}
+
+func (opt PreviewOption) inPage(index int64) bool {
+	return index >= opt.Offset && index < opt.Offset+int64(opt.Limit)
+}
+
+// PreviewText preview csv, tsv or jsonl content. complete indicate reader contains whole content,
+// otherwise the last partial line is dropped and total rows is unknown
+func PreviewText(reader io.Reader, format Format, complete bool, opt PreviewOption) (*Preview, error) {
+	opt = opt.normalize()
+	if !complete {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		if end := bytes.LastIndexByte(data, '\n'); end >= 0 {
+			data = data[:end+1]
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	var preview *Preview
+	var totalRows int64
+	var err error
+	switch format {
+	case FormatCSV:
+		preview, totalRows, err = previewCSV(reader, ',', opt)
+	case FormatTSV:
+		preview, totalRows, err = previewCSV(reader, '\t', opt)
+	case FormatJSONLines:
+		preview, totalRows, err = previewJSONLines(reader, opt)
+	default:
+		return nil, fmt.Errorf("format %s %w", format, ErrUnsupportedTable)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		preview.TotalRows = &totalRows
+	}
+	return preview, nil
+}
+
+// inferText infer type of text value
+func inferText(value string) ColumnType {
+	if len(value) == 0 {
+		return TypeNull
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return TypeInteger
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return TypeNumber
+	}
+	if strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+		return TypeBoolean
+	}
+	return TypeString
+}
+
+func previewCSV(reader io.Reader, comma rune, opt PreviewOption) (*Preview, int64, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = comma
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	csvReader.ReuseRecord = true
+
+	preview := &Preview{Columns: []PreviewColumn{}, Rows: [][]interface{}{}}
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return preview, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("read header %v %w", err, ErrInvalidTable)
+	}
+	types := make([]ColumnType, len(header))
+	for _, name := range header {
+		preview.Columns = append(preview.Columns, PreviewColumn{Name: name})
+	}
+
+	var index int64
+	for ; ; index++ {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("read row %d %v %w", index, err, ErrInvalidTable)
+		}
+
+		for i := range types {
+			value := ""
+			if i < len(record) {
+				value = record[i]
+			}
+			types[i] = mergeType(types[i], inferText(value), TypeString)
+		}
+		if opt.inPage(index) {
+			row := make([]interface{}, len(header))
+			for i := range row {
+				if i < len(record) {
+					row[i] = record[i]
+				}
+			}
+			preview.Rows = append(preview.Rows, row)
+		}
+	}
+
+	for i := range preview.Columns {
+		preview.Columns[i].Type = types[i]
+		if len(types[i]) == 0 {
+			preview.Columns[i].Type = TypeNull
+		}
+	}
+	return preview, index, nil
+}
+
+// inferJSON infer type of value decoded by json with UseNumber
+func inferJSON(value interface{}) ColumnType {
+	switch v := value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return TypeInteger
+		}
+		return TypeNumber
+	case string:
+		return TypeString
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		return TypeArray
+	}
+	return TypeMixed
+}
+
+func previewJSONLines(reader io.Reader, opt PreviewOption) (*Preview, int64, error) {
+	columnIndex := map[string]int{}
+	var types []ColumnType
+	preview := &Preview{Columns: []PreviewColumn{}, Rows: [][]interface{}{}}
+	var pageRecords []map[string]interface{}
+
+	bufReader := bufio.NewReader(reader)
+	var index int64
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := bufReader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, 0, err
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			record := map[string]interface{}{}
+			decoder := json.NewDecoder(bytes.NewReader(trimmed))
+			decoder.UseNumber()
+			if decodeErr := decoder.Decode(&record); decodeErr != nil {
+				return nil, 0, fmt.Errorf("decode line %d %v %w", lineNumber, decodeErr, ErrInvalidTable)
+			}
+
+			// columns are ordered by first appearance, new columns in same record are sorted by name
+			for _, key := range sortedKeys(record) {
+				pos, ok := columnIndex[key]
+				if !ok {
+					pos = len(types)
+					columnIndex[key] = pos
+					preview.Columns = append(preview.Columns, PreviewColumn{Name: key})
+					types = append(types, TypeNull)
+				}
+				types[pos] = mergeType(types[pos], inferJSON(record[key]), TypeMixed)
+			}
+			if opt.inPage(index) {
+				pageRecords = append(pageRecords, record)
+			}
+			index++
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	for i := range preview.Columns {
+		preview.Columns[i].Type = types[i]
+	}
+	for _, record := range pageRecords {
+		row := make([]interface{}, len(preview.Columns))
+		for key, value := range record {
+			row[columnIndex[key]] = value
+		}
+		preview.Rows = append(preview.Rows, row)
+	}
+	return preview, index, nil
+}
+
+func sortedKeys(record map[string]interface{}) []string {
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,290 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	for path, expect := range map[string]Format{
+		"a/b.csv":            FormatCSV,
+		"b.TSV":              FormatTSV,
+		"c.jsonl":            FormatJSONLines,
+		"d.ndjson":           FormatJSONLines,
+		"e/f.snappy.parquet": FormatParquet,
+	} {
+		format, ok := DetectFormat(path)
+		require.True(t, ok)
+		require.Equal(t, expect, format)
+	}
+	_, ok := DetectFormat("a.txt")
+	require.False(t, ok)
+}
+
+func TestPreviewCSV(t *testing.T) {
+	content := "id,name,score,active\n1,alice,1.5,true\n2,,2,false\n3,carol,x,TRUE\n"
+	preview, err := PreviewText(strings.NewReader(content), FormatCSV, true, PreviewOption{Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []PreviewColumn{
+		{Name: "id", Type: TypeInteger},
+		{Name: "name", Type: TypeString},
+		{Name: "score", Type: TypeString},
+		{Name: "active", Type: TypeBoolean},
+	}, preview.Columns)
+	require.Equal(t, [][]interface{}{{"2", "", "2", "false"}}, preview.Rows)
+	require.Equal(t, int64(3), *preview.TotalRows)
+
+	t.Run("partial content", func(t *testing.T) {
+		preview, err := PreviewText(strings.NewReader("a\tb\n1\t2.5\n3\t4\n5\t"), FormatTSV, false, PreviewOption{})
+		require.NoError(t, err)
+		require.Equal(t, []PreviewColumn{{Name: "a", Type: TypeInteger}, {Name: "b", Type: TypeNumber}}, preview.Columns)
+		require.Equal(t, [][]interface{}{{"1", "2.5"}, {"3", "4"}}, preview.Rows)
+		require.Nil(t, preview.TotalRows)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		preview, err := PreviewText(strings.NewReader(""), FormatCSV, true, PreviewOption{})
+		require.NoError(t, err)
+		require.Empty(t, preview.Columns)
+		require.Equal(t, int64(0), *preview.TotalRows)
+	})
+}
+
+func TestPreviewJSONLines(t *testing.T) {
+	content := `{"id":1,"name":"a"}
+{"id":2.5,"tags":["x"],"name":null}
+
+{"name":"c","id":3,"extra":{"k":1}}
+{"id":4,"name":5}
+`
+	preview, err := PreviewText(strings.NewReader(content), FormatJSONLines, true, PreviewOption{Offset: 1, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []PreviewColumn{
+		{Name: "id", Type: TypeNumber},
+		{Name: "name", Type: TypeMixed},
+		{Name: "tags", Type: TypeArray},
+		{Name: "extra", Type: TypeObject},
+	}, preview.Columns)
+	require.Equal(t, [][]interface{}{
+		{json.Number("2.5"), nil, []interface{}{"x"}, nil},
+		{json.Number("3"), "c", nil, map[string]interface{}{"k": json.Number("1")}},
+	}, preview.Rows)
+	require.Equal(t, int64(4), *preview.TotalRows)
+
+	_, err = PreviewText(strings.NewReader("{\"id\":1}\nnot json\n"), FormatJSONLines, true, PreviewOption{})
+	require.ErrorIs(t, err, ErrInvalidTable)
+
+	_, err = PreviewText(strings.NewReader(""), FormatParquet, true, PreviewOption{})
+	require.ErrorIs(t, err, ErrUnsupportedTable)
+}
+
+// testChunk column chunk of test parquet, pages are encoded with page header
+type testChunk struct {
+	physicalType int32
+	codec        int32
+	numValues    int64
+	dictionary   []byte
+	dictValues   int32
+	pages        [][]byte
+}
+
+func compressPage(codec int32, data []byte) []byte {
+	if codec == 1 {
+		return snappy.Encode(nil, data)
+	}
+	return data
+}
+
+func writePage(buf *bytes.Buffer, codec int32, pageType int32, headerID int16, header tStruct, data []byte) {
+	compressed := compressPage(codec, data)
+	writeValue(buf, tStruct{{1, pageType}, {2, int32(len(data))}, {3, int32(len(compressed))}, {headerID, header}})
+	buf.Write(compressed)
+}
+
+// dataPage page v1 with 4 bytes length prefixed definition levels if defLevels is not nil
+func (chunk *testChunk) dataPage(numValues, encoding int32, defLevels []byte, values []byte) {
+	data := &bytes.Buffer{}
+	if defLevels != nil {
+		_ = binary.Write(data, binary.LittleEndian, uint32(len(defLevels)))
+		data.Write(defLevels)
+	}
+	data.Write(values)
+	buf := &bytes.Buffer{}
+	writePage(buf, chunk.codec, dataPage, 5, tStruct{{1, numValues}, {2, encoding}, {3, int32(3)}, {4, int32(3)}}, data.Bytes())
+	chunk.pages = append(chunk.pages, buf.Bytes())
+}
+
+func plainByteArray(values ...string) []byte {
+	buf := &bytes.Buffer{}
+	for _, value := range values {
+		_ = binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+		buf.WriteString(value)
+	}
+	return buf.Bytes()
+}
+
+func plainInt(values ...int64) []byte {
+	var data []byte
+	for _, value := range values {
+		data = binary.LittleEndian.AppendUint64(data, uint64(value))
+	}
+	return data
+}
+
+// buildDataParquet build parquet with columns id INT64, name optional STRING in dictionary and day DATE in page v2
+func buildDataParquet() []byte {
+	rowGroups := [][]*testChunk{
+		{
+			{physicalType: 2, numValues: 3},
+			{physicalType: 6, codec: 1, numValues: 3, dictionary: plainByteArray("a", "b"), dictValues: 2},
+			{physicalType: 1, numValues: 3},
+		},
+		{
+			{physicalType: 2, numValues: 2},
+			{physicalType: 6, codec: 1, numValues: 2, dictionary: plainByteArray("b"), dictValues: 1},
+			{physicalType: 1, numValues: 2},
+		},
+	}
+	rowGroups[0][0].dataPage(2, encodingPlain, nil, plainInt(1, 2))
+	rowGroups[0][0].dataPage(1, encodingPlain, nil, plainInt(3))
+	// definition levels 1,0,1 bit packed, dictionary indices 0,1 bit packed with bit width 1
+	rowGroups[0][1].dataPage(3, encodingRLEDictionary, []byte{0x03, 0x05}, []byte{0x01, 0x03, 0x02})
+	rowGroups[1][0].dataPage(2, encodingPlain, nil, plainInt(4, 5))
+	// definition levels run of two 1, dictionary indices run of two 0
+	rowGroups[1][1].dataPage(2, encodingPlainDictionary, []byte{0x04, 0x01}, []byte{0x01, 0x04, 0x00})
+
+	days := [][]int32{{0, 1, 19000}, {10, 11}}
+	for i, rowGroup := range rowGroups {
+		var values []byte
+		for _, day := range days[i] {
+			values = binary.LittleEndian.AppendUint32(values, uint32(day))
+		}
+		buf := &bytes.Buffer{}
+		num := int32(len(days[i]))
+		writePage(buf, 0, dataPageV2, 8, tStruct{{1, num}, {2, int32(0)}, {3, num}, {4, int32(encodingPlain)}, {5, int32(0)}, {6, int32(0)}, {7, false}}, values)
+		rowGroup[2].pages = append(rowGroup[2].pages, buf.Bytes())
+	}
+
+	file := &bytes.Buffer{}
+	file.Write(parquetMagic)
+	names := []string{"id", "name", "day"}
+	var rowGroupMetas []interface{}
+	for _, rowGroup := range rowGroups {
+		var chunkMetas []interface{}
+		for col, chunk := range rowGroup {
+			start := int64(file.Len())
+			var dictOffset int64
+			if chunk.dictionary != nil {
+				dictOffset = start
+				writePage(file, chunk.codec, dictionaryPage, 7, tStruct{{1, chunk.dictValues}, {2, int32(encodingPlain)}}, chunk.dictionary)
+			}
+			dataOffset := int64(file.Len())
+			for _, page := range chunk.pages {
+				file.Write(page)
+			}
+			size := int64(file.Len()) - start
+			columnMeta := tStruct{
+				{1, chunk.physicalType},
+				{2, tList{compactI32, []interface{}{int32(0)}}},
+				{3, tList{compactBinary, []interface{}{names[col]}}},
+				{4, chunk.codec},
+				{5, chunk.numValues},
+				{6, size},
+				{7, size},
+				{9, dataOffset},
+			}
+			if dictOffset > 0 {
+				columnMeta = append(columnMeta, tField{11, dictOffset})
+			}
+			chunkMetas = append(chunkMetas, tStruct{{2, start}, {3, columnMeta}})
+		}
+		rowGroupMetas = append(rowGroupMetas, tStruct{
+			{1, tList{compactStruct, chunkMetas}},
+			{2, int64(0)},
+			{3, rowGroup[0].numValues},
+		})
+	}
+
+	footer := &bytes.Buffer{}
+	writeValue(footer, tStruct{
+		{1, int32(1)},
+		{2, tList{compactStruct, []interface{}{
+			tStruct{{4, "schema"}, {5, int32(3)}},
+			tStruct{{1, int32(2)}, {3, int32(0)}, {4, "id"}},
+			tStruct{{1, int32(6)}, {3, int32(1)}, {4, "name"}, {6, int32(0)}},
+			tStruct{{1, int32(1)}, {3, int32(0)}, {4, "day"}, {6, int32(6)}},
+		}}},
+		{3, int64(5)},
+		{4, tList{compactStruct, rowGroupMetas}},
+	})
+	file.Write(footer.Bytes())
+	_ = binary.Write(file, binary.LittleEndian, uint32(footer.Len()))
+	file.Write(parquetMagic)
+	return file.Bytes()
+}
+
+func TestPreviewParquet(t *testing.T) {
+	data := buildDataParquet()
+	preview, err := PreviewParquet(bytes.NewReader(data), int64(len(data)), PreviewOption{})
+	require.NoError(t, err)
+	require.Equal(t, []PreviewColumn{
+		{Name: "id", Type: TypeInteger},
+		{Name: "name", Type: TypeString},
+		{Name: "day", Type: TypeDate},
+	}, preview.Columns)
+	require.Equal(t, [][]interface{}{
+		{int64(1), "a", "1970-01-01"},
+		{int64(2), nil, "1970-01-02"},
+		{int64(3), "b", "2022-01-08"},
+		{int64(4), "b", "1970-01-11"},
+		{int64(5), "b", "1970-01-12"},
+	}, preview.Rows)
+	require.Equal(t, int64(5), *preview.TotalRows)
+
+	t.Run("page across row groups", func(t *testing.T) {
+		preview, err := PreviewParquet(bytes.NewReader(data), int64(len(data)), PreviewOption{Offset: 2, Limit: 2})
+		require.NoError(t, err)
+		require.Equal(t, [][]interface{}{
+			{int64(3), "b", "2022-01-08"},
+			{int64(4), "b", "1970-01-11"},
+		}, preview.Rows)
+	})
+
+	t.Run("offset out of rows", func(t *testing.T) {
+		preview, err := PreviewParquet(bytes.NewReader(data), int64(len(data)), PreviewOption{Offset: 10})
+		require.NoError(t, err)
+		require.Empty(t, preview.Rows)
+		require.Equal(t, int64(5), *preview.TotalRows)
+	})
+
+	t.Run("nested column", func(t *testing.T) {
+		nested := buildParquet(t, testFileMeta())
+		_, err := PreviewParquet(bytes.NewReader(nested), int64(len(nested)), PreviewOption{})
+		require.ErrorIs(t, err, ErrUnsupportedTable)
+	})
+
+	t.Run("corrupted page", func(t *testing.T) {
+		corrupted := append([]byte{}, data...)
+		for i := 4; i < 40; i++ {
+			corrupted[i] = 0xff
+		}
+		_, err := PreviewParquet(bytes.NewReader(corrupted), int64(len(corrupted)), PreviewOption{})
+		require.ErrorIs(t, err, ErrInvalidParquet)
+	})
+}
+
+func TestDecodeHybrid(t *testing.T) {
+	// run of three 5 in bit width 3, then 8 bit packed values 0..7
+	values, err := decodeHybrid([]byte{0x06, 0x05, 0x03, 0x88, 0xc6, 0xfa}, 3, 11)
+	require.NoError(t, err)
+	require.Equal(t, []int{5, 5, 5, 0, 1, 2, 3, 4, 5, 6, 7}, values)
+
+	_, err = decodeHybrid([]byte{0x06}, 3, 3)
+	require.ErrorIs(t, err, ErrInvalidParquet)
+}
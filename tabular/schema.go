@@ -0,0 +1,127 @@
+package tabular
+
+import (
+	"regexp"
+	"strconv"
+)
+
+type SchemaChangeType string
+
+const (
+	ColumnAdded       SchemaChangeType = "column_added"
+	ColumnRemoved     SchemaChangeType = "column_removed"
+	TypeChanged       SchemaChangeType = "type_changed"
+	RepetitionChanged SchemaChangeType = "repetition_changed"
+)
+
+// SchemaChange change of column between two schemas. Breaking change may fail readers of old schema,
+// like removed column, type narrowing or optional column become required
+type SchemaChange struct {
+	Type     SchemaChangeType
+	Column   string
+	Old      string
+	New      string
+	Breaking bool
+}
+
+// DiffSchema compare leaf columns of two schemas, columns are matched by name
+func DiffSchema(oldColumns, newColumns []ParquetColumn) []SchemaChange {
+	changes := make([]SchemaChange, 0)
+	newIndex := make(map[string]ParquetColumn, len(newColumns))
+	for _, column := range newColumns {
+		newIndex[column.Name] = column
+	}
+	oldIndex := make(map[string]struct{}, len(oldColumns))
+
+	for _, oldColumn := range oldColumns {
+		oldIndex[oldColumn.Name] = struct{}{}
+		newColumn, ok := newIndex[oldColumn.Name]
+		if !ok {
+			changes = append(changes, SchemaChange{Type: ColumnRemoved, Column: oldColumn.Name, Old: oldColumn.Type(), Breaking: true})
+			continue
+		}
+
+		if oldColumn.Type() != newColumn.Type() {
+			changes = append(changes, SchemaChange{
+				Type:     TypeChanged,
+				Column:   oldColumn.Name,
+				Old:      oldColumn.Type(),
+				New:      newColumn.Type(),
+				Breaking: !isTypeWidening(oldColumn, newColumn),
+			})
+		}
+		if oldColumn.Repetition != newColumn.Repetition {
+			changes = append(changes, SchemaChange{
+				Type:   RepetitionChanged,
+				Column: oldColumn.Name,
+				Old:    oldColumn.Repetition,
+				New:    newColumn.Repetition,
+				// only relax required column to optional is compatible
+				Breaking: !(oldColumn.Repetition == "REQUIRED" && newColumn.Repetition == "OPTIONAL"),
+			})
+		}
+	}
+
+	for _, newColumn := range newColumns {
+		if _, ok := oldIndex[newColumn.Name]; !ok {
+			changes = append(changes, SchemaChange{Type: ColumnAdded, Column: newColumn.Name, New: newColumn.Type()})
+		}
+	}
+	return changes
+}
+
+// HasBreakingChange check whether any change is breaking
+func HasBreakingChange(changes []SchemaChange) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+var widenPhysicalTypes = map[string][]string{
+	"INT32": {"INT64", "DOUBLE"},
+	"FLOAT": {"DOUBLE"},
+}
+
+var (
+	intLogicalType     = regexp.MustCompile(`^INT\((\d+),(true|false)\)$`)
+	decimalLogicalType = regexp.MustCompile(`^DECIMAL\((\d+),(\d+)\)$`)
+)
+
+// isTypeWidening check whether new type could represent all values of old type
+func isTypeWidening(oldColumn, newColumn ParquetColumn) bool {
+	if oldColumn.PhysicalType != newColumn.PhysicalType {
+		widen := false
+		for _, physicalType := range widenPhysicalTypes[oldColumn.PhysicalType] {
+			widen = widen || physicalType == newColumn.PhysicalType
+		}
+		if !widen {
+			return false
+		}
+	}
+
+	if oldColumn.LogicalType == newColumn.LogicalType {
+		return true
+	}
+	oldInt, newInt := intLogicalType.FindStringSubmatch(oldColumn.LogicalType), intLogicalType.FindStringSubmatch(newColumn.LogicalType)
+	if oldInt != nil && len(newColumn.LogicalType) == 0 {
+		// plain int is signed
+		return oldInt[2] == "true"
+	}
+	if oldInt != nil && newInt != nil {
+		return oldInt[2] == newInt[2] && atoi(oldInt[1]) <= atoi(newInt[1])
+	}
+	oldDecimal, newDecimal := decimalLogicalType.FindStringSubmatch(oldColumn.LogicalType), decimalLogicalType.FindStringSubmatch(newColumn.LogicalType)
+	if oldDecimal != nil && newDecimal != nil {
+		// keep scale and increase precision
+		return oldDecimal[2] == newDecimal[2] && atoi(oldDecimal[1]) <= atoi(newDecimal[1])
+	}
+	return false
+}
+
+func atoi(value string) int {
+	result, _ := strconv.Atoi(value)
+	return result
+}
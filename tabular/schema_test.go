@@ -0,0 +1,55 @@
+package tabular
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchema(t *testing.T) {
+	oldColumns := []ParquetColumn{
+		{Name: "id", PhysicalType: "INT32", LogicalType: "INT(32,true)", Repetition: "REQUIRED"},
+		{Name: "name", PhysicalType: "BYTE_ARRAY", LogicalType: "STRING", Repetition: "OPTIONAL"},
+		{Name: "score", PhysicalType: "DOUBLE", Repetition: "OPTIONAL"},
+		{Name: "price", PhysicalType: "INT64", LogicalType: "DECIMAL(10,2)", Repetition: "OPTIONAL"},
+		{Name: "flag", PhysicalType: "BOOLEAN", Repetition: "REQUIRED"},
+	}
+	newColumns := []ParquetColumn{
+		{Name: "id", PhysicalType: "INT64", LogicalType: "INT(64,true)", Repetition: "OPTIONAL"},
+		{Name: "name", PhysicalType: "BYTE_ARRAY", LogicalType: "STRING", Repetition: "REQUIRED"},
+		{Name: "score", PhysicalType: "FLOAT", Repetition: "OPTIONAL"},
+		{Name: "price", PhysicalType: "INT64", LogicalType: "DECIMAL(12,2)", Repetition: "OPTIONAL"},
+		{Name: "tag", PhysicalType: "BYTE_ARRAY", Repetition: "OPTIONAL"},
+	}
+
+	changes := DiffSchema(oldColumns, newColumns)
+	require.Equal(t, []SchemaChange{
+		{Type: TypeChanged, Column: "id", Old: "INT32(INT(32,true))", New: "INT64(INT(64,true))"},
+		{Type: RepetitionChanged, Column: "id", Old: "REQUIRED", New: "OPTIONAL"},
+		{Type: RepetitionChanged, Column: "name", Old: "OPTIONAL", New: "REQUIRED", Breaking: true},
+		{Type: TypeChanged, Column: "score", Old: "DOUBLE", New: "FLOAT", Breaking: true},
+		{Type: TypeChanged, Column: "price", Old: "INT64(DECIMAL(10,2))", New: "INT64(DECIMAL(12,2))"},
+		{Type: ColumnRemoved, Column: "flag", Old: "BOOLEAN", Breaking: true},
+		{Type: ColumnAdded, Column: "tag", New: "BYTE_ARRAY"},
+	}, changes)
+	require.True(t, HasBreakingChange(changes))
+	require.False(t, HasBreakingChange(DiffSchema(oldColumns, oldColumns)))
+}
+
+func TestIsTypeWidening(t *testing.T) {
+	testCases := []struct {
+		old, new ParquetColumn
+		widen    bool
+	}{
+		{ParquetColumn{PhysicalType: "INT32"}, ParquetColumn{PhysicalType: "DOUBLE"}, true},
+		{ParquetColumn{PhysicalType: "INT64"}, ParquetColumn{PhysicalType: "INT32"}, false},
+		{ParquetColumn{PhysicalType: "INT32", LogicalType: "INT(8,true)"}, ParquetColumn{PhysicalType: "INT32", LogicalType: "INT(16,true)"}, true},
+		{ParquetColumn{PhysicalType: "INT32", LogicalType: "INT(16,true)"}, ParquetColumn{PhysicalType: "INT32", LogicalType: "INT(8,true)"}, false},
+		{ParquetColumn{PhysicalType: "INT32", LogicalType: "INT(16,false)"}, ParquetColumn{PhysicalType: "INT32"}, false},
+		{ParquetColumn{PhysicalType: "INT64", LogicalType: "DECIMAL(10,2)"}, ParquetColumn{PhysicalType: "INT64", LogicalType: "DECIMAL(10,3)"}, false},
+		{ParquetColumn{PhysicalType: "BYTE_ARRAY", LogicalType: "STRING"}, ParquetColumn{PhysicalType: "BYTE_ARRAY"}, false},
+	}
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.widen, isTypeWidening(testCase.old, testCase.new), "%v => %v", testCase.old, testCase.new)
+	}
+}
@@ -0,0 +1,250 @@
+package tabular
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// thrift compact protocol types, see https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md
+const (
+	compactStop         = 0
+	compactBooleanTrue  = 1
+	compactBooleanFalse = 2
+	compactByte         = 3
+	compactI16          = 4
+	compactI32          = 5
+	compactI64          = 6
+	compactDouble       = 7
+	compactBinary       = 8
+	compactList         = 9
+	compactSet          = 10
+	compactMap          = 11
+	compactStruct       = 12
+)
+
+// maxThriftDepth limit nesting of struct and container to avoid stack overflow by malformed data
+const maxThriftDepth = 64
+
+// thriftStruct decoded struct, field id => value. value is bool, int64, float64, []byte, []interface{} or thriftStruct
+type thriftStruct map[int16]interface{}
+
+func (s thriftStruct) int(id int16) (int64, bool) {
+	value, ok := s[id].(int64)
+	return value, ok
+}
+
+func (s thriftStruct) bool(id int16) (bool, bool) {
+	value, ok := s[id].(bool)
+	return value, ok
+}
+
+func (s thriftStruct) binary(id int16) ([]byte, bool) {
+	value, ok := s[id].([]byte)
+	return value, ok
+}
+
+func (s thriftStruct) string(id int16) string {
+	value, _ := s.binary(id)
+	return string(value)
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	value, _ := s[id].([]interface{})
+	return value
+}
+
+func (s thriftStruct) structField(id int16) (thriftStruct, bool) {
+	value, ok := s[id].(thriftStruct)
+	return value, ok
+}
+
+// compactReader decode thrift compact protocol into generic values
+type compactReader struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func (r *compactReader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, fmt.Errorf("unexpected end of thrift data %w", ErrInvalidParquet)
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *compactReader) readVarint() (uint64, error) {
+	value, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint in thrift data %w", ErrInvalidParquet)
+	}
+	r.pos += n
+	return value, nil
+}
+
+func (r *compactReader) readZigzag() (int64, error) {
+	value, err := r.readVarint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(value>>1) ^ -int64(value&1), nil
+}
+
+func (r *compactReader) readBinary() ([]byte, error) {
+	length, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("binary length %d exceed thrift data %w", length, ErrInvalidParquet)
+	}
+	value := r.data[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return value, nil
+}
+
+func (r *compactReader) enter() error {
+	r.depth++
+	if r.depth > maxThriftDepth {
+		return fmt.Errorf("thrift data nested too deep %w", ErrInvalidParquet)
+	}
+	return nil
+}
+
+func (r *compactReader) readStruct() (thriftStruct, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { r.depth-- }()
+
+	result := thriftStruct{}
+	var lastID int16
+	for {
+		header, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if header == compactStop {
+			return result, nil
+		}
+
+		fieldType := header & 0x0f
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			longID, err := r.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(longID)
+		}
+		lastID = id
+
+		switch fieldType {
+		case compactBooleanTrue:
+			result[id] = true
+		case compactBooleanFalse:
+			result[id] = false
+		default:
+			value, err := r.readValue(fieldType)
+			if err != nil {
+				return nil, err
+			}
+			result[id] = value
+		}
+	}
+}
+
+func (r *compactReader) readValue(valueType byte) (interface{}, error) {
+	switch valueType {
+	case compactBooleanTrue, compactBooleanFalse:
+		// bool in container is encoded as one byte
+		b, err := r.readByte()
+		return b == compactBooleanTrue, err
+	case compactByte:
+		b, err := r.readByte()
+		return int64(int8(b)), err
+	case compactI16, compactI32, compactI64:
+		return r.readZigzag()
+	case compactDouble:
+		if len(r.data)-r.pos < 8 {
+			return nil, fmt.Errorf("unexpected end of thrift data %w", ErrInvalidParquet)
+		}
+		value := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return value, nil
+	case compactBinary:
+		return r.readBinary()
+	case compactList, compactSet:
+		return r.readList()
+	case compactMap:
+		return nil, r.skipMap()
+	case compactStruct:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type %d %w", valueType, ErrInvalidParquet)
+}
+
+func (r *compactReader) readList() ([]interface{}, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { r.depth-- }()
+
+	header, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(header >> 4)
+	if size == 15 {
+		size, err = r.readVarint()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// every element takes one byte at least
+	if size > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("list size %d exceed thrift data %w", size, ErrInvalidParquet)
+	}
+
+	elemType := header & 0x0f
+	values := make([]interface{}, 0, size)
+	for i := uint64(0); i < size; i++ {
+		value, err := r.readValue(elemType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// skipMap skip map value, parquet metadata has no map field in use
+func (r *compactReader) skipMap() error {
+	if err := r.enter(); err != nil {
+		return err
+	}
+	defer func() { r.depth-- }()
+
+	size, err := r.readVarint()
+	if err != nil || size == 0 {
+		return err
+	}
+	if size > uint64(len(r.data)-r.pos) {
+		return fmt.Errorf("map size %d exceed thrift data %w", size, ErrInvalidParquet)
+	}
+	types, err := r.readByte()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < size; i++ {
+		if _, err = r.readValue(types >> 4); err != nil {
+			return err
+		}
+		if _, err = r.readValue(types & 0x0f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
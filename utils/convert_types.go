@@ -924,3 +924,11 @@ func Map[T any](src *map[string]T) map[string]T {
 	}
 	return *src
 }
+
+// Slice get value of slice ptr, if ptr is nil, return empty slice
+func Slice[T any](src *[]T) []T {
+	if src == nil || *src == nil {
+		return []T{}
+	}
+	return *src
+}
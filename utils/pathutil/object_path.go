@@ -0,0 +1,19 @@
+package pathutil
+
+import (
+	"errors"
+	"regexp"
+)
+
+var (
+	ReValidPath = regexp.MustCompile(`^[^\x00/:*?"<>|]*/?([^/\s\x00:*?"<>|]+/)*[^/\s\x00:*?"<>|]+(?:\.[a-zA-Z0-9]+)?$`)
+
+	ErrInvalidObjectPath = errors.New("invalid object path: it must not contain null characters or NTFS forbidden characters")
+)
+
+func ValidateObjectPath(path string) error {
+	if !ReValidPath.MatchString(path) {
+		return ErrInvalidObjectPath
+	}
+	return nil
+}
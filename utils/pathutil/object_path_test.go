@@ -0,0 +1,33 @@
+package pathutil
+
+import "testing"
+
+func TestValidateObjectPath(t *testing.T) {
+	//Validate Obj Path
+	validObjectPaths := []string{"path/to/object", "file.txt", "folder/file.txt", "我的图片.png", "我的文件/我的应用.exe", "私のビデオ.mp3", "/video.mp3", "/path/pic.png"}
+	for _, path := range validObjectPaths {
+		err := ValidateObjectPath(path)
+		if err != nil {
+			t.Errorf("Expected no error for object path '%s', but got: %s", path, err)
+		}
+	}
+
+	//Invalidate Obj Path
+	invalidObjectPaths := []struct {
+		path  string
+		error string
+	}{
+		{"path/with/null\x00character", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
+		{"path/with/invalid/characters/:", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
+		{"path/with/invalid/characters/*", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
+		{"path/with/invalid/characters/\"", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
+		{"path/with/invalid/characters/<?", "invalid object path: it must not contain null characters or NTFS forbidden characters"},
+	}
+
+	for _, testCase := range invalidObjectPaths {
+		err := ValidateObjectPath(testCase.path)
+		if err == nil || err.Error() != testCase.error {
+			t.Errorf("Expected error '%s' for invalid object path '%s', but got: %v", testCase.error, testCase.path, err)
+		}
+	}
+}
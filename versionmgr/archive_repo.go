@@ -1,7 +1,9 @@
 package versionmgr
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
@@ -10,8 +12,10 @@ import (
 	path2 "path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	chunker "github.com/ipfs/boxo/chunker"
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/ipfs/go-cid"
 
@@ -33,30 +37,51 @@ type RepoArchiver struct {
 	rootPath  string
 	walker    IWalk
 	getReader func(context.Context, *models.Blob, string) (io.ReadCloser, error)
+	// modTime modify time of directories in archive
+	modTime time.Time
 }
 
 func NewRepoArchiver(rootPath string, walker IWalk, getReader func(context.Context, *models.Blob, string) (io.ReadCloser, error)) *RepoArchiver {
-	return &RepoArchiver{rootPath: rootPath, walker: walker, getReader: getReader}
+	return &RepoArchiver{rootPath: rootPath, walker: walker, getReader: getReader, modTime: time.Unix(0, 0)}
 }
 
-func (repo *RepoArchiver) ArchiveZip(ctx context.Context, dest string) error {
-	zipFile, err := os.Create(dest)
-	if err != nil {
-		return err
+// WithModTime set modify time of directories, use time of archived commit so that archives of same commit are identical
+func (repo *RepoArchiver) WithModTime(modTime time.Time) *RepoArchiver {
+	repo.modTime = modTime
+	return repo
+}
+
+// Archive write all files under walker to w in the format of archiveType. archive is not finished if error happened,
+// so that readers find it corrupted rather than take it as a complete archive
+func (repo *RepoArchiver) Archive(ctx context.Context, archiveType ArchiveType, w io.Writer) error {
+	switch archiveType {
+	case ZipArchiveType:
+		return repo.ArchiveZip(ctx, w)
+	case CarArchiveType:
+		return repo.ArchiveCar(ctx, w)
+	case TarArchiveType:
+		return repo.ArchiveTar(ctx, w)
+	case TarGzArchiveType:
+		return repo.ArchiveTarGz(ctx, w)
+	case TarZstArchiveType:
+		return repo.ArchiveTarZst(ctx, w)
+	default:
+		return fmt.Errorf("unexpect archive type %s", archiveType)
 	}
-	defer zipFile.Close() //nolint:errcheck
+}
 
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close() //nolint:errcheck
+func (repo *RepoArchiver) ArchiveZip(ctx context.Context, w io.Writer) error {
+	// close only when all files are written, central directory make an incomplete archive look complete
+	zipWriter := zip.NewWriter(w)
 
-	_, err = zipWriter.CreateHeader(&zip.FileHeader{
+	_, err := zipWriter.CreateHeader(&zip.FileHeader{
 		Name: repo.rootPath + "/",
 	})
 	if err != nil {
 		return err
 	}
 
-	return repo.walker.Walk(ctx, func(entry *models.TreeEntry, blob *models.Blob, path string) error {
+	err = repo.walker.Walk(ctx, func(entry *models.TreeEntry, blob *models.Blob, path string) error {
 		if entry.IsDir {
 			path = fmt.Sprintf("%s%c", path, os.PathSeparator)
 			_, err = zipWriter.CreateHeader(&zip.FileHeader{
@@ -79,9 +104,91 @@ func (repo *RepoArchiver) ArchiveZip(ctx context.Context, dest string) error {
 		_, err = io.Copy(f, reader)
 		return err
 	})
+	if err != nil {
+		return err
+	}
+	return zipWriter.Close()
 }
 
-func (repo *RepoArchiver) ArchiveCar(ctx context.Context, dest string) error {
+// ArchiveTar write all files under walker to w in tar format
+func (repo *RepoArchiver) ArchiveTar(ctx context.Context, w io.Writer) error {
+	// close only when all files are written, end of archive make an incomplete archive look complete
+	tarWriter := tar.NewWriter(w)
+
+	err := tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     repo.rootPath + "/",
+		Mode:     0755,
+		ModTime:  repo.modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = repo.walker.Walk(ctx, func(entry *models.TreeEntry, blob *models.Blob, path string) error {
+		if entry.IsDir {
+			return tarWriter.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     path2.Join(repo.rootPath, path) + "/",
+				Mode:     0755,
+				ModTime:  repo.modTime,
+			})
+		}
+
+		reader, err := repo.getReader(ctx, blob, path)
+		if err != nil {
+			return err
+		}
+		defer reader.Close() //nolint
+
+		err = tarWriter.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path2.Join(repo.rootPath, path),
+			Size:     blob.Size,
+			Mode:     0644,
+			ModTime:  blob.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(tarWriter, reader)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tarWriter.Close()
+}
+
+// ArchiveTarGz write all files under walker to w in gzip compressed tar format
+func (repo *RepoArchiver) ArchiveTarGz(ctx context.Context, w io.Writer) error {
+	gzWriter := gzip.NewWriter(w)
+	defer gzWriter.Close() //nolint:errcheck
+
+	err := repo.ArchiveTar(ctx, gzWriter)
+	if err != nil {
+		return err
+	}
+	return gzWriter.Close()
+}
+
+// ArchiveTarZst write all files under walker to w in zstd compressed tar format
+func (repo *RepoArchiver) ArchiveTarZst(ctx context.Context, w io.Writer) error {
+	zstWriter, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	defer zstWriter.Close() //nolint:errcheck
+
+	err = repo.ArchiveTar(ctx, zstWriter)
+	if err != nil {
+		return err
+	}
+	return zstWriter.Close()
+}
+
+func (repo *RepoArchiver) ArchiveCar(ctx context.Context, w io.Writer) error {
 	db := dssync.MutexWrap(ds.NewMapDatastore()) //todo use disk to cache data
 	bs := bstore.NewBlockstore(db)
 	blockSrv := bserv.New(bs, offline.Exchange(bs))
@@ -95,6 +202,11 @@ func (repo *RepoArchiver) ArchiveCar(ctx context.Context, dest string) error {
 	defer root.Close() //nolint:errcheck
 
 	rootDir := root.GetDirectory()
+	_, err = mkdirP(rootDir, repo.rootPath)
+	if err != nil {
+		return err
+	}
+
 	err = repo.walker.Walk(ctx, func(entry *models.TreeEntry, blob *models.Blob, treePath string) error {
 		path := path2.Join(repo.rootPath, treePath)
 		if entry.IsDir {
@@ -134,12 +246,7 @@ func (repo *RepoArchiver) ArchiveCar(ctx context.Context, dest string) error {
 		return err
 	}
 
-	carFile, err := os.Create(dest)
-	if err != nil {
-		return err
-	}
-	defer carFile.Close() //nolint:errcheck
-	return car.WriteCar(ctx, dagSrv, []cid.Cid{ipldNode.Cid()}, carFile)
+	return car.WriteCar(ctx, dagSrv, []cid.Cid{ipldNode.Cid()}, w)
 }
 
 func mkdirP(root *mfs.Directory, pth string) (*mfs.Directory, error) {
@@ -1,13 +1,19 @@
 package versionmgr
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"path"
 	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
 
 	bserv "github.com/ipfs/boxo/blockservice"
 	bstore "github.com/ipfs/boxo/blockstore"
@@ -81,14 +87,132 @@ func TestRepoArchiver_ArchiveZip(t *testing.T) {
 		},
 	)
 
-	tmpDir, err := os.MkdirTemp(os.TempDir(), "*")
+	buf := bytes.NewBuffer(nil)
+	err := archiver.ArchiveZip(ctx, buf)
 	require.NoError(t, err)
-	tmpFile := path.Join(tmpDir, "test.zip")
 
-	err = archiver.ArchiveZip(ctx, tmpFile)
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
 	require.NoError(t, err)
+	for file, data := range wk.files {
+		fs, err := zipReader.Open(path.Join(archiver.rootPath, file))
+		require.NoError(t, err)
+		actualData, err := io.ReadAll(fs)
+		require.NoError(t, err)
+		require.Equal(t, data, actualData)
+	}
+}
 
-	fmt.Println(tmpFile)
+func TestRepoArchiver_ArchiveTar(t *testing.T) {
+	ctx := context.Background()
+	wk := &mockWalker{
+		dirs: []string{
+			"a",
+			"a/b",
+			"m",
+		},
+		files: map[string][]byte{
+			"1.txt":     []byte("111111111111111111111111"),
+			"a/2.txt":   []byte("222222222222222222222222"),
+			"a/3.txt":   []byte("3333333333333333333333333"),
+			"a/b/4.txt": []byte("4444444444444444444444444444"),
+			"m/5.txt":   []byte("555555555555555555555"),
+		},
+	}
+	archiver := NewRepoArchiver(
+		"testdir",
+		wk,
+		func(ctx context.Context, _ *models.Blob, s string) (io.ReadCloser, error) {
+			data, ok := wk.files[s]
+			if !ok {
+				return nil, fmt.Errorf("data not found %s", s)
+			}
+			return utils.CloserWraper{Reader: bytes.NewReader(data)}, nil
+		},
+	)
+
+	checkTar := func(t *testing.T, reader io.Reader) {
+		tarReader := tar.NewReader(reader)
+		files := map[string][]byte{}
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if header.Typeflag == tar.TypeDir {
+				continue
+			}
+			data, err := io.ReadAll(tarReader)
+			require.NoError(t, err)
+			files[header.Name] = data
+		}
+		require.Len(t, files, len(wk.files))
+		for file, data := range wk.files {
+			require.Equal(t, data, files[path.Join(archiver.rootPath, file)])
+		}
+	}
+
+	t.Run("tar", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, archiver.Archive(ctx, TarArchiveType, buf))
+		checkTar(t, buf)
+	})
+
+	t.Run("tar.gz", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, archiver.Archive(ctx, TarGzArchiveType, buf))
+		gzReader, err := gzip.NewReader(buf)
+		require.NoError(t, err)
+		checkTar(t, gzReader)
+	})
+
+	t.Run("tar.zst", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, archiver.Archive(ctx, TarZstArchiveType, buf))
+		zstReader, err := zstd.NewReader(buf)
+		require.NoError(t, err)
+		defer zstReader.Close()
+		checkTar(t, zstReader)
+	})
+
+	t.Run("unexpect type", func(t *testing.T) {
+		require.Error(t, archiver.Archive(ctx, "rar", bytes.NewBuffer(nil)))
+	})
+
+	t.Run("same archive of directories", func(t *testing.T) {
+		modTime := time.Unix(1700000000, 0)
+		dirArchiver := NewRepoArchiver("testdir", &mockWalker{dirs: wk.dirs}, archiver.getReader).WithModTime(modTime)
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, dirArchiver.Archive(ctx, TarArchiveType, buf))
+		again := bytes.NewBuffer(nil)
+		require.NoError(t, dirArchiver.Archive(ctx, TarArchiveType, again))
+		require.Equal(t, buf.Bytes(), again.Bytes())
+
+		header, err := tar.NewReader(buf).Next()
+		require.NoError(t, err)
+		require.True(t, modTime.Equal(header.ModTime))
+	})
+
+	t.Run("unfinished archive when failed", func(t *testing.T) {
+		failArchiver := NewRepoArchiver("testdir", wk, func(context.Context, *models.Blob, string) (io.ReadCloser, error) {
+			return nil, errors.New("read fail")
+		})
+
+		// tar ends with two zero blocks
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, archiver.Archive(ctx, TarArchiveType, buf))
+		require.True(t, bytes.HasSuffix(buf.Bytes(), make([]byte, 1024)))
+
+		buf.Reset()
+		require.Error(t, failArchiver.Archive(ctx, TarArchiveType, buf))
+		require.False(t, bytes.HasSuffix(buf.Bytes(), make([]byte, 1024)))
+
+		buf.Reset()
+		require.Error(t, failArchiver.Archive(ctx, ZipArchiveType, buf))
+		_, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.Error(t, err)
+	})
 }
 
 func TestRepoArchiver_ArchiveCar(t *testing.T) {
@@ -119,20 +243,14 @@ func TestRepoArchiver_ArchiveCar(t *testing.T) {
 		},
 	)
 
-	tmpDir, err := os.MkdirTemp(os.TempDir(), "*")
-	require.NoError(t, err)
-	tmpFile := path.Join(tmpDir, "test.car")
-
-	err = archiver.ArchiveCar(ctx, tmpFile)
+	buf := bytes.NewBuffer(nil)
+	err := archiver.ArchiveCar(ctx, buf)
 	require.NoError(t, err)
 
 	//check data in car
-	fs, err := os.Open(tmpFile)
-	require.NoError(t, err)
-
 	db := dssync.MutexWrap(ds.NewMapDatastore())
 	bs := bstore.NewBlockstore(db)
-	header, err := car.LoadCar(ctx, bs, fs)
+	header, err := car.LoadCar(ctx, bs, buf)
 	require.NoError(t, err)
 	blockserv := bserv.New(bs, offline.Exchange(bs))
 	dagSrv := dag.NewDAGService(blockserv)
@@ -0,0 +1,384 @@
+package versionmgr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/gobwas/glob"
+)
+
+// AttributesPath path of attributes file at the root of tree, each line maps path pattern to merge driver, for example
+//
+//	# comment
+//	*.lock       merge=ours
+//	logs/*.log   merge=union-lines
+//	**.json      merge=json-merge
+//
+// pattern without "/" matches file name in any directory, rule in later line takes precedence
+const AttributesPath = ".jzattributes"
+
+// MergeDriver decide how to merge path changed by both side
+type MergeDriver string
+
+const (
+	// MergeDriverOurs use change of the branch merged into
+	MergeDriverOurs MergeDriver = "ours"
+	// MergeDriverTheirs use change of the merged commit
+	MergeDriverTheirs MergeDriver = "theirs"
+	// MergeDriverUnionLines keep lines of both side
+	MergeDriverUnionLines MergeDriver = "union-lines"
+	// MergeDriverJSON merge json document key by key
+	MergeDriverJSON MergeDriver = "json-merge"
+	// MergeDriverBinaryFail never merge content, conflict must be resolved by merger
+	MergeDriverBinaryFail MergeDriver = "binary-fail"
+)
+
+var ErrInvalidAttributes = errors.New("invalid attributes")
+
+// MaxMergeSize content larger than this size is not merged by merge drivers, conflict falls back to resolver
+var MaxMergeSize int64 = 1 << 24
+
+var errMergeTooLarge = errors.New("content too large to merge")
+
+// AutoResolve return whether conflict of the driver could be resolved without decision of merger
+func (driver MergeDriver) AutoResolve() bool {
+	switch driver {
+	case MergeDriverOurs, MergeDriverTheirs, MergeDriverUnionLines, MergeDriverJSON:
+		return true
+	}
+	return false
+}
+
+func (driver MergeDriver) valid() bool {
+	return driver.AutoResolve() || driver == MergeDriverBinaryFail
+}
+
+type attributeRule struct {
+	glob      glob.Glob
+	matchName bool
+	driver    MergeDriver
+}
+
+// Attributes merge drivers of paths
+type Attributes struct {
+	rules []attributeRule
+}
+
+// ParseAttributes parse attributes file content, attributes other than merge are ignored
+func ParseAttributes(r io.Reader) (*Attributes, error) {
+	attrs := &Attributes{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			driver, ok := strings.CutPrefix(field, "merge=")
+			if !ok {
+				continue
+			}
+			if !MergeDriver(driver).valid() {
+				return nil, fmt.Errorf("unknown merge driver %s at line %d %w", driver, lineNum, ErrInvalidAttributes)
+			}
+
+			pattern := strings.TrimPrefix(fields[0], "/")
+			g, err := glob.Compile(pattern, '/')
+			if err != nil {
+				return nil, fmt.Errorf("pattern %s at line %d %w", fields[0], lineNum, ErrInvalidAttributes)
+			}
+			attrs.rules = append(attrs.rules, attributeRule{
+				glob:      g,
+				matchName: !strings.Contains(fields[0], "/"),
+				driver:    MergeDriver(driver),
+			})
+		}
+	}
+	return attrs, scanner.Err()
+}
+
+// MergeDriver return merge driver of path, false if no rule match the path
+func (attrs *Attributes) MergeDriver(fullPath string) (MergeDriver, bool) {
+	if attrs == nil {
+		return "", false
+	}
+	for i := len(attrs.rules) - 1; i >= 0; i-- {
+		rule := attrs.rules[i]
+		target := fullPath
+		if rule.matchName {
+			target = path.Base(fullPath)
+		}
+		if rule.glob.Match(target) {
+			return rule.driver, true
+		}
+	}
+	return "", false
+}
+
+// BlobStore read and write content of blob
+type BlobStore interface {
+	ReadBlob(ctx context.Context, blob *models.Blob, rangeSpec *string) (io.ReadCloser, error)
+	WriteBlob(ctx context.Context, body io.Reader, contentLength int64, properties models.Property) (*models.Blob, error)
+}
+
+var _ BlobStore = (*WorkRepository)(nil)
+
+// LoadAttributes load attributes file of tree, return empty attributes if file not exit
+func LoadAttributes(ctx context.Context, fileTreeRepo models.IFileTreeRepo, store BlobStore, treeHash hash.Hash) (*Attributes, error) {
+	workTree, err := NewWorkTree(ctx, fileTreeRepo, models.NewRootTreeEntry(treeHash))
+	if err != nil {
+		return nil, err
+	}
+
+	blob, _, err := workTree.FindBlob(ctx, AttributesPath)
+	if errors.Is(err, ErrPathNotFound) {
+		return &Attributes{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := store.ReadBlob(ctx, blob, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close() //nolint
+	return ParseAttributes(reader)
+}
+
+// AttributesResolver resolve conflict with merge driver of path before fall back to resolver.
+// left is change of merged commit(theirs) and right is change of branch merged into(ours),
+// union-lines and json-merge fall back to resolver when content can not be merged or is larger than MaxMergeSize
+func AttributesResolver(ctx context.Context, fileTreeRepo models.IFileTreeRepo, store BlobStore, attrs *Attributes, resolver ConflictResolver) ConflictResolver {
+	fallback := func(left IChange, right IChange) (IChange, error) {
+		if resolver != nil {
+			return resolver(left, right)
+		}
+		return nil, fmt.Errorf("path %s confilict %w", right.Path(), ErrConflict)
+	}
+
+	return func(left IChange, right IChange) (IChange, error) {
+		driver, ok := attrs.MergeDriver(left.Path())
+		if !ok {
+			return fallback(left, right)
+		}
+
+		var mergeContent func(base, ours, theirs []byte, hasBase bool) ([]byte, error)
+		switch driver {
+		case MergeDriverOurs:
+			return right, nil
+		case MergeDriverTheirs:
+			return left, nil
+		case MergeDriverUnionLines:
+			mergeContent = func(_, ours, theirs []byte, _ bool) ([]byte, error) {
+				return UnionLines(ours, theirs), nil
+			}
+		case MergeDriverJSON:
+			mergeContent = MergeJSON
+		default:
+			return fallback(left, right)
+		}
+
+		// content merge need both side exit
+		if left.To() == nil || right.To() == nil {
+			return fallback(left, right)
+		}
+
+		readContent := func(blobHash hash.Hash) ([]byte, *models.Blob, error) {
+			blob, err := fileTreeRepo.Blob(ctx, blobHash)
+			if err != nil {
+				return nil, nil, err
+			}
+			if blob.Size > MaxMergeSize {
+				return nil, nil, errMergeTooLarge
+			}
+			reader, err := store.ReadBlob(ctx, blob, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			defer reader.Close() //nolint
+			// read one more byte to find out content larger than size recorded
+			data, err := io.ReadAll(io.LimitReader(reader, MaxMergeSize+1))
+			if err == nil && int64(len(data)) > MaxMergeSize {
+				return nil, nil, errMergeTooLarge
+			}
+			return data, blob, err
+		}
+
+		basePath, err := ancestorPath(left, right)
+		if err != nil {
+			return nil, err
+		}
+
+		var base, ours, theirs []byte
+		var oursBlob *models.Blob
+		err = func() (err error) {
+			ours, oursBlob, err = readContent(right.To().Hash())
+			if err != nil {
+				return err
+			}
+			theirs, _, err = readContent(left.To().Hash())
+			if err != nil || basePath == nil {
+				return err
+			}
+			base, _, err = readContent(basePath.Hash())
+			return err
+		}()
+		if errors.Is(err, errMergeTooLarge) {
+			return fallback(left, right)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		merged, err := mergeContent(base, ours, theirs, basePath != nil)
+		if errors.Is(err, ErrConflict) {
+			return fallback(left, right)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		blob, err := store.WriteBlob(ctx, bytes.NewReader(merged), int64(len(merged)), oursBlob.Properties)
+		if err != nil {
+			return nil, err
+		}
+		_, err = fileTreeRepo.Insert(ctx, blob.FileTree())
+		if err != nil {
+			return nil, err
+		}
+		return NewBlobResolvedChange(left, right, blob.Hash)
+	}
+}
+
+// UnionLines keep all lines of ours and append lines of theirs which not exit in ours
+func UnionLines(ours, theirs []byte) []byte {
+	oursLines := splitLines(ours)
+	count := make(map[string]int, len(oursLines))
+	for _, line := range oursLines {
+		count[line]++
+	}
+
+	lines := oursLines
+	for _, line := range splitLines(theirs) {
+		if count[line] > 0 {
+			count[line]--
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return []byte{}
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func splitLines(data []byte) []string {
+	content := strings.TrimSuffix(string(data), "\n")
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
+
+// missingValue mark key not exit in json object
+var missingValue = &struct{}{}
+
+// MergeJSON three-way merge json documents, objects are merged key by key, ErrConflict returned when both side change same value differently
+func MergeJSON(base, ours, theirs []byte, hasBase bool) ([]byte, error) {
+	decode := func(data []byte) (interface{}, error) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var value interface{}
+		err := decoder.Decode(&value)
+		return value, err
+	}
+
+	var baseValue interface{} = missingValue
+	if hasBase {
+		var err error
+		baseValue, err = decode(base)
+		if err != nil {
+			// base is not json, merge as both side added
+			baseValue = missingValue
+		}
+	}
+	oursValue, err := decode(ours)
+	if err != nil {
+		return nil, fmt.Errorf("ours is not json %w", ErrConflict)
+	}
+	theirsValue, err := decode(theirs)
+	if err != nil {
+		return nil, fmt.Errorf("theirs is not json %w", ErrConflict)
+	}
+
+	merged, err := mergeJSONValue(baseValue, oursValue, theirsValue)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func mergeJSONValue(base, ours, theirs interface{}) (interface{}, error) {
+	if reflect.DeepEqual(ours, theirs) {
+		return ours, nil
+	}
+	if reflect.DeepEqual(base, ours) {
+		return theirs, nil
+	}
+	if reflect.DeepEqual(base, theirs) {
+		return ours, nil
+	}
+
+	oursObj, oursOk := ours.(map[string]interface{})
+	theirsObj, theirsOk := theirs.(map[string]interface{})
+	if !oursOk || !theirsOk {
+		return nil, ErrConflict
+	}
+	baseObj, _ := base.(map[string]interface{})
+
+	merged := make(map[string]interface{}, len(oursObj))
+	keyValue := func(obj map[string]interface{}, key string) interface{} {
+		if value, ok := obj[key]; ok {
+			return value
+		}
+		return missingValue
+	}
+	for _, obj := range []map[string]interface{}{baseObj, oursObj, theirsObj} {
+		for key := range obj {
+			if _, ok := merged[key]; ok {
+				continue
+			}
+			value, err := mergeJSONValue(keyValue(baseObj, key), keyValue(oursObj, key), keyValue(theirsObj, key))
+			if err != nil {
+				return nil, fmt.Errorf("key %s %w", key, err)
+			}
+			merged[key] = value
+		}
+	}
+
+	for key, value := range merged {
+		if value == missingValue {
+			delete(merged, key)
+		}
+	}
+	return merged, nil
+}
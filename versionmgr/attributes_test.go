@@ -0,0 +1,103 @@
+package versionmgr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAttributes(t *testing.T) {
+	t.Run("match driver", func(t *testing.T) {
+		attrs, err := ParseAttributes(strings.NewReader(`
+# generated files
+*.lock          merge=ours
+logs/*.log      merge=union-lines
+data/**.json    merge=json-merge
+data/raw.json   merge=binary-fail
+/vendor.txt     text merge=theirs
+*.bin           binary
+`))
+		require.NoError(t, err)
+
+		testCases := map[string]MergeDriver{
+			"a.lock":              MergeDriverOurs,
+			"deps/b/c.lock":       MergeDriverOurs,
+			"logs/x.log":          MergeDriverUnionLines,
+			"data/a/b/index.json": MergeDriverJSON,
+			"data/raw.json":       MergeDriverBinaryFail,
+			"vendor.txt":          MergeDriverTheirs,
+		}
+		for path, expect := range testCases {
+			driver, ok := attrs.MergeDriver(path)
+			require.True(t, ok, path)
+			require.Equal(t, expect, driver, path)
+		}
+
+		for _, path := range []string{"a.bin", "logs/a/x.log", "index.json", "sub/vendor.txt"} {
+			_, ok := attrs.MergeDriver(path)
+			require.False(t, ok, path)
+		}
+	})
+
+	t.Run("unknown driver", func(t *testing.T) {
+		_, err := ParseAttributes(strings.NewReader("*.lock merge=mine"))
+		require.ErrorIs(t, err, ErrInvalidAttributes)
+	})
+
+	t.Run("nil attributes", func(t *testing.T) {
+		var attrs *Attributes
+		_, ok := attrs.MergeDriver("a.lock")
+		require.False(t, ok)
+	})
+}
+
+func TestMergeDriverAutoResolve(t *testing.T) {
+	require.True(t, MergeDriverOurs.AutoResolve())
+	require.True(t, MergeDriverJSON.AutoResolve())
+	require.False(t, MergeDriverBinaryFail.AutoResolve())
+	require.False(t, MergeDriver("").AutoResolve())
+}
+
+func TestUnionLines(t *testing.T) {
+	require.Equal(t, "a\nb\nc\nd\n", string(UnionLines([]byte("a\nb\nc\n"), []byte("a\nb\nd"))))
+	require.Equal(t, "a\na\n", string(UnionLines([]byte("a"), []byte("a\na\n"))))
+	require.Equal(t, "b\n", string(UnionLines(nil, []byte("b\n"))))
+	require.Equal(t, "", string(UnionLines(nil, nil)))
+}
+
+func TestMergeJSON(t *testing.T) {
+	decode := func(data []byte) map[string]interface{} {
+		value := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data, &value))
+		return value
+	}
+
+	t.Run("merge different keys", func(t *testing.T) {
+		merged, err := MergeJSON(
+			[]byte(`{"a":1,"b":{"c":1,"d":1},"e":1}`),
+			[]byte(`{"a":2,"b":{"c":2,"d":1},"e":1}`),
+			[]byte(`{"a":1,"b":{"c":1,"d":3},"f":1}`),
+			true,
+		)
+		require.NoError(t, err)
+		require.Equal(t, decode([]byte(`{"a":2,"b":{"c":2,"d":3},"f":1}`)), decode(merged))
+	})
+
+	t.Run("merge both added", func(t *testing.T) {
+		merged, err := MergeJSON(nil, []byte(`{"a":1}`), []byte(`{"b":1}`), false)
+		require.NoError(t, err)
+		require.Equal(t, decode([]byte(`{"a":1,"b":1}`)), decode(merged))
+	})
+
+	t.Run("conflict value", func(t *testing.T) {
+		_, err := MergeJSON([]byte(`{"a":1}`), []byte(`{"a":2}`), []byte(`{"a":3}`), true)
+		require.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		_, err := MergeJSON([]byte(`{"a":1}`), []byte(`a`), []byte(`{"a":3}`), true)
+		require.ErrorIs(t, err, ErrConflict)
+	})
+}
@@ -0,0 +1,242 @@
+package versionmgr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	path2 "path"
+	"strings"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/utils/pathutil"
+	bserv "github.com/ipfs/boxo/blockservice"
+	bstore "github.com/ipfs/boxo/blockstore"
+	dag "github.com/ipfs/boxo/ipld/merkledag"
+	ft "github.com/ipfs/boxo/ipld/unixfs"
+	uio "github.com/ipfs/boxo/ipld/unixfs/io"
+	"github.com/ipfs/go-cid"
+	flatfs "github.com/ipfs/go-ds-flatfs"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	ipld "github.com/ipfs/go-ipld-format"
+	car "github.com/ipld/go-car"
+)
+
+var (
+	ErrCarRootIsFile  = errors.New("root of car is a file, a destination path must be given")
+	ErrInvalidCarPath = errors.New("invalid path in car")
+)
+
+// MaxCarSize car larger than this size is rejected
+var MaxCarSize int64 = 4 << 30
+
+// CarWalk walk files of unixfs dag stored in car file
+type CarWalk struct {
+	dagSrv  ipld.DAGService
+	root    cid.Cid
+	store   *flatfs.Datastore
+	tempDir string
+}
+
+// NewCarWalk load car content into a temporary directory and prepare to walk the unixfs dag in it, only car with single root is supported.
+// Close must be called to remove the temporary directory
+func NewCarWalk(ctx context.Context, reader io.Reader) (_ *CarWalk, err error) {
+	tempDir, err := os.MkdirTemp("", "jiaozifs-car")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(tempDir)
+		}
+	}()
+
+	store, err := flatfs.CreateOrOpen(tempDir, flatfs.NextToLast(2), false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = store.Close()
+		}
+	}()
+
+	// flatfs only accepts keys at root, blocks are not put under namespace
+	bs := bstore.NewBlockstoreNoPrefix(store)
+	header, err := car.LoadCar(ctx, bs, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(header.Roots) != 1 {
+		return nil, fmt.Errorf("car must have exactly one root, but got %d", len(header.Roots))
+	}
+
+	return &CarWalk{
+		dagSrv:  dag.NewDAGService(bserv.New(bs, offline.Exchange(bs))),
+		root:    header.Roots[0],
+		store:   store,
+		tempDir: tempDir,
+	}, nil
+}
+
+// Close remove blocks of car cached on disk
+func (wk *CarWalk) Close() error {
+	err := wk.store.Close()
+	return errors.Join(err, os.RemoveAll(wk.tempDir))
+}
+
+// Walk call fn with every file in dag, path is relative to root directory and is empty when root is a file
+func (wk *CarWalk) Walk(ctx context.Context, fn func(path string, size int64, reader io.Reader) error) error {
+	rootNode, err := wk.dagSrv.Get(ctx, wk.root)
+	if err != nil {
+		return err
+	}
+	return wk.walk(ctx, rootNode, "", fn)
+}
+
+func (wk *CarWalk) walk(ctx context.Context, node ipld.Node, path string, fn func(path string, size int64, reader io.Reader) error) error {
+	if !isUnixfsDir(node) {
+		reader, err := uio.NewDagReader(ctx, node, wk.dagSrv)
+		if err != nil {
+			return err
+		}
+		defer reader.Close() //nolint
+
+		return fn(path, int64(reader.Size()), reader)
+	}
+
+	dir, err := uio.NewDirectoryFromNode(wk.dagSrv, node)
+	if err != nil {
+		return err
+	}
+
+	links, err := dir.Links(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		// names are joined without cleaning, so that a name like .. never escapes its directory
+		if err = validateLinkName(link.Name); err != nil {
+			return err
+		}
+
+		subNode, err := link.GetNode(ctx, wk.dagSrv)
+		if err != nil {
+			return err
+		}
+
+		err = wk.walk(ctx, subNode, path2.Join(path, link.Name), fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLinkName check name of file or directory in car is a valid single segment of object path
+func validateLinkName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") || pathutil.ValidateObjectPath(name) != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidCarPath, name)
+	}
+	return nil
+}
+
+func isUnixfsDir(node ipld.Node) bool {
+	protoNode, ok := node.(*dag.ProtoNode)
+	if !ok {
+		//raw node always be file
+		return false
+	}
+
+	fsNode, err := ft.FSNodeFromBytes(protoNode.Data())
+	if err != nil {
+		return false
+	}
+	return fsNode.IsDir()
+}
+
+// ImportCar write every file in car to storage and add them into wip under destPath.
+// existing file with different content only be replaced when isReplace is true, otherwise ErrEntryExit returned.
+// all files are checked before any blob is written, so a rejected import writes nothing
+func (repository *WorkRepository) ImportCar(ctx context.Context, reader io.Reader, destPath string, isReplace bool) ([]string, error) {
+	if repository.state != InWip {
+		return nil, fmt.Errorf("working repo not in wip state")
+	}
+
+	wk, err := NewCarWalk(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+	defer wk.Close() //nolint
+
+	destPath = CleanPath(destPath)
+	var files []string
+	err = repository.ChangeInWip(ctx, func(workTree *WorkTree) error {
+		// files with the same content as existing ones are skipped
+		oldBlobs := make(map[string]*models.Blob)
+		skipped := make(map[string]bool)
+		err := wk.Walk(ctx, func(path string, _ int64, reader io.Reader) error {
+			fullPath := CleanPath(path2.Join(destPath, path))
+			if len(fullPath) == 0 {
+				return ErrCarRootIsFile
+			}
+
+			oldBlob, _, err := workTree.FindBlob(ctx, fullPath)
+			if errors.Is(err, ErrPathNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			hashReader := hash.NewHashingReader(reader, hash.Md5)
+			_, err = io.Copy(io.Discard, hashReader)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(oldBlob.CheckSum, hashReader.Md5.Sum(nil)) {
+				skipped[fullPath] = true
+				return nil
+			}
+			if !isReplace {
+				return fmt.Errorf("%s %w", fullPath, ErrEntryExit)
+			}
+			oldBlobs[fullPath] = oldBlob
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return wk.Walk(ctx, func(path string, size int64, reader io.Reader) error {
+			fullPath := CleanPath(path2.Join(destPath, path))
+			if !skipped[fullPath] {
+				blob, err := repository.WriteBlob(ctx, reader, size, models.DefaultLeafProperty())
+				if err != nil {
+					return err
+				}
+
+				if oldBlobs[fullPath] == nil {
+					err = workTree.AddLeaf(ctx, fullPath, blob)
+				} else {
+					err = workTree.ReplaceLeaf(ctx, fullPath, blob)
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			files = append(files, fullPath)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,196 @@
+package versionmgr
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/block/mem"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils"
+	bserv "github.com/ipfs/boxo/blockservice"
+	bstore "github.com/ipfs/boxo/blockstore"
+	dag "github.com/ipfs/boxo/ipld/merkledag"
+	ft "github.com/ipfs/boxo/ipld/unixfs"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	ipld "github.com/ipfs/go-ipld-format"
+	car "github.com/ipld/go-car"
+	"github.com/stretchr/testify/require"
+)
+
+func makeCarData(ctx context.Context, rootName string, dirs []string, files map[string][]byte) ([]byte, error) {
+	wk := &mockWalker{dirs: dirs, files: files}
+	archiver := NewRepoArchiver(
+		rootName,
+		wk,
+		func(ctx context.Context, _ *models.Blob, s string) (io.ReadCloser, error) {
+			data, ok := wk.files[s]
+			if !ok {
+				return nil, fmt.Errorf("data not found %s", s)
+			}
+			return utils.CloserWraper{Reader: bytes.NewReader(data)}, nil
+		},
+	)
+
+	buf := bytes.NewBuffer(nil)
+	err := archiver.ArchiveCar(ctx, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// makeCarWithLink make car of a directory with a single file linked by name, name is not checked like archiver does
+func makeCarWithLink(ctx context.Context, name string, content []byte) ([]byte, error) {
+	bs := bstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	dagSrv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+
+	file := dag.NewRawNode(content)
+	dir := ft.EmptyDirNode()
+	err := dir.AddNodeLink(name, file)
+	if err != nil {
+		return nil, err
+	}
+	err = dagSrv.AddMany(ctx, []ipld.Node{file, dir})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err = car.WriteCar(ctx, dagSrv, []cid.Cid{dir.Cid()}, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func TestCarWalk(t *testing.T) {
+	ctx := context.Background()
+	files := map[string][]byte{
+		"1.txt":     []byte("111111111111111111111111"),
+		"a/2.txt":   []byte("222222222222222222222222"),
+		"a/b/4.txt": []byte("4444444444444444444444444444"),
+	}
+	data, err := makeCarData(ctx, "testdir", []string{"a", "a/b"}, files)
+	require.NoError(t, err)
+
+	wk, err := NewCarWalk(ctx, bytes.NewReader(data))
+	require.NoError(t, err)
+	defer wk.Close() //nolint
+
+	actual := map[string][]byte{}
+	err = wk.Walk(ctx, func(path string, size int64, reader io.Reader) error {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		require.Equal(t, int64(len(content)), size)
+		actual[path] = content
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, actual, len(files))
+	for path, content := range files {
+		require.Equal(t, content, actual["testdir/"+path])
+	}
+
+	_, err = NewCarWalk(ctx, bytes.NewReader([]byte("not a car")))
+	require.Error(t, err)
+
+	for _, name := range []string{"..", ".", "", "a/b", "a:b"} {
+		data, err = makeCarWithLink(ctx, name, []byte("escape"))
+		require.NoError(t, err)
+		invalidWk, err := NewCarWalk(ctx, bytes.NewReader(data))
+		require.NoError(t, err)
+		err = invalidWk.Walk(ctx, func(string, int64, io.Reader) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrInvalidCarPath, name)
+		require.NoError(t, invalidWk.Close())
+	}
+}
+
+func TestWorkRepository_ImportCar(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+	repo := models.NewRepo(db)
+
+	user, err := makeUser(ctx, repo.UserRepo(), "admin")
+	require.NoError(t, err)
+
+	project, err := makeRepository(ctx, repo, user, t.Name())
+	require.NoError(t, err)
+
+	adapter := mem.New(ctx)
+	workRepo := NewWorkRepositoryFromAdapter(ctx, user, project, repo, adapter)
+
+	data, err := makeCarData(ctx, "testdir", []string{"a"}, map[string][]byte{
+		"1.txt":   []byte("111111111111111111111111"),
+		"a/2.txt": []byte("222222222222222222222222"),
+	})
+	require.NoError(t, err)
+
+	//not in wip
+	err = workRepo.CheckOut(ctx, InBranch, "main")
+	require.NoError(t, err)
+	_, err = workRepo.ImportCar(ctx, bytes.NewReader(data), "", false)
+	require.Error(t, err)
+
+	_, _, err = workRepo.GetOrCreateWip(ctx)
+	require.NoError(t, err)
+	err = workRepo.CheckOut(ctx, InWip, "main")
+	require.NoError(t, err)
+
+	files, err := workRepo.ImportCar(ctx, bytes.NewReader(data), "x", false)
+	require.NoError(t, err)
+	sort.Strings(files)
+	require.Equal(t, []string{"x/testdir/1.txt", "x/testdir/a/2.txt"}, files)
+
+	workTree, err := workRepo.RootTree(ctx)
+	require.NoError(t, err)
+	blob, _, err := workTree.FindBlob(ctx, "x/testdir/a/2.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(24), blob.Size)
+
+	//same content is skipped
+	_, err = workRepo.ImportCar(ctx, bytes.NewReader(data), "x", false)
+	require.NoError(t, err)
+
+	changedData, err := makeCarData(ctx, "testdir", nil, map[string][]byte{
+		"1.txt": []byte("changed"),
+		"3.txt": []byte("new file"),
+	})
+	require.NoError(t, err)
+
+	_, err = workRepo.ImportCar(ctx, bytes.NewReader(changedData), "x", false)
+	require.ErrorIs(t, err, ErrEntryExit)
+	//rejected import write nothing
+	for _, content := range []string{"changed", "new file"} {
+		checkSum := md5.Sum([]byte(content))
+		_, err = workRepo.ReadBlob(ctx, &models.Blob{CheckSum: checkSum[:], Size: int64(len(content))}, nil)
+		require.Error(t, err)
+	}
+
+	escapeData, err := makeCarWithLink(ctx, "..", []byte("escape"))
+	require.NoError(t, err)
+	_, err = workRepo.ImportCar(ctx, bytes.NewReader(escapeData), "x", false)
+	require.ErrorIs(t, err, ErrInvalidCarPath)
+
+	_, err = workRepo.ImportCar(ctx, bytes.NewReader(changedData), "x", true)
+	require.NoError(t, err)
+
+	workTree, err = workRepo.RootTree(ctx)
+	require.NoError(t, err)
+	blob, _, err = workTree.FindBlob(ctx, "x/testdir/1.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(len("changed")), blob.Size)
+}
@@ -129,6 +129,8 @@ type ChangePair struct {
 	Left       IChange
 	Right      IChange
 	IsConflict bool
+	// MergeDriver merge driver in attributes used to merge conflict path
+	MergeDriver MergeDriver
 }
 
 func (changePair ChangePair) Path() string {
@@ -0,0 +1,32 @@
+package versionmgr
+
+import (
+	"io"
+
+	bserv "github.com/ipfs/boxo/blockservice"
+	bstore "github.com/ipfs/boxo/blockstore"
+	chunker "github.com/ipfs/boxo/chunker"
+	dag "github.com/ipfs/boxo/ipld/merkledag"
+	importer "github.com/ipfs/boxo/ipld/unixfs/importer"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+)
+
+// CalculateFileCid build unixfs dag of content in the same way as ArchiveCar, blocks are discarded and only the root cid
+// and cumulative size of dag returned
+func CalculateFileCid(reader io.Reader) (cid.Cid, uint64, error) {
+	bs := bstore.NewBlockstore(ds.NewNullDatastore())
+	dagSrv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+
+	nd, err := importer.BuildDagFromReader(dagSrv, chunker.DefaultSplitter(reader))
+	if err != nil {
+		return cid.Undef, 0, err
+	}
+
+	size, err := nd.Size()
+	if err != nil {
+		return cid.Undef, 0, err
+	}
+	return nd.Cid(), size, nil
+}
@@ -0,0 +1,50 @@
+package versionmgr
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateCid(t *testing.T) {
+	ctx := context.Background()
+	files := map[string][]byte{
+		"1.txt":   []byte("111111111111111111111111"),
+		"a/2.txt": bytes.Repeat([]byte("2"), 1024*1024), //bigger than default chunk size
+	}
+	data, err := makeCarData(ctx, "testdir", []string{"a"}, files)
+	require.NoError(t, err)
+
+	wk, err := NewCarWalk(ctx, bytes.NewReader(data))
+	require.NoError(t, err)
+	defer wk.Close() //nolint
+
+	fileEntry := func(name string, content []byte) models.TreeEntry {
+		fileCid, size, err := CalculateFileCid(bytes.NewReader(content))
+		require.NoError(t, err)
+		return models.TreeEntry{Name: name, Cid: fileCid.String(), CidSize: size}
+	}
+	dirEntry := func(name string, subObjects ...models.TreeEntry) models.TreeEntry {
+		node, err := models.NewTreeNode(models.DefaultDirProperty(), uuid.New(), subObjects...)
+		require.NoError(t, err)
+		require.NotEmpty(t, node.Cid)
+		return models.TreeEntry{Name: name, IsDir: true, Cid: node.Cid, CidSize: node.CidSize}
+	}
+
+	root := dirEntry("",
+		dirEntry("testdir",
+			fileEntry("1.txt", files["1.txt"]),
+			dirEntry("a", fileEntry("2.txt", files["a/2.txt"])),
+		),
+	)
+	require.Equal(t, wk.root.String(), root.Cid)
+
+	//tree without cid in sub objects
+	node, err := models.NewTreeNode(models.DefaultDirProperty(), uuid.New(), models.TreeEntry{Name: "a.txt"})
+	require.NoError(t, err)
+	require.Empty(t, node.Cid)
+}
@@ -0,0 +1,82 @@
+package versionmgr
+
+import (
+	"context"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/GitDataAI/jiaozifs/versionmgr/merkletrie"
+)
+
+// ComputeCommitStats count files and bytes changed by commit against parentTree, parentTree of first commit is hash.Empty.
+// modified file count its new size as added bytes and its old size as removed bytes
+func ComputeCommitStats(ctx context.Context, object models.IFileTreeRepo, commit *models.Commit, parentTree hash.Hash) (*models.CommitStats, error) {
+	stats := &models.CommitStats{
+		RepositoryID: commit.RepositoryID,
+		CommitHash:   commit.Hash,
+		AuthorName:   commit.Author.Name,
+		AuthorEmail:  commit.Author.Email,
+		IsMerge:      len(commit.ParentHashes) > 1,
+		CommittedAt:  commit.Committer.When,
+		CreatedAt:    time.Now(),
+	}
+
+	workTree, err := NewWorkTree(ctx, object, models.NewRootTreeEntry(parentTree))
+	if err != nil {
+		return nil, err
+	}
+	changes, err := workTree.Diff(ctx, commit.TreeHash, "")
+	if err != nil {
+		return nil, err
+	}
+
+	blobSize := func(h []byte) (int64, error) {
+		blob, err := object.Blob(ctx, h)
+		if err != nil {
+			return 0, err
+		}
+		return blob.Size, nil
+	}
+	err = changes.ForEach(func(change IChange) error {
+		action, err := change.Action()
+		if err != nil {
+			return err
+		}
+
+		var added, removed int64
+		switch action {
+		case merkletrie.Insert:
+			stats.FilesAdded++
+			added, err = blobSize(change.To().Hash())
+		case merkletrie.Delete:
+			stats.FilesRemoved++
+			removed, err = blobSize(change.From().Hash())
+		case merkletrie.Modify:
+			stats.FilesModified++
+			added, err = blobSize(change.To().Hash())
+			if err == nil {
+				removed, err = blobSize(change.From().Hash())
+			}
+		}
+		if err != nil {
+			return err
+		}
+		stats.BytesAdded += added
+		stats.BytesRemoved += removed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// saveCommitStats compute and save stats of commit just created, so that activities never need to diff commits again
+func saveCommitStats(ctx context.Context, repo models.IRepo, commit *models.Commit, parentTree hash.Hash) error {
+	stats, err := ComputeCommitStats(ctx, repo.FileTreeRepo(commit.RepositoryID), commit, parentTree)
+	if err != nil {
+		return err
+	}
+	return repo.CommitStatsRepo().Insert(ctx, stats)
+}
@@ -0,0 +1,68 @@
+package versionmgr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeCommitStats(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repoID := uuid.New()
+	objRepo := models.NewFileTree(db, repoID)
+
+	workTree, err := NewWorkTree(ctx, objRepo, EmptyDirEntry)
+	require.NoError(t, err)
+
+	newBlob := func(content string) *models.Blob {
+		blob, err := models.NewBlob(models.DefaultLeafProperty(), repoID, hash.Hash(content), int64(len(content)))
+		require.NoError(t, err)
+		return blob
+	}
+	addFile := func(path string, content string) {
+		require.NoError(t, workTree.AddLeaf(ctx, path, newBlob(content)))
+	}
+	addFile("a.txt", "a")
+	addFile("data/b.csv", "1111")
+	addFile("data/c.csv", "22")
+	parentTree := workTree.Root().Hash()
+
+	require.NoError(t, workTree.ReplaceLeaf(ctx, "a.txt", newBlob("abc")))
+	addFile("d.txt", "dddd")
+	require.NoError(t, workTree.RemoveEntry(ctx, "data/c.csv"))
+
+	commit := &models.Commit{
+		Hash:         hash.Hash("commit"),
+		RepositoryID: repoID,
+		Author:       models.Signature{Name: "author", Email: "author@example.com"},
+		Committer:    models.Signature{Name: "committer", When: time.Now()},
+		TreeHash:     workTree.Root().Hash(),
+		ParentHashes: []hash.Hash{hash.Hash("parent")},
+	}
+
+	stats, err := ComputeCommitStats(ctx, objRepo, commit, parentTree)
+	require.NoError(t, err)
+	require.Equal(t, "author", stats.AuthorName)
+	require.False(t, stats.IsMerge)
+	require.Equal(t, int64(1), stats.FilesAdded)
+	require.Equal(t, int64(1), stats.FilesModified)
+	require.Equal(t, int64(1), stats.FilesRemoved)
+	require.Equal(t, int64(7), stats.BytesAdded)
+	require.Equal(t, int64(3), stats.BytesRemoved)
+
+	// first commit add all files
+	stats, err = ComputeCommitStats(ctx, objRepo, commit, hash.Empty)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), stats.FilesAdded)
+	require.Equal(t, int64(0), stats.FilesRemoved)
+	require.Equal(t, int64(8), stats.BytesAdded)
+}
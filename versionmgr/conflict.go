@@ -2,12 +2,24 @@ package versionmgr
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/GitDataAI/jiaozifs/utils/hash"
 	"github.com/GitDataAI/jiaozifs/versionmgr/merkletrie"
+	"github.com/GitDataAI/jiaozifs/versionmgr/merkletrie/noder"
 )
 
+// decisions of conflict path, besides these decisions hash of blob can be used as the content of path
+const (
+	ResolveLeft   = "left"
+	ResolveRight  = "right"
+	ResolveDelete = "delete"
+)
+
+// ErrInvalidResolution conflict resolution is neither a side nor an existing blob, or not applicable to the conflict
+var ErrInvalidResolution = errors.New("invalid conflict resolution")
+
 // ConflictResolver resolve conflict between two change
 type ConflictResolver func(left IChange, right IChange) (IChange, error)
 
@@ -60,11 +72,151 @@ func OneSideResolver(useLeft bool) ConflictResolver {
 	}
 }
 
+// ResolveFromSelector resolve conflict by the decision of each path, decision is left, right, delete or hash of blob.
+// path without decision use right change
 func ResolveFromSelector(resolveMsg map[string]string) ConflictResolver {
 	return func(left IChange, right IChange) (IChange, error) {
-		if resolveMsg[left.Path()] == "left" {
+		switch decision := resolveMsg[left.Path()]; decision {
+		case ResolveLeft:
 			return left, nil
+		case "", ResolveRight:
+			return right, nil
+		case ResolveDelete:
+			return NewDeleteResolvedChange(left, right)
+		default:
+			blobHash, err := hash.FromHex(decision)
+			if err != nil {
+				return nil, fmt.Errorf("%s of path %s %w", decision, left.Path(), ErrInvalidResolution)
+			}
+			return NewBlobResolvedChange(left, right, blobHash)
 		}
-		return right, nil
 	}
 }
+
+var _ IChange = (*ResolvedChange)(nil)
+
+// ResolvedChange change of conflict path made by merger rather than picked from one side
+type ResolvedChange struct {
+	action merkletrie.Action
+	path   string
+	from   noder.Path
+	to     noder.Path
+}
+
+// NewDeleteResolvedChange remove conflict path from merge result, return nil change if path not exit in ancestor,
+// in this case both side insert the path and nothing need to be applied
+func NewDeleteResolvedChange(left IChange, right IChange) (IChange, error) {
+	from, err := ancestorPath(left, right)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, nil
+	}
+	return &ResolvedChange{action: merkletrie.Delete, path: left.Path(), from: from}, nil
+}
+
+// NewBlobResolvedChange use blob as the content of conflict path in merge result
+func NewBlobResolvedChange(left IChange, right IChange, blobHash hash.Hash) (IChange, error) {
+	from, err := ancestorPath(left, right)
+	if err != nil {
+		return nil, err
+	}
+
+	sideTo := left.To()
+	if sideTo == nil {
+		sideTo = right.To()
+	}
+	if sideTo == nil {
+		return nil, fmt.Errorf("both sides of path %s are deleted %w", left.Path(), ErrInvalidResolution)
+	}
+
+	to := make(noder.Path, len(sideTo))
+	copy(to, sideTo)
+	to[len(to)-1] = &blobNoder{name: sideTo.Name(), hash: blobHash}
+
+	action := merkletrie.Modify
+	if from == nil {
+		action = merkletrie.Insert
+	}
+	return &ResolvedChange{action: action, path: left.Path(), from: from, to: to}, nil
+}
+
+// ancestorPath return the path in common ancestor, nil means path inserted by both side
+func ancestorPath(left IChange, right IChange) (noder.Path, error) {
+	leftAction, err := left.Action()
+	if err != nil {
+		return nil, err
+	}
+	if leftAction == merkletrie.Insert {
+		return nil, nil
+	}
+	if left.From() != nil {
+		return left.From(), nil
+	}
+	return right.From(), nil
+}
+
+// Action return change action
+func (c *ResolvedChange) Action() (merkletrie.Action, error) {
+	return c.action, nil
+}
+
+// From return change from
+func (c *ResolvedChange) From() noder.Path {
+	return c.from
+}
+
+// To return change to
+func (c *ResolvedChange) To() noder.Path {
+	return c.to
+}
+
+// Path return change path
+func (c *ResolvedChange) Path() string {
+	return c.path
+}
+
+func (c *ResolvedChange) String() string {
+	return fmt.Sprintf("<%s %s>", c.action, c.path)
+}
+
+var _ noder.Noder = (*blobNoder)(nil)
+
+// blobNoder file node point to blob chosen by merger
+type blobNoder struct {
+	name string
+	hash hash.Hash
+}
+
+func (n *blobNoder) Hash() []byte {
+	return n.hash
+}
+
+func (n *blobNoder) String() string {
+	return n.name
+}
+
+func (n *blobNoder) Name() string {
+	return n.name
+}
+
+func (n *blobNoder) IsDir() bool {
+	return false
+}
+
+func (n *blobNoder) Children() ([]noder.Noder, error) {
+	return noder.NoChildren, nil
+}
+
+func (n *blobNoder) NumChildren() (int, error) {
+	return 0, nil
+}
+
+func (n *blobNoder) Skip() bool {
+	return false
+}
+
+func (n *blobNoder) Equal(other noder.Noder) bool {
+	return bytes.Equal(n.Hash(), other.Hash())
+}
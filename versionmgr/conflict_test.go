@@ -1,6 +1,7 @@
 package versionmgr
 
 import (
+	"encoding/hex"
 	"testing"
 
 	"github.com/GitDataAI/jiaozifs/versionmgr/merkletrie"
@@ -124,3 +125,72 @@ func TestResolveFromSelector(t *testing.T) {
 	}
 	require.False(t, iter.Has())
 }
+
+func TestResolveFromSelectorWithDecision(t *testing.T) {
+	resolver := ResolveFromSelector(map[string]string{
+		"a.txt": "delete",
+		"b.txt": "delete",
+		"c.txt": hex.EncodeToString([]byte("h3")),
+		"d.txt": hex.EncodeToString([]byte("h3")),
+		"e.txt": "middle",
+	})
+	t.Run("delete modified path", func(t *testing.T) {
+		ch1, err := makeMockChange("3|a.txt|h1")
+		require.NoError(t, err)
+		ch2, err := makeMockChange("2|a.txt|h2")
+		require.NoError(t, err)
+
+		selectCh, err := resolver(ch1, ch2)
+		require.NoError(t, err)
+		selectAct, err := selectCh.Action()
+		require.NoError(t, err)
+		require.Equal(t, merkletrie.Delete, selectAct)
+		require.Equal(t, "a.txt", selectCh.From().String())
+	})
+	t.Run("delete path inserted by both side", func(t *testing.T) {
+		ch1, err := makeMockChange("1|b.txt|h1")
+		require.NoError(t, err)
+		ch2, err := makeMockChange("1|b.txt|h2")
+		require.NoError(t, err)
+
+		selectCh, err := resolver(ch1, ch2)
+		require.NoError(t, err)
+		require.Nil(t, selectCh)
+	})
+	t.Run("use blob for inserted path", func(t *testing.T) {
+		ch1, err := makeMockChange("1|c.txt|h1")
+		require.NoError(t, err)
+		ch2, err := makeMockChange("1|c.txt|h2")
+		require.NoError(t, err)
+
+		selectCh, err := resolver(ch1, ch2)
+		require.NoError(t, err)
+		selectAct, err := selectCh.Action()
+		require.NoError(t, err)
+		require.Equal(t, merkletrie.Insert, selectAct)
+		require.Equal(t, "h3", string(selectCh.To().Hash()))
+		require.Equal(t, "c.txt", selectCh.To().String())
+	})
+	t.Run("use blob for deleted path", func(t *testing.T) {
+		ch1, err := makeMockChange("2|d.txt|h1")
+		require.NoError(t, err)
+		ch2, err := makeMockChange("3|d.txt|h2")
+		require.NoError(t, err)
+
+		selectCh, err := resolver(ch1, ch2)
+		require.NoError(t, err)
+		selectAct, err := selectCh.Action()
+		require.NoError(t, err)
+		require.Equal(t, merkletrie.Modify, selectAct)
+		require.Equal(t, "h3", string(selectCh.To().Hash()))
+	})
+	t.Run("invalid decision", func(t *testing.T) {
+		ch1, err := makeMockChange("1|e.txt|h1")
+		require.NoError(t, err)
+		ch2, err := makeMockChange("1|e.txt|h2")
+		require.NoError(t, err)
+
+		_, err = resolver(ch1, ch2)
+		require.ErrorIs(t, err, ErrInvalidResolution)
+	})
+}
@@ -58,6 +58,28 @@ func (c *WrapCommitNode) IsAncestor(ctx context.Context, other *WrapCommitNode)
 	return found, err
 }
 
+// CountExclusive returns the number of commits reachable from the actual commit but not from the passed one.
+// It mimics the behavior of `git rev-list --count other..actual`, nil other count all ancestors of actual commit
+func (c *WrapCommitNode) CountExclusive(ctx context.Context, other *WrapCommitNode) (int, error) {
+	otherHistory := map[string]bool{}
+	if other != nil {
+		err := NewCommitIterBSF(ctx, other, nil, nil).ForEach(func(commit *WrapCommitNode) error {
+			otherHistory[commit.Commit().Hash.Hex()] = true
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	count := 0
+	err := NewCommitIterBSF(ctx, c, otherHistory, nil).ForEach(func(_ *WrapCommitNode) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
 // ancestorsIndex returns a map with the ancestors of the starting commit if the
 // excluded one is not one of them. It returns errIsReachable if the excluded commit
 // is ancestor of the starting, or another error if the history is not traversable.
@@ -68,6 +68,16 @@ e|b
 		require.Len(t, ancestorNode, 1)
 		require.Equal(t, "b", string(ancestorNode[0].Commit().Hash))
 	})
+
+	t.Run("count exclusive", func(t *testing.T) {
+		count, err := commitMap["f"].CountExclusive(ctx, commitMap["e"])
+		require.NoError(t, err)
+		require.Equal(t, 5, count)
+
+		count, err = commitMap["f1"].CountExclusive(ctx, commitMap["f"])
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
 }
 
 func loadCommitTestData(ctx context.Context, commitRepo models.ICommitRepo, testData string) (map[string]*WrapCommitNode, error) {
@@ -0,0 +1,213 @@
+package versionmgr
+
+import (
+	"context"
+	"path"
+	"sort"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	lru "github.com/hnlq715/golang-lru"
+)
+
+// MaxLargestEntries max count of largest files and directories kept in tree stats
+const MaxLargestEntries = 100
+
+const treeStatsCacheSize = 4096
+
+// trees are addressed by the hash of their content, so stats of a tree hash never change
+var treeStatsCache, _ = lru.New(treeStatsCacheSize)
+
+// SizeEntry size of file or directory, FileCount of file is 1
+type SizeEntry struct {
+	Path      string
+	Size      int64
+	FileCount int64
+}
+
+// TreeStats size summary of tree. largest files and directories are ordered by size desc, paths are relative to tree
+type TreeStats struct {
+	Size         int64
+	FileCount    int64
+	DirCount     int64
+	LargestFiles []SizeEntry
+	LargestDirs  []SizeEntry
+}
+
+func (stats *TreeStats) clone() *TreeStats {
+	cloned := *stats
+	cloned.LargestFiles = append([]SizeEntry(nil), stats.LargestFiles...)
+	cloned.LargestDirs = append([]SizeEntry(nil), stats.LargestDirs...)
+	return &cloned
+}
+
+// SizeNode size of entry in directory tree, children are ordered by size desc
+type SizeNode struct {
+	Name      string
+	Path      string
+	IsDir     bool
+	Size      int64
+	FileCount int64
+	Children  []*SizeNode
+	// Truncated some children are omitted because of limit
+	Truncated bool
+}
+
+// Stats return size summary of directory at fullPath, callers are free to modify it
+func (workTree *WorkTree) Stats(ctx context.Context, fullPath string) (*TreeStats, error) {
+	node, err := workTree.SubTree(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := treeStats(ctx, workTree.object, node)
+	if err != nil {
+		return nil, err
+	}
+	return stats.clone(), nil
+}
+
+// SizeTree return size of directory at fullPath and its entries down to depth levels, each directory keep at most limit largest children.
+// non-positive limit means no limit
+func (workTree *WorkTree) SizeTree(ctx context.Context, fullPath string, depth int, limit int) (*SizeNode, error) {
+	fullPath = CleanPath(fullPath)
+	node, err := workTree.SubTree(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := treeStats(ctx, workTree.object, node)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &SizeNode{
+		Name:      path.Base("/" + fullPath),
+		Path:      fullPath,
+		IsDir:     true,
+		Size:      stats.Size,
+		FileCount: stats.FileCount,
+	}
+	if len(fullPath) == 0 {
+		root.Name = ""
+	}
+	return root, workTree.expandSizeNode(ctx, root, node, depth, limit)
+}
+
+func (workTree *WorkTree) expandSizeNode(ctx context.Context, sizeNode *SizeNode, node *TreeNode, depth int, limit int) error {
+	if depth <= 0 {
+		return nil
+	}
+
+	type child struct {
+		sizeNode *SizeNode
+		treeNode *TreeNode
+	}
+	children := make([]child, 0, len(node.SubObjects()))
+	for _, entry := range node.SubObjects() {
+		childNode := &SizeNode{
+			Name:  entry.Name,
+			Path:  path.Join(sizeNode.Path, entry.Name),
+			IsDir: entry.IsDir,
+		}
+		if !entry.IsDir {
+			blob, err := workTree.object.Blob(ctx, entry.Hash)
+			if err != nil {
+				return err
+			}
+			childNode.Size, childNode.FileCount = blob.Size, 1
+			children = append(children, child{sizeNode: childNode})
+			continue
+		}
+
+		subNode, err := NewTreeNode(ctx, entry, workTree.object)
+		if err != nil {
+			return err
+		}
+		stats, err := treeStats(ctx, workTree.object, subNode)
+		if err != nil {
+			return err
+		}
+		childNode.Size, childNode.FileCount = stats.Size, stats.FileCount
+		children = append(children, child{sizeNode: childNode, treeNode: subNode})
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].sizeNode.Size > children[j].sizeNode.Size
+	})
+	if limit > 0 && len(children) > limit {
+		children = children[:limit]
+		sizeNode.Truncated = true
+	}
+
+	sizeNode.Children = make([]*SizeNode, 0, len(children))
+	for _, c := range children {
+		if c.treeNode != nil {
+			err := workTree.expandSizeNode(ctx, c.sizeNode, c.treeNode, depth-1, limit)
+			if err != nil {
+				return err
+			}
+		}
+		sizeNode.Children = append(sizeNode.Children, c.sizeNode)
+	}
+	return nil
+}
+
+// treeStats compute stats of tree from stats of its sub trees, stats of every tree are cached by tree hash.
+// stats returned are shared by cache, they must not be modified
+func treeStats(ctx context.Context, object models.IFileTreeRepo, node *TreeNode) (*TreeStats, error) {
+	key := node.TreeNode().Hash.Hex()
+	if value, ok := treeStatsCache.Get(key); ok {
+		return value.(*TreeStats), nil
+	}
+
+	stats := &TreeStats{}
+	for _, entry := range node.SubObjects() {
+		if !entry.IsDir {
+			blob, err := object.Blob(ctx, entry.Hash)
+			if err != nil {
+				return nil, err
+			}
+			stats.Size += blob.Size
+			stats.FileCount++
+			stats.LargestFiles = append(stats.LargestFiles, SizeEntry{Path: entry.Name, Size: blob.Size, FileCount: 1})
+			continue
+		}
+
+		subNode, err := NewTreeNode(ctx, entry, object)
+		if err != nil {
+			return nil, err
+		}
+		subStats, err := treeStats(ctx, object, subNode)
+		if err != nil {
+			return nil, err
+		}
+		stats.Size += subStats.Size
+		stats.FileCount += subStats.FileCount
+		stats.DirCount += subStats.DirCount + 1
+		stats.LargestDirs = append(stats.LargestDirs, SizeEntry{Path: entry.Name, Size: subStats.Size, FileCount: subStats.FileCount})
+		for _, dir := range subStats.LargestDirs {
+			stats.LargestDirs = append(stats.LargestDirs, SizeEntry{Path: path.Join(entry.Name, dir.Path), Size: dir.Size, FileCount: dir.FileCount})
+		}
+		for _, file := range subStats.LargestFiles {
+			stats.LargestFiles = append(stats.LargestFiles, SizeEntry{Path: path.Join(entry.Name, file.Path), Size: file.Size, FileCount: 1})
+		}
+	}
+	stats.LargestFiles = largestEntries(stats.LargestFiles)
+	stats.LargestDirs = largestEntries(stats.LargestDirs)
+
+	treeStatsCache.Add(key, stats)
+	return stats, nil
+}
+
+func largestEntries(entries []SizeEntry) []SizeEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size == entries[j].Size {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Size > entries[j].Size
+	})
+	if len(entries) > MaxLargestEntries {
+		// copy to release the rest entries, result is kept in cache
+		return append([]SizeEntry(nil), entries[:MaxLargestEntries]...)
+	}
+	return entries
+}
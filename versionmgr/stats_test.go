@@ -0,0 +1,113 @@
+package versionmgr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/GitDataAI/jiaozifs/utils/hash"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeStats(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repoID := uuid.New()
+	objRepo := models.NewFileTree(db, repoID)
+
+	workTree, err := NewWorkTree(ctx, objRepo, EmptyDirEntry)
+	require.NoError(t, err)
+
+	addFile := func(path string, content string) {
+		blob, err := models.NewBlob(models.DefaultLeafProperty(), repoID, hash.Hash(content), int64(len(content)))
+		require.NoError(t, err)
+		require.NoError(t, workTree.AddLeaf(ctx, path, blob))
+	}
+	addFile("a.txt", "a")
+	addFile("data/train/1.csv", "1111")
+	addFile("data/train/2.csv", "22")
+	addFile("data/test/1.csv", "333")
+	addFile("data/README.md", "readme")
+	// same content is counted once in unique blobs
+	addFile("copy/README.md", "readme")
+
+	stats, err := workTree.Stats(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(22), stats.Size)
+	require.Equal(t, int64(6), stats.FileCount)
+	require.Equal(t, int64(4), stats.DirCount)
+	require.Equal(t, []SizeEntry{
+		{Path: "copy/README.md", Size: 6, FileCount: 1},
+		{Path: "data/README.md", Size: 6, FileCount: 1},
+		{Path: "data/train/1.csv", Size: 4, FileCount: 1},
+		{Path: "data/test/1.csv", Size: 3, FileCount: 1},
+		{Path: "data/train/2.csv", Size: 2, FileCount: 1},
+		{Path: "a.txt", Size: 1, FileCount: 1},
+	}, stats.LargestFiles)
+	require.Equal(t, []SizeEntry{
+		{Path: "data", Size: 15, FileCount: 4},
+		{Path: "copy", Size: 6, FileCount: 1},
+		{Path: "data/train", Size: 6, FileCount: 2},
+		{Path: "data/test", Size: 3, FileCount: 1},
+	}, stats.LargestDirs)
+
+	stats, err = workTree.Stats(ctx, "data/train")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), stats.Size)
+	require.Equal(t, "1.csv", stats.LargestFiles[0].Path)
+
+	// stats in cache are not changed by callers
+	stats.LargestFiles[0].Path = "changed"
+	stats, err = workTree.Stats(ctx, "data/train")
+	require.NoError(t, err)
+	require.Equal(t, "1.csv", stats.LargestFiles[0].Path)
+
+	_, err = workTree.Stats(ctx, "a.txt")
+	require.ErrorIs(t, err, ErrNotDirectory)
+
+	storedBytes, storedObjects, err := objRepo.StoredBlobs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(16), storedBytes)
+	require.Equal(t, int64(5), storedObjects)
+
+	t.Run("size tree", func(t *testing.T) {
+		sizeTree, err := workTree.SizeTree(ctx, "", 1, 0)
+		require.NoError(t, err)
+		require.Equal(t, "", sizeTree.Name)
+		require.Equal(t, int64(22), sizeTree.Size)
+		require.False(t, sizeTree.Truncated)
+		require.Len(t, sizeTree.Children, 3)
+		require.Equal(t, "data", sizeTree.Children[0].Path)
+		require.True(t, sizeTree.Children[0].IsDir)
+		require.Equal(t, int64(4), sizeTree.Children[0].FileCount)
+		require.Empty(t, sizeTree.Children[0].Children)
+		require.Equal(t, "a.txt", sizeTree.Children[2].Path)
+
+		sizeTree, err = workTree.SizeTree(ctx, "data", 2, 2)
+		require.NoError(t, err)
+		require.Equal(t, "data", sizeTree.Name)
+		require.True(t, sizeTree.Truncated)
+		require.Len(t, sizeTree.Children, 2)
+		require.Equal(t, "data/README.md", sizeTree.Children[0].Path)
+		require.Equal(t, "data/train", sizeTree.Children[1].Path)
+		require.Len(t, sizeTree.Children[1].Children, 2)
+		require.Equal(t, "data/train/1.csv", sizeTree.Children[1].Children[0].Path)
+	})
+}
+
+func TestLargestEntries(t *testing.T) {
+	entries := make([]SizeEntry, 0, MaxLargestEntries*2)
+	for i := 0; i < MaxLargestEntries*2; i++ {
+		entries = append(entries, SizeEntry{Path: fmt.Sprintf("%d.txt", i), Size: int64(i)})
+	}
+
+	largest := largestEntries(entries)
+	require.Len(t, largest, MaxLargestEntries)
+	require.Equal(t, int64(MaxLargestEntries*2-1), largest[0].Size)
+	require.Equal(t, int64(MaxLargestEntries), largest[MaxLargestEntries-1].Size)
+}
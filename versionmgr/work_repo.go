@@ -18,9 +18,12 @@ import (
 	"github.com/GitDataAI/jiaozifs/block/factory"
 	"github.com/GitDataAI/jiaozifs/block/params"
 	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/protection"
+	"github.com/GitDataAI/jiaozifs/quota"
 	"github.com/GitDataAI/jiaozifs/utils/hash"
 	"github.com/GitDataAI/jiaozifs/utils/httputil"
 	"github.com/GitDataAI/jiaozifs/utils/pathutil"
+	"github.com/GitDataAI/jiaozifs/webhook"
 	logging "github.com/ipfs/go-log/v2"
 )
 
@@ -40,6 +43,8 @@ type WorkRepository struct {
 	repoModel *models.Repository
 	adapter   block.Adapter
 	repo      models.IRepo
+	emitter   webhook.Emitter
+	quota     *quota.Manager
 	state     WorkRepoState
 	//cache
 	headTree *hash.Hash
@@ -68,9 +73,22 @@ func NewWorkRepositoryFromAdapter(_ context.Context, operator *models.User, repo
 		operator:  operator,
 		repoModel: repoModel,
 		repo:      repo, adapter: adapter,
+		emitter: webhook.NoopEmitter{},
 	}
 }
 
+// WithEmitter set emitter to notify events like commit, branch and tag creation happened in repository
+func (repository *WorkRepository) WithEmitter(emitter webhook.Emitter) *WorkRepository {
+	repository.emitter = emitter
+	return repository
+}
+
+// WithQuota set quota manager to limit and account storage used by blobs written into repository
+func (repository *WorkRepository) WithQuota(manager *quota.Manager) *WorkRepository {
+	repository.quota = manager
+	return repository
+}
+
 // WriteBlob write blob content to storage
 func (repository *WorkRepository) WriteBlob(ctx context.Context, body io.Reader, contentLength int64, properties models.Property) (*models.Blob, error) {
 	// handle the upload itself
@@ -96,17 +114,47 @@ func (repository *WorkRepository) WriteBlob(ctx context.Context, body io.Reader,
 		_ = os.RemoveAll(name)
 	}()
 
-	address := pathutil.PathOfHash(checkSum)
-	err = repository.adapter.Put(ctx, block.ObjectPointer{
+	fileCid, cidSize, err := CalculateFileCid(tempf)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tempf.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	pointer := block.ObjectPointer{
 		StorageNamespace: utils.StringValue(repository.repoModel.StorageNamespace),
 		IdentifierType:   block.IdentifierTypeRelative,
-		Identifier:       address,
-	}, contentLength, tempf, block.PutOpts{})
+		Identifier:       pathutil.PathOfHash(checkSum),
+	}
+	// blobs are addressed by checksum, only content not charged yet takes more space.
+	// usage is reserved before upload so that concurrent uploads can not exceed limits together
+	reserved := false
+	if repository.quota != nil {
+		reserved, err = repository.quota.Reserve(ctx, repository.repoModel, checkSum, hashReader.CopiedSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = repository.adapter.Put(ctx, pointer, contentLength, tempf, block.PutOpts{})
 	if err != nil {
+		if reserved {
+			if releaseErr := repository.quota.Unreserve(ctx, repository.repoModel, checkSum, hashReader.CopiedSize); releaseErr != nil {
+				return nil, errors.Join(err, releaseErr)
+			}
+		}
 		return nil, err
 	}
 
-	return models.NewBlob(properties, repository.repoModel.ID, checkSum, hashReader.CopiedSize)
+	blob, err := models.NewBlob(properties, repository.repoModel.ID, checkSum, hashReader.CopiedSize)
+	if err != nil {
+		return nil, err
+	}
+	blob.Cid = fileCid.String()
+	blob.CidSize = cidSize
+	return blob, nil
 }
 
 // ReadBlob read blob content with range
@@ -141,6 +189,45 @@ func (repository *WorkRepository) ReadBlob(ctx context.Context, blob *models.Blo
 	return reader, nil
 }
 
+// BlobReaderAt return io.ReaderAt of blob content, each read is a range read to storage
+func (repository *WorkRepository) BlobReaderAt(ctx context.Context, blob *models.Blob) io.ReaderAt {
+	return &blobReaderAt{ctx: ctx, repository: repository, blob: blob}
+}
+
+type blobReaderAt struct {
+	ctx        context.Context
+	repository *WorkRepository
+	blob       *models.Blob
+}
+
+func (r *blobReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.blob.Size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	end := off + int64(len(p)) - 1
+	if end >= r.blob.Size {
+		end = r.blob.Size - 1
+	}
+	reader, err := r.repository.ReadBlob(r.ctx, r.blob, utils.String(fmt.Sprintf("bytes=%d-%d", off, end)))
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close() //nolint
+
+	n, err := io.ReadFull(reader, p[:end-off+1])
+	if err != nil {
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
 // RootTree return worktree at root
 func (repository *WorkRepository) RootTree(ctx context.Context) (*WorkTree, error) {
 	return repository.rootTree(ctx, repository.repo)
@@ -378,6 +465,8 @@ func (repository *WorkRepository) CommitChanges(ctx context.Context, msg string)
 	repository.branch.CommitHash = commit.Hash
 	repository.wip.BaseCommit = commit.Hash
 	repository.headTree = &repository.wip.CurrentTree
+	repository.emitter.Emit(ctx, repository.repoModel, repository.operator, models.WipCommitEvent, &webhook.CommitPayload{Branch: repository.branch, Commit: commit, Wip: repository.wip})
+	repository.emitter.Emit(ctx, repository.repoModel, repository.operator, models.PushEvent, &webhook.CommitPayload{Branch: repository.branch, Commit: commit})
 	return commit, err
 }
 
@@ -437,6 +526,7 @@ func (repository *WorkRepository) ChangeAndCommit(ctx context.Context, msg strin
 	repository.branch.CommitHash = commit.Hash
 	repository.wip.BaseCommit = commit.Hash
 	//dont set commit here, wip possibly wip changes
+	repository.emitter.Emit(ctx, repository.repoModel, repository.operator, models.PushEvent, &webhook.CommitPayload{Branch: repository.branch, Commit: commit})
 	return commit, err
 }
 
@@ -455,8 +545,14 @@ func (repository *WorkRepository) changeInWip(ctx context.Context, repo models.I
 
 func (repository *WorkRepository) commitChangeRoot(ctx context.Context, repo models.IRepo, author models.Signature, root hash.Hash, msg string) (*models.Commit, error) {
 	parentHash := make([]hash.Hash, 0) //avoid nil parent
+	parentTree := hash.Empty
 	if !repository.branch.CommitHash.IsEmpty() {
 		parentHash = []hash.Hash{repository.branch.CommitHash}
+		parentCommit, err := repo.CommitRepo(repository.repoModel.ID).Commit(ctx, repository.branch.CommitHash)
+		if err != nil {
+			return nil, err
+		}
+		parentTree = parentCommit.TreeHash
 	}
 
 	commit := &models.Commit{
@@ -486,14 +582,49 @@ func (repository *WorkRepository) commitChangeRoot(ctx context.Context, repo mod
 		return nil, err
 	}
 
+	err = saveCommitStats(ctx, repo, commit, parentTree)
+	if err != nil {
+		return nil, err
+	}
+
 	// Update branch
-	err = repo.BranchRepo().UpdateByID(ctx, models.NewUpdateBranchParams(repository.branch.ID).SetCommitHash(commitHash))
+	err = repository.updateBranchHead(ctx, repo, commitHash)
 	if err != nil {
 		return nil, err
 	}
 	return commit, err
 }
 
+// updateBranchHead move branch to commit, approvals of merge requests from this branch are dismissed because the content reviewed is changed.
+// branch protected from force push only move to commit descends from its head
+func (repository *WorkRepository) updateBranchHead(ctx context.Context, repo models.IRepo, commitHash hash.Hash) error {
+	oldHash := repository.branch.CommitHash
+	if !oldHash.IsEmpty() && !bytes.Equal(oldHash, commitHash) {
+		err := protection.NewChecker(repo).CheckForcePush(ctx, repository.repoModel.ID, repository.branch.Name, func(ctx context.Context) (bool, error) {
+			commitRepo := repo.CommitRepo(repository.repoModel.ID)
+			oldCommit, err := commitRepo.Commit(ctx, oldHash)
+			if err != nil {
+				return false, err
+			}
+			newCommit, err := commitRepo.Commit(ctx, commitHash)
+			if err != nil {
+				return false, err
+			}
+			return NewWrapCommitNode(commitRepo, oldCommit).IsAncestor(ctx, NewWrapCommitNode(commitRepo, newCommit))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := repo.BranchRepo().UpdateByID(ctx, models.NewUpdateBranchParams(repository.branch.ID).SetCommitHash(commitHash))
+	if err != nil {
+		return err
+	}
+	_, err = repo.ReviewRepo().DismissStaleApprovals(ctx, repository.branch.ID, commitHash)
+	return err
+}
+
 // CreateBranch create branch base on current head
 func (repository *WorkRepository) CreateBranch(ctx context.Context, branchName string) (*models.Branch, error) {
 	//check exit
@@ -518,12 +649,17 @@ func (repository *WorkRepository) CreateBranch(ctx context.Context, branchName s
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
-	return repository.repo.BranchRepo().Insert(ctx, newBranch)
+	newBranch, err = repository.repo.BranchRepo().Insert(ctx, newBranch)
+	if err != nil {
+		return nil, err
+	}
+	repository.emitter.Emit(ctx, repository.repoModel, repository.operator, models.BranchCreateEvent, &webhook.BranchPayload{Branch: newBranch})
+	return newBranch, nil
 }
 
 // DeleteBranch delete branch also delete wip belong this branch
 func (repository *WorkRepository) DeleteBranch(ctx context.Context) error {
-	return repository.repo.Transaction(ctx, func(repo models.IRepo) error {
+	err := repository.repo.Transaction(ctx, func(repo models.IRepo) error {
 		deleteBranchParams := models.NewDeleteBranchParams().
 			SetRepositoryID(repository.repoModel.ID).
 			SetName(repository.branch.Name)
@@ -540,6 +676,11 @@ func (repository *WorkRepository) DeleteBranch(ctx context.Context) error {
 
 		return err
 	})
+	if err != nil {
+		return err
+	}
+	repository.emitter.Emit(ctx, repository.repoModel, repository.operator, models.BranchDeleteEvent, &webhook.BranchPayload{Branch: repository.branch})
+	return nil
 }
 
 // CreateTag create tag base on current head
@@ -573,7 +714,12 @@ func (repository *WorkRepository) CreateTag(ctx context.Context, tagName string,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
-	return repository.repo.TagRepo().Insert(ctx, newTag)
+	newTag, err = repository.repo.TagRepo().Insert(ctx, newTag)
+	if err != nil {
+		return nil, err
+	}
+	repository.emitter.Emit(ctx, repository.repoModel, repository.operator, models.TagCreateEvent, &webhook.TagPayload{Tag: newTag})
+	return newTag, nil
 }
 
 // DeleteTag delete tag
@@ -670,7 +816,9 @@ func (repository *WorkRepository) GetCommitChanges(ctx context.Context, pathPref
 	return workTree.Diff(ctx, repository.commit.TreeHash, pathPrefix)
 }
 
-func (repository *WorkRepository) GetMergeState(ctx context.Context, toMergeCommitHash hash.Hash) ([]*ChangePair, error) {
+// GetMergeState compute changes of merging toMergeCommitHash into head of current branch, attributes are always loaded from
+// targetCommitHash, the commit of branch merged into, so merge drivers do not depend on which side is checked out
+func (repository *WorkRepository) GetMergeState(ctx context.Context, toMergeCommitHash hash.Hash, targetCommitHash hash.Hash) ([]*ChangePair, error) {
 	if repository.state != InBranch {
 		return nil, errors.New("must merge on branch")
 	}
@@ -722,6 +870,18 @@ func (repository *WorkRepository) GetMergeState(ctx context.Context, toMergeComm
 		return nil, err
 	}
 
+	attrs := &Attributes{}
+	if !targetCommitHash.IsEmpty() {
+		targetCommit, err := repository.repo.CommitRepo(repository.repoModel.ID).Commit(ctx, targetCommitHash)
+		if err != nil {
+			return nil, err
+		}
+		attrs, err = LoadAttributes(ctx, repository.repo.FileTreeRepo(repository.repoModel.ID), repository, targetCommit.TreeHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	changePairs := make([]*ChangePair, 0)
 	iter := NewChangesPairIter(baseDiff, mergeDiff)
 	for iter.Has() {
@@ -729,11 +889,40 @@ func (repository *WorkRepository) GetMergeState(ctx context.Context, toMergeComm
 		if err != nil {
 			return nil, err
 		}
+		if changePair.IsConflict {
+			changePair.MergeDriver, _ = attrs.MergeDriver(changePair.Path())
+		}
 		changePairs = append(changePairs, changePair)
 	}
 	return changePairs, nil
 }
 
+// CountBehind return the number of commits reachable from toMergeCommitHash but not merged into current branch
+func (repository *WorkRepository) CountBehind(ctx context.Context, toMergeCommitHash hash.Hash) (int, error) {
+	if repository.state != InBranch {
+		return 0, errors.New("must count on branch")
+	}
+	if toMergeCommitHash.IsEmpty() {
+		return 0, nil
+	}
+
+	commitRepo := repository.repo.CommitRepo(repository.repoModel.ID)
+	toMergeCommit, err := commitRepo.Commit(ctx, toMergeCommitHash)
+	if err != nil {
+		return 0, err
+	}
+
+	var branchCommitNode *WrapCommitNode
+	if !repository.branch.CommitHash.IsEmpty() {
+		branchCommit, err := commitRepo.Commit(ctx, repository.branch.CommitHash)
+		if err != nil {
+			return 0, err
+		}
+		branchCommitNode = NewWrapCommitNode(commitRepo, branchCommit)
+	}
+	return NewWrapCommitNode(commitRepo, toMergeCommit).CountExclusive(ctx, branchCommitNode)
+}
+
 // Merge implement merge like git, docs https://en.wikipedia.org/wiki/Merge_(version_control)
 func (repository *WorkRepository) Merge(ctx context.Context, toMergeCommitHash hash.Hash, msg string, resolver ConflictResolver) (*models.Commit, error) {
 	if repository.state != InBranch {
@@ -766,66 +955,70 @@ func (repository *WorkRepository) Merge(ctx context.Context, toMergeCommitHash h
 		if err != nil {
 			return err
 		}
-		newCommit, err = merge(ctx, commitRepo, fileTreeRepo, repository.repoModel, repository.operator, bestAncestor, sourceCommit, targetCommit, msg, resolver)
+		newCommit, err = merge(ctx, commitRepo, fileTreeRepo, repository, repository.repoModel, repository.operator, bestAncestor, sourceCommit, targetCommit, msg, resolver)
 		if err != nil {
 			return err
 		}
 
-		updateParams := models.NewUpdateBranchParams(repository.branch.ID).SetCommitHash(newCommit.Hash)
-		return repo.BranchRepo().UpdateByID(ctx, updateParams)
+		// only merge commit is new, fast-forward reuse commit whose stats are saved already
+		if sourceCommit != nil && targetCommit != nil && !bytes.Equal(newCommit.Hash, sourceCommit.Hash) && !bytes.Equal(newCommit.Hash, targetCommit.Hash) {
+			err = saveCommitStats(ctx, repo, newCommit, targetCommit.TreeHash)
+			if err != nil {
+				return err
+			}
+		}
+
+		return repository.updateBranchHead(ctx, repo, newCommit.Hash)
 	})
 	if err != nil {
 		return nil, err
 	}
+	repository.branch.CommitHash = newCommit.Hash
+	repository.emitter.Emit(ctx, repository.repoModel, repository.operator, models.PushEvent, &webhook.CommitPayload{Branch: repository.branch, Commit: newCommit})
 	return newCommit, nil
 }
 
 type ArchiveType string
 
 const (
-	ZipArchiveType ArchiveType = "zip"
-	CarArchiveType ArchiveType = "car"
+	ZipArchiveType    ArchiveType = "zip"
+	CarArchiveType    ArchiveType = "car"
+	TarArchiveType    ArchiveType = "tar"
+	TarGzArchiveType  ArchiveType = "tar.gz"
+	TarZstArchiveType ArchiveType = "tar.zst"
 )
 
-func (repository *WorkRepository) Archive(ctx context.Context, archiveType ArchiveType) (io.ReadCloser, int64, error) {
+// Archiver return an archiver contains files under pathPrefix of current head, empty pathPrefix means whole tree
+func (repository *WorkRepository) Archiver(ctx context.Context, pathPrefix string) (*RepoArchiver, error) {
 	rootTree, err := repository.RootTree(ctx)
 	if err != nil {
-		return nil, 0, err
-	}
-
-	wk := NewFileWalk(rootTree.object, rootTree.root)
-	reader := func(ctx context.Context, blob *models.Blob, s string) (io.ReadCloser, error) {
-		return repository.ReadBlob(ctx, blob, nil)
+		return nil, err
 	}
 
-	archiver := NewRepoArchiver(repository.repoModel.Name, wk, reader)
-	tmpDir, err := os.MkdirTemp(os.TempDir(), "*") //todo file cache for archive
+	pathPrefix = CleanPath(pathPrefix)
+	subTree, err := rootTree.SubTree(ctx, pathPrefix)
 	if err != nil {
-		return nil, 0, err
-	}
-	var tmpFile string
-	switch archiveType {
-	case ZipArchiveType:
-		tmpFile = path.Join(tmpDir, hash.Hash(rootTree.root.Hash()).Hex()+".zip")
-		err = archiver.ArchiveZip(ctx, tmpFile)
-	case CarArchiveType:
-		tmpFile = path.Join(tmpDir, hash.Hash(rootTree.root.Hash()).Hex()+".car")
-		err = archiver.ArchiveZip(ctx, tmpFile)
-	default:
-		return nil, 0, fmt.Errorf("unexpect archive type %s", archiveType)
+		return nil, err
 	}
-	if err != nil {
-		return nil, 0, err
+
+	wk := NewFileWalk(rootTree.object, subTree)
+	reader := func(ctx context.Context, blob *models.Blob, s string) (io.ReadCloser, error) {
+		return repository.ReadBlob(ctx, blob, nil)
 	}
-	st, err := os.Stat(tmpFile)
-	if err != nil {
-		return nil, 0, err
+	archiver := NewRepoArchiver(path.Join(repository.repoModel.Name, pathPrefix), wk, reader)
+	if repository.commit != nil {
+		archiver.WithModTime(repository.commit.Committer.When)
 	}
-	fs, err := os.Open(tmpFile)
+	return archiver, nil
+}
+
+// Archive write files under pathPrefix of current head to w
+func (repository *WorkRepository) Archive(ctx context.Context, archiveType ArchiveType, pathPrefix string, w io.Writer) error {
+	archiver, err := repository.Archiver(ctx, pathPrefix)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
-	return fs, st.Size(), nil
+	return archiver.Archive(ctx, archiveType, w)
 }
 
 func (repository *WorkRepository) setCurState(state WorkRepoState, wip *models.WorkingInProcess, branch *models.Branch, tag *models.Tag, commit *models.Commit) {
@@ -897,7 +1090,7 @@ func findBestAncestor(ctx context.Context,
 			return nil, err
 		}
 
-		virtualCommit, err := merge(ctx, commitRepo, fileTreeRepo, repoModel, merger, subBestAncestor, bestAncestor[0].Commit(), bestAncestor[1].Commit(), "virtual commit", ForbidResolver)
+		virtualCommit, err := merge(ctx, commitRepo, fileTreeRepo, nil, repoModel, merger, subBestAncestor, bestAncestor[0].Commit(), bestAncestor[1].Commit(), "virtual commit", ForbidResolver)
 		if err != nil {
 			return nil, err
 		}
@@ -909,9 +1102,11 @@ func findBestAncestor(ctx context.Context,
 
 // merge
 // todo too much arguments, need a better solution
+// store is used to apply merge drivers in attributes of target commit, nil store skip attributes
 func merge(ctx context.Context,
 	commitRepo models.ICommitRepo,
 	fileTreeRepo models.IFileTreeRepo,
+	store BlobStore,
 	repoModel *models.Repository,
 	merger *models.User,
 	bestAncestor *models.Commit,
@@ -982,12 +1177,24 @@ func merge(ctx context.Context,
 		return nil, err
 	}
 
+	if store != nil {
+		attrs, err := LoadAttributes(ctx, fileTreeRepo, store, targetCommit.TreeHash)
+		if err != nil {
+			return nil, err
+		}
+		resolver = AttributesResolver(ctx, fileTreeRepo, store, attrs, resolver)
+	}
+
 	cmw := NewChangesMergeIter(sourceDiff, targetDiff, resolver)
 	for cmw.Has() {
 		change, err := cmw.Next()
 		if err != nil {
 			return nil, err
 		}
+		if change == nil {
+			//conflict resolved to keep nothing
+			continue
+		}
 		//apply change
 		err = baseWorkTree.ApplyOneChange(ctx, change)
 		if err != nil {
@@ -1,6 +1,7 @@
 package versionmgr
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"encoding/hex"
@@ -377,7 +378,7 @@ func TestWorkRepositoryMergeState(t *testing.T) {
 		baseCommit, err := addChangesToWip(ctx, workRepo, "feat/base", "base commit", testData1)
 		require.NoError(t, err)
 
-		_, err = workRepo.GetMergeState(ctx, baseCommit.Hash)
+		_, err = workRepo.GetMergeState(ctx, baseCommit.Hash, hash.Empty)
 		require.Error(t, err)
 	})
 
@@ -401,7 +402,7 @@ func TestWorkRepositoryMergeState(t *testing.T) {
 
 		err = workRepo.CheckOut(ctx, InBranch, "main")
 		require.NoError(t, err)
-		changes, err := workRepo.GetMergeState(ctx, baseCommit.Hash)
+		changes, err := workRepo.GetMergeState(ctx, baseCommit.Hash, workRepo.CurBranch().CommitHash)
 		require.NoError(t, err)
 		require.Len(t, changes, 1)
 	})
@@ -425,7 +426,7 @@ func TestWorkRepositoryMergeState(t *testing.T) {
 
 		err = workRepo.CheckOut(ctx, InBranch, "feat/base")
 		require.NoError(t, err)
-		changes, err := workRepo.GetMergeState(ctx, hash.Empty)
+		changes, err := workRepo.GetMergeState(ctx, hash.Empty, workRepo.CurBranch().CommitHash)
 		require.NoError(t, err)
 		require.Len(t, changes, 1)
 	})
@@ -440,7 +441,7 @@ func TestWorkRepositoryMergeState(t *testing.T) {
 
 		err = workRepo.CheckOut(ctx, InBranch, "main")
 		require.NoError(t, err)
-		_, err = workRepo.GetMergeState(ctx, hash.Empty)
+		_, err = workRepo.GetMergeState(ctx, hash.Empty, workRepo.CurBranch().CommitHash)
 		require.Error(t, err)
 	})
 
@@ -478,7 +479,7 @@ func TestWorkRepositoryMergeState(t *testing.T) {
 
 		err = workRepo.CheckOut(ctx, InBranch, "feat/base")
 		require.NoError(t, err)
-		_, err = workRepo.GetMergeState(ctx, secondCommit.Hash)
+		_, err = workRepo.GetMergeState(ctx, secondCommit.Hash, workRepo.CurBranch().CommitHash)
 		require.Error(t, err)
 	})
 
@@ -525,7 +526,7 @@ func TestWorkRepositoryMergeState(t *testing.T) {
 
 		err = workRepo.CheckOut(ctx, InBranch, "feat/base")
 		require.NoError(t, err)
-		changes, err := workRepo.GetMergeState(ctx, secondCommit.Hash)
+		changes, err := workRepo.GetMergeState(ctx, secondCommit.Hash, workRepo.CurBranch().CommitHash)
 		require.NoError(t, err)
 		require.Len(t, changes, 2)
 	})
@@ -586,12 +587,12 @@ func TestWorkRepository_Archive(t *testing.T) {
 	adapter := mem.New(ctx)
 	workRepo := NewWorkRepositoryFromAdapter(ctx, user, project, repo, adapter)
 
-	reader, _, err := workRepo.Archive(ctx, ZipArchiveType)
-	defer reader.Close() //nolint
+	err = workRepo.Archive(ctx, ZipArchiveType, "", bytes.NewBuffer(nil))
 	require.NoError(t, err)
 
 	testData1 := `
 1|a.txt	|aaaaaaa
+1|b/c.txt	|ccccccc
 `
 	_, err = addChangesToWip(ctx, workRepo, "main", "", testData1)
 	require.NoError(t, err)
@@ -601,26 +602,46 @@ func TestWorkRepository_Archive(t *testing.T) {
 
 	{
 		//unexpect type
-		_, _, err = workRepo.Archive(ctx, "gz")
+		err = workRepo.Archive(ctx, "gz", "", bytes.NewBuffer(nil))
 		require.Error(t, err)
 	}
 
-	{
-		//test zip
-		reader, _, err := workRepo.Archive(ctx, ZipArchiveType)
-		require.NoError(t, err)
-		defer reader.Close() //nolint
-		_, err = io.ReadAll(reader)
+	for _, archiveType := range []ArchiveType{ZipArchiveType, CarArchiveType, TarArchiveType, TarGzArchiveType, TarZstArchiveType} {
+		buf := bytes.NewBuffer(nil)
+		err = workRepo.Archive(ctx, archiveType, "", buf)
 		require.NoError(t, err)
+		require.NotZero(t, buf.Len())
 	}
 
 	{
-		//test car
-		reader, _, err := workRepo.Archive(ctx, CarArchiveType)
-		require.NoError(t, err)
-		defer reader.Close() //nolint
-		_, err = io.ReadAll(reader)
+		//archive sub directory
+		buf := bytes.NewBuffer(nil)
+		err = workRepo.Archive(ctx, TarArchiveType, "b", buf)
 		require.NoError(t, err)
+
+		tarReader := tar.NewReader(buf)
+		var files []string
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if header.Typeflag == tar.TypeReg {
+				files = append(files, header.Name)
+			}
+		}
+		require.Equal(t, []string{path.Join(project.Name, "b/c.txt")}, files)
+	}
+
+	{
+		//path not exit
+		err = workRepo.Archive(ctx, TarArchiveType, "x", bytes.NewBuffer(nil))
+		require.ErrorIs(t, err, ErrPathNotFound)
+
+		//path is a file
+		err = workRepo.Archive(ctx, TarArchiveType, "a.txt", bytes.NewBuffer(nil))
+		require.ErrorIs(t, err, ErrNotDirectory)
 	}
 }
 func makeUser(ctx context.Context, userRepo models.IUserRepo, name string) (*models.User, error) {
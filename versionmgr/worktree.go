@@ -34,6 +34,7 @@ type FullTreeEntry struct {
 	IsDir bool      `json:"is_dir"`
 	Hash  hash.Hash `json:"hash"`
 	Size  int64     `json:"size"`
+	Cid   string    `json:"cid,omitempty"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -234,9 +235,11 @@ func (workTree *WorkTree) AddLeaf(ctx context.Context, fullPath string, blob *mo
 				return err
 			}
 			lastEntry = models.TreeEntry{
-				Name:  path,
-				IsDir: false,
-				Hash:  blob.Hash,
+				Name:    path,
+				IsDir:   false,
+				Hash:    blob.Hash,
+				Cid:     blob.Cid,
+				CidSize: blob.CidSize,
 			}
 			continue
 		}
@@ -250,9 +253,11 @@ func (workTree *WorkTree) AddLeaf(ctx context.Context, fullPath string, blob *mo
 			return err
 		}
 		lastEntry = models.TreeEntry{
-			Name:  path,
-			IsDir: true,
-			Hash:  newTree.Hash,
+			Name:    path,
+			IsDir:   true,
+			Hash:    newTree.Hash,
+			Cid:     newTree.Cid,
+			CidSize: newTree.CidSize,
 		}
 	}
 
@@ -277,9 +282,11 @@ func (workTree *WorkTree) AddLeaf(ctx context.Context, fullPath string, blob *mo
 			return err
 		}
 		lastEntry = models.TreeEntry{
-			Name:  node.Entry().Name, // use old name but replace with new hase
-			IsDir: true,
-			Hash:  newNode.Hash,
+			Name:    node.Entry().Name, // use old name but replace with new hase
+			IsDir:   true,
+			Hash:    newNode.Hash,
+			Cid:     newNode.Cid,
+			CidSize: newNode.CidSize,
 		}
 	}
 	workTree.root, err = NewTreeNode(ctx, lastEntry, workTree.object)
@@ -314,9 +321,11 @@ func (workTree *WorkTree) ReplaceLeaf(ctx context.Context, fullPath string, blob
 	for index, node := range existNode {
 		if index == 0 {
 			lastEntry = models.TreeEntry{
-				Name:  node.Entry().Name,
-				IsDir: false,
-				Hash:  blob.Hash,
+				Name:    node.Entry().Name,
+				IsDir:   false,
+				Hash:    blob.Hash,
+				Cid:     blob.Cid,
+				CidSize: blob.CidSize,
 			}
 			continue
 		}
@@ -330,9 +339,11 @@ func (workTree *WorkTree) ReplaceLeaf(ctx context.Context, fullPath string, blob
 			return err
 		}
 		lastEntry = models.TreeEntry{
-			Name:  node.Entry().Name,
-			IsDir: true,
-			Hash:  newNode.Hash,
+			Name:    node.Entry().Name,
+			IsDir:   true,
+			Hash:    newNode.Hash,
+			Cid:     newNode.Cid,
+			CidSize: newNode.CidSize,
 		}
 	}
 	workTree.root, err = NewTreeNode(ctx, lastEntry, workTree.object)
@@ -389,6 +400,8 @@ func (workTree *WorkTree) RemoveEntry(ctx context.Context, fullPath string) erro
 			}
 			if !isEmpty {
 				lastEntry.Hash = newNode.Hash
+				lastEntry.Cid = newNode.Cid
+				lastEntry.CidSize = newNode.CidSize
 			}
 		} else {
 			newNode, err = subWorkTree.ReplaceSubTreeEntry(ctx, lastEntry)
@@ -396,9 +409,11 @@ func (workTree *WorkTree) RemoveEntry(ctx context.Context, fullPath string) erro
 				return err
 			}
 			lastEntry = models.TreeEntry{
-				Name:  node.Entry().Name,
-				IsDir: true,
-				Hash:  newNode.Hash,
+				Name:    node.Entry().Name,
+				IsDir:   true,
+				Hash:    newNode.Hash,
+				Cid:     newNode.Cid,
+				CidSize: newNode.CidSize,
 			}
 		}
 	}
@@ -443,6 +458,29 @@ func (workTree *WorkTree) Ls(ctx context.Context, pattern string) ([]FullTreeEnt
 	return workTree.getFullEntry(ctx, lastNode.Node().SubObjects)
 }
 
+// SubTree return tree node of directory at fullPath, return root while fullPath is empty
+func (workTree *WorkTree) SubTree(ctx context.Context, fullPath string) (*TreeNode, error) {
+	fullPath = CleanPath(fullPath)
+	if len(fullPath) == 0 {
+		return workTree.root, nil
+	}
+
+	existNode, missingPath, err := workTree.findNodeByPath(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missingPath) > 0 {
+		return nil, ErrPathNotFound
+	}
+
+	lastNode := existNode[len(existNode)-1]
+	if lastNode.Node().Type != models.TreeObject {
+		return nil, ErrNotDirectory
+	}
+	return NewTreeNode(ctx, lastNode.Entry(), workTree.object)
+}
+
 type TreeManifest struct {
 	Size     int64    `json:"size"`
 	FileList []string `json:"file_list"`
@@ -488,6 +526,7 @@ func (workTree *WorkTree) getFullEntry(ctx context.Context, treeEntries []models
 			if err != nil {
 				return nil, err
 			}
+			fe.Cid = blob.Cid
 			fe.CreatedAt = blob.CreatedAt
 			fe.UpdatedAt = blob.UpdatedAt
 			entries = append(entries, fe)
@@ -497,6 +536,7 @@ func (workTree *WorkTree) getFullEntry(ctx context.Context, treeEntries []models
 				return nil, err
 			}
 			fe.Size = blob.Size
+			fe.Cid = blob.Cid
 			fe.CreatedAt = blob.CreatedAt
 			fe.UpdatedAt = blob.UpdatedAt
 			entries = append(entries, fe)
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/controller/validator"
+)
+
+// NewClient create http client to post urls given by users. unless private network allowed, address is checked when dialing
+// so that redirects and hosts resolved to internal address can not reach internal services, proxy is not used for the same reason
+func NewClient(cfg *config.WebhookConfig, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivateNetwork {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   validator.WebhookDialControl,
+		}
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/models"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+)
+
+var log = logging.Logger("webhook")
+
+var (
+	// MaxAttempts delivery marked as failed after attempts
+	MaxAttempts = 6
+	// BaseBackoff wait time before first retry, doubled for every retry
+	BaseBackoff = 10 * time.Second
+	// MaxBackoff max wait time between retries
+	MaxBackoff = time.Hour
+
+	pollInterval    = 5 * time.Second
+	deliverTimeout  = 10 * time.Second
+	maxMessageSize  = 1024
+	deliveriesBatch = 100
+)
+
+var _ Emitter = (*WebhookManager)(nil)
+
+// WebhookManager persist events as deliveries of subscribed webhooks and post them in background with retry
+type WebhookManager struct {
+	repo   models.IRepo
+	client *http.Client
+	notify chan struct{}
+}
+
+func NewWebhookManager(repo models.IRepo, cfg *config.WebhookConfig) *WebhookManager {
+	return &WebhookManager{
+		repo:   repo,
+		client: NewClient(cfg, deliverTimeout),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// NewWebhookEmitter create webhook manager and run delivery loop along with lifecycle
+func NewWebhookEmitter(lc fx.Lifecycle, repo models.IRepo, cfg *config.WebhookConfig) Emitter {
+	manager := NewWebhookManager(repo, cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			go manager.Run(ctx)
+			return nil
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return manager
+}
+
+// Emit create pending delivery for every active webhook subscribed the event, failure only logged to not break the operation
+func (manager *WebhookManager) Emit(ctx context.Context, repository *models.Repository, operator *models.User, eventType models.EventType, data any) {
+	webhooks, _, err := manager.repo.WebhookRepo().List(ctx, models.NewListWebhookParams().SetRepositoryID(repository.ID).SetEvent(eventType).SetActive(true))
+	if err != nil {
+		log.Errorf("list webhooks of repository %s %v", repository.ID, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(NewEvent(repository, operator, eventType, data))
+	if err != nil {
+		log.Errorf("marshal %s event %v", eventType, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		_, err = manager.repo.WebhookDeliveryRepo().Insert(ctx, &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			RepositoryID:  repository.ID,
+			Event:         eventType,
+			Payload:       string(payload),
+			State:         models.DeliveryPending,
+			NextAttemptAt: time.Now(),
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		})
+		if err != nil {
+			log.Errorf("create delivery of webhook %s %v", webhook.ID, err)
+		}
+	}
+
+	select {
+	case manager.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run deliver pending deliveries until ctx done
+func (manager *WebhookManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-manager.notify:
+		}
+
+		err := manager.DeliverPending(ctx)
+		if err != nil {
+			log.Errorf("deliver pending webhooks %v", err)
+		}
+	}
+}
+
+// DeliverPending try to deliver all deliveries which reach the attempt time
+func (manager *WebhookManager) DeliverPending(ctx context.Context) error {
+	for {
+		deliveries, hasMore, err := manager.repo.WebhookDeliveryRepo().List(ctx, models.NewListWebhookDeliveryParams().
+			SetState(models.DeliveryPending).
+			SetNextAttemptBefore(time.Now()).
+			SetAmount(deliveriesBatch))
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			err = manager.Deliver(ctx, delivery)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !hasMore {
+			return nil
+		}
+	}
+}
+
+// Deliver post delivery to webhook once, and record the result. delivery failed is not an error but scheduled to retry
+func (manager *WebhookManager) Deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	attempts := delivery.Attempts + 1
+	updateParams := models.NewUpdateWebhookDeliveryParams(delivery.ID).SetAttempts(attempts)
+
+	webhook, err := manager.repo.WebhookRepo().Get(ctx, models.NewGetWebhookParams().SetID(delivery.WebhookID))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return manager.repo.WebhookDeliveryRepo().UpdateByID(ctx, updateParams.SetState(models.DeliveryFailed).SetMessage("webhook not found"))
+		}
+		return err
+	}
+
+	if !webhook.Active {
+		return manager.repo.WebhookDeliveryRepo().UpdateByID(ctx, updateParams.SetState(models.DeliveryFailed).SetMessage("webhook is inactive"))
+	}
+
+	code, message, err := manager.post(ctx, webhook, delivery)
+	updateParams.SetResponseCode(code)
+	if err != nil {
+		message = err.Error()
+	}
+	updateParams.SetMessage(message)
+
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		updateParams.SetState(models.DeliverySuccess)
+	case attempts >= MaxAttempts:
+		updateParams.SetState(models.DeliveryFailed)
+	default:
+		updateParams.SetNextAttemptAt(time.Now().Add(Backoff(attempts)))
+	}
+	return manager.repo.WebhookDeliveryRepo().UpdateByID(ctx, updateParams)
+}
+
+func (manager *WebhookManager) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jiaozifs-webhook")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	if len(webhook.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(webhook.Secret, payload))
+	}
+
+	resp, err := manager.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close() //nolint
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxMessageSize)))
+	if err != nil {
+		return resp.StatusCode, "", fmt.Errorf("read response body %w", err)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// Backoff return wait time before next attempt
+func Backoff(attempts int) time.Duration {
+	backoff := BaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// EventHeader header contains event type of payload
+	EventHeader = "X-Jiaozifs-Event"
+	// DeliveryHeader header contains id of delivery, keep the same while retry
+	DeliveryHeader = "X-Jiaozifs-Delivery"
+	// SignatureHeader header contains hmac-sha256 signature of payload in format sha256=<hex>
+	SignatureHeader = "X-Jiaozifs-Signature-256"
+)
+
+// Emitter emit repository events to subscribers
+type Emitter interface {
+	Emit(ctx context.Context, repository *models.Repository, operator *models.User, eventType models.EventType, data any)
+}
+
+var _ Emitter = (*NoopEmitter)(nil)
+
+// NoopEmitter drop all events
+type NoopEmitter struct{}
+
+func (NoopEmitter) Emit(context.Context, *models.Repository, *models.User, models.EventType, any) {}
+
+var _ Emitter = (Emitters)(nil)
+
+// Emitters emit event to every emitter in order
+type Emitters []Emitter
+
+func (emitters Emitters) Emit(ctx context.Context, repository *models.Repository, operator *models.User, eventType models.EventType, data any) {
+	for _, emitter := range emitters {
+		emitter.Emit(ctx, repository, operator, eventType, data)
+	}
+}
+
+type EventRepository struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	OwnerID uuid.UUID `json:"owner_id"`
+}
+
+type EventOperator struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// Event payload post to webhook
+type Event struct {
+	ID         uuid.UUID        `json:"id"`
+	Type       models.EventType `json:"event"`
+	Repository EventRepository  `json:"repository"`
+	Operator   EventOperator    `json:"operator"`
+	Data       any              `json:"data"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+func NewEvent(repository *models.Repository, operator *models.User, eventType models.EventType, data any) *Event {
+	return &Event{
+		ID:   uuid.New(),
+		Type: eventType,
+		Repository: EventRepository{
+			ID:      repository.ID,
+			Name:    repository.Name,
+			OwnerID: repository.OwnerID,
+		},
+		Operator: EventOperator{
+			ID:   operator.ID,
+			Name: operator.Name,
+		},
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+}
+
+// CommitPayload data of push and wip commit event
+type CommitPayload struct {
+	Branch *models.Branch           `json:"branch"`
+	Commit *models.Commit           `json:"commit"`
+	Wip    *models.WorkingInProcess `json:"wip,omitempty"`
+}
+
+// BranchPayload data of branch create/delete event
+type BranchPayload struct {
+	Branch *models.Branch `json:"branch"`
+}
+
+// TagPayload data of tag create event
+type TagPayload struct {
+	Tag *models.Tag `json:"tag"`
+}
+
+// MergeRequestPayload data of merge request events
+type MergeRequestPayload struct {
+	MergeRequest *models.MergeRequest `json:"merge_request"`
+	Commit       *models.Commit       `json:"commit,omitempty"`
+}
+
+// Sign return signature of payload in format sha256=<hex>
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify check whether signature match the payload
+func Verify(secret string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
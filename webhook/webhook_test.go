@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GitDataAI/jiaozifs/config"
+	"github.com/GitDataAI/jiaozifs/controller/validator"
+	"github.com/GitDataAI/jiaozifs/models"
+	"github.com/GitDataAI/jiaozifs/testhelper"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"event":"push"}`)
+	signature := Sign("secret", payload)
+	require.Equal(t, "sha256=", signature[:7])
+	require.True(t, Verify("secret", payload, signature))
+	require.False(t, Verify("other", payload, signature))
+	require.False(t, Verify("secret", []byte(`{"event":"tag_create"}`), signature))
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, BaseBackoff, Backoff(1))
+	require.Equal(t, BaseBackoff*2, Backoff(2))
+	require.Equal(t, BaseBackoff*8, Backoff(4))
+	require.Equal(t, MaxBackoff, Backoff(100))
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(&config.WebhookConfig{}, time.Second).Get(server.URL) //nolint
+	require.ErrorIs(t, err, validator.ErrPrivateAddress)
+
+	resp, err := NewClient(&config.WebhookConfig{AllowPrivateNetwork: true}, time.Second).Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestWebhookManager(t *testing.T) {
+	ctx := context.Background()
+	closeDB, _, db := testhelper.SetupDatabase(ctx, t)
+	defer closeDB()
+
+	repo := models.NewRepo(db)
+	manager := NewWebhookManager(repo, &config.WebhookConfig{AllowPrivateNetwork: true})
+
+	var failTimes atomic.Int32
+	failTimes.Store(1)
+	received := make(chan *http.Request, 10)
+	receivedBody := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if failTimes.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received <- r
+		receivedBody <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repository := &models.Repository{ID: uuid.New(), Name: "repo", OwnerID: uuid.New()}
+	operator := &models.User{ID: uuid.New(), Name: "jimmy"}
+
+	webhook, err := repo.WebhookRepo().Insert(ctx, &models.Webhook{
+		RepositoryID: repository.ID,
+		URL:          server.URL,
+		Secret:       "secret",
+		Events:       []models.EventType{models.PushEvent},
+		Active:       true,
+		CreatorID:    operator.ID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	})
+	require.NoError(t, err)
+
+	// not subscribed event
+	manager.Emit(ctx, repository, operator, models.TagCreateEvent, &TagPayload{})
+	deliveries, _, err := repo.WebhookDeliveryRepo().List(ctx, models.NewListWebhookDeliveryParams().SetWebhookID(webhook.ID))
+	require.NoError(t, err)
+	require.Len(t, deliveries, 0)
+
+	manager.Emit(ctx, repository, operator, models.PushEvent, &CommitPayload{})
+	deliveries, _, err = repo.WebhookDeliveryRepo().List(ctx, models.NewListWebhookDeliveryParams().SetWebhookID(webhook.ID))
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	// first attempt failed, schedule to retry
+	require.NoError(t, manager.DeliverPending(ctx))
+	delivery, err := repo.WebhookDeliveryRepo().Get(ctx, models.NewGetWebhookDeliveryParams().SetID(deliveries[0].ID))
+	require.NoError(t, err)
+	require.Equal(t, models.DeliveryPending, delivery.State)
+	require.Equal(t, 1, delivery.Attempts)
+	require.Equal(t, http.StatusInternalServerError, delivery.ResponseCode)
+	require.True(t, delivery.NextAttemptAt.After(time.Now()))
+
+	// retry
+	require.NoError(t, repo.WebhookDeliveryRepo().UpdateByID(ctx, models.NewUpdateWebhookDeliveryParams(delivery.ID).SetNextAttemptAt(time.Now())))
+	require.NoError(t, manager.DeliverPending(ctx))
+	delivery, err = repo.WebhookDeliveryRepo().Get(ctx, models.NewGetWebhookDeliveryParams().SetID(deliveries[0].ID))
+	require.NoError(t, err)
+	require.Equal(t, models.DeliverySuccess, delivery.State)
+	require.Equal(t, 2, delivery.Attempts)
+
+	req := <-received
+	body := <-receivedBody
+	require.Equal(t, string(models.PushEvent), req.Header.Get(EventHeader))
+	require.Equal(t, delivery.ID.String(), req.Header.Get(DeliveryHeader))
+	require.True(t, Verify("secret", body, req.Header.Get(SignatureHeader)))
+
+	event := &Event{}
+	require.NoError(t, json.Unmarshal(body, event))
+	require.Equal(t, models.PushEvent, event.Type)
+	require.Equal(t, repository.ID, event.Repository.ID)
+	require.Equal(t, operator.Name, event.Operator.Name)
+}